@@ -0,0 +1,22 @@
+package lode
+
+// applicable returns the models applies accepts, or models itself when it
+// accepts them all (or is nil).
+func applicable[Model any](models []Model, applies func(Model) bool) []Model {
+	if applies == nil {
+		return models
+	}
+	for i, m := range models {
+		if applies(m) {
+			continue
+		}
+		kept := append(make([]Model, 0, len(models)-1), models[:i]...)
+		for _, m := range models[i+1:] {
+			if applies(m) {
+				kept = append(kept, m)
+			}
+		}
+		return kept
+	}
+	return models
+}
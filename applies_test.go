@@ -0,0 +1,94 @@
+package lode
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestMany_Applies(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	authors := []*Author{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}, {ID: 5}}
+	NewEngine().InitHandles(authors)
+
+	var fetched [][]int
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "invoices",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Applies:     func(a *Author) bool { return a.ID%2 == 1 }, // paid plans
+		Fetch: func(_ context.Context, keys []int) ([]*Book, error) {
+			fetched = append(fetched, slices.Sorted(slices.Values(keys)))
+			var books []*Book
+			for _, k := range keys {
+				books = append(books, &Book{AuthorID: k})
+			}
+			return books, nil
+		},
+	}
+	for _, a := range authors {
+		books, err := Many(ctx, spec.For(a))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := a.ID % 2; len(books) != want {
+			t.Fatalf("author %d has %d books; want %d", a.ID, len(books), want)
+		}
+	}
+	if len(fetched) != 1 || !slices.Equal(fetched[0], []int{1, 3, 5}) {
+		t.Fatalf("fetched %v; want one fetch of [1 3 5]", fetched)
+	}
+
+	unbound := &Author{ID: 2}
+	if books, err := Many(ctx, spec.For(unbound)); books != nil || err != nil {
+		t.Fatalf("Many(unbound, not applicable) = %v, %v; want nil, nil", books, err)
+	}
+}
+
+func TestResolve_Applies(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	authors := []*Author{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}, {ID: 5}}
+	NewEngine().InitHandles(authors)
+
+	var built [][]*Author
+	spec := ResolveSpec[*Author, int]{
+		CacheKey: "balance",
+		Applies:  func(a *Author) bool { return a.ID != 2 && a.ID != 4 },
+		Build: func(_ context.Context, models []*Author) (ResolverFunc[*Author, int], error) {
+			built = append(built, models)
+			return func(a *Author) int { return 100 * a.ID }, nil
+		},
+	}
+	for _, a := range authors {
+		got, err := Resolve(ctx, spec.For(a))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := 100 * a.ID
+		if a.ID == 2 || a.ID == 4 {
+			want = 0
+		}
+		if got != want {
+			t.Fatalf("author %d resolved %d; want %d", a.ID, got, want)
+		}
+	}
+	if len(built) != 1 || !ptrsEq(built[0], []*Author{authors[0], authors[2], authors[4]}) {
+		t.Fatalf("builds = %v; want one with authors 1, 3, 5", built)
+	}
+	if got, err := Resolve(ctx, spec.For(&Author{ID: 4})); got != 0 || err != nil {
+		t.Fatalf("Resolve(unbound, not applicable) = %d, %v; want 0, nil", got, err)
+	}
+}
+
+func TestApplicable_NoCopyWhenAllApply(t *testing.T) {
+	t.Parallel()
+	models := []int{1, 2, 3}
+	if got := applicable(models, func(int) bool { return true }); &got[0] != &models[0] {
+		t.Fatal("applicable copied models that all apply")
+	}
+	if got := applicable(models, func(n int) bool { return n != 1 }); !slices.Equal(got, []int{2, 3}) || !slices.Equal(models, []int{1, 2, 3}) {
+		t.Fatalf("applicable = %v, models = %v; want [2 3] and models untouched", got, models)
+	}
+}
@@ -0,0 +1,85 @@
+package lode
+
+// BackRef asks Many to give the relations it fetches their parent for free:
+// after binding the relations, it stores a resolver under CacheKey on their
+// state that maps each relation to the model it was fetched for.  A
+// relation method such as
+//
+//	func (b *Book) Author(ctx context.Context) (*Author, error) {
+//		return lode.One(ctx, lode.RelationSpec[uint, *Book, *Author]{CacheKey: "author", ...})
+//	}
+//
+// is then a cache hit, provided its CacheKey matches and it is not
+// SinglePerKey.  The relation type must embed a Handle.
+//
+// The resolver is only stored on states whose every model was fetched by
+// this build and has a parent in the batch, so it never answers for a
+// relation it knows nothing about; an entry already on the state is kept.
+type BackRef[JoinKey comparable, Relation any] struct {
+	CacheKey string
+	// RelationToParentKey returns the key of a relation's parent.  It
+	// defaults to the spec's RelationKey (or RelationKeyOK).
+	RelationToParentKey func(Relation) JoinKey
+}
+
+// bindBackRef stores the BackRef resolver on the states of relations.
+func (args RelationSpec[JoinKey, Model, Relation]) bindBackRef(e *Engine, models []Model, relations []Relation) {
+	parents := make(map[JoinKey]Model, len(models))
+	for _, m := range models {
+		if k, ok := args.ModelKey(m); ok {
+			if _, dup := parents[k]; !dup {
+				parents[k] = m
+			}
+		}
+	}
+	parentOf := func(r Relation) (Model, bool) {
+		var k JoinKey
+		ok := true
+		if args.BackRef.RelationToParentKey != nil {
+			k = args.BackRef.RelationToParentKey(r)
+		} else {
+			k, ok = args.relationKey(r)
+		}
+		p, found := parents[k]
+		return p, ok && found
+	}
+
+	fetched := make(map[*loaderState]int)
+	for _, r := range relations {
+		hs, ok := any(r).(hasState)
+		if !ok {
+			return
+		}
+		if s := hs.lodeState(); s != nil {
+			fetched[s]++
+		}
+	}
+	for s, n := range fetched {
+		rels, ok := modelsAs[Relation](s)
+		if !ok || len(rels) != n {
+			continue // the state holds relations this build did not fetch
+		}
+		byRel := make(map[any]Model, len(rels))
+		complete := true
+		for _, r := range rels {
+			p, ok := parentOf(r)
+			if !ok {
+				complete = false
+				break
+			}
+			byRel[any(r)] = p
+		}
+		if !complete {
+			continue
+		}
+		resolver := ResolverFunc[Relation, []Model](func(r Relation) []Model {
+			if p, ok := byRel[any(r)]; ok {
+				return []Model{p}
+			}
+			return nil
+		})
+		key := e.key(args.BackRef.CacheKey)
+		version, _ := e.version(key)
+		s.resolverEntries.LoadOrStore(key, builtEntry(&resolverHolder{resolver: resolver, version: version}))
+	}
+}
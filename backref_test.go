@@ -0,0 +1,97 @@
+package lode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/willhf/lode/lodetest"
+)
+
+func TestBackRef(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine(WithKeyNamespace("ns"))
+
+	a1, a2 := &Author{ID: 1, Name: "Alice"}, &Author{ID: 2, Name: "Bob"}
+	eng.InitHandles([]*Author{a1, a2})
+	allBooks := []*Book{
+		{ID: 10, AuthorID: 1, Title: "A"},
+		{ID: 11, AuthorID: 2, Title: "B"},
+		{ID: 12, AuthorID: 1, Title: "C"},
+	}
+
+	booksSpec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       a1,
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch:       lodetest.FetchFromSlice(allBooks, func(b *Book) int { return b.AuthorID }),
+		BackRef:     BackRef[int, *Book]{CacheKey: "author"},
+	}
+	books, err := Many(ctx, booksSpec)
+	if err != nil || len(books) != 2 {
+		t.Fatalf("Many = %v, %v", titles(books), err)
+	}
+	booksSpec.Model = a2
+	b2, _ := Many(ctx, booksSpec)
+	books = append(books, b2...)
+
+	// Book.Author as it would be written without knowing about BackRef.
+	authorFetch := lodetest.FetchFunc(func(context.Context, []int) ([]*Author, error) {
+		t.Fatal("author fetched despite BackRef")
+		return nil, nil
+	})
+	authorOf := func(b *Book) *Author {
+		t.Helper()
+		a, err := One(ctx, RelationSpec[int, *Book, *Author]{
+			CacheKey:    "author",
+			Model:       b,
+			ModelKey:    func(b *Book) (int, bool) { return b.AuthorID, true },
+			RelationKey: func(a *Author) int { return a.ID },
+			Fetch:       authorFetch.Fetch,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	for _, b := range books {
+		if got := authorOf(b); got == nil || got.ID != b.AuthorID {
+			t.Fatalf("author of %q = %+v", b.Title, got)
+		}
+	}
+	if got := authorOf(books[0]); got != a1 {
+		t.Fatalf("back reference is %p; want the loaded parent %p", got, a1)
+	}
+	if authorFetch.Calls() != 0 {
+		t.Fatalf("author fetches = %d; want 0", authorFetch.Calls())
+	}
+}
+
+func TestBackRef_SkipsStatesWithOtherModels(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+	// The fetched book shares a state with one this build knows nothing
+	// about, as when a query callback bound a wider result.
+	mine, other := &Book{ID: 1, AuthorID: 1}, &Book{ID: 2, AuthorID: 9}
+	eng.InitHandles([]*Book{mine, other})
+
+	_, err := Many(ctx, RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       a,
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch:       func(context.Context, []int) ([]*Book, error) { return []*Book{mine}, nil },
+		BackRef:     BackRef[int, *Book]{CacheKey: "author"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mine.core.resolverEntries.Load("author"); ok {
+		t.Fatal("back reference stored on a state with unrelated books")
+	}
+}
@@ -0,0 +1,15 @@
+package lode
+
+// Keys returns the distinct join keys of every model bound alongside model,
+// model included, in first-seen order, without resolving anything: the key
+// set a Many build for model's batch would fetch, for passing to an API lode
+// does not manage.  Models for which modelKey reports !ok are left out; as in
+// Many, modelKey sees nil models of the batch too.  The slice is the
+// caller's.
+func Keys[JoinKey comparable, Model hasState](model Model, modelKey func(Model) (JoinKey, bool)) ([]JoinKey, error) {
+	models, err := ModelsOf(model)
+	if err != nil {
+		return nil, err
+	}
+	return RelationSpec[JoinKey, Model, struct{}]{ModelKey: modelKey}.modelKeys(models), nil
+}
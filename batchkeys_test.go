@@ -0,0 +1,46 @@
+package lode
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestKeys(t *testing.T) {
+	t.Parallel()
+	authors := []*Author{{ID: 3}, {ID: 1}, {ID: 3}, nil, {ID: 0}, {ID: 2}}
+	NewEngine().InitHandles(authors)
+	modelKey := func(a *Author) (int, bool) {
+		if a == nil {
+			return 0, false
+		}
+		return a.ID, a.ID != 0
+	}
+
+	keys, err := Keys(authors[1], modelKey)
+	if err != nil || !slices.Equal(keys, []int{3, 1, 2}) {
+		t.Fatalf("Keys = %v, %v; want [3 1 2]", keys, err)
+	}
+
+	// Many fetches the same set.
+	var fetched []int
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    modelKey,
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(_ context.Context, ids []int) ([]*Book, error) {
+			fetched = ids
+			return nil, nil
+		},
+	}
+	if _, err := Many(context.Background(), spec.For(authors[0])); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(fetched, keys) {
+		t.Fatalf("Many fetched %v; Keys returned %v", fetched, keys)
+	}
+
+	if _, err := Keys(&Author{ID: 1}, modelKey); err == nil {
+		t.Fatal("Keys of an unbound model succeeded")
+	}
+}
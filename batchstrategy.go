@@ -0,0 +1,102 @@
+package lode
+
+import "reflect"
+
+// BatchStrategy decides how models bound together are split into states.
+// It is given the models as a reflect.Value of a []*T (nil elements
+// included) and returns the indexes of each state's models; a state's
+// models are in the order of its indexes.  Every index of a non-nil model
+// should appear in exactly one group.  A strategy returning nil leaves the
+// models to FixedSize with the engine's WithBatchSize.
+type BatchStrategy func(models reflect.Value) [][]int
+
+// WithBatchStrategy makes the engine split the models it binds with s
+// instead of by WithBatchSize alone.
+func WithBatchStrategy(s BatchStrategy) ConfigOption {
+	return func(c *Config) { c.batchStrategy = s }
+}
+
+// FixedSize splits models into consecutive groups of at most n, the default
+// strategy (with n from WithBatchSize).  n <= 0 keeps them in one group.
+func FixedSize(n int) BatchStrategy {
+	return func(models reflect.Value) [][]int {
+		var groups [][]int
+		for start, end := range ChunkRanges(models.Len(), n) {
+			group := make([]int, 0, end-start)
+			for i := start; i < end; i++ {
+				group = append(group, i)
+			}
+			groups = append(groups, group)
+		}
+		return groups
+	}
+}
+
+// GroupByFunc puts models of type M that f maps to the same key in the same
+// state, e.g. all of one tenant's models, so the fetches of each state stay
+// within one group.  Groups are ordered by their first model and are not
+// capped in size.  Models of other types are left to WithBatchSize, and nil
+// models are left out.
+func GroupByFunc[M any, K comparable](f func(M) K) BatchStrategy {
+	return func(models reflect.Value) [][]int {
+		if !models.Type().Elem().AssignableTo(reflect.TypeFor[M]()) {
+			return nil
+		}
+		var groups [][]int
+		index := make(map[K]int)
+		for i := range models.Len() {
+			el := models.Index(i)
+			if el.IsNil() {
+				continue
+			}
+			k := f(el.Interface().(M))
+			g, ok := index[k]
+			if !ok {
+				g = len(groups)
+				index[k] = g
+				groups = append(groups, nil)
+			}
+			groups[g] = append(groups[g], i)
+		}
+		return groups
+	}
+}
+
+// batches splits ps, a []*T, into the model slices of its states.
+func (e *Engine) batches(ps reflect.Value) []reflect.Value {
+	var out []reflect.Value
+	if s := e.config.batchStrategy; s != nil {
+		if groups := s(ps); groups != nil {
+			for _, g := range groups {
+				if len(g) > 0 {
+					out = append(out, subset(ps, g))
+				}
+			}
+			return out
+		}
+	}
+	for _, br := range batchRanges(ps.Len(), e.config.batchSize) {
+		out = append(out, ps.Slice(br.StartInclusive, br.EndExclusive))
+	}
+	return out
+}
+
+// subset returns the elements of ps at indexes, sharing ps's backing array
+// when they are a contiguous run.
+func subset(ps reflect.Value, indexes []int) reflect.Value {
+	contiguous := true
+	for j, i := range indexes {
+		if i != indexes[0]+j {
+			contiguous = false
+			break
+		}
+	}
+	if contiguous {
+		return ps.Slice(indexes[0], indexes[0]+len(indexes))
+	}
+	out := reflect.MakeSlice(ps.Type(), len(indexes), len(indexes))
+	for j, i := range indexes {
+		out.Index(j).Set(ps.Index(i))
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+package lode
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestGroupByFunc_TenantGroups(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine(WithBatchSize(2), WithBatchStrategy(GroupByFunc(func(b *Book) int { return b.AuthorID })))
+	books := []*Book{
+		{ID: 1, AuthorID: 10}, {ID: 2, AuthorID: 20}, nil, {ID: 3, AuthorID: 10},
+		{ID: 4, AuthorID: 30}, {ID: 5, AuthorID: 20}, {ID: 6, AuthorID: 10},
+	}
+	res, err := eng.Bind(books)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Batches) != 3 {
+		t.Fatalf("Bind made %d batches; want one per author", len(res.Batches))
+	}
+
+	var builds [][]int
+	spec := ResolveSpec[*Book, int]{
+		CacheKey: "tenant_size",
+		Build: func(_ context.Context, models []*Book) (ResolverFunc[*Book, int], error) {
+			var ids []int
+			for _, b := range models {
+				ids = append(ids, b.ID)
+			}
+			builds = append(builds, ids)
+			return func(*Book) int { return len(models) }, nil
+		},
+	}
+	want := map[int]int{1: 3, 2: 2, 3: 3, 4: 1, 5: 2, 6: 3}
+	for _, b := range books {
+		if b == nil {
+			continue
+		}
+		if n, err := Resolve(ctx, spec.For(b)); err != nil || n != want[b.ID] {
+			t.Fatalf("book %d: tenant size = %d, %v; want %d", b.ID, n, err, want[b.ID])
+		}
+	}
+	if !reflect.DeepEqual(builds, [][]int{{1, 3, 6}, {2, 5}, {4}}) {
+		t.Fatalf("builds saw %v; want the non-contiguous tenant groups", builds)
+	}
+
+	// Other model types fall back to WithBatchSize.
+	authors := []*Author{{ID: 1}, {ID: 2}, {ID: 3}}
+	if res, _ := eng.Bind(authors); len(res.Batches) != 2 {
+		t.Fatalf("authors bound in %d batches; want 2 of WithBatchSize(2)", len(res.Batches))
+	}
+}
+
+func TestFixedSize(t *testing.T) {
+	t.Parallel()
+	models := reflect.ValueOf(make([]*Author, 5))
+	if got := FixedSize(2)(models); !reflect.DeepEqual(got, [][]int{{0, 1}, {2, 3}, {4}}) {
+		t.Fatalf("FixedSize(2) = %v", got)
+	}
+	if got := FixedSize(0)(models); !reflect.DeepEqual(got, [][]int{{0, 1, 2, 3, 4}}) {
+		t.Fatalf("FixedSize(0) = %v", got)
+	}
+
+	authors := []*Author{{ID: 1}, {ID: 2}, {ID: 3}}
+	NewEngine(WithBatchStrategy(FixedSize(2))).InitHandles(authors)
+	first, _ := ModelsOf(authors[0])
+	last, _ := ModelsOf(authors[2])
+	if !slices.Equal(first, authors[:2]) || !slices.Equal(last, authors[2:]) {
+		t.Fatalf("states hold %v and %v", first, last)
+	}
+}
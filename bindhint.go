@@ -0,0 +1,54 @@
+package lode
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"weak"
+)
+
+// WithBindingHint adds hint, e.g. "did you register lodegorm.RegisterCallback
+// on this *gorm.DB?", to the error reported for models that were never
+// bound.  An unbound model has no engine, so the hint shown is that of an
+// engine that has bound the model's type before; models of types no engine
+// has bound get none.
+func WithBindingHint(hint string) ConfigOption {
+	return func(c *Config) { c.bindingHint = hint }
+}
+
+// SetDefaultBindingHint sets e's binding hint (see WithBindingHint) unless
+// it already has one.  Integrations such as lodegorm.RegisterCallback use it
+// to say how they bind models.
+func (e *Engine) SetDefaultBindingHint(hint string) {
+	e.bindingHint.CompareAndSwap(nil, &hint)
+}
+
+// boundTypes maps each model type bound so far to an engine that bound it,
+// weakly, so as not to keep the engine and its caches alive.
+var boundTypes sync.Map // reflect.Type -> weak.Pointer[engineCore]
+
+// noteBound records that e binds models of type t (e.g. *app.Author).
+func (e *Engine) noteBound(t reflect.Type) {
+	if v, ok := boundTypes.Load(t); ok && v.(weak.Pointer[engineCore]).Value() != nil {
+		return
+	}
+	boundTypes.Store(t, weak.Make(e.engineCore))
+}
+
+// notInitialized returns the error for a model without a state, explaining
+// what it can.
+func notInitialized(m any) error {
+	if isNil(m) {
+		return ErrNotInitialized
+	}
+	if v, ok := boundTypes.Load(reflect.TypeOf(m)); ok {
+		msg := fmt.Sprintf("other %T values have been bound, so this one came from a path that skips binding", m)
+		if core := v.(weak.Pointer[engineCore]).Value(); core != nil {
+			if hint := core.bindingHint.Load(); hint != nil {
+				msg += "; " + *hint
+			}
+		}
+		return fmt.Errorf("%s: %w: %s", packagePrefix, ErrNotInitialized, msg)
+	}
+	return ErrNotInitialized
+}
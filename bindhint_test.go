@@ -0,0 +1,93 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"weak"
+)
+
+// forgetBound drops t's record in boundTypes when the test ends, so reruns
+// see the type as never bound.
+func forgetBound(t *testing.T, typ reflect.Type) {
+	t.Cleanup(func() { boundTypes.Delete(typ) })
+}
+
+func TestBindingHint(t *testing.T) {
+	t.Parallel()
+	type hintedModel struct {
+		ID int
+		Handle
+	}
+	forgetBound(t, reflect.TypeFor[*hintedModel]())
+	ctx := context.Background()
+	spec := func(m *hintedModel) ResolveSpec[*hintedModel, int] {
+		return ResolveSpec[*hintedModel, int]{
+			CacheKey: "n",
+			Model:    m,
+			Build: func(context.Context, []*hintedModel) (ResolverFunc[*hintedModel, int], error) {
+				return func(*hintedModel) int { return 1 }, nil
+			},
+		}
+	}
+
+	// No engine has bound the type, so no engine's hint applies.
+	eng := NewEngine(WithBindingHint("did you call InitHandles after loading?"))
+	if _, err := Resolve(ctx, spec(&hintedModel{})); err != ErrNotInitialized {
+		t.Fatalf("err = %v; want the plain ErrNotInitialized", err)
+	}
+
+	// Once it has, its hint is shown, and an engine's own hint wins over a
+	// default one.
+	eng.SetDefaultBindingHint("ignored")
+	eng.InitHandles(&hintedModel{ID: 1})
+	_, err := Resolve(ctx, spec(&hintedModel{ID: 2}))
+	if !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("err = %v; want ErrNotInitialized", err)
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "values have been bound") || !strings.Contains(msg, "did you call InitHandles after loading?") || strings.Contains(msg, "ignored") {
+		t.Fatalf("err = %v; want the bound-type explanation and hint", err)
+	}
+
+	// Nil models still report the plain error.
+	if _, err := ModelsOf[*hintedModel](nil); err != ErrNotInitialized {
+		t.Fatalf("ModelsOf(nil) = %v; want ErrNotInitialized", err)
+	}
+}
+
+func TestSetDefaultBindingHint(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine()
+	eng.SetDefaultBindingHint("first")
+	eng.SetDefaultBindingHint("second")
+	if got := eng.bindingHint.Load(); got == nil || *got != "first" {
+		t.Fatalf("hint = %v; want first", got)
+	}
+	if got := eng.Scope().bindingHint.Load(); got == nil || *got != "first" {
+		t.Fatal("scopes should share the hint")
+	}
+}
+
+func TestBoundTypes_DoNotKeepEnginesAlive(t *testing.T) {
+	t.Parallel()
+	type collectedModel struct {
+		Handle
+	}
+	forgetBound(t, reflect.TypeFor[*collectedModel]())
+	NewEngine().InitHandles(&collectedModel{})
+	v, ok := boundTypes.Load(reflect.TypeFor[*collectedModel]())
+	if !ok {
+		t.Fatal("type not recorded as bound")
+	}
+	for range 10 {
+		runtime.GC()
+		if v.(weak.Pointer[engineCore]).Value() == nil {
+			return
+		}
+	}
+	t.Fatal("the engine that bound the type was never collected")
+}
@@ -0,0 +1,141 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WithCircuitBreaker makes the engine track consecutive build failures per
+// cache key and model type across all of its states.  After threshold
+// consecutive failures, builds for that key fail immediately with
+// ErrCircuitOpen until cooldown has passed; then a single probe build is let
+// through, which closes the circuit on success and reopens it on failure.
+// Builds that fail with context.Canceled do not count, since the cancellation
+// belongs to one caller.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.breakerThreshold = threshold
+		c.breakerCooldown = cooldown
+	}
+}
+
+// CircuitState is the state of one cache key's circuit breaker.
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitStats is a snapshot of one cache key's circuit breaker.
+type CircuitStats struct {
+	State               CircuitState
+	ConsecutiveFailures int
+	// OpenedAt is when the circuit last opened; zero if it never has.
+	OpenedAt time.Time
+}
+
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	circuits map[KeyLabel]*circuit
+}
+
+type circuit struct {
+	failures int
+	open     bool
+	probing  bool // a half-open probe build is in flight
+	openedAt time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		circuits:  make(map[KeyLabel]*circuit),
+	}
+}
+
+func (c *circuit) state(now time.Time, cooldown time.Duration) CircuitState {
+	switch {
+	case !c.open:
+		return CircuitClosed
+	case c.probing || now.Sub(c.openedAt) >= cooldown:
+		return CircuitHalfOpen
+	default:
+		return CircuitOpen
+	}
+}
+
+// allow reports whether a build for key may run now.
+func (b *circuitBreaker) allow(key KeyLabel, now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuits[key]
+	if c == nil || !c.open {
+		return nil
+	}
+	if c.probing || now.Sub(c.openedAt) < b.cooldown {
+		return fmt.Errorf("%s: key %q: %w", packagePrefix, key.CacheKey, ErrCircuitOpen)
+	}
+	c.probing = true
+	return nil
+}
+
+// record updates key's circuit with the outcome of a build allowed by allow.
+func (b *circuitBreaker) record(key KeyLabel, err error, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuits[key]
+	if c == nil {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+	probe := c.probing
+	c.probing = false
+	switch {
+	case err == nil:
+		c.failures = 0
+		c.open = false
+	case errors.Is(err, context.Canceled):
+		// not the backend's fault; a canceled probe leaves the circuit open
+	default:
+		c.failures++
+		if probe || c.failures >= b.threshold {
+			c.open = true
+			c.openedAt = now
+		}
+	}
+}
+
+func (b *circuitBreaker) stats(now time.Time) map[KeyLabel]CircuitStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make(map[KeyLabel]CircuitStats, len(b.circuits))
+	for key, c := range b.circuits {
+		out[key] = CircuitStats{
+			State:               c.state(now, b.cooldown),
+			ConsecutiveFailures: c.failures,
+			OpenedAt:            c.openedAt,
+		}
+	}
+	return out
+}
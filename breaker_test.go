@@ -0,0 +1,232 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/willhf/lode/lodetest"
+)
+
+// breakerHarness resolves key on a freshly bound author each time, since a
+// state caches the outcome of its one build.
+type breakerHarness struct {
+	eng    *Engine
+	builds int
+	fail   bool
+	panic  bool
+}
+
+var errDownstream = errors.New("downstream down")
+
+func (h *breakerHarness) resolve(t *testing.T, key string) error {
+	t.Helper()
+	a := &Author{ID: 1}
+	h.eng.InitHandles(a)
+	_, err := Resolve(context.Background(), ResolveSpec[*Author, int]{
+		CacheKey: key,
+		Model:    a,
+		Build: func(context.Context, []*Author) (ResolverFunc[*Author, int], error) {
+			h.builds++
+			if h.panic {
+				panic("boom")
+			}
+			if h.fail {
+				return nil, errDownstream
+			}
+			return func(*Author) int { return 1 }, nil
+		},
+	})
+	return err
+}
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	t.Parallel()
+	clock := lodetest.NewFakeClock(time.Unix(1000, 0))
+	h := &breakerHarness{eng: NewEngine(WithCircuitBreaker(3, time.Minute), WithClock(clock)), fail: true}
+
+	for i := 0; i < 3; i++ {
+		if err := h.resolve(t, "books"); !errors.Is(err, errDownstream) {
+			t.Fatalf("attempt %d: err = %v; want downstream error", i, err)
+		}
+	}
+	if got := h.eng.Stats().Circuits[KeyLabel{ModelType: "*lode.Author", CacheKey: "books"}]; got.State != CircuitOpen || got.ConsecutiveFailures != 3 || !got.OpenedAt.Equal(clock.Now()) {
+		t.Fatalf("stats = %+v; want open with 3 failures", got)
+	}
+
+	err := h.resolve(t, "books")
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v; want ErrCircuitOpen", err)
+	}
+	if h.builds != 3 {
+		t.Fatalf("builds = %d; want 3 (open circuit must not build)", h.builds)
+	}
+
+	// Other keys are unaffected.
+	h.fail = false
+	if err := h.resolve(t, "chapters"); err != nil {
+		t.Fatalf("other key err = %v", err)
+	}
+}
+
+func TestCircuitBreaker_HalfOpenProbe(t *testing.T) {
+	t.Parallel()
+	clock := lodetest.NewFakeClock(time.Unix(1000, 0))
+	h := &breakerHarness{eng: NewEngine(WithCircuitBreaker(1, time.Minute), WithClock(clock)), fail: true}
+
+	if err := h.resolve(t, "books"); !errors.Is(err, errDownstream) {
+		t.Fatal(err)
+	}
+	clock.Advance(59 * time.Second)
+	if err := h.resolve(t, "books"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("before cooldown: err = %v; want ErrCircuitOpen", err)
+	}
+
+	// Failed probe reopens for another cooldown.
+	clock.Advance(time.Second)
+	if got := h.eng.Stats().Circuits[KeyLabel{ModelType: "*lode.Author", CacheKey: "books"}].State; got != CircuitHalfOpen {
+		t.Fatalf("state = %v; want half-open", got)
+	}
+	if err := h.resolve(t, "books"); !errors.Is(err, errDownstream) {
+		t.Fatalf("probe: err = %v; want downstream error", err)
+	}
+	if err := h.resolve(t, "books"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("after failed probe: err = %v; want ErrCircuitOpen", err)
+	}
+
+	// Successful probe closes the circuit.
+	clock.Advance(time.Minute)
+	h.fail = false
+	if err := h.resolve(t, "books"); err != nil {
+		t.Fatalf("probe: err = %v; want success", err)
+	}
+	if got := h.eng.Stats().Circuits[KeyLabel{ModelType: "*lode.Author", CacheKey: "books"}]; got.State != CircuitClosed || got.ConsecutiveFailures != 0 {
+		t.Fatalf("stats = %+v; want closed with 0 failures", got)
+	}
+	if h.builds != 3 {
+		t.Fatalf("builds = %d; want 3", h.builds)
+	}
+}
+
+func TestCircuitBreaker_PanickingProbe(t *testing.T) {
+	t.Parallel()
+	clock := lodetest.NewFakeClock(time.Unix(1000, 0))
+	h := &breakerHarness{eng: NewEngine(WithCircuitBreaker(1, time.Minute), WithClock(clock)), fail: true}
+	if err := h.resolve(t, "books"); !errors.Is(err, errDownstream) {
+		t.Fatal(err)
+	}
+
+	// The probe panics; it counts as a failure and reopens the circuit.
+	clock.Advance(time.Minute)
+	h.panic = true
+	err := Parallel(context.Background(), func(context.Context) error { return h.resolve(t, "books") })
+	if !errors.Is(err, errPanicked) {
+		t.Fatalf("probe: err = %v; want the recovered panic", err)
+	}
+	if err := h.resolve(t, "books"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("after the panicking probe: err = %v; want ErrCircuitOpen", err)
+	}
+
+	// The next cooldown lets a probe through again.
+	clock.Advance(time.Minute)
+	h.panic, h.fail = false, false
+	if err := h.resolve(t, "books"); err != nil {
+		t.Fatalf("probe after cooldown: err = %v; want success", err)
+	}
+}
+
+func TestCircuitBreaker_SuccessResetsCount(t *testing.T) {
+	t.Parallel()
+	clock := lodetest.NewFakeClock(time.Unix(1000, 0))
+	h := &breakerHarness{eng: NewEngine(WithCircuitBreaker(2, time.Minute), WithClock(clock))}
+
+	for _, fail := range []bool{true, false, true} {
+		h.fail = fail
+		_ = h.resolve(t, "books")
+	}
+	if got := h.eng.Stats().Circuits[KeyLabel{ModelType: "*lode.Author", CacheKey: "books"}]; got.State != CircuitClosed || got.ConsecutiveFailures != 1 {
+		t.Fatalf("stats = %+v; want closed with 1 failure", got)
+	}
+}
+
+func TestCircuitBreaker_DisabledByDefault(t *testing.T) {
+	t.Parallel()
+	h := &breakerHarness{eng: NewEngine(), fail: true}
+	for i := 0; i < 5; i++ {
+		if err := h.resolve(t, "books"); !errors.Is(err, errDownstream) {
+			t.Fatalf("err = %v; want downstream error", err)
+		}
+	}
+	if h.eng.Stats().Circuits != nil {
+		t.Fatal("Circuits should be nil without WithCircuitBreaker")
+	}
+}
+
+func TestSharedCacheKey_AcrossModelTypes(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	clock := lodetest.NewFakeClock(time.Unix(1000, 0))
+	eng := NewEngine(WithCircuitBreaker(2, time.Minute), WithClock(clock), WithFetchStats(0, 0))
+
+	const key = "author:books"
+	authorBooks := func(a *Author) error {
+		_, err := Many(ctx, RelationSpec[int, *Author, *Book]{
+			CacheKey:    key,
+			Model:       a,
+			ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+			RelationKey: func(b *Book) int { return b.AuthorID },
+			Fetch:       func(context.Context, []int) ([]*Book, error) { return nil, errDownstream },
+		})
+		return err
+	}
+	publisherBooks := func(p *Publisher) ([]*Book, error) {
+		return Many(ctx, RelationSpec[int, *Publisher, *Book]{
+			CacheKey:    key,
+			Model:       p,
+			ModelKey:    func(p *Publisher) (int, bool) { return p.ID, true },
+			RelationKey: func(b *Book) int { return b.ID },
+			Fetch: func(_ context.Context, ids []int) ([]*Book, error) {
+				return []*Book{{ID: ids[0], Title: "published"}}, nil
+			},
+		})
+	}
+
+	// Trip the Author circuit.
+	for i := 0; i < 3; i++ {
+		a := &Author{ID: 1}
+		eng.InitHandles(a)
+		if err := authorBooks(a); err == nil {
+			t.Fatal("want an error")
+		}
+	}
+	authorLabel := KeyLabel{ModelType: "*lode.Author", CacheKey: key}
+	publisherLabel := KeyLabel{ModelType: "*lode.Publisher", CacheKey: key}
+	if got := eng.Stats().Circuits[authorLabel].State; got != CircuitOpen {
+		t.Fatalf("author circuit = %v; want open", got)
+	}
+
+	// Publishers using the same key are unaffected.
+	for i := 0; i < 2; i++ {
+		p := &Publisher{ID: 7}
+		eng.InitHandles(p)
+		got, err := publisherBooks(p)
+		if err != nil || len(got) != 1 || got[0].Title != "published" {
+			t.Fatalf("publisher books = %v, %v", got, err)
+		}
+	}
+
+	stats := eng.Stats()
+	if got := stats.Circuits[publisherLabel]; got.State != CircuitClosed || got.ConsecutiveFailures != 0 {
+		t.Fatalf("publisher circuit = %+v; want closed", got)
+	}
+	if got := stats.Fetches[authorLabel].Fetches; got != 2 {
+		t.Fatalf("author fetches = %d; want 2 (the third was short-circuited)", got)
+	}
+	if got := stats.Fetches[publisherLabel].Fetches; got != 2 {
+		t.Fatalf("publisher fetches = %d; want 2", got)
+	}
+	if s := publisherLabel.String(); s != "author:books (*lode.Publisher)" {
+		t.Fatalf("label = %q", s)
+	}
+}
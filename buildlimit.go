@@ -0,0 +1,102 @@
+package lode
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WithMaxConcurrentBuilds caps the number of builds (the Build of Resolve,
+// and so the fetches of Many and One) running at once across the engine and
+// its scopes, to keep a burst of cold caches from saturating the database's
+// connection pool.  Builds beyond the limit wait their turn; a call whose ctx
+// ends while it waits fails with the ctx's error, and nothing is cached.
+// Builds started from within a running build (a Build that itself resolves)
+// use their parent's slot rather than waiting for one, and the chunks of a
+// fetch run at once under WithFetchConcurrency take a slot each.  A Build
+// that hands work to goroutines whose ctx does not derive from its own can
+// still deadlock the engine, as those goroutines wait for a slot the Build
+// holds.
+// Zero or negative means no limit.  Waits are reported through
+// Hooks.OnBuildWait.
+func WithMaxConcurrentBuilds(n int) ConfigOption {
+	return func(c *Config) { c.maxBuilds = max(n, 0) }
+}
+
+// BuildWaitEvent describes one build's wait for a slot under
+// WithMaxConcurrentBuilds.
+type BuildWaitEvent struct {
+	CacheKey  string
+	ModelType string // empty for Global
+	Wait      time.Duration
+	// Err is set if the build gave up waiting.
+	Err error
+}
+
+// buildSlotKey marks the ctx of a build that holds a slot of the engine
+// core it maps to.
+type buildSlotKey struct{}
+
+// acquireBuild takes a build slot for key, returning the ctx the build should
+// run with and the function that frees the slot.
+func (e *Engine) acquireBuild(ctx context.Context, key KeyLabel) (context.Context, func(), error) {
+	if e.buildSlots == nil || ctx.Value(buildSlotKey{}) == e.engineCore {
+		return ctx, func() {}, nil
+	}
+	start := e.now()
+	var err error
+	select {
+	case e.buildSlots <- struct{}{}:
+	case <-ctx.Done():
+		err = fmt.Errorf("%s: key %q: waiting for a build slot: %w", packagePrefix, key.CacheKey, ctx.Err())
+	}
+	e.onBuildWait(BuildWaitEvent{CacheKey: key.CacheKey, ModelType: key.ModelType, Wait: e.now().Sub(start), Err: err})
+	if err != nil {
+		return ctx, nil, err
+	}
+	return context.WithValue(ctx, buildSlotKey{}, e.engineCore), func() { <-e.buildSlots }, nil
+}
+
+func (e *Engine) onBuildWait(ev BuildWaitEvent) {
+	for _, h := range e.config.hooks {
+		if h.OnBuildWait != nil {
+			h.OnBuildWait(ev)
+		}
+	}
+}
+
+// chunkSlots hands build slots to the chunks of one build fetched at once
+// under WithFetchConcurrency, so each counts as a build: the first chunk in
+// flight runs on the build's own slot, and each other takes one of the
+// engine's.
+type chunkSlots struct {
+	e   *Engine
+	own chan struct{} // holds the build's slot while no chunk uses it
+}
+
+func (e *Engine) chunkSlots() *chunkSlots {
+	c := &chunkSlots{e: e, own: make(chan struct{}, 1)}
+	c.own <- struct{}{}
+	return c
+}
+
+// acquire waits for a slot for a chunk of the build of key, returning the
+// function that frees it.
+func (c *chunkSlots) acquire(ctx context.Context, key string) (func(), error) {
+	if c.e.buildSlots == nil {
+		return func() {}, nil
+	}
+	select {
+	case <-c.own:
+		return func() { c.own <- struct{}{} }, nil
+	default:
+	}
+	select {
+	case <-c.own:
+		return func() { c.own <- struct{}{} }, nil
+	case c.e.buildSlots <- struct{}{}:
+		return func() { <-c.e.buildSlots }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("%s: key %q: waiting for a build slot: %w", packagePrefix, key, ctx.Err())
+	}
+}
@@ -0,0 +1,210 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// resolveWith resolves key on a with build as the Build.
+func resolveWith(ctx context.Context, a *Author, key string, build func(context.Context) error) error {
+	_, err := Resolve(ctx, ResolveSpec[*Author, int]{
+		CacheKey: key,
+		Model:    a,
+		Build: func(ctx context.Context, _ []*Author) (ResolverFunc[*Author, int], error) {
+			if err := build(ctx); err != nil {
+				return nil, err
+			}
+			return func(*Author) int { return 0 }, nil
+		},
+	})
+	return err
+}
+
+func TestMaxConcurrentBuilds(t *testing.T) {
+	t.Parallel()
+	var waits atomic.Int32
+	eng := NewEngine(WithMaxConcurrentBuilds(2), WithHooks(Hooks{
+		OnBuildWait: func(BuildWaitEvent) { waits.Add(1) },
+	}))
+	if n := eng.Config().MaxConcurrentBuilds(); n != 2 {
+		t.Fatalf("MaxConcurrentBuilds() = %d; want 2", n)
+	}
+
+	var active, peak atomic.Int32
+	var wg sync.WaitGroup
+	for i := range 8 {
+		a := &Author{ID: i}
+		eng.InitHandles(a) // a state, and so a build, per author
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := resolveWith(context.Background(), a, "slow", func(context.Context) error {
+				n := active.Add(1)
+				for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+				}
+				time.Sleep(5 * time.Millisecond)
+				active.Add(-1)
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if p := peak.Load(); p < 1 || p > 2 {
+		t.Fatalf("%d builds overlapped; want at most 2", p)
+	}
+	if n := waits.Load(); n != 8 {
+		t.Fatalf("OnBuildWait called %d times; want 8", n)
+	}
+}
+
+func TestMaxConcurrentBuilds_CanceledWait(t *testing.T) {
+	t.Parallel()
+	var events []BuildWaitEvent
+	var mu sync.Mutex
+	eng := NewEngine(WithMaxConcurrentBuilds(1), WithHooks(Hooks{
+		OnBuildWait: func(ev BuildWaitEvent) {
+			mu.Lock()
+			events = append(events, ev)
+			mu.Unlock()
+		},
+	}))
+	holder, waiter := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles(holder)
+	eng.InitHandles(waiter)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- resolveWith(context.Background(), holder, "slow", func(context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := resolveWith(ctx, waiter, "slow", func(context.Context) error {
+		t.Error("build ran without a slot")
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v; want DeadlineExceeded", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 || !errors.Is(events[1].Err, context.DeadlineExceeded) || events[1].Wait <= 0 || events[1].ModelType != "*lode.Author" {
+		t.Fatalf("events = %+v; want the second to report the failed wait", events)
+	}
+}
+
+func TestMaxConcurrentBuilds_NestedBuildsShareSlot(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine(WithMaxConcurrentBuilds(1))
+	outer, inner := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles(outer)
+	eng.Scope().InitHandles(inner)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := resolveWith(ctx, outer, "outer", func(ctx context.Context) error {
+		return resolveWith(ctx, inner, "inner", func(context.Context) error { return nil })
+	})
+	if err != nil {
+		t.Fatalf("nested build: %v", err)
+	}
+}
+
+func TestMaxConcurrentBuilds_WaitDoesNotHoldEntry(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine(WithMaxConcurrentBuilds(1))
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+
+	// A builds x, holding the only slot, and resolves y from within; B asks
+	// for y meanwhile and has to wait for the slot.
+	started, proceed := make(chan struct{}), make(chan struct{})
+	done := make(chan error, 2)
+	go func() {
+		done <- resolveWith(context.Background(), a, "x", func(ctx context.Context) error {
+			close(started)
+			<-proceed
+			return resolveWith(ctx, a, "y", func(context.Context) error { return nil })
+		})
+	}()
+	<-started
+	go func() {
+		done <- resolveWith(context.Background(), a, "y", func(context.Context) error { return nil })
+	}()
+	time.Sleep(10 * time.Millisecond) // let B start waiting
+	close(proceed)
+
+	for range 2 {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("a build waiting for a slot blocked a nested build")
+		}
+	}
+}
+
+func TestMaxConcurrentBuilds_WaitersTakeNoSlot(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine(WithMaxConcurrentBuilds(2))
+	a, b := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles(a)
+	eng.InitHandles(b)
+
+	// x's build, holding one slot, needs y built elsewhere; the callers
+	// waiting on x must leave the other slot to y's build.
+	started, yBuilt := make(chan struct{}), make(chan struct{})
+	build := func(context.Context) error {
+		close(started)
+		select {
+		case <-yBuilt:
+			return nil
+		case <-time.After(2 * time.Second):
+			return errors.New("waiters on x starved y's build")
+		}
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := resolveWith(context.Background(), a, "x", build); err != nil {
+			t.Error(err)
+		}
+	}()
+	<-started
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := resolveWith(context.Background(), a, "x", build); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond) // let the waiters queue up
+	if err := resolveWith(context.Background(), b, "y", func(context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	close(yBuilt)
+	wg.Wait()
+}
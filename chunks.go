@@ -0,0 +1,36 @@
+package lode
+
+import "iter"
+
+// Chunks splits items into consecutive sub-slices of at most size elements,
+// which is handy in Fetch functions that talk to APIs with a page size limit.
+// The chunks share items' backing array but are capacity-limited, so
+// appending to one never overwrites the next.  A size <= 0 yields all of
+// items as a single chunk; empty input yields nothing.
+func Chunks[T any](items []T, size int) iter.Seq[[]T] {
+	return func(yield func([]T) bool) {
+		for start, end := range ChunkRanges(len(items), size) {
+			if !yield(items[start:end:end]) {
+				return
+			}
+		}
+	}
+}
+
+// ChunkRanges is the index form of Chunks: it yields the [start, end) range
+// of each chunk of n items.  The same rules apply for size <= 0 and n <= 0.
+func ChunkRanges(n, size int) iter.Seq2[int, int] {
+	return func(yield func(int, int) bool) {
+		if n <= 0 {
+			return
+		}
+		if size <= 0 {
+			size = n
+		}
+		for i := 0; i < n; i += size {
+			if !yield(i, min(i+size, n)) {
+				return
+			}
+		}
+	}
+}
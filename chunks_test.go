@@ -0,0 +1,104 @@
+package lode
+
+import (
+	"slices"
+	"testing"
+	"testing/quick"
+)
+
+func TestChunks_ConcatReproducesInput(t *testing.T) {
+	t.Parallel()
+	prop := func(items []int, size int8) bool {
+		var got []int
+		n := 0
+		for chunk := range Chunks(items, int(size)) {
+			if len(chunk) == 0 {
+				return false // never yield empty chunks
+			}
+			if size > 0 && len(chunk) > int(size) {
+				return false
+			}
+			got = append(got, chunk...)
+			n++
+		}
+		if len(items) == 0 {
+			return n == 0
+		}
+		if size <= 0 && n != 1 {
+			return false
+		}
+		return slices.Equal(got, items)
+	}
+	if err := quick.Check(prop, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChunkRanges_Contiguous(t *testing.T) {
+	t.Parallel()
+	prop := func(n uint8, size int8) bool {
+		next := 0
+		for start, end := range ChunkRanges(int(n), int(size)) {
+			if start != next || end <= start || end > int(n) {
+				return false
+			}
+			next = end
+		}
+		return next == int(n)
+	}
+	if err := quick.Check(prop, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChunks_EdgeCases(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		items []int
+		size  int
+		want  [][]int
+	}{
+		{name: "nil input", items: nil, size: 2, want: nil},
+		{name: "empty input", items: []int{}, size: 2, want: nil},
+		{name: "zero size", items: []int{1, 2, 3}, size: 0, want: [][]int{{1, 2, 3}}},
+		{name: "negative size", items: []int{1, 2, 3}, size: -1, want: [][]int{{1, 2, 3}}},
+		{name: "exact multiple", items: []int{1, 2, 3, 4}, size: 2, want: [][]int{{1, 2}, {3, 4}}},
+		{name: "remainder", items: []int{1, 2, 3}, size: 2, want: [][]int{{1, 2}, {3}}},
+		{name: "size larger than input", items: []int{1, 2}, size: 10, want: [][]int{{1, 2}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got [][]int
+			for chunk := range Chunks(tc.items, tc.size) {
+				got = append(got, chunk)
+			}
+			if !slices.EqualFunc(got, tc.want, slices.Equal) {
+				t.Fatalf("Chunks(%v, %d) = %v; want %v", tc.items, tc.size, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestChunks_AppendDoesNotClobber(t *testing.T) {
+	t.Parallel()
+	items := []int{1, 2, 3, 4}
+	for chunk := range Chunks(items, 2) {
+		_ = append(chunk, 99)
+	}
+	if !slices.Equal(items, []int{1, 2, 3, 4}) {
+		t.Fatalf("items mutated: %v", items)
+	}
+}
+
+func TestBatchRanges_UsesChunkRanges(t *testing.T) {
+	t.Parallel()
+	got := batchRanges(5, 2)
+	want := []rangeIndex{{0, 2}, {2, 4}, {4, 5}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("batchRanges(5, 2) = %v; want %v", got, want)
+	}
+	if got := batchRanges(0, 2); got != nil {
+		t.Fatalf("batchRanges(0, 2) = %v; want nil", got)
+	}
+}
@@ -0,0 +1,29 @@
+package lode
+
+import "time"
+
+// Clock is the engine's source of time.  Every time-dependent feature reads
+// time only through the engine's clock, so tests can control it with
+// lodetest.FakeClock.
+type Clock interface {
+	Now() time.Time
+	// After returns a channel that receives the time once d has passed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock returns the Clock backed by package time, which engines use
+// unless configured WithClock.
+func SystemClock() Clock { return systemClock{} }
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// WithClock makes the engine read time from c.
+func WithClock(c Clock) ConfigOption {
+	return func(cfg *Config) { cfg.clock = c }
+}
+
+// now returns the current time on the engine's clock.
+func (e *Engine) now() time.Time { return e.config.clock.Now() }
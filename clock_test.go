@@ -0,0 +1,26 @@
+package lode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/willhf/lode/lodetest"
+)
+
+var _ Clock = (*lodetest.FakeClock)(nil)
+
+func TestWithClock(t *testing.T) {
+	t.Parallel()
+	if _, ok := NewEngine().Config().Clock().(systemClock); !ok {
+		t.Fatal("default clock is not the system clock")
+	}
+	clock := lodetest.NewFakeClock(time.Unix(42, 0))
+	eng := NewEngine(WithClock(clock))
+	if !eng.now().Equal(time.Unix(42, 0)) {
+		t.Fatalf("now = %v", eng.now())
+	}
+	clock.Advance(time.Second)
+	if !eng.now().Equal(time.Unix(43, 0)) {
+		t.Fatalf("now after Advance = %v", eng.now())
+	}
+}
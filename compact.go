@@ -0,0 +1,91 @@
+package lode
+
+// compactGroups is the CompactGroups form of a Many result: the relations of
+// each key are the range index[key] of backing.
+type compactGroups[JoinKey comparable, Relation any] struct {
+	backing []Relation
+	index   map[JoinKey]rangeIndex
+}
+
+// lookup returns id's group.  Its capacity ends with the group, so an append
+// by the caller cannot overwrite the next one.
+func (c compactGroups[JoinKey, Relation]) lookup(id JoinKey) []Relation {
+	r, ok := c.index[id]
+	if !ok {
+		return nil
+	}
+	return c.backing[r.StartInclusive:r.EndExclusive:r.EndExclusive]
+}
+
+// compactGroup is group for CompactGroups, returning how many relations had
+// no key.  Relations keep their fetch order within a key.
+func (args RelationSpec[JoinKey, Model, Relation]) compactGroup(relations []Relation) (compactGroups[JoinKey, Relation], int) {
+	keys := make([]JoinKey, len(relations))
+	placed := make([]bool, len(relations))
+	index := make(map[JoinKey]rangeIndex)
+	var order []JoinKey // keys in order of first appearance
+	unplaced := 0
+	for i, relation := range relations {
+		k, ok := args.relationKey(relation)
+		if !ok {
+			unplaced++
+			continue
+		}
+		keys[i], placed[i] = k, true
+		r, seen := index[k]
+		if !seen {
+			order = append(order, k)
+		}
+		r.EndExclusive++ // a count until the ranges are laid out
+		index[k] = r
+	}
+
+	// Lay the ranges out back to back, each starting out empty and growing
+	// as its relations are copied in.
+	start := 0
+	for _, k := range order {
+		n := index[k].EndExclusive
+		index[k] = rangeIndex{StartInclusive: start, EndExclusive: start}
+		start += n
+	}
+	backing := make([]Relation, start)
+	for i, relation := range relations {
+		if !placed[i] {
+			continue
+		}
+		r := index[keys[i]]
+		backing[r.EndExclusive] = relation
+		r.EndExclusive++
+		index[keys[i]] = r
+	}
+	return compactGroups[JoinKey, Relation]{backing: backing, index: index}, unplaced
+}
+
+// packGroups copies the groups of grouped back to back into one slice, those
+// of keys first and in their order, and points each group at its copy.  It
+// returns the slice.
+func packGroups[JoinKey comparable, Relation any](grouped map[JoinKey][]Relation, keys []JoinKey) []Relation {
+	n := 0
+	for _, rs := range grouped {
+		n += len(rs)
+	}
+	backing := make([]Relation, 0, n)
+	pack := func(k JoinKey) {
+		start := len(backing)
+		backing = append(backing, grouped[k]...)
+		grouped[k] = backing[start:len(backing):len(backing)]
+	}
+	packed := make(map[JoinKey]bool, len(keys))
+	for _, k := range keys {
+		if _, ok := grouped[k]; ok && !packed[k] {
+			packed[k] = true
+			pack(k)
+		}
+	}
+	for k := range grouped {
+		if !packed[k] {
+			pack(k)
+		}
+	}
+	return backing
+}
@@ -0,0 +1,88 @@
+package lode
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestMany_CompactGroups(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fetched := []*Book{
+		{ID: 1, AuthorID: 2, Title: "B1"},
+		{ID: 2, AuthorID: 1, Title: "A1"},
+		{ID: 3, AuthorID: 0, Title: "orphan"},
+		{ID: 4, AuthorID: 2, Title: "B2"},
+		{ID: 5, AuthorID: 1, Title: "A2"},
+		{ID: 6, AuthorID: 2, Title: "B3"},
+	}
+
+	results := make(map[bool][][]string)
+	for _, compact := range []bool{false, true} {
+		eng := NewEngine()
+		authors := []*Author{{ID: 1}, {ID: 2}, {ID: 3}}
+		eng.InitHandles(authors)
+		spec := RelationSpec[int, *Author, *Book]{
+			CacheKey:      "books",
+			ModelKey:      func(a *Author) (int, bool) { return a.ID, true },
+			RelationKeyOK: func(b *Book) (int, bool) { return b.AuthorID, b.AuthorID != 0 },
+			Fetch:         func(context.Context, []int) ([]*Book, error) { return fetched, nil },
+			CompactGroups: compact,
+		}
+		for _, a := range authors {
+			got, err := Many(ctx, spec.For(a))
+			if err != nil {
+				t.Fatal(err)
+			}
+			results[compact] = append(results[compact], titles(got))
+		}
+		if got := eng.Stats().SkippedRelations; got != 1 {
+			t.Fatalf("compact=%v: SkippedRelations = %d; want 1", compact, got)
+		}
+	}
+	want := [][]string{{"A1", "A2"}, {"B1", "B2", "B3"}, {}}
+	if !reflect.DeepEqual(results[true], want) || !reflect.DeepEqual(results[false], want) {
+		t.Fatalf("plain = %v, compact = %v; want %v", results[false], results[true], want)
+	}
+}
+
+func TestCompactGroup_AppendDoesNotClobber(t *testing.T) {
+	t.Parallel()
+	spec := RelationSpec[int, *Author, *Book]{RelationKey: func(b *Book) int { return b.AuthorID }}
+	c, _ := spec.compactGroup([]*Book{{AuthorID: 1, Title: "A1"}, {AuthorID: 2, Title: "B1"}})
+	_ = append(c.lookup(1), &Book{Title: "extra"})
+	if got := titles(c.lookup(2)); !equalStrings(got, []string{"B1"}) {
+		t.Fatalf("group 2 = %v after appending to group 1", got)
+	}
+	if c.lookup(3) != nil {
+		t.Fatal("missing key should have no group")
+	}
+}
+
+func BenchmarkMany_CompactGroups(b *testing.B) {
+	const keys, perKey = 50000, 10
+	books := make([]*Book, 0, keys*perKey)
+	for i := 0; i < perKey; i++ {
+		for k := 0; k < keys; k++ {
+			books = append(books, &Book{AuthorID: k})
+		}
+	}
+	for _, compact := range []bool{false, true} {
+		b.Run(fmt.Sprintf("compact=%v", compact), func(b *testing.B) {
+			spec := RelationSpec[int, *Author, *Book]{
+				RelationKey:   func(b *Book) int { return b.AuthorID },
+				CompactGroups: compact,
+			}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if compact {
+					spec.compactGroup(books)
+				} else {
+					spec.group(books)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package lode
+
+import (
+	"maps"
+	"slices"
+	"time"
+)
+
+// Clone returns a copy of c that shares nothing mutable with it, so options
+// applied to one (WithHooks appends) never show up in the other.
+func (c Config) Clone() Config {
+	c.hooks = slices.Clone(c.hooks)
+	c.immutableKeys = slices.Clone(c.immutableKeys)
+	c.limiters = maps.Clone(c.limiters)
+	return c
+}
+
+// Config returns a copy of the engine's configuration.
+func (e *Engine) Config() Config { return e.config.Clone() }
+
+// BatchSize returns the maximum number of models per state; see
+// WithBatchSize.
+func (c Config) BatchSize() int { return c.batchSize }
+
+// BatchStrategy returns the strategy set with WithBatchStrategy, or nil.
+func (c Config) BatchStrategy() BatchStrategy { return c.batchStrategy }
+
+// KeyNamespace returns the cache key prefix; see WithKeyNamespace.
+func (c Config) KeyNamespace() string { return c.keyNamespace }
+
+// KeyVersion returns the key version the engine was created with; see
+// WithKeyVersion and Engine.KeyVersion.
+func (c Config) KeyVersion() string { return c.keyVersion }
+
+// MembershipCheck reports whether WithMembershipCheck is set.
+func (c Config) MembershipCheck() bool { return c.membershipCheck }
+
+// Debug reports whether WithDebug is set.
+func (c Config) Debug() bool { return c.debug }
+
+// FetchDedup reports whether WithFetchDedup is set.
+func (c Config) FetchDedup() bool { return c.fetchDedup }
+
+// FallbackFetch reports whether WithFallbackFetch is set.
+func (c Config) FallbackFetch() bool { return c.fallbackFetch }
+
+// ImmutableKeys returns the keys set with WithImmutableKeys.
+func (c Config) ImmutableKeys() []string { return slices.Clone(c.immutableKeys) }
+
+// FetchChunkSize returns the chunk size set with WithFetchChunkSize.
+func (c Config) FetchChunkSize() int { return c.specDefaults.MaxKeysPerFetch }
+
+// FetchConcurrency returns the limit set with WithFetchConcurrency.
+func (c Config) FetchConcurrency() int { return c.fetchConcurrency }
+
+// FetchDescriptors reports whether WithFetchDescriptors is set.
+func (c Config) FetchDescriptors() bool { return c.fetchDescriptors }
+
+// NilModelPolicy returns the policy set with WithNilModelPolicy.
+func (c Config) NilModelPolicy() NilModelPolicy { return c.specDefaults.NilModel }
+
+// GraphTrace reports whether WithGraphTrace is set.
+func (c Config) GraphTrace() bool { return c.graphTrace }
+
+// StrictKeys reports whether WithStrictKeys is set.
+func (c Config) StrictKeys() bool { return c.strictKeys }
+
+// DefinedKeys reports whether WithDefinedKeys is set.
+func (c Config) DefinedKeys() bool { return c.definedKeys }
+
+// LeakTracking reports whether WithLeakTracking is set.
+func (c Config) LeakTracking() bool { return c.leakTracking }
+
+// MaxConcurrentBuilds returns the build limit, zero for none; see
+// WithMaxConcurrentBuilds.
+func (c Config) MaxConcurrentBuilds() int { return c.maxBuilds }
+
+// RebuildPolicy returns what callers get during rebuilds; see
+// WithRebuildPolicy.
+func (c Config) RebuildPolicy() RebuildPolicy { return c.rebuildPolicy }
+
+// MemoryBudget returns the memory budget and its policy; see
+// WithMemoryBudget.
+func (c Config) MemoryBudget() (n int, policy BudgetPolicy) { return c.memBudget, c.memPolicy }
+
+// MaxRelationsPerBuild returns the relation limit; see
+// WithMaxRelationsPerBuild.
+func (c Config) MaxRelationsPerBuild() int { return c.maxRelations }
+
+// CircuitBreaker returns the breaker settings; a zero threshold means the
+// breaker is off.  See WithCircuitBreaker.
+func (c Config) CircuitBreaker() (threshold int, cooldown time.Duration) {
+	return c.breakerThreshold, c.breakerCooldown
+}
+
+// Clock returns the engine's clock; see WithClock.
+func (c Config) Clock() Clock { return c.clock }
+
+// Hooks returns a copy of the registered hooks, in registration order.
+func (c Config) Hooks() []Hooks { return slices.Clone(c.hooks) }
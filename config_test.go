@@ -0,0 +1,70 @@
+package lode
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfig_PresetsAndClone(t *testing.T) {
+	t.Parallel()
+	var calls []string
+	hook := func(name string) ConfigOption {
+		return WithHooks(Hooks{OnFetch: func(FetchEvent) { calls = append(calls, name) }})
+	}
+
+	base := NewConfig(WithBatchSize(100), WithKeyNamespace("svc"), hook("base"))
+	// Leave spare capacity in base's hook slice, which is what would let a
+	// shallow copy's append leak into a sibling.
+	base.hooks = append(make([]Hooks, 0, 8), base.hooks...)
+
+	bulk := NewEngineFrom(base, WithBatchSize(10000), hook("bulk"))
+	web := NewEngineFrom(base, WithCircuitBreaker(3, time.Second), hook("web"))
+
+	if got := bulk.Config().BatchSize(); got != 10000 {
+		t.Fatalf("bulk BatchSize = %d", got)
+	}
+	if got := web.Config().BatchSize(); got != 100 {
+		t.Fatalf("web BatchSize = %d", got)
+	}
+	if web.Config().KeyNamespace() != "svc" || bulk.Config().KeyNamespace() != "svc" {
+		t.Fatal("namespace not inherited")
+	}
+	if th, cd := web.Config().CircuitBreaker(); th != 3 || cd != time.Second {
+		t.Fatalf("web breaker = %d, %v", th, cd)
+	}
+	if th, _ := bulk.Config().CircuitBreaker(); th != 0 {
+		t.Fatal("breaker leaked into bulk")
+	}
+
+	if n := len(base.Hooks()); n != 1 {
+		t.Fatalf("base has %d hooks; want 1", n)
+	}
+	for _, h := range bulk.Config().Hooks() {
+		h.OnFetch(FetchEvent{})
+	}
+	for _, h := range web.Config().Hooks() {
+		h.OnFetch(FetchEvent{})
+	}
+	if !equalStrings(calls, []string{"base", "bulk", "base", "web"}) {
+		t.Fatalf("hook calls = %v", calls)
+	}
+
+	// Mutating an accessor's copy does not reach the engine.
+	hs := web.Config().Hooks()
+	hs[0] = Hooks{}
+	if web.config.hooks[0].OnFetch == nil {
+		t.Fatal("Hooks() exposed the engine's slice")
+	}
+}
+
+func TestNewEngineFrom_ZeroConfig(t *testing.T) {
+	t.Parallel()
+	eng := NewEngineFrom(Config{}, WithDebug())
+	if !eng.Config().Debug() || eng.config.clock == nil {
+		t.Fatalf("config = %+v", eng.Config())
+	}
+	a := &Author{ID: 1}
+	if _, err := eng.Bind([]*Author{a, {ID: 2}}); err != nil {
+		t.Fatal(err)
+	}
+}
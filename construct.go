@@ -0,0 +1,68 @@
+package lode
+
+import (
+	"context"
+	"fmt"
+)
+
+// NewRelation returns a RelationSpec for cacheKey with its type parameters
+// inferred from the key functions, so call sites need not spell them out:
+//
+//	var authorBooks = lode.NewRelation(
+//		func(a *Author) (uint, bool) { return a.ID, true },
+//		func(b *Book) uint { return b.AuthorID },
+//		lodegorm.Fetch[*Book, uint](db, "author_id"),
+//		"books",
+//	)
+//
+//	books, err := lode.Many(ctx, authorBooks.For(author))
+//
+// Set any other fields on the result.  cacheKey is registered with
+// DefineKeys, for engines created WithDefinedKeys.  NewRelation panics if a function is nil or cacheKey is empty:
+// specs are usually built once, at init, where a panic is the clearest
+// report.
+func NewRelation[JoinKey comparable, Model hasState, Relation any](
+	modelKey func(Model) (JoinKey, bool),
+	relationKey func(Relation) JoinKey,
+	fetch func(context.Context, []JoinKey) ([]Relation, error),
+	cacheKey string,
+) RelationSpec[JoinKey, Model, Relation] {
+	switch {
+	case cacheKey == "":
+		panic(fmt.Sprintf("%s: NewRelation: empty cache key", packagePrefix))
+	case modelKey == nil, relationKey == nil, fetch == nil:
+		panic(fmt.Sprintf("%s: NewRelation: key %q: modelKey, relationKey, and fetch must be set", packagePrefix, cacheKey))
+	}
+	DefineKeys(cacheKey)
+	return RelationSpec[JoinKey, Model, Relation]{
+		CacheKey:    cacheKey,
+		ModelKey:    modelKey,
+		RelationKey: relationKey,
+		Fetch:       fetch,
+	}
+}
+
+// NewResolve is NewRelation for ResolveSpec: its type parameters are
+// inferred from build, which may return a plain func(Model) Result.  It
+// panics if build is nil or cacheKey is empty.
+func NewResolve[Model hasState, Result any](cacheKey string, build func(context.Context, []Model) (func(Model) Result, error)) ResolveSpec[Model, Result] {
+	switch {
+	case cacheKey == "":
+		panic(fmt.Sprintf("%s: NewResolve: empty cache key", packagePrefix))
+	case build == nil:
+		panic(fmt.Sprintf("%s: NewResolve: key %q: build must be set", packagePrefix, cacheKey))
+	}
+	DefineKeys(cacheKey)
+	return ResolveSpec[Model, Result]{
+		CacheKey: cacheKey,
+		Build: func(ctx context.Context, models []Model) (ResolverFunc[Model, Result], error) {
+			return build(ctx, models)
+		},
+	}
+}
+
+// For returns a copy of the spec with Model set to m.
+func (spec ResolveSpec[Model, Result]) For(m Model) ResolveSpec[Model, Result] {
+	spec.Model = m
+	return spec
+}
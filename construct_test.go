@@ -0,0 +1,72 @@
+package lode
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// These specs are built without explicit type arguments: that they compile
+// is the test of NewRelation's and NewResolve's inference.
+var (
+	authorBooks = NewRelation(
+		func(a *Author) (int, bool) { return a.ID, true },
+		func(b *Book) int { return b.AuthorID },
+		func(_ context.Context, ids []int) ([]*Book, error) {
+			var books []*Book
+			for _, id := range ids {
+				books = append(books, &Book{ID: 10 * id, AuthorID: id})
+			}
+			return books, nil
+		},
+		"books",
+	)
+	authorDouble = NewResolve("double", func(_ context.Context, _ []*Author) (func(*Author) int, error) {
+		return func(a *Author) int { return 2 * a.ID }, nil
+	})
+)
+
+func TestNewRelation(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	NewEngine().InitHandles([]*Author{a1, a2})
+
+	books, err := Many(ctx, authorBooks.For(a2))
+	if err != nil || len(books) != 1 || books[0].ID != 20 {
+		t.Fatalf("Many = %v, %v", books, err)
+	}
+	book, err := One(ctx, authorBooks.For(a1))
+	if err != nil || book.ID != 10 {
+		t.Fatalf("One = %v, %v", book, err)
+	}
+	if n, err := Resolve(ctx, authorDouble.For(a2)); err != nil || n != 4 {
+		t.Fatalf("Resolve = %d, %v; want 4", n, err)
+	}
+}
+
+func TestNewRelation_ValidatesInputs(t *testing.T) {
+	t.Parallel()
+	modelKey := func(a *Author) (int, bool) { return a.ID, true }
+	relationKey := func(b *Book) int { return b.AuthorID }
+	fetch := func(context.Context, []int) ([]*Book, error) { return nil, nil }
+	for name, f := range map[string]func(){
+		"empty key":       func() { NewRelation(modelKey, relationKey, fetch, "") },
+		"nil ModelKey":    func() { NewRelation[int, *Author](nil, relationKey, fetch, "books") },
+		"nil RelationKey": func() { NewRelation(modelKey, nil, fetch, "books") },
+		"nil Fetch":       func() { NewRelation(modelKey, relationKey, nil, "books") },
+		"NewResolve key": func() {
+			NewResolve("", func(context.Context, []*Author) (func(*Author) int, error) { return nil, nil })
+		},
+		"NewResolve build": func() { NewResolve[*Author, int]("double", nil) },
+	} {
+		func() {
+			defer func() {
+				if r, _ := recover().(string); !strings.HasPrefix(r, "lode: New") {
+					t.Errorf("%s: recovered %q; want a lode panic", name, r)
+				}
+			}()
+			f()
+		}()
+	}
+}
@@ -0,0 +1,39 @@
+package lode
+
+import "context"
+
+// WithDetachedBuildContext runs builds on a context whose deadline and
+// cancellation come from base rather than from the caller that happened to
+// trigger the build, so one short-deadline caller cannot fail a build that
+// its siblings are also waiting on.  Values (trace IDs, tenants, and so on)
+// are still looked up in the triggering caller's context.  A nil base means
+// context.Background(): builds then run to completion unless the fetches
+// themselves give up.
+//
+// The triggering caller still waits for the build it started, so it may
+// return after its own deadline.
+func WithDetachedBuildContext(base context.Context) ConfigOption {
+	if base == nil {
+		base = context.Background()
+	}
+	return func(c *Config) { c.buildBase = base }
+}
+
+// buildContext returns the context a build triggered by ctx runs with.
+func (e *Engine) buildContext(ctx context.Context) context.Context {
+	if e.config.buildBase == nil {
+		return ctx
+	}
+	return detachedCtx{Context: e.config.buildBase, values: context.WithoutCancel(ctx)}
+}
+
+// detachedCtx takes its deadline and cancellation from the embedded base and
+// its values from the triggering context.  values has had its cancellation
+// stripped so the context package does not mistake it for a cancelable
+// parent.
+type detachedCtx struct {
+	context.Context
+	values context.Context
+}
+
+func (c detachedCtx) Value(key any) any { return c.values.Value(key) }
@@ -0,0 +1,96 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type traceKey struct{}
+
+// slowBooks returns a spec whose fetch takes 20ms unless its ctx ends first,
+// recording the trace value it saw.
+func slowBooks(seen *[]any) RelationSpec[int, *Author, *Book] {
+	return RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(ctx context.Context, keys []int) ([]*Book, error) {
+			*seen = append(*seen, ctx.Value(traceKey{}))
+			select {
+			case <-time.After(20 * time.Millisecond):
+				return []*Book{{AuthorID: 1, Title: "T"}, {AuthorID: 2, Title: "U"}}, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		},
+	}
+}
+
+func shortDeadline(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), traceKey{}, "req-1"), time.Millisecond)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestDetachedBuildContext(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine(WithDetachedBuildContext(nil))
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles([]*Author{a1, a2})
+
+	var seen []any
+	spec := slowBooks(&seen)
+	spec.Model = a1
+	if got, err := Many(shortDeadline(t), spec); err != nil || len(got) != 1 {
+		t.Fatalf("triggering caller: %v, %v", titles(got), err)
+	}
+
+	spec.Model = a2
+	if got, err := Many(context.Background(), spec); err != nil || !equalStrings(titles(got), []string{"U"}) {
+		t.Fatalf("sibling: %v, %v", titles(got), err)
+	}
+	if len(seen) != 1 || seen[0] != "req-1" {
+		t.Fatalf("fetch saw values %v; want the triggering caller's", seen)
+	}
+}
+
+func TestDetachedBuildContext_BaseCancels(t *testing.T) {
+	t.Parallel()
+	base, cancel := context.WithCancel(context.Background())
+	cancel()
+	eng := NewEngine(WithDetachedBuildContext(base))
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+
+	var seen []any
+	spec := slowBooks(&seen)
+	spec.Model = a
+	if _, err := Many(context.Background(), spec); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v; want the base's cancellation", err)
+	}
+}
+
+func TestAttachedBuildContext_Default(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine()
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles([]*Author{a1, a2})
+
+	var seen []any
+	spec := slowBooks(&seen)
+	spec.Model = a1
+	if _, err := Many(shortDeadline(t), spec); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v; want DeadlineExceeded", err)
+	}
+	// Without detachment the build fails, but the failure is not kept: the
+	// sibling's live ctx builds again.
+	spec.Model = a2
+	if got, err := Many(context.Background(), spec); err != nil || !equalStrings(titles(got), []string{"U"}) {
+		t.Fatalf("sibling: %v, %v", titles(got), err)
+	}
+	if len(seen) != 2 {
+		t.Fatalf("fetched %d times; want 2", len(seen))
+	}
+}
@@ -0,0 +1,117 @@
+package lode
+
+import (
+	"reflect"
+	"sync"
+)
+
+var handleType = reflect.TypeFor[Handle]()
+
+// DeepCopy returns a copy of the value graph rooted at m that shares no
+// pointers, slices, or maps with the original and has every embedded Handle
+// zeroed, so the result is detached from any loader state.  It is meant for
+// snapshotting loaded models (e.g. for audit logs); resolving relations on
+// the copy returns the not-initialized error until it is bound again.
+//
+// Exported fields are copied recursively.  Unexported fields are copied
+// shallowly, which keeps types such as time.Time intact but means a Handle
+// reachable only through unexported fields is not zeroed.  Cycles and shared
+// pointers are preserved: a pointer reached twice in the original is copied
+// once.  Funcs and channels are copied as-is.
+func DeepCopy[T any](m *T) *T {
+	if m == nil {
+		return nil
+	}
+	c := copier{visited: make(map[visitKey]reflect.Value)}
+	return c.copy(reflect.ValueOf(m)).Interface().(*T)
+}
+
+type visitKey struct {
+	ptr uintptr
+	typ reflect.Type // a struct and its first field share an address
+}
+
+type copier struct {
+	visited map[visitKey]reflect.Value
+}
+
+func (c *copier) copy(src reflect.Value) reflect.Value {
+	dst := reflect.New(src.Type()).Elem()
+	c.copyInto(dst, src)
+	return dst
+}
+
+func (c *copier) copyInto(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			return
+		}
+		key := visitKey{ptr: src.Pointer(), typ: src.Type()}
+		if p, ok := c.visited[key]; ok {
+			dst.Set(p)
+			return
+		}
+		p := reflect.New(src.Type().Elem())
+		c.visited[key] = p
+		c.copyInto(p.Elem(), src.Elem())
+		dst.Set(p)
+	case reflect.Struct:
+		if src.Type() == handleType {
+			return // leave zeroed
+		}
+		dst.Set(src) // unexported fields are copied shallowly
+		for _, i := range exportedFields(src.Type()) {
+			f := dst.Field(i)
+			f.SetZero()
+			c.copyInto(f, src.Field(i))
+		}
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		s := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			c.copyInto(s.Index(i), src.Index(i))
+		}
+		dst.Set(s)
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			c.copyInto(dst.Index(i), src.Index(i))
+		}
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			m.SetMapIndex(c.copy(iter.Key()), c.copy(iter.Value()))
+		}
+		dst.Set(m)
+	case reflect.Interface:
+		if src.IsNil() {
+			return
+		}
+		dst.Set(c.copy(src.Elem()))
+	default:
+		dst.Set(src)
+	}
+}
+
+var exportedFieldCache sync.Map // reflect.Type -> []int
+
+// exportedFields returns the indices of t's exported fields, cached per type.
+func exportedFields(t reflect.Type) []int {
+	if v, ok := exportedFieldCache.Load(t); ok {
+		return v.([]int)
+	}
+	var idx []int
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			idx = append(idx, i)
+		}
+	}
+	v, _ := exportedFieldCache.LoadOrStore(t, idx)
+	return v.([]int)
+}
@@ -0,0 +1,96 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type authorReport struct {
+	Author    *Author
+	Books     []*Book
+	ByTitle   map[string]*Book
+	Extra     any
+	Generated time.Time
+	Self      *authorReport
+}
+
+func TestDeepCopy_DetachesGraph(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+
+	a := &Author{ID: 1, Name: "Alice"}
+	eng.InitHandles([]*Author{a})
+	books, err := Many(ctx, RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       a,
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(context.Context, []int) ([]*Book, error) {
+			return []*Book{{ID: 1, AuthorID: 1, Title: "One"}, {ID: 2, AuthorID: 1, Title: "Two"}}, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orig := &authorReport{
+		Author:    a,
+		Books:     books,
+		ByTitle:   map[string]*Book{books[0].Title: books[0]},
+		Extra:     books[1],
+		Generated: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	orig.Self = orig
+
+	cp := DeepCopy(orig)
+
+	if cp == orig || cp.Author == a || cp.Books[0] == books[0] || cp.Books[1] == books[1] {
+		t.Fatal("copy shares pointers with the original")
+	}
+	if &cp.Books[0] == &orig.Books[0] {
+		t.Fatal("copy shares the Books backing array")
+	}
+	if cp.Self != cp {
+		t.Fatal("cycle not preserved: Self should point at the copy")
+	}
+	if cp.ByTitle["One"] != cp.Books[0] || cp.Extra.(*Book) != cp.Books[1] {
+		t.Fatal("shared pointers in the original should map to one copy")
+	}
+	if cp.Author.Name != "Alice" || cp.Books[1].Title != "Two" || !cp.Generated.Equal(orig.Generated) {
+		t.Fatalf("field values not copied: %+v", cp)
+	}
+
+	for _, m := range []hasState{cp.Author, cp.Books[0], cp.Books[1]} {
+		if _, ok := StateOf(m); ok {
+			t.Fatalf("%T copy still has a state", m)
+		}
+	}
+	if _, ok := StateOf(a); !ok {
+		t.Fatal("original lost its state")
+	}
+
+	_, err = Resolve(ctx, ResolveSpec[*Author, int]{
+		CacheKey: "x",
+		Model:    cp.Author,
+		Build: func(context.Context, []*Author) (ResolverFunc[*Author, int], error) {
+			return func(*Author) int { return 1 }, nil
+		},
+	})
+	if !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("Resolve on copy err = %v; want ErrNotInitialized", err)
+	}
+}
+
+func TestDeepCopy_Nil(t *testing.T) {
+	t.Parallel()
+	if got := DeepCopy[Author](nil); got != nil {
+		t.Fatalf("DeepCopy(nil) = %v; want nil", got)
+	}
+	cp := DeepCopy(&authorReport{})
+	if cp.Books != nil || cp.ByTitle != nil || cp.Extra != nil || cp.Self != nil {
+		t.Fatalf("nil fields should stay nil: %+v", cp)
+	}
+}
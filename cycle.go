@@ -0,0 +1,68 @@
+package lode
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Builds and locking
+//
+// A build holds one lock while it runs: its entry's once.  Under
+// WithMaxConcurrentBuilds it also holds a build slot, which nested builds
+// share; the slot is taken before the once, so no goroutine waits for a slot
+// while holding an entry's once that a running build may need.  What a build
+// does to other states (binding the relations it fetched, storing BackRef
+// resolvers, Seed) goes through sync.Map operations and atomics that never
+// wait on another build, and no mutex is held while calling out to a build,
+// a Fetch, or a hook.  So two model types resolving into each other,
+// Author.Books binding books whose Book.Author binds authors, cannot
+// deadlock on lode's own bookkeeping, however their builds interleave.
+//
+// The one wait left is on another entry's once, when a build resolves a key
+// that is still building, and that can only deadlock if builds depend on one
+// another in a cycle.  Each build's ctx records the chain of entries being
+// built, and a build that would wait on an entry of its own chain fails with
+// errBuildCycle instead.  A cycle split across goroutines that do not share
+// a ctx is not detected; it needs the same cyclic dependency, which shows up
+// as an error the first time one goroutine runs it end to end.
+
+var errBuildCycle = &kindError{"build depends on itself", ErrMisconfigured}
+
+// buildChainKey carries the *buildChain of the builds in progress on a ctx.
+type buildChainKey struct{}
+
+type buildChain struct {
+	entry    *resolverEntry
+	cacheKey string
+	e        *Engine // see Warn
+	parent   *buildChain
+}
+
+// withBuild returns ctx recording that c's entry is building.
+func (c *CacheEntry) withBuild(ctx context.Context) context.Context {
+	parent, _ := ctx.Value(buildChainKey{}).(*buildChain)
+	return context.WithValue(ctx, buildChainKey{}, &buildChain{entry: c.entry, cacheKey: c.cacheKey, e: c.e, parent: parent})
+}
+
+// checkCycle returns an error if ctx belongs to a build of c's entry, which
+// waiting on the entry would deadlock.
+func (c *CacheEntry) checkCycle(ctx context.Context) error {
+	chain, _ := ctx.Value(buildChainKey{}).(*buildChain)
+	for b := chain; b != nil; b = b.parent {
+		if b.entry != c.entry {
+			continue
+		}
+		var keys []string
+		for b := chain; b != nil; b = b.parent {
+			keys = append(keys, b.cacheKey)
+			if b.entry == c.entry {
+				break
+			}
+		}
+		slices.Reverse(keys)
+		return fmt.Errorf("%s: key %q: %w: %s -> %s", packagePrefix, c.cacheKey, errBuildCycle, strings.Join(keys, " -> "), c.cacheKey)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBuildCycle_Reported(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	a := &Author{ID: 1}
+	NewEngine().InitHandles([]*Author{a})
+
+	var first, second ResolveSpec[*Author, int]
+	first = ResolveSpec[*Author, int]{
+		CacheKey: "first",
+		Build: func(ctx context.Context, _ []*Author) (ResolverFunc[*Author, int], error) {
+			n, err := Resolve(ctx, second.For(a))
+			return func(*Author) int { return n }, err
+		},
+	}
+	second = ResolveSpec[*Author, int]{
+		CacheKey: "second",
+		Build: func(ctx context.Context, _ []*Author) (ResolverFunc[*Author, int], error) {
+			n, err := Resolve(ctx, first.For(a))
+			return func(*Author) int { return n }, err
+		},
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := Resolve(ctx, first.For(a))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, errBuildCycle) || !strings.Contains(err.Error(), "first -> second -> first") {
+			t.Fatalf("err = %v; want the cycle first -> second -> first", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("cyclic builds deadlocked")
+	}
+}
+
+// TestMutualResolution_Stress ping-pongs between authors and books, each
+// fetch binding fresh models, from many goroutines sharing the first batch,
+// with BackRef stores and builds nested across states.  Run it with -race.
+func TestMutualResolution_Stress(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine(WithBatchSize(4))
+	authors := make([]*Author, 12)
+	for i := range authors {
+		authors[i] = &Author{ID: i + 1}
+	}
+	eng.InitHandles(authors)
+
+	bookAuthor := RelationSpec[int, *Book, *Author]{
+		CacheKey:    "author",
+		ModelKey:    func(b *Book) (int, bool) { return b.AuthorID, true },
+		RelationKey: func(a *Author) int { return a.ID },
+		Fetch: func(_ context.Context, ids []int) ([]*Author, error) {
+			out := make([]*Author, len(ids))
+			for i, id := range ids {
+				out[i] = &Author{ID: id}
+			}
+			return out, nil
+		},
+	}
+	authorBooks := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(_ context.Context, ids []int) ([]*Book, error) {
+			var out []*Book
+			for _, id := range ids {
+				for j := range 3 {
+					out = append(out, &Book{ID: 10*id + j, AuthorID: id})
+				}
+			}
+			return out, nil
+		},
+		BackRef: BackRef[int, *Book]{CacheKey: "author"},
+	}
+	// coAuthors is built on a book's state and resolves through the
+	// authors' states from within its build.
+	coAuthors := ResolveSpec[*Book, int]{
+		CacheKey: "co_authors",
+		Build: func(ctx context.Context, books []*Book) (ResolverFunc[*Book, int], error) {
+			counts := make(map[*Book]int, len(books))
+			for _, b := range books {
+				a, err := One(ctx, bookAuthor.For(b))
+				if err != nil {
+					return nil, err
+				}
+				siblings, err := Many(ctx, authorBooks.For(a))
+				if err != nil {
+					return nil, err
+				}
+				counts[b] = len(siblings)
+			}
+			return func(b *Book) int { return counts[b] }, nil
+		},
+	}
+
+	var pingPong func(a *Author, depth int) error
+	pingPong = func(a *Author, depth int) error {
+		books, err := Many(ctx, authorBooks.For(a))
+		if err != nil || depth == 0 {
+			return err
+		}
+		for _, b := range books {
+			if n, err := Resolve(ctx, coAuthors.For(b)); err != nil || n != 3 {
+				return errors.Join(err, errors.New("wrong co-author count"))
+			}
+			back, err := One(ctx, bookAuthor.For(b))
+			if err != nil {
+				return err
+			}
+			if err := pingPong(back, depth-1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for g := range 32 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if g%4 == 0 {
+				if err := authors[g%len(authors)].Invalidate("books"); err != nil {
+					errs <- err
+				}
+			}
+			if err := pingPong(authors[g%len(authors)], 2); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("mutual resolution deadlocked")
+	}
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
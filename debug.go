@@ -0,0 +1,196 @@
+package lode
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"sync/atomic"
+	"unsafe"
+)
+
+// WithDebug turns on checks that cost a little on every call but catch
+// common misuse, reporting what they find through Hooks.OnWarning (or the
+// standard logger when no OnWarning hook is registered).  Currently:
+//
+//   - Resolve, Many, and One fail, like WithMembershipCheck, for a model whose
+//     Handle has moved since it was bound: the model was copied by value.
+//   - Many and One warn when specs sharing a cache key on one state disagree
+//     on their key functions or types.
+//   - Many warns when a fetch's relations group in a way that suggests
+//     RelationKey and ModelKey do not line up: none under any requested key,
+//     or all under one key when several were requested.
+//   - Resolve, Many, and One warn once per cache key not registered with
+//     Engine.RegisterKeys, if any keys are registered.
+//   - Builds warn once when the ctx they were given is used after they
+//     returned, typically by a resolver that captured it for lazy fetches
+//     and fails once the triggering request ends.  The check is a
+//     heuristic: it sees calls to the ctx's Done and Err methods (which
+//     fetches and derived contexts make), not a captured ctx that is only
+//     read for values or never consulted, and it may report a goroutine the
+//     build left waiting on the ctx.
+func WithDebug() ConfigOption {
+	return func(c *Config) { c.debug = true }
+}
+
+// WarningEvent is a problem found by a WithDebug check or reported with Warn.
+type WarningEvent struct {
+	CacheKey string
+	Message  string
+}
+
+// Warn reports message through the OnWarning hooks of the engine whose build
+// ctx belongs to, with the cache key being built, or through the standard
+// logger when there are none or ctx is not a build's.  Fetch and Build
+// functions use it to flag work that succeeds but should be fixed, such as
+// a fetch that queries key by key.
+func Warn(ctx context.Context, message string) {
+	chain, ok := ctx.Value(buildChainKey{}).(*buildChain)
+	if !ok {
+		log.Printf("%s: %s", packagePrefix, message)
+		return
+	}
+	chain.e.warn(WarningEvent{CacheKey: chain.cacheKey, Message: message})
+}
+
+func (e *Engine) warn(ev WarningEvent) {
+	handled := false
+	for _, h := range e.config.hooks {
+		if h.OnWarning != nil {
+			h.OnWarning(ev)
+			handled = true
+		}
+	}
+	if !handled {
+		log.Printf("%s: key %q: %s", packagePrefix, ev.CacheKey, ev.Message)
+	}
+}
+
+// relationShape identifies the parts of a RelationSpec that specs sharing a
+// cache key must agree on.  Functions are compared by code pointer, so two
+// closures from the same literal look alike even if they capture different
+// values; the check catches different functions, not every mistake.
+type relationShape struct {
+	joinKey, relation reflect.Type
+	modelKey          uintptr
+	relationKey       uintptr
+	relationKeyOK     uintptr
+}
+
+func funcPC(f any) uintptr {
+	v := reflect.ValueOf(f)
+	if v.IsNil() {
+		return 0
+	}
+	return v.Pointer()
+}
+
+// checkShape records the shape of the first spec used with cacheKey on the
+// state and warns, once per state and key, when a later spec differs.
+func (args RelationSpec[JoinKey, Model, Relation]) checkShape(s *loaderState, cacheKey string) {
+	shape := relationShape{
+		joinKey:       reflect.TypeFor[JoinKey](),
+		relation:      reflect.TypeFor[Relation](),
+		modelKey:      funcPC(args.ModelKey),
+		relationKey:   funcPC(args.RelationKey),
+		relationKeyOK: funcPC(args.RelationKeyOK),
+	}
+	prev, loaded := s.shapes.LoadOrStore(cacheKey, shape)
+	if !loaded || prev == shape || prev == (relationShape{}) {
+		return
+	}
+	// Store a sentinel so the mismatch is reported once, not on every call.
+	if !s.shapes.CompareAndSwap(cacheKey, prev, relationShape{}) {
+		return
+	}
+	first := prev.(relationShape)
+	msg := "specs sharing this cache key disagree on "
+	switch {
+	case first.joinKey != shape.joinKey || first.relation != shape.relation:
+		msg += "types: first used with JoinKey " + first.joinKey.String() + " and Relation " + first.relation.String() +
+			", now " + shape.joinKey.String() + " and " + shape.relation.String()
+	case first.modelKey != shape.modelKey:
+		msg += "ModelKey; results are grouped by the first spec's ModelKey"
+	default:
+		msg += "RelationKey; results are grouped by the first spec's RelationKey"
+	}
+	s.engine.warn(WarningEvent{CacheKey: cacheKey, Message: msg})
+}
+
+// checkOrigin reports a model whose Handle is not where it was at bind time.
+// Models bound before the engine ran in debug mode have no origin and pass.
+func checkOrigin(m hasState, cacheKey string) error {
+	h := m.handle()
+	if h.origin == nil || h.origin == h {
+		return nil
+	}
+	// Report model addresses when the model is a pointer, else the Handles'.
+	at, bound := uintptr(unsafe.Pointer(h)), uintptr(unsafe.Pointer(h.origin))
+	if v := reflect.ValueOf(m); v.Kind() == reflect.Pointer {
+		bound += v.Pointer() - at
+		at = v.Pointer()
+	}
+	return fmt.Errorf("%s: key %q: %w: state says this %T should be at %#x but it is at %#x (was it copied by value after InitHandles?)",
+		packagePrefix, cacheKey, errNotMember, m, bound, at)
+}
+
+// minKeysForSkew is the number of requested keys from which all relations
+// landing under one of them is reported as suspicious.
+const minKeysForSkew = 3
+
+// checkGrouping warns about groupings that almost always mean the spec's key
+// functions disagree.  grouped holds the keys that got relations.  It costs
+// one pass over the requested keys.
+func checkGrouping[JoinKey comparable, Group any](e *Engine, cacheKey string, keys []JoinKey, grouped map[JoinKey]Group, placed int) {
+	if placed == 0 {
+		return
+	}
+	matched := 0
+	for _, k := range keys {
+		if _, ok := grouped[k]; ok {
+			matched++
+		}
+	}
+	switch {
+	case matched == 0:
+		e.warn(WarningEvent{CacheKey: cacheKey, Message: fmt.Sprintf(
+			"fetched %d relations but none matched any of the %d model keys; does RelationKey return the parent's key, as ModelKey does?",
+			placed, len(keys))})
+	case len(grouped) == 1 && placed > 1 && len(keys) >= minKeysForSkew:
+		e.warn(WarningEvent{CacheKey: cacheKey, Message: fmt.Sprintf(
+			"all %d relations landed under one key although %d model keys were requested; does RelationKey return the parent's key?",
+			placed, len(keys))})
+	}
+}
+
+// buildCtxGuard wraps the ctx a build runs with under WithDebug and warns
+// the first time its Done or Err is called after the build returned.
+type buildCtxGuard struct {
+	context.Context
+	e        *Engine
+	cacheKey string
+	returned atomic.Bool
+	warned   atomic.Bool
+}
+
+func (g *buildCtxGuard) Done() <-chan struct{} {
+	g.check()
+	return g.Context.Done()
+}
+
+func (g *buildCtxGuard) Err() error {
+	g.check()
+	return g.Context.Err()
+}
+
+func (g *buildCtxGuard) check() {
+	if !g.returned.Load() || !g.warned.CompareAndSwap(false, true) {
+		return
+	}
+	msg := "the ctx passed to Build was used after Build returned"
+	if g.Context.Err() != nil {
+		msg += ", and it is already canceled"
+	}
+	msg += "; resolvers must not capture it (fetch in Build, or take a ctx from the caller)"
+	g.e.warn(WarningEvent{CacheKey: g.cacheKey, Message: msg})
+}
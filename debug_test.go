@@ -0,0 +1,288 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestDebug_WarnsOnMismatchedSpecs(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	var warnings []WarningEvent
+	eng := NewEngine(WithDebug(), WithKeyNamespace("ns"), WithHooks(Hooks{
+		OnWarning: func(ev WarningEvent) { warnings = append(warnings, ev) },
+	}))
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles([]*Author{a1, a2})
+
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       a1,
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch:       func(context.Context, []int) ([]*Book, error) { return nil, nil },
+	}
+	if _, err := Many(ctx, spec); err != nil {
+		t.Fatal(err)
+	}
+	// The same spec through One on a sibling is fine.
+	spec.Model = a2
+	if _, err := One(ctx, spec); err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("warnings for consistent specs: %+v", warnings)
+	}
+
+	// Keying relations by their own ID is the classic mistake.
+	spec.RelationKey = func(b *Book) int { return b.ID }
+	for range 2 {
+		if _, err := One(ctx, spec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("warnings = %+v; want exactly one", warnings)
+	}
+	if warnings[0].CacheKey != "ns:books" || !strings.Contains(warnings[0].Message, "RelationKey") {
+		t.Fatalf("warning = %+v", warnings[0])
+	}
+}
+
+func TestDebug_WarnsOnMismatchedTypes(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	var warnings []WarningEvent
+	eng := NewEngine(WithDebug(), WithHooks(Hooks{
+		OnWarning: func(ev WarningEvent) { warnings = append(warnings, ev) },
+	}))
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+
+	modelKey := func(a *Author) (int, bool) { return a.ID, true }
+	_, _ = Many(ctx, RelationSpec[int, *Author, *Book]{
+		CacheKey: "rel", Model: a, ModelKey: modelKey,
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch:       func(context.Context, []int) ([]*Book, error) { return nil, nil },
+	})
+	_, _ = Many(ctx, RelationSpec[int, *Author, *Publisher]{
+		CacheKey: "rel", Model: a, ModelKey: modelKey,
+		RelationKey: func(p *Publisher) int { return p.ID },
+		Fetch:       func(context.Context, []int) ([]*Publisher, error) { return nil, nil },
+	})
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Message, "Publisher") {
+		t.Fatalf("warnings = %+v", warnings)
+	}
+}
+
+func TestDebug_OffByDefault(t *testing.T) {
+	t.Parallel()
+	a := &Author{ID: 1}
+	NewEngine().InitHandles(a)
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       a,
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch:       func(context.Context, []int) ([]*Book, error) { return nil, nil },
+	}
+	_, _ = Many(context.Background(), spec)
+	if _, ok := a.core.shapes.Load("books"); ok {
+		t.Fatal("shape recorded without WithDebug")
+	}
+}
+
+func TestDebug_CopiedModelFails(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	build := func(ctx context.Context, models []*Author) (ResolverFunc[*Author, string], error) {
+		return func(a *Author) string { return a.Name }, nil
+	}
+
+	eng := NewEngine(WithDebug())
+	authors := []Author{{ID: 1, Name: "Alice"}, {ID: 2, Name: "Bob"}}
+	eng.InitHandles(authors)
+
+	if got, err := Resolve(ctx, ResolveSpec[*Author, string]{CacheKey: "name", Model: &authors[1], Build: build}); err != nil || got != "Bob" {
+		t.Fatalf("Resolve(bound) = %q, %v", got, err)
+	}
+
+	cp := copyModel(&authors[1])
+	_, err := Resolve(ctx, ResolveSpec[*Author, string]{CacheKey: "name", Model: cp, Build: build})
+	if !errors.Is(err, errNotMember) {
+		t.Fatalf("Resolve(copy) err = %v; want errNotMember", err)
+	}
+	want := fmt.Sprintf("should be at %p but it is at %p", &authors[1], cp)
+	if !strings.Contains(err.Error(), want) {
+		t.Fatalf("err = %q; want it to contain %q", err, want)
+	}
+}
+
+// TestHandle_VetFlagsCopies runs go vet over testdata/copyvet, which copies
+// bound models by value, and expects the copylocks check to catch it.
+func TestHandle_VetFlagsCopies(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go vet")
+	}
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+	out, err := exec.Command(gobin, "vet", "-copylocks", "./testdata/copyvet").CombinedOutput()
+	if err == nil {
+		t.Fatal("go vet passed; want copylocks reports")
+	}
+	for _, want := range []string{"range var a copies lock", "ByValue passes lock by value"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("go vet output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestDebug_WarnsOnSuspiciousGrouping(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	books := []*Book{
+		{ID: 10, AuthorID: 1}, {ID: 11, AuthorID: 2}, {ID: 12, AuthorID: 3},
+	}
+
+	cases := []struct {
+		name        string
+		authors     int
+		relationKey func(*Book) int
+		fetched     []*Book
+		want        string // substring of the warning; "" for none
+	}{
+		{"keyed on the relation's own ID", 3, func(b *Book) int { return b.ID }, books, "none matched any of the 3 model keys"},
+		{"everything under one key", 3, func(b *Book) int { return 1 }, books, "all 3 relations landed under one key"},
+		{"correct keys", 3, func(b *Book) int { return b.AuthorID }, books, ""},
+		{"nothing fetched", 3, func(b *Book) int { return b.ID }, nil, ""},
+		{"one key requested", 1, func(b *Book) int { return 1 }, books[:2], ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var warnings []WarningEvent
+			eng := NewEngine(WithDebug(), WithHooks(Hooks{
+				OnWarning: func(ev WarningEvent) { warnings = append(warnings, ev) },
+			}))
+			var authors []*Author
+			for i := 1; i <= tc.authors; i++ {
+				authors = append(authors, &Author{ID: i})
+			}
+			eng.InitHandles(authors)
+			_, err := Many(ctx, RelationSpec[int, *Author, *Book]{
+				CacheKey:    "books",
+				Model:       authors[0],
+				ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+				RelationKey: tc.relationKey,
+				Fetch:       func(context.Context, []int) ([]*Book, error) { return tc.fetched, nil },
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch {
+			case tc.want == "" && len(warnings) != 0:
+				t.Fatalf("unexpected warnings: %+v", warnings)
+			case tc.want != "" && (len(warnings) != 1 || warnings[0].CacheKey != "books" || !strings.Contains(warnings[0].Message, tc.want)):
+				t.Fatalf("warnings = %+v; want one containing %q", warnings, tc.want)
+			}
+		})
+	}
+}
+
+func TestDebug_WarnsOnSuspiciousFetchGrouped(t *testing.T) {
+	t.Parallel()
+	var warnings []WarningEvent
+	eng := NewEngine(WithDebug(), WithHooks(Hooks{
+		OnWarning: func(ev WarningEvent) { warnings = append(warnings, ev) },
+	}))
+	authors := []*Author{{ID: 1}, {ID: 2}, {ID: 3}}
+	eng.InitHandles(authors)
+	_, err := Many(context.Background(), RelationSpec[int, *Author, *Book]{
+		CacheKey: "books",
+		Model:    authors[0],
+		ModelKey: func(a *Author) (int, bool) { return a.ID, true },
+		FetchGrouped: func(context.Context, []int) (map[int][]*Book, error) {
+			return map[int][]*Book{10: {{ID: 10}}, 11: {{ID: 11}}}, nil // keyed on the books' own IDs
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Message, "none matched any of the 3 model keys") {
+		t.Fatalf("warnings = %+v; want one about the unmatched groups", warnings)
+	}
+}
+
+func TestDebug_WarnsOnBuildCtxUsedLater(t *testing.T) {
+	t.Parallel()
+	var warnings []WarningEvent
+	eng := NewEngine(WithDebug(), WithHooks(Hooks{
+		OnWarning: func(ev WarningEvent) { warnings = append(warnings, ev) },
+	}))
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles([]*Author{a1, a2})
+
+	resolve := func(ctx context.Context, a *Author, key string, lazy bool) {
+		t.Helper()
+		_, err := Resolve(ctx, ResolveSpec[*Author, error]{
+			CacheKey: key,
+			Model:    a,
+			Build: func(buildCtx context.Context, _ []*Author) (ResolverFunc[*Author, error], error) {
+				if err := buildCtx.Err(); err != nil { // fine: still building
+					return nil, err
+				}
+				return func(*Author) error {
+					if lazy {
+						return buildCtx.Err() // the bug: a lazy fetch on the build ctx
+					}
+					return nil
+				}, nil
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resolve(context.Background(), a1, "eager", false)
+	resolve(context.Background(), a2, "eager", false)
+	if len(warnings) != 0 {
+		t.Fatalf("warnings for a resolver that keeps no ctx: %+v", warnings)
+	}
+
+	resolve(context.Background(), a1, "lazy", true) // warns, once
+	resolve(context.Background(), a2, "lazy", true)
+	resolve(context.Background(), a1, "lazy", true)
+	if len(warnings) != 1 || warnings[0].CacheKey != "lazy" {
+		t.Fatalf("warnings = %+v; want one for the lazy key", warnings)
+	}
+}
+
+func TestWarn_ReportsThroughTheBuildingEngine(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	var warnings []WarningEvent
+	eng := NewEngine(WithKeyNamespace("app"), WithHooks(Hooks{OnWarning: func(ev WarningEvent) {
+		warnings = append(warnings, ev)
+	}}))
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+	Resolve(ctx, ResolveSpec[*Author, int]{
+		CacheKey: "slow",
+		Model:    a,
+		Build: func(ctx context.Context, _ []*Author) (ResolverFunc[*Author, int], error) {
+			Warn(ctx, "built the slow way")
+			return func(*Author) int { return 0 }, nil
+		},
+	})
+	if len(warnings) != 1 || warnings[0] != (WarningEvent{CacheKey: "app:slow", Message: "built the slow way"}) {
+		t.Fatalf("warnings = %+v", warnings)
+	}
+}
@@ -0,0 +1,149 @@
+package lode
+
+import (
+	"context"
+	"fmt"
+	"hash/maphash"
+	"reflect"
+	"sync"
+)
+
+// WithFetchDedup lets Many builds on one state share a fetch when their
+// specs set the same RelationSpec.FetchID and request the same key set, e.g.
+// old and new relation methods that coexist during a migration.  Each spec
+// still groups the shared relations itself.
+//
+// Specs opt in only through FetchID; lode never guesses whether two Fetch
+// closures are equivalent.  FetchGrouped and PartitionBy specs are not
+// deduplicated.  A failed fetch is not shared with later builds, which fetch
+// again, and any reset or invalidation of the state drops its shared
+// fetches.
+func WithFetchDedup() ConfigOption {
+	return func(c *Config) { c.fetchDedup = true }
+}
+
+var dedupSeed = maphash.MakeSeed()
+
+// sharedFetch is one fetch shared by the builds with its fingerprint.
+type sharedFetch struct {
+	once      sync.Once
+	keys      any // []JoinKey
+	relations any // []Relation, nils dropped and bound
+	nils      int
+	err       error
+}
+
+// fetchBound fetches the relations for keys, drops nil ones, and binds the
+// rest, sharing the work with other builds on loader under WithFetchDedup.
+// grouped is as for fetch.
+func (args RelationSpec[JoinKey, Model, Relation]) fetchBound(ctx context.Context, loader *loaderState, keys []JoinKey) (relations []Relation, grouped map[JoinKey][]Relation, nils int, err error) {
+	if !loader.engine.config.fetchDedup || args.FetchID == "" || args.FetchGrouped != nil || args.PartitionBy != nil {
+		return args.fetchAndBind(ctx, loader, keys)
+	}
+	fp := args.fetchFingerprint(keys)
+	v, _ := loader.sharedFetches.LoadOrStore(fp, &sharedFetch{})
+	sf := v.(*sharedFetch)
+	sf.once.Do(func() {
+		var rels []Relation
+		rels, _, sf.nils, sf.err = args.fetchAndBind(ctx, loader, keys)
+		sf.keys, sf.relations = keys, rels
+	})
+	if sf.err != nil {
+		// Builds sharing the failed fetch get its error, but the retry of
+		// any of them fetches again.
+		loader.sharedFetches.CompareAndDelete(fp, sf)
+	}
+	rels, ok := sf.relations.([]Relation)
+	if !ok || !sameKeySet(sf.keys, keys) {
+		// A fingerprint collision, or a FetchID reused for another Relation.
+		return args.fetchAndBind(ctx, loader, keys)
+	}
+	return rels, nil, sf.nils, sf.err
+}
+
+func (args RelationSpec[JoinKey, Model, Relation]) fetchAndBind(ctx context.Context, s *loaderState, keys []JoinKey) ([]Relation, map[JoinKey][]Relation, int, error) {
+	relations, grouped, err := args.fetch(ctx, s, keys)
+	if err == nil {
+		err = args.validate(relations)
+	}
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	// Nil elements (e.g. LEFT JOIN artifacts) can neither be bound nor
+	// keyed, so they are dropped up front.
+	relations, nils := dropNil(relations)
+
+	// note that this setup code is not necessary in the gorm case because
+	// SetupLoaders has likely already been called by the gorm callback,
+	// but I left this here because I think it will be useful in other cases
+	if args.valueRelations() {
+		return relations, grouped, nils, nil // bound once grouped; see load
+	}
+	if args.bindRelations() {
+		s.engine.InitHandles(relations)
+	}
+	s.engine.onRelationsBound(args.CacheKey, relations)
+	return relations, grouped, nils, nil
+}
+
+// bindRelations reports whether fetched relations should be bound: unless
+// the spec opts out, whenever Relation can be a model.
+func (args RelationSpec[JoinKey, Model, Relation]) bindRelations() bool {
+	if args.BindRelations != nil && !*args.BindRelations {
+		return false
+	}
+	return relationsBindable(reflect.TypeFor[Relation]())
+}
+
+// valueRelations reports whether relations are models held by value (a
+// Book, not a *Book).  Grouping copies such relations out of the slice they
+// would be bound in, so Many binds them once grouped instead, in one slice
+// holding the groups back to back, and returns the bound values themselves.
+func (args RelationSpec[JoinKey, Model, Relation]) valueRelations() bool {
+	k := reflect.TypeFor[Relation]().Kind()
+	return k != reflect.Pointer && k != reflect.Interface && args.bindRelations()
+}
+
+var bindableRelations sync.Map // reflect.Type -> bool
+
+// relationsBindable reports whether a []t can hold models, caching the
+// answer so handle-less relation types skip the reflective walk of Bind.
+// Interface types may hold models, so they count as bindable.
+func relationsBindable(t reflect.Type) bool {
+	if v, ok := bindableRelations.Load(t); ok {
+		return v.(bool)
+	}
+	ok := t.Kind() == reflect.Interface || t.Implements(hasStateType) || reflect.PointerTo(t).Implements(hasStateType)
+	bindableRelations.Store(t, ok)
+	return ok
+}
+
+// fetchFingerprint identifies the spec's FetchID with the key set keys, in
+// any order.
+func (args RelationSpec[JoinKey, Model, Relation]) fetchFingerprint(keys []JoinKey) string {
+	var sum uint64
+	for _, k := range keys {
+		sum += maphash.Comparable(dedupSeed, k)
+	}
+	return fmt.Sprintf("%s\x00%v\x00%d\x00%x", args.FetchID, reflect.TypeFor[JoinKey](), len(keys), sum)
+}
+
+// sameKeySet reports whether shared, a []JoinKey, holds the distinct keys
+// in keys.
+func sameKeySet[JoinKey comparable](shared any, keys []JoinKey) bool {
+	s, ok := shared.([]JoinKey)
+	if !ok || len(s) != len(keys) {
+		return false
+	}
+	set := make(map[JoinKey]struct{}, len(s))
+	for _, k := range s {
+		set[k] = struct{}{}
+	}
+	for _, k := range keys {
+		if _, ok := set[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,244 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestFetchDedup(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	all := []*Book{
+		{ID: 1, AuthorID: 1, Title: "A1"},
+		{ID: 2, AuthorID: 2, Title: "B1"},
+		{ID: 3, AuthorID: 1, Title: "A2"},
+	}
+	setup := func(opts ...ConfigOption) (*Author, *int, func(cacheKey, fetchID string) RelationSpec[int, *Author, *Book]) {
+		eng := NewEngine(opts...)
+		authors := []*Author{{ID: 1}, {ID: 2}}
+		eng.InitHandles(authors)
+		calls := 0
+		spec := func(cacheKey, fetchID string) RelationSpec[int, *Author, *Book] {
+			return RelationSpec[int, *Author, *Book]{
+				CacheKey:    cacheKey,
+				Model:       authors[0],
+				ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+				RelationKey: func(b *Book) int { return b.AuthorID },
+				Fetch: func(context.Context, []int) ([]*Book, error) {
+					calls++
+					return all, nil
+				},
+				FetchID: fetchID,
+			}
+		}
+		return authors[0], &calls, spec
+	}
+
+	t.Run("shared", func(t *testing.T) {
+		a, calls, spec := setup(WithFetchDedup())
+		oldBooks, err := Many(ctx, spec("books", "books-by-author"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		bound, _ := StateOf(all[0])
+		// The new definition groups the shared relations its own way.
+		newSpec := spec("booksV2", "books-by-author")
+		newSpec.SinglePerKey = true
+		first, err := One(ctx, newSpec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *calls != 1 {
+			t.Fatalf("fetch calls = %d; want 1 shared", *calls)
+		}
+		if !equalStrings(titles(oldBooks), []string{"A1", "A2"}) || first.Title != "A1" {
+			t.Fatalf("Many = %v, One = %v", titles(oldBooks), first.Title)
+		}
+		if s, _ := StateOf(all[0]); s != bound {
+			t.Fatal("shared relations were bound again")
+		}
+
+		// Invalidation drops the shared fetch.
+		if err := a.Invalidate("books"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Many(ctx, spec("books", "books-by-author")); err != nil {
+			t.Fatal(err)
+		}
+		if *calls != 2 {
+			t.Fatalf("fetch calls after Invalidate = %d; want 2", *calls)
+		}
+	})
+
+	t.Run("not shared", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			opts     []ConfigOption
+			idA, idB string
+		}{
+			"no option":         {nil, "books-by-author", "books-by-author"},
+			"no FetchID":        {[]ConfigOption{WithFetchDedup()}, "", ""},
+			"different FetchID": {[]ConfigOption{WithFetchDedup()}, "books-by-author", "books-by-editor"},
+		} {
+			_, calls, spec := setup(tc.opts...)
+			if _, err := Many(ctx, spec("books", tc.idA)); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Many(ctx, spec("booksV2", tc.idB)); err != nil {
+				t.Fatal(err)
+			}
+			if *calls != 2 {
+				t.Fatalf("%s: fetch calls = %d; want 2", name, *calls)
+			}
+		}
+	})
+
+	t.Run("different key sets", func(t *testing.T) {
+		_, calls, spec := setup(WithFetchDedup())
+		if _, err := Many(ctx, spec("books", "books-by-author")); err != nil {
+			t.Fatal(err)
+		}
+		odd := spec("oddBooks", "books-by-author")
+		odd.ModelKey = func(a *Author) (int, bool) { return a.ID, a.ID%2 == 1 }
+		if _, err := Many(ctx, odd); err != nil {
+			t.Fatal(err)
+		}
+		if *calls != 2 {
+			t.Fatalf("fetch calls = %d; want 2", *calls)
+		}
+	})
+}
+
+func TestFetchDedup_FailedFetchIsRetried(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine(WithFetchDedup())
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+
+	calls := 0
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       a,
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		FetchID:     "books-by-author",
+		Fetch: func(context.Context, []int) ([]*Book, error) {
+			calls++
+			if calls == 1 {
+				return nil, errors.New("transient")
+			}
+			return []*Book{{ID: 1, AuthorID: 1}}, nil
+		},
+	}
+	if _, err := Many(ctx, spec); err == nil {
+		t.Fatal("want the first fetch's error")
+	}
+	if books, err := Many(ctx, spec); err != nil || len(books) != 1 || calls != 2 {
+		t.Fatalf("retry = %v, %v after %d fetches; want a fresh fetch", books, err, calls)
+	}
+}
+
+func TestSameKeySet(t *testing.T) {
+	t.Parallel()
+	if !sameKeySet(any([]int{3, 1, 2}), []int{1, 2, 3}) {
+		t.Fatal("same keys in another order should match")
+	}
+	if sameKeySet(any([]int{1, 2}), []int{1, 3}) || sameKeySet(any([]string{"1"}), []int{1}) {
+		t.Fatal("different key sets should not match")
+	}
+}
+
+// plainBook is a relation type without a Handle.
+type plainBook struct {
+	AuthorID int
+	Title    string
+}
+
+func TestMany_BindRelations(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	a := &Author{ID: 1}
+	NewEngine().InitHandles(a)
+
+	no := false
+	for _, bind := range []*bool{nil, &no} {
+		books, err := Many(ctx, RelationSpec[int, *Author, *Book]{
+			CacheKey:      fmt.Sprint("books", bind == nil),
+			Model:         a,
+			ModelKey:      func(a *Author) (int, bool) { return a.ID, true },
+			RelationKey:   func(b *Book) int { return b.AuthorID },
+			Fetch:         func(context.Context, []int) ([]*Book, error) { return []*Book{{AuthorID: 1}}, nil },
+			BindRelations: bind,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bound := books[0].core != nil; bound != (bind == nil) {
+			t.Fatalf("BindRelations=%v: bound = %v", bind, bound)
+		}
+	}
+
+	plain, err := Many(ctx, RelationSpec[int, *Author, plainBook]{
+		CacheKey:    "plain",
+		Model:       a,
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b plainBook) int { return b.AuthorID },
+		Fetch:       func(context.Context, []int) ([]plainBook, error) { return []plainBook{{AuthorID: 1}}, nil },
+	})
+	if err != nil || len(plain) != 1 {
+		t.Fatalf("Many = %v, %v", plain, err)
+	}
+	for _, tc := range []struct {
+		t    reflect.Type
+		want bool
+	}{
+		{reflect.TypeFor[*Book](), true},
+		{reflect.TypeFor[Book](), true},
+		{reflect.TypeFor[HasHandle](), true},
+		{reflect.TypeFor[plainBook](), false},
+		{reflect.TypeFor[*plainBook](), false},
+	} {
+		if got := relationsBindable(tc.t); got != tc.want {
+			t.Errorf("relationsBindable(%v) = %v; want %v", tc.t, got, tc.want)
+		}
+	}
+}
+
+func BenchmarkFetchAndBind_PlainRelations(b *testing.B) {
+	const n = 10000
+	plain := make([]plainBook, n)
+	books := make([]Book, n)
+	eng := NewEngine()
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+	no := false
+	run := func(b *testing.B, fetchAndBind func() error) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := fetchAndBind(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.Run("handleless", func(b *testing.B) {
+		spec := RelationSpec[int, *Author, plainBook]{
+			Fetch: func(context.Context, []int) ([]plainBook, error) { return plain, nil },
+		}
+		run(b, func() error { _, _, _, err := spec.fetchAndBind(context.Background(), a.core, []int{1}); return err })
+	})
+	b.Run("handleless/walked", func(b *testing.B) {
+		// What every fetch cost before: Bind's walk of the relations.
+		run(b, func() error { eng.InitHandles(plain); return nil })
+	})
+	b.Run("optout", func(b *testing.B) {
+		spec := RelationSpec[int, *Author, Book]{
+			Fetch:         func(context.Context, []int) ([]Book, error) { return books, nil },
+			BindRelations: &no,
+		}
+		run(b, func() error { _, _, _, err := spec.fetchAndBind(context.Background(), a.core, []int{1}); return err })
+	})
+}
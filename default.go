@@ -0,0 +1,55 @@
+package lode
+
+import (
+	"fmt"
+	"sync"
+)
+
+// defaultEngine is the engine returned by Default.
+var defaultEngine struct {
+	mu   sync.Mutex
+	e    *Engine
+	used bool // Default has handed e out
+}
+
+// Default returns the package-level engine, creating it with NewEngine on
+// first use, for scripts and small services that need only one.  Larger
+// programs should construct and pass engines explicitly.
+func Default() *Engine {
+	defaultEngine.mu.Lock()
+	defer defaultEngine.mu.Unlock()
+	if defaultEngine.e == nil {
+		defaultEngine.e = NewEngine()
+	}
+	defaultEngine.used = true
+	return defaultEngine.e
+}
+
+// defaultIfSet returns the default engine, or nil if Default has yet to
+// create it and SetDefault to set it, without marking it used.
+func defaultIfSet() *Engine {
+	defaultEngine.mu.Lock()
+	defer defaultEngine.mu.Unlock()
+	return defaultEngine.e
+}
+
+var errDefaultUsed = &kindError{"SetDefault after the default engine was used", ErrFrozen}
+
+// SetDefault makes e the engine Default returns.  It must be called before
+// Default is first used (by Init, lodegorm.RegisterDefaultCallback, or
+// directly), since whatever got the engine from Default keeps it; later calls
+// return an error and leave the default as it is.
+func SetDefault(e *Engine) error {
+	defaultEngine.mu.Lock()
+	defer defaultEngine.mu.Unlock()
+	if defaultEngine.used {
+		return fmt.Errorf("%s: %w", packagePrefix, errDefaultUsed)
+	}
+	defaultEngine.e = e
+	return nil
+}
+
+// Init binds models to the default engine; see Engine.InitHandles.
+func Init(models any) {
+	Default().InitHandles(models)
+}
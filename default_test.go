@@ -0,0 +1,70 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// resetDefault forgets the default engine, for tests.
+func resetDefault() {
+	defaultEngine.mu.Lock()
+	defer defaultEngine.mu.Unlock()
+	defaultEngine.e, defaultEngine.used = nil, false
+}
+
+func TestDefault(t *testing.T) {
+	resetDefault()
+	t.Cleanup(resetDefault)
+
+	eng := NewEngine(WithBatchSize(1))
+	if err := SetDefault(eng); err != nil {
+		t.Fatal(err)
+	}
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	Init([]*Author{a1, a2})
+	if Default() != eng || a1.lodeState().engine != eng {
+		t.Fatal("Init did not bind through the engine given to SetDefault")
+	}
+	if models, _ := ModelsOf(a1); len(models) != 1 {
+		t.Fatalf("a1's batch has %d models; want 1 under WithBatchSize(1)", len(models))
+	}
+	if n, err := Resolve(context.Background(), ResolveSpec[*Author, int]{
+		CacheKey: "id",
+		Model:    a2,
+		Build: func(context.Context, []*Author) (ResolverFunc[*Author, int], error) {
+			return func(a *Author) int { return a.ID }, nil
+		},
+	}); err != nil || n != 2 {
+		t.Fatalf("Resolve = %d, %v", n, err)
+	}
+
+	if err := SetDefault(NewEngine()); !errors.Is(err, ErrFrozen) {
+		t.Fatalf("SetDefault after use: err = %v; want ErrFrozen", err)
+	}
+	if Default() != eng {
+		t.Fatal("a failed SetDefault replaced the default")
+	}
+}
+
+func TestDefault_ConcurrentFirstUse(t *testing.T) {
+	resetDefault()
+	t.Cleanup(resetDefault)
+
+	engines := make([]*Engine, 16)
+	var wg sync.WaitGroup
+	for i := range engines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			engines[i] = Default()
+		}()
+	}
+	wg.Wait()
+	for _, e := range engines {
+		if e == nil || e != engines[0] {
+			t.Fatal("concurrent first calls to Default got different engines")
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package lode
+
+import (
+	"context"
+	"sync"
+)
+
+// MaxFetchDescriptorLen is the length, in bytes, past which
+// SetFetchDescriptor truncates descriptors.
+const MaxFetchDescriptorLen = 1024
+
+// maxFetchDescriptors is the number of descriptors kept per build; later
+// ones are dropped.
+const maxFetchDescriptors = 32
+
+// fetchDescriptorsKey carries the *fetchDescriptors of the build in
+// progress.
+type fetchDescriptorsKey struct{}
+
+type fetchDescriptors struct {
+	mu   sync.Mutex
+	list []string
+}
+
+// WithFetchDescriptors makes builds collect what their fetches report with
+// SetFetchDescriptor.  It is off by default, as describing a fetch can cost
+// as much as building its query again.
+func WithFetchDescriptors() ConfigOption {
+	return func(c *Config) { c.fetchDescriptors = true }
+}
+
+// CollectsFetchDescriptors reports whether ctx belongs to a build that keeps
+// what SetFetchDescriptor records, so fetches can skip describing
+// themselves when nobody is collecting.
+func CollectsFetchDescriptors(ctx context.Context) bool {
+	_, ok := ctx.Value(fetchDescriptorsKey{}).(*fetchDescriptors)
+	return ok
+}
+
+// SetFetchDescriptor records desc, typically the SQL a fetch ran, as
+// describing the fetch made with ctx, so a wrong cached result can be traced
+// back to the query that produced it: descriptors are kept with the built
+// value and reported by CacheEntry.FetchDescriptors.  Fetch functions call
+// it with the ctx Many passes them; outside a build of an engine with
+// WithFetchDescriptors it does nothing.  Descriptors are kept as given, so
+// leave out argument values that should not be retained; they are truncated
+// to MaxFetchDescriptorLen bytes.  It is safe for concurrent use.
+func SetFetchDescriptor(ctx context.Context, desc string) {
+	d, ok := ctx.Value(fetchDescriptorsKey{}).(*fetchDescriptors)
+	if !ok {
+		return
+	}
+	if len(desc) > MaxFetchDescriptorLen {
+		desc = desc[:MaxFetchDescriptorLen] + "..."
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.list) < maxFetchDescriptors {
+		d.list = append(d.list, desc)
+	}
+}
+
+// recorded returns the descriptors recorded so far, nil for a nil d.
+func (d *fetchDescriptors) recorded() []string {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.list
+}
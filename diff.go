@@ -0,0 +1,150 @@
+package lode
+
+import "context"
+
+// Diff is the difference between two loads of a relation, by parent key and
+// relation ID; see DiffMany.  Added and Removed hold only the keys whose
+// relations changed, and each ID once per occurrence, so an ID loaded twice
+// before and once after is removed once.
+type Diff[JoinKey, ID comparable] struct {
+	Added   map[JoinKey][]ID
+	Removed map[JoinKey][]ID
+	// Unchanged counts the relations present both before and after.
+	Unchanged int
+}
+
+// NumAdded returns the number of relations added across all keys.
+func (d Diff[JoinKey, ID]) NumAdded() int { return countIDs(d.Added) }
+
+// NumRemoved returns the number of relations removed across all keys.
+func (d Diff[JoinKey, ID]) NumRemoved() int { return countIDs(d.Removed) }
+
+// Changed reports whether anything was added or removed.
+func (d Diff[JoinKey, ID]) Changed() bool { return len(d.Added) > 0 || len(d.Removed) > 0 }
+
+// Keys returns the keys with added or removed relations, each once.
+func (d Diff[JoinKey, ID]) Keys() []JoinKey {
+	keys := make([]JoinKey, 0, len(d.Added)+len(d.Removed))
+	for k := range d.Added {
+		keys = append(keys, k)
+	}
+	for k := range d.Removed {
+		if _, ok := d.Added[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func countIDs[JoinKey, ID comparable](m map[JoinKey][]ID) int {
+	n := 0
+	for _, ids := range m {
+		n += len(ids)
+	}
+	return n
+}
+
+// DiffMany compares two loads of a relation, such as Snapshot before and
+// after an Invalidate, identifying relations by id.  Order within a key does
+// not matter.  Added IDs are listed in after's order and removed IDs in
+// before's; a key missing from one side counts as having no relations.
+func DiffMany[JoinKey, ID comparable, Relation any](before, after map[JoinKey][]Relation, id func(Relation) ID) Diff[JoinKey, ID] {
+	d := Diff[JoinKey, ID]{Added: map[JoinKey][]ID{}, Removed: map[JoinKey][]ID{}}
+	for k, rs := range before {
+		added, removed, same := diffIDs(rs, after[k], id)
+		d.Unchanged += same
+		if len(added) > 0 {
+			d.Added[k] = added
+		}
+		if len(removed) > 0 {
+			d.Removed[k] = removed
+		}
+	}
+	for k, rs := range after {
+		if _, ok := before[k]; ok || len(rs) == 0 {
+			continue
+		}
+		ids := make([]ID, len(rs))
+		for i, r := range rs {
+			ids[i] = id(r)
+		}
+		d.Added[k] = ids
+	}
+	return d
+}
+
+// diffIDs diffs one key's relations as multisets of IDs.
+func diffIDs[ID comparable, Relation any](before, after []Relation, id func(Relation) ID) (added, removed []ID, same int) {
+	left := make(map[ID]int, len(before))
+	for _, r := range before {
+		left[id(r)]++
+	}
+	for _, r := range after {
+		i := id(r)
+		if left[i] > 0 {
+			left[i]--
+			same++
+			continue
+		}
+		added = append(added, i)
+	}
+	for _, r := range before {
+		if i := id(r); left[i] > 0 {
+			left[i]--
+			removed = append(removed, i)
+		}
+	}
+	return added, removed, same
+}
+
+// Snapshot returns the relations of every model bound with spec.Model, as
+// Many would load them (from the cache if warm), by model key.  Models
+// without a key are left out.  The slices are shared with the cache and must
+// be treated as read-only; the map is the caller's.
+func Snapshot[JoinKey comparable, Model hasState, Relation any](ctx context.Context, spec RelationSpec[JoinKey, Model, Relation]) (map[JoinKey][]Relation, error) {
+	if isNil(spec.Model) {
+		return nil, spec.NilModel.nilModel(spec.CacheKey, spec.FallbackEngine)
+	}
+	models, err := ModelsOf(spec.Model)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[JoinKey][]Relation, len(models))
+	for _, m := range models {
+		if isNil(m) {
+			continue
+		}
+		k, ok := spec.ModelKey(m)
+		if !ok {
+			continue
+		}
+		if _, done := out[k]; done {
+			continue
+		}
+		rs, err := Many(ctx, spec.For(m))
+		if err != nil {
+			return nil, err
+		}
+		out[k] = rs
+	}
+	return out, nil
+}
+
+// RefreshDiff invalidates spec's cache key on spec.Model's state, reloads
+// it, and returns what changed for the batch, e.g. for an audit log.  The
+// reload calls Fetch even if nothing was cached before: the snapshot taken
+// first builds the group.  A frozen state fails with ErrFrozen.
+func RefreshDiff[JoinKey, ID comparable, Model hasState, Relation any](ctx context.Context, spec RelationSpec[JoinKey, Model, Relation], id func(Relation) ID) (Diff[JoinKey, ID], error) {
+	before, err := Snapshot(ctx, spec)
+	if err != nil || before == nil {
+		return Diff[JoinKey, ID]{}, err
+	}
+	if err := spec.Model.handle().Invalidate(spec.CacheKey); err != nil {
+		return Diff[JoinKey, ID]{}, err
+	}
+	after, err := Snapshot(ctx, spec)
+	if err != nil {
+		return Diff[JoinKey, ID]{}, err
+	}
+	return DiffMany(before, after, id), nil
+}
@@ -0,0 +1,103 @@
+package lode
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestDiffMany(t *testing.T) {
+	t.Parallel()
+	id := func(b *Book) int { return b.ID }
+	books := func(ids ...int) []*Book {
+		var out []*Book
+		for _, i := range ids {
+			out = append(out, &Book{ID: i})
+		}
+		return out
+	}
+	before := map[int][]*Book{
+		1: books(10, 11, 12),
+		2: books(20, 20, 21),
+		3: books(30),
+		4: nil,
+	}
+	after := map[int][]*Book{
+		1: books(12, 10, 11), // reordered only
+		2: books(21, 20, 22, 22),
+		4: books(40),
+		5: books(50, 51),
+		6: nil,
+	}
+	d := DiffMany(before, after, id)
+
+	wantAdded := map[int][]int{2: {22, 22}, 4: {40}, 5: {50, 51}}
+	wantRemoved := map[int][]int{2: {20}, 3: {30}}
+	if !mapsOfSlicesEqual(d.Added, wantAdded) || !mapsOfSlicesEqual(d.Removed, wantRemoved) {
+		t.Fatalf("Added = %v, Removed = %v; want %v, %v", d.Added, d.Removed, wantAdded, wantRemoved)
+	}
+	if d.Unchanged != 5 || d.NumAdded() != 5 || d.NumRemoved() != 2 || !d.Changed() {
+		t.Fatalf("Unchanged = %d, NumAdded = %d, NumRemoved = %d, Changed = %v", d.Unchanged, d.NumAdded(), d.NumRemoved(), d.Changed())
+	}
+	keys := d.Keys()
+	slices.Sort(keys)
+	if !slices.Equal(keys, []int{2, 3, 4, 5}) {
+		t.Fatalf("Keys = %v", keys)
+	}
+
+	if d := DiffMany(after, after, id); d.Changed() || d.Unchanged != 10 {
+		t.Fatalf("self diff = %+v; want no change", d)
+	}
+}
+
+func mapsOfSlicesEqual(a, b map[int][]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || !slices.Equal(v, w) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRefreshDiff(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	authors := []*Author{{ID: 1}, {ID: 2}, {ID: 0}}
+	NewEngine().InitHandles(authors)
+
+	rows := []*Book{{ID: 10, AuthorID: 1}, {ID: 20, AuthorID: 2}}
+	fetches := 0
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, a.ID != 0 },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(context.Context, []int) ([]*Book, error) {
+			fetches++
+			return slices.Clone(rows), nil
+		},
+	}
+	snap, err := Snapshot(ctx, spec.For(authors[2]))
+	if err != nil || len(snap) != 2 || len(snap[1]) != 1 || fetches != 1 {
+		t.Fatalf("Snapshot = %v, %v after %d fetches", snap, err, fetches)
+	}
+
+	rows = []*Book{{ID: 11, AuthorID: 1}, {ID: 20, AuthorID: 2}}
+	d, err := RefreshDiff(ctx, spec.For(authors[0]), func(b *Book) int { return b.ID })
+	if err != nil || fetches != 2 {
+		t.Fatalf("RefreshDiff err = %v after %d fetches", err, fetches)
+	}
+	if !slices.Equal(d.Added[1], []int{11}) || !slices.Equal(d.Removed[1], []int{10}) || d.Unchanged != 1 {
+		t.Fatalf("diff = %+v", d)
+	}
+	if books, _ := Many(ctx, spec.For(authors[0])); len(books) != 1 || books[0].ID != 11 {
+		t.Fatalf("Many after RefreshDiff = %v", titles(books))
+	}
+
+	authors[0].Freeze()
+	if _, err := RefreshDiff(ctx, spec.For(authors[0]), func(b *Book) int { return b.ID }); err == nil {
+		t.Fatal("RefreshDiff on a frozen state succeeded")
+	}
+}
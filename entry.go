@@ -0,0 +1,251 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// CacheEntry is one cached build slot: the value cached for one cache key on
+// one state (or, for Global, on one engine).  It is the machinery Resolve,
+// Many, and One are built on, exported for building custom loader primitives
+// with a caching discipline of their own.
+//
+// This is advanced API.  Values are untyped and must agree across every user
+// of a key, including lode itself: Resolve caches a ResolverFunc per key.
+// Entry reports the cache, not the models, so its behavior around resets may
+// change along with Resolve's.
+type CacheEntry struct {
+	e         *Engine
+	entries   *sync.Map    // the map entry is stored in
+	state     *loaderState // the state owning entries; nil for Global
+	entry     *resolverEntry
+	cacheKey  string
+	modelType string // empty for Global
+}
+
+// Entry returns the entry for key on model's state, applying the engine's
+// key namespace and, when configured, its membership and debug checks.  The
+// entry is replaced, not cleared, by resets: hold it only as long as one
+// operation.
+func Entry(model hasState, key string) (*CacheEntry, error) {
+	if isNil(model) || model.lodeState() == nil {
+		return nil, notInitialized(model)
+	}
+	s := model.lodeState()
+	if err := s.engine.checkKey(key); err != nil {
+		return nil, err
+	}
+	cacheKey := s.engine.key(key)
+	if s.engine.config.debug {
+		if err := checkOrigin(model, cacheKey); err != nil {
+			return nil, err
+		}
+	}
+	if s.engine.config.membershipCheck && !s.isMember(model) {
+		return nil, fmt.Errorf("%s: key %q: %w: %T at %p is not among the %d bound models (was it copied after InitHandles?)",
+			packagePrefix, cacheKey, errNotMember, model, model, reflect.ValueOf(s.models).Len())
+	}
+	if entries, ok := s.engine.immutableEntries(cacheKey, s.modelType()); ok {
+		c := s.engine.entry(entries, cacheKey)
+		c.modelType = s.modelType()
+		return c, nil
+	}
+	c := s.engine.entry(&s.resolverEntries, cacheKey)
+	c.modelType = s.modelType()
+	c.state = s
+	return c, nil
+}
+
+// entry returns the entry for an already namespaced key in entries.
+func (e *Engine) entry(entries *sync.Map, cacheKey string) *CacheEntry {
+	pm, _ := entries.LoadOrStore(cacheKey, &resolverEntry{})
+	return &CacheEntry{e: e, entries: entries, entry: pm.(*resolverEntry), cacheKey: cacheKey}
+}
+
+// Key returns the entry's cache key, namespace included.
+func (c *CacheEntry) Key() string { return c.cacheKey }
+
+// FetchDescriptors returns what the fetches of the build that produced the
+// entry's value reported with SetFetchDescriptor, in order, or nil if the
+// entry is not built.  The slice is shared: do not modify it.
+func (c *CacheEntry) FetchDescriptors() []string {
+	if h := c.entry.ready.Load(); h != nil {
+		return h.descriptors
+	}
+	return nil
+}
+
+// GetOrBuild returns the entry's value, calling build to produce it if no
+// caller has yet.  Concurrent callers share one build.  Its value is kept
+// until a reset; its error is returned to the callers that shared the build,
+// and the next call retries, again as one build shared by concurrent callers.
+// A caller whose ctx is still live retries at once rather than take an error
+// of another caller's ctx.  build runs under the engine's circuit breaker and
+// build context (see WithDetachedBuildContext).
+func (c *CacheEntry) GetOrBuild(ctx context.Context, build func(ctx context.Context) (any, error)) (any, error) {
+	h, _ := c.getOrBuild(ctx, build)
+	return h.resolver, h.err
+}
+
+// GetOrBuildAs is GetOrBuild for a value of type T.  A value of another type
+// already cached under the key is reported as an error.
+func GetOrBuildAs[T any](ctx context.Context, c *CacheEntry, build func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	v, err := c.GetOrBuild(ctx, func(ctx context.Context) (any, error) { return build(ctx) })
+	if err != nil {
+		return zero, err
+	}
+	if v == nil {
+		return zero, nil // a nil interface value
+	}
+	t, ok := v.(T)
+	if !ok {
+		return zero, typeMismatch[T](c.cacheKey, reflect.TypeOf(v))
+	}
+	return t, nil
+}
+
+// getOrBuild is GetOrBuild returning the holder, and whether this call ran
+// the build.  See cycle.go for how it avoids deadlocks.
+func (c *CacheEntry) getOrBuild(ctx context.Context, build func(ctx context.Context) (any, error)) (*resolverHolder, bool) {
+	version, versioned := c.e.version(c.cacheKey)
+	mode, _ := ctx.Value(callModeKey{}).(callMode)
+	if mode.noStore {
+		return c.buildUncached(ctx, build, version, versioned, mode.forceRefresh)
+	}
+	if mode.forceRefresh {
+		if err := c.refresh(); err != nil {
+			return &resolverHolder{err: err}, false
+		}
+	}
+	if h := c.entry.ready.Load(); h != nil && h.err != nil {
+		c.retry()
+	}
+	if h := c.entry.ready.Load(); h != nil {
+		if !versioned || h.version == version {
+			c.touch()
+			return h, false
+		}
+		c.renew(c.entries, version)
+		if h := c.entry.ready.Load(); h != nil {
+			c.touch()
+			return h, false
+		}
+	}
+	if err := c.checkCycle(ctx); err != nil {
+		return &resolverHolder{err: err}, false
+	}
+	if c.entry.stale != nil && !c.entry.claimed.CompareAndSwap(false, true) {
+		if h := c.entry.ready.Load(); h != nil {
+			return h, false
+		}
+		return c.entry.stale, false
+	}
+	slotCtx, release, err := c.buildSlot(ctx)
+	if err != nil {
+		return &resolverHolder{err: err, version: version}, false
+	}
+	built := false
+	func() {
+		defer release()
+		c.entry.once.Do(func() {
+			built = true
+			c.entry.building.Store(true)
+			c.entry.ready.Store(c.run(slotCtx, build, version))
+			if c.e.mem != nil && c.state != nil {
+				// A reset that removed the entry while it built saw nothing
+				// to release.
+				if v, _ := c.entries.Load(c.cacheKey); v != c.entry {
+					c.state.release(c.entry)
+				}
+				c.touch()
+			}
+		})
+	}()
+	h := c.entry.ready.Load()
+	if h == nil {
+		// The build panicked, and the panic was recovered above us: the
+		// once is spent with nothing stored, so build again.
+		c.retry()
+		return c.getOrBuild(ctx, build)
+	}
+	if !built && isContextErr(h.err) && ctx.Err() == nil {
+		return c.getOrBuild(ctx, build)
+	}
+	return h, built
+}
+
+// buildSlot takes a build slot for a caller about to enter the entry's
+// once, returning the ctx to build with and the function that frees the
+// slot.  The slot is taken before the once, never while holding it: a build
+// that resolves this entry would otherwise wait on a once whose holder waits
+// for that build's slot.  Callers that find a build already started take no
+// slot, since they only wait for it.
+func (c *CacheEntry) buildSlot(ctx context.Context) (context.Context, func(), error) {
+	if c.entry.building.Load() {
+		return ctx, func() {}, nil
+	}
+	slotCtx, release, err := c.e.acquireBuild(ctx, KeyLabel{ModelType: c.modelType, CacheKey: c.cacheKey})
+	if err != nil {
+		return ctx, nil, err
+	}
+	if c.entry.building.Load() || c.entry.ready.Load() != nil {
+		release() // built or building while we waited
+		return ctx, func() {}, nil
+	}
+	return slotCtx, release, nil
+}
+
+// retry replaces c's entry, whose build failed, so that the next caller
+// builds again.  Callers that find the entry replaced share the replacement.
+func (c *CacheEntry) retry() {
+	fresh := c.e.successor(c.entry)
+	if c.entries.CompareAndSwap(c.cacheKey, c.entry, fresh) {
+		c.state.release(c.entry)
+		c.entry = fresh
+		return
+	}
+	v, _ := c.entries.LoadOrStore(c.cacheKey, fresh)
+	c.entry = v.(*resolverEntry)
+}
+
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// run runs build for c's key under the engine's build slots, build context,
+// and circuit breaker, and returns the holder for its result.
+func (c *CacheEntry) run(ctx context.Context, build func(ctx context.Context) (any, error), version uint64) *resolverHolder {
+	var info Info
+	label := KeyLabel{ModelType: c.modelType, CacheKey: c.cacheKey}
+	buildCtx, release, err := c.e.acquireBuild(c.withBuild(c.e.buildContext(ctx)), label)
+	if err != nil {
+		return &resolverHolder{err: err, version: version}
+	}
+	defer release()
+	if _, ok := buildCtx.Value(callModeKey{}).(callMode); ok {
+		buildCtx = context.WithValue(buildCtx, callModeKey{}, callMode{}) // see WithForceRefresh
+	}
+	var guard *buildCtxGuard
+	if c.e.config.debug {
+		guard = &buildCtxGuard{Context: buildCtx, e: c.e, cacheKey: c.cacheKey}
+		buildCtx = guard
+	}
+	var descriptors *fetchDescriptors
+	buildCtx = context.WithValue(buildCtx, buildInfoKey{}, &info)
+	if c.e.config.fetchDescriptors {
+		descriptors = new(fetchDescriptors)
+		buildCtx = context.WithValue(buildCtx, fetchDescriptorsKey{}, descriptors)
+	}
+	start := c.e.now()
+	res, err := c.e.build(label, func() (any, error) { return build(buildCtx) })
+	if guard != nil {
+		guard.returned.Store(true)
+	}
+	info.BuildDuration = c.e.now().Sub(start)
+	c.e.onBuild(BuildEvent{CacheKey: c.e.callerKey(c.cacheKey), ModelType: c.modelType, Duration: info.BuildDuration, Err: err})
+	return &resolverHolder{resolver: res, err: err, info: info, descriptors: descriptors.recorded(), version: version}
+}
@@ -0,0 +1,213 @@
+package lode
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEntry_GetOrBuild(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine(WithKeyNamespace("ns"))
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles([]*Author{a1, a2})
+
+	builds := 0
+	count := func(ctx context.Context) (int, error) {
+		builds++
+		models, err := ModelsOf(a1)
+		return len(models), err
+	}
+	for _, a := range []*Author{a1, a2} {
+		entry, err := Entry(a, "count")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.Key() != "ns:count" {
+			t.Fatalf("Key() = %q", entry.Key())
+		}
+		if n, err := GetOrBuildAs(ctx, entry, count); err != nil || n != 2 {
+			t.Fatalf("GetOrBuildAs = %d, %v", n, err)
+		}
+	}
+	if builds != 1 {
+		t.Fatalf("builds = %d; want 1 shared by the batch", builds)
+	}
+
+	// Entries are the same slots Resolve uses, and are replaced by resets.
+	if _, err := Resolve(ctx, ResolveSpec[*Author, int]{CacheKey: "count", Model: a1}); err == nil {
+		t.Fatal("Resolve over a non-resolver entry: want an incompatible type error")
+	}
+	a1.Reset()
+	entry, _ := Entry(a1, "count")
+	if _, err := GetOrBuildAs(ctx, entry, count); err != nil || builds != 2 {
+		t.Fatalf("after Reset: builds = %d, err = %v", builds, err)
+	}
+	if _, err := GetOrBuildAs(ctx, entry, func(context.Context) (string, error) { return "", nil }); err == nil {
+		t.Fatal("GetOrBuildAs with another type: want error")
+	}
+}
+
+func TestEntry_ErrorsAreRetried(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles([]*Author{a1, a2})
+
+	errBoom := errors.New("boom")
+	calls := 0
+	spec := ResolveSpec[*Author, int]{
+		CacheKey: "k",
+		Build: func(context.Context, []*Author) (ResolverFunc[*Author, int], error) {
+			calls++
+			if calls == 1 {
+				return nil, errBoom
+			}
+			return func(a *Author) int { return a.ID }, nil
+		},
+	}
+	if _, err := Resolve(ctx, spec.For(a1)); !errors.Is(err, errBoom) {
+		t.Fatalf("first Resolve: err = %v; want boom", err)
+	}
+	for _, a := range []*Author{a1, a2, a1} {
+		if n, err := Resolve(ctx, spec.For(a)); err != nil || n != a.ID {
+			t.Fatalf("Resolve after failure = %d, %v", n, err)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d; want 2", calls)
+	}
+
+	if _, err := Entry(&Author{}, "k"); !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("unbound: err = %v", err)
+	}
+	checked := NewEngine(WithMembershipCheck())
+	authors := []Author{{ID: 1}}
+	checked.InitHandles(authors)
+	if _, err := Entry(copyModel(&authors[0]), "k"); !errors.Is(err, errNotMember) {
+		t.Fatalf("copy: err = %v; want errNotMember", err)
+	}
+}
+
+func TestCacheEntry_FetchDescriptors(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	NewEngine(WithFetchDescriptors()).InitHandles([]*Author{a1, a2})
+
+	long := strings.Repeat("x", 2*MaxFetchDescriptorLen)
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:        "books",
+		ModelKey:        func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey:     func(b *Book) int { return b.AuthorID },
+		MaxKeysPerFetch: 1,
+		KeyOrder:        cmp.Compare[int],
+		Fetch: func(ctx context.Context, keys []int) ([]*Book, error) {
+			if !CollectsFetchDescriptors(ctx) {
+				t.Error("the build does not collect descriptors")
+			}
+			SetFetchDescriptor(ctx, fmt.Sprintf("SELECT * FROM books WHERE author_id IN (%d)", keys[0]))
+			if keys[0] == 2 {
+				SetFetchDescriptor(ctx, long)
+			}
+			return nil, nil
+		},
+	}
+	entry, err := Entry(a2, "books")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := entry.FetchDescriptors(); d != nil {
+		t.Fatalf("unbuilt entry has descriptors %q", d)
+	}
+	if _, err := Many(ctx, spec.For(a1)); err != nil {
+		t.Fatal(err)
+	}
+	d := entry.FetchDescriptors()
+	if len(d) != 3 || d[0] != "SELECT * FROM books WHERE author_id IN (1)" || d[1] != "SELECT * FROM books WHERE author_id IN (2)" {
+		t.Fatalf("FetchDescriptors = %q", d)
+	}
+	if len(d[2]) != MaxFetchDescriptorLen+len("...") {
+		t.Fatalf("long descriptor kept %d bytes", len(d[2]))
+	}
+
+	SetFetchDescriptor(ctx, "outside a build") // no-op
+
+	// Without WithFetchDescriptors, nothing is collected.
+	a3 := &Author{ID: 3}
+	NewEngine().InitHandles(a3)
+	spec.Fetch = func(ctx context.Context, _ []int) ([]*Book, error) {
+		if CollectsFetchDescriptors(ctx) {
+			t.Error("descriptors collected without WithFetchDescriptors")
+		}
+		SetFetchDescriptor(ctx, "dropped")
+		return nil, nil
+	}
+	if _, err := Many(ctx, spec.For(a3)); err != nil {
+		t.Fatal(err)
+	}
+	if entry, _ := Entry(a3, "books"); entry.FetchDescriptors() != nil {
+		t.Fatalf("FetchDescriptors = %q; want none", entry.FetchDescriptors())
+	}
+}
+
+func TestEntry_RebuildsAfterRecoveredPanic(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	a := &Author{ID: 1}
+	NewEngine().InitHandles(a)
+	err := Parallel(ctx, func(ctx context.Context) error {
+		return resolveWith(ctx, a, "k", func(context.Context) error { panic("boom") })
+	})
+	if !errors.Is(err, errPanicked) {
+		t.Fatalf("Parallel err = %v; want the recovered panic", err)
+	}
+	if err := resolveWith(ctx, a, "k", func(context.Context) error { return nil }); err != nil {
+		t.Fatalf("Resolve after a recovered panic: %v", err)
+	}
+}
+
+func TestEntry_RetryIsShared(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+	entry, _ := Entry(a, "k")
+	if _, err := entry.GetOrBuild(ctx, func(context.Context) (any, error) { return nil, errors.New("boom") }); err == nil {
+		t.Fatal("want the build's error")
+	}
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entry, _ := Entry(a, "k")
+			v, err := entry.GetOrBuild(ctx, func(context.Context) (any, error) {
+				calls.Add(1)
+				<-release
+				return 1, nil
+			})
+			if err != nil || v != 1 {
+				t.Errorf("GetOrBuild = %v, %v", v, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("retried %d times; want one shared retry", n)
+	}
+}
@@ -0,0 +1,99 @@
+package lode
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// The errors below are lode's stable error taxonomy: errors returned by the
+// package (and by lodegorm) match them with errors.Is whatever context they
+// are wrapped in, so callers never need to match on messages.  Errors from
+// specs' own Fetch and Build functions are passed through as returned, and
+// their panics returned as errors naming the function.
+var (
+	// ErrNotInitialized is returned for models that were not bound with
+	// Engine.InitHandles (or Bind, Register, and the like), or whose state
+	// does not list them.
+	ErrNotInitialized = errors.New("model not initialized with loader")
+	// ErrNilModel is returned (wrapped with the cache key) for nil models
+	// under NilReturnsError.
+	ErrNilModel = errors.New("nil model")
+	// ErrTypeMismatch is returned when a value does not have the type it is
+	// used as: a cache key shared by specs of different result types, an
+	// override or entry read as the wrong type, a value that cannot be
+	// bound.  It is returned as a *TypeMismatchError where the types are
+	// known.
+	ErrTypeMismatch = errors.New("type mismatch")
+	// ErrNotFound is returned when something looked up is missing: OneStrict
+	// finding no relation, a strict map spec's Build leaving a model out, an
+	// unregistered key under WithStrictKeys, a model without a key under
+	// RequireModelKey.
+	ErrNotFound = errors.New("not found")
+	// ErrMultiple is returned, as a *MultipleError, by OneStrict when a model
+	// has more than one relation.
+	ErrMultiple = errors.New("multiple relations")
+	// ErrUnexpectedRelation is returned (wrapped with the cache key and
+	// counts) by StrictFetch builds whose fetch returned relations that
+	// belong to no requested key.
+	ErrUnexpectedRelation = errors.New("unexpected relation")
+	// ErrCircuitOpen is returned (wrapped with the cache key) by builds that
+	// were short-circuited by the engine's circuit breaker.
+	ErrCircuitOpen = errors.New("circuit open")
+	// ErrBudgetExceeded is returned (wrapped with the cache key and sizes) by
+	// builds that would take the engine over its WithMemoryBudget, and by
+	// builds calling FetchPage more often than MaxPages allows.
+	ErrBudgetExceeded = errors.New("memory budget exceeded")
+	// ErrFrozen is returned (wrapped) by resets of a frozen state (see
+	// Handle.Freeze) and by SetDefault once the default engine is in use.
+	ErrFrozen = errors.New("state is frozen")
+	// ErrMisconfigured is returned when specs or options ask for something
+	// lode cannot do: a spec setting two fetch forms, a Limiter that was
+	// never registered, Stream without FetchStream, a build depending on
+	// itself, an association lodegorm cannot load.
+	ErrMisconfigured = errors.New("misconfigured")
+)
+
+// TypeMismatchError is the ErrTypeMismatch reported when the types are known.
+type TypeMismatchError struct {
+	CacheKey string // empty if the mismatch is not about a cache key
+	Got      reflect.Type
+	Want     reflect.Type
+}
+
+func (e *TypeMismatchError) Error() string {
+	msg := fmt.Sprintf("%v: got %v, want %v", ErrTypeMismatch, e.Got, e.Want)
+	if e.CacheKey != "" {
+		msg = fmt.Sprintf("key %q: %s", e.CacheKey, msg)
+	}
+	return msg
+}
+
+func (e *TypeMismatchError) Is(target error) bool { return target == ErrTypeMismatch }
+
+// MultipleError is the ErrMultiple reported by OneStrict.
+type MultipleError struct {
+	CacheKey string
+	Count    int
+}
+
+func (e *MultipleError) Error() string {
+	return fmt.Sprintf("key %q: %v: got %d, want 1", e.CacheKey, ErrMultiple, e.Count)
+}
+
+func (e *MultipleError) Is(target error) bool { return target == ErrMultiple }
+
+// kindError is an unexported sentinel, kept for its message, that matches
+// one of the exported ones.
+type kindError struct {
+	msg  string
+	kind error
+}
+
+func (e *kindError) Error() string { return e.msg }
+func (e *kindError) Unwrap() error { return e.kind }
+
+// typeMismatch returns the ErrTypeMismatch for a got used as Want.
+func typeMismatch[Want any](cacheKey string, got reflect.Type) error {
+	return fmt.Errorf("%s: %w", packagePrefix, &TypeMismatchError{CacheKey: cacheKey, Got: got, Want: reflect.TypeFor[Want]()})
+}
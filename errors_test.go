@@ -0,0 +1,213 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestErrorTaxonomy pins each public failure mode to its sentinel, so errors
+// cannot be reworded or rewrapped out from under errors.Is.
+func TestErrorTaxonomy(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	count := func(key string) ResolveSpec[*Author, int] {
+		return ResolveSpec[*Author, int]{
+			CacheKey: key,
+			Build: func(context.Context, []*Author) (ResolverFunc[*Author, int], error) {
+				return func(*Author) int { return 1 }, nil
+			},
+		}
+	}
+	bound := func(opts ...ConfigOption) *Author {
+		a := &Author{ID: 1}
+		NewEngine(opts...).InitHandles(a)
+		return a
+	}
+	books := threeBooks(map[int]int{})
+	noBooks := books
+	noBooks.CacheKey = "no_books"
+	noBooks.Fetch = func(context.Context, []int) ([]*Book, error) { return nil, nil }
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"Resolve on an unbound model", func() error {
+			spec := count("n")
+			spec.Model = &Author{}
+			_, err := Resolve(ctx, spec)
+			return err
+		}, ErrNotInitialized},
+		{"Many on an unbound model", func() error {
+			_, err := Many(ctx, books.For(&Author{}))
+			return err
+		}, ErrNotInitialized},
+		{"ModelsOf an unbound model", func() error {
+			_, err := ModelsOf(&Author{})
+			return err
+		}, ErrNotInitialized},
+		{"Resolve on a nil model with NilReturnsError", func() error {
+			spec := count("n")
+			spec.NilModel = NilReturnsError
+			_, err := Resolve(ctx, spec)
+			return err
+		}, ErrNilModel},
+		{"a cache key shared by result types", func() error {
+			a := bound()
+			Resolve(ctx, count("shared").For(a))
+			_, err := Resolve(ctx, ResolveSpec[*Author, string]{
+				CacheKey: "shared",
+				Model:    a,
+				Build: func(context.Context, []*Author) (ResolverFunc[*Author, string], error) {
+					return func(*Author) string { return "" }, nil
+				},
+			})
+			return err
+		}, ErrTypeMismatch},
+		{"an override of the wrong type", func() error {
+			a := bound()
+			if err := Override(a, "n", "one"); err != nil {
+				return err
+			}
+			_, err := Resolve(ctx, count("n").For(a))
+			return err
+		}, ErrTypeMismatch},
+		{"binding a non-model", func() error {
+			_, err := NewEngine().Bind(42)
+			return err
+		}, ErrTypeMismatch},
+		{"OneStrict without a relation", func() error {
+			_, err := OneStrict(ctx, noBooks.For(bound()))
+			return err
+		}, ErrNotFound},
+		{"a strict map Build leaving a model out", func() error {
+			_, err := ResolveMap(ctx, ResolveSpecMap[*Author, int]{
+				CacheKey: "map",
+				Model:    bound(),
+				Build:    func(context.Context, []*Author) (map[*Author]int, error) { return nil, nil },
+				Strict:   true,
+			})
+			return err
+		}, ErrNotFound},
+		{"an unregistered key under WithStrictKeys", func() error {
+			_, err := Resolve(ctx, count("n").For(bound(WithStrictKeys())))
+			return err
+		}, ErrNotFound},
+		{"a model without a key under RequireModelKey", func() error {
+			yes := true
+			spec := books.For(bound())
+			spec.ModelKey = func(*Author) (int, bool) { return 0, false }
+			spec.RequireModelKey = &yes
+			_, err := Many(ctx, spec)
+			return err
+		}, ErrNotFound},
+		{"OneStrict with several relations", func() error {
+			_, err := OneStrict(ctx, books.For(bound()))
+			return err
+		}, ErrMultiple},
+		{"a stray relation under StrictFetch", func() error {
+			yes := true
+			spec := books.For(bound())
+			spec.RelationKey = func(b *Book) int { return b.AuthorID + 1 }
+			spec.StrictFetch = &yes
+			_, err := Many(ctx, spec)
+			return err
+		}, ErrUnexpectedRelation},
+		{"a build while the circuit is open", func() error {
+			a := bound(WithCircuitBreaker(1, time.Hour))
+			spec := count("n")
+			spec.Build = func(context.Context, []*Author) (ResolverFunc[*Author, int], error) {
+				return nil, errors.New("down")
+			}
+			Resolve(ctx, spec.For(a))
+			a.Reset()
+			_, err := Resolve(ctx, spec.For(a))
+			return err
+		}, ErrCircuitOpen},
+		{"a build over the memory budget", func() error {
+			_, err := Many(ctx, books.For(bound(WithMemoryBudget(1, BudgetFail))))
+			return err
+		}, ErrBudgetExceeded},
+		{"a fetch over MaxPages", func() error {
+			calls := 0
+			spec := books.For(bound())
+			spec.Fetch = nil
+			spec.FetchPage = pagedBooks(make([]*Book, 5), &calls, nil)
+			spec.MaxPages = 1
+			_, err := Many(ctx, spec)
+			return err
+		}, ErrBudgetExceeded},
+		{"a spec setting Fetch and FetchPage", func() error {
+			calls := 0
+			spec := books.For(bound())
+			spec.FetchPage = pagedBooks(nil, &calls, nil)
+			_, err := Many(ctx, spec)
+			return err
+		}, ErrMisconfigured},
+		{"a spec setting FetchGrouped and Fetch", func() error {
+			spec := books.For(bound())
+			spec.FetchGrouped = func(context.Context, []int) (map[int][]*Book, error) { return nil, nil }
+			_, err := Many(ctx, spec)
+			return err
+		}, ErrMisconfigured},
+		{"an unregistered Limiter", func() error {
+			spec := books.For(bound())
+			spec.Limiter = "missing"
+			_, err := Many(ctx, spec)
+			return err
+		}, ErrMisconfigured},
+		{"Stream without FetchStream", func() error {
+			return Stream(ctx, books.For(bound()), func(int, *Book) error { return nil })
+		}, ErrMisconfigured},
+		{"resetting a frozen state", func() error {
+			a := bound()
+			a.Freeze()
+			return a.Reset()
+		}, ErrFrozen},
+	}
+	for _, tt := range tests {
+		if err := tt.call(); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v; want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestErrorTaxonomy_Structured(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	a := &Author{ID: 1}
+	NewEngine().InitHandles(a)
+
+	_, err := OneStrict(ctx, threeBooks(map[int]int{}).For(a))
+	var multiple *MultipleError
+	if !errors.As(err, &multiple) || multiple.CacheKey != "books" || multiple.Count != 3 {
+		t.Fatalf("OneStrict err = %v; want a *MultipleError for 3 books", err)
+	}
+
+	if err := Override(a, "n", "one"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = Resolve(ctx, ResolveSpec[*Author, int]{CacheKey: "n", Model: a})
+	var mismatch *TypeMismatchError
+	if !errors.As(err, &mismatch) || mismatch.CacheKey != "n" || mismatch.Got != reflect.TypeFor[string]() || mismatch.Want != reflect.TypeFor[int]() {
+		t.Fatalf("override err = %v; want a *TypeMismatchError from string to int", err)
+	}
+	if got, want := err.Error(), `lode: key "n": type mismatch: got string, want int`; got != want {
+		t.Fatalf("err = %q; want %q", got, want)
+	}
+
+	if book, err := OneStrict(ctx, RelationSpec[int, *Author, *Book]{
+		CacheKey:    "first_book",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(context.Context, []int) ([]*Book, error) {
+			return []*Book{{ID: 7, AuthorID: 1}}, nil
+		},
+	}.For(a)); err != nil || book.ID != 7 {
+		t.Fatalf("OneStrict = %+v, %v; want book 7", book, err)
+	}
+}
@@ -0,0 +1,281 @@
+// Package benchmarks compares loading a three-level relation (authors, their
+// books, and the books' chapters) naively, with gorm's Preload, and with
+// lode, on a seeded sqlite database.  Run it with
+//
+//	go run ./benchmarks/cmd/lodebench -authors 200 -books 5 -chapters 10
+//
+// from the example module.
+package benchmarks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/willhf/lode"
+	"github.com/willhf/lode/lodegorm"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type Author struct {
+	ID    uint
+	Name  string
+	Books []*Book // only filled by Preload
+	lode.Handle
+}
+
+type Book struct {
+	ID       uint
+	AuthorID uint
+	Title    string
+	Chapters []*Chapter // only filled by Preload
+	lode.Handle
+}
+
+type Chapter struct {
+	ID     uint
+	BookID uint
+	Title  string
+	lode.Handle
+}
+
+func (a *Author) LodeBooks(ctx context.Context, db *gorm.DB) ([]*Book, error) {
+	return lode.Many(ctx, lode.RelationSpec[uint, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       a,
+		ModelKey:    func(a *Author) (uint, bool) { return a.ID, true },
+		RelationKey: func(b *Book) uint { return b.AuthorID },
+		Fetch:       lodegorm.Fetch[*Book, uint](db, "author_id"),
+	})
+}
+
+func (b *Book) LodeChapters(ctx context.Context, db *gorm.DB) ([]*Chapter, error) {
+	return lode.Many(ctx, lode.RelationSpec[uint, *Book, *Chapter]{
+		CacheKey:    "chapters",
+		Model:       b,
+		ModelKey:    func(b *Book) (uint, bool) { return b.ID, true },
+		RelationKey: func(c *Chapter) uint { return c.BookID },
+		Fetch:       lodegorm.Fetch[*Chapter, uint](db, "book_id"),
+	})
+}
+
+// Sizes says how much data Seed creates.
+type Sizes struct {
+	Authors         int
+	BooksPerAuthor  int
+	ChaptersPerBook int
+}
+
+// Open returns an in-memory sqlite database with the benchmark schema and
+// lodegorm's callback registered on engine.
+func Open(engine *lode.Engine) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return nil, err
+	}
+	// Every connection to :memory: gets a database of its own.
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&Author{}, &Book{}, &Chapter{}); err != nil {
+		return nil, err
+	}
+	lodegorm.RegisterCallback(engine, db)
+	return db, nil
+}
+
+// Seed inserts sizes' worth of authors, books, and chapters.
+func Seed(db *gorm.DB, sizes Sizes) error {
+	const batch = 500
+	authors := make([]*Author, sizes.Authors)
+	for i := range authors {
+		authors[i] = &Author{Name: fmt.Sprintf("author %d", i)}
+	}
+	if err := db.CreateInBatches(authors, batch).Error; err != nil {
+		return err
+	}
+	var books []*Book
+	for _, a := range authors {
+		for i := 0; i < sizes.BooksPerAuthor; i++ {
+			books = append(books, &Book{AuthorID: a.ID, Title: fmt.Sprintf("book %d of %d", i, a.ID)})
+		}
+	}
+	if err := db.CreateInBatches(books, batch).Error; err != nil {
+		return err
+	}
+	var chapters []*Chapter
+	for _, b := range books {
+		for i := 0; i < sizes.ChaptersPerBook; i++ {
+			chapters = append(chapters, &Chapter{BookID: b.ID, Title: fmt.Sprintf("chapter %d", i)})
+		}
+	}
+	return db.CreateInBatches(chapters, batch).Error
+}
+
+// QueryCounter is a gorm logger that counts the statements it sees and
+// logs nothing.  Install it with CountQueries.  It is safe for concurrent
+// use.
+type QueryCounter struct {
+	mu      sync.Mutex
+	queries []string
+}
+
+// CountQueries returns a session of db whose statements are counted by c.
+func (c *QueryCounter) CountQueries(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{Logger: c})
+}
+
+// Count returns the number of statements seen so far.
+func (c *QueryCounter) Count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.queries)
+}
+
+// Queries returns the SQL of the statements seen so far, in order.
+func (c *QueryCounter) Queries() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.queries)
+}
+
+// Reset forgets the statements seen so far.
+func (c *QueryCounter) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries = nil
+}
+
+func (c *QueryCounter) LogMode(logger.LogLevel) logger.Interface      { return c }
+func (c *QueryCounter) Info(context.Context, string, ...interface{})  {}
+func (c *QueryCounter) Warn(context.Context, string, ...interface{})  {}
+func (c *QueryCounter) Error(context.Context, string, ...interface{}) {}
+
+func (c *QueryCounter) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries = append(c.queries, sql)
+}
+
+// Strategy loads every author's books and their chapters and returns the
+// number of chapters it saw.
+type Strategy struct {
+	Name string
+	Load func(ctx context.Context, db *gorm.DB) (int, error)
+}
+
+// Strategies returns the strategies Run compares.
+func Strategies() []Strategy {
+	return []Strategy{
+		{Name: "naive N+1", Load: loadNaive},
+		{Name: "gorm Preload", Load: loadPreload},
+		{Name: "lode", Load: loadLode},
+	}
+}
+
+func loadNaive(ctx context.Context, db *gorm.DB) (int, error) {
+	var authors []*Author
+	if err := db.WithContext(ctx).Find(&authors).Error; err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, a := range authors {
+		var books []*Book
+		if err := db.WithContext(ctx).Where("author_id = ?", a.ID).Find(&books).Error; err != nil {
+			return 0, err
+		}
+		for _, b := range books {
+			var chapters []*Chapter
+			if err := db.WithContext(ctx).Where("book_id = ?", b.ID).Find(&chapters).Error; err != nil {
+				return 0, err
+			}
+			total += len(chapters)
+		}
+	}
+	return total, nil
+}
+
+func loadPreload(ctx context.Context, db *gorm.DB) (int, error) {
+	var authors []*Author
+	if err := db.WithContext(ctx).Preload("Books.Chapters").Find(&authors).Error; err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, a := range authors {
+		for _, b := range a.Books {
+			total += len(b.Chapters)
+		}
+	}
+	return total, nil
+}
+
+func loadLode(ctx context.Context, db *gorm.DB) (int, error) {
+	var authors []*Author
+	if err := db.WithContext(ctx).Find(&authors).Error; err != nil {
+		return 0, err
+	}
+	total := 0
+	for _, a := range authors {
+		books, err := a.LodeBooks(ctx, db)
+		if err != nil {
+			return 0, err
+		}
+		for _, b := range books {
+			chapters, err := b.LodeChapters(ctx, db)
+			if err != nil {
+				return 0, err
+			}
+			total += len(chapters)
+		}
+	}
+	return total, nil
+}
+
+// Result is one strategy's measurement.
+type Result struct {
+	Strategy string
+	Duration time.Duration
+	Queries  int
+	Chapters int
+}
+
+// Run seeds a fresh database with sizes and measures each strategy on it.
+func Run(ctx context.Context, sizes Sizes) ([]Result, error) {
+	db, err := Open(lode.NewEngine())
+	if err != nil {
+		return nil, err
+	}
+	if err := Seed(db, sizes); err != nil {
+		return nil, err
+	}
+	var results []Result
+	for _, s := range Strategies() {
+		var counter QueryCounter
+		start := time.Now()
+		n, err := s.Load(ctx, counter.CountQueries(db))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.Name, err)
+		}
+		results = append(results, Result{Strategy: s.Name, Duration: time.Since(start), Queries: counter.Count(), Chapters: n})
+	}
+	return results, nil
+}
+
+// PrintTable writes results as an aligned table.
+func PrintTable(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "strategy\tqueries\tchapters\ttime\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%v\t\n", r.Strategy, r.Queries, r.Chapters, r.Duration.Round(time.Microsecond))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,64 @@
+package benchmarks
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/willhf/lode"
+)
+
+func TestRun(t *testing.T) {
+	sizes := Sizes{Authors: 4, BooksPerAuthor: 3, ChaptersPerBook: 2}
+	results, err := Run(context.Background(), sizes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	books := sizes.Authors * sizes.BooksPerAuthor
+	want := map[string]int{
+		"naive N+1":    1 + sizes.Authors + books,
+		"gorm Preload": 3,
+		"lode":         3,
+	}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results; want %d", len(results), len(want))
+	}
+	for _, r := range results {
+		if r.Chapters != books*sizes.ChaptersPerBook {
+			t.Errorf("%s saw %d chapters; want %d", r.Strategy, r.Chapters, books*sizes.ChaptersPerBook)
+		}
+		if r.Queries != want[r.Strategy] {
+			t.Errorf("%s ran %d queries; want %d", r.Strategy, r.Queries, want[r.Strategy])
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := PrintTable(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != len(results)+1 {
+		t.Fatalf("table has %d lines; want %d:\n%s", lines, len(results)+1, buf.String())
+	}
+}
+
+func BenchmarkStrategies(b *testing.B) {
+	ctx := context.Background()
+	for _, s := range Strategies() {
+		b.Run(s.Name, func(b *testing.B) {
+			db, err := Open(lode.NewEngine())
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err := Seed(db, Sizes{Authors: 50, BooksPerAuthor: 3, ChaptersPerBook: 5}); err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := s.Load(ctx, db); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
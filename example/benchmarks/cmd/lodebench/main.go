@@ -0,0 +1,28 @@
+// Command lodebench prints a table comparing naive N+1 loading, gorm
+// Preload, and lode on a seeded sqlite database.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/willhf/lode/example/benchmarks"
+)
+
+func main() {
+	var sizes benchmarks.Sizes
+	flag.IntVar(&sizes.Authors, "authors", 200, "number of authors")
+	flag.IntVar(&sizes.BooksPerAuthor, "books", 5, "books per author")
+	flag.IntVar(&sizes.ChaptersPerBook, "chapters", 10, "chapters per book")
+	flag.Parse()
+
+	results, err := benchmarks.Run(context.Background(), sizes)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := benchmarks.PrintTable(os.Stdout, results); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -41,35 +41,33 @@ type Books []*Book
 
 type Chapters []*Chapter
 
-// Next, use lode.Many and lode.One to define the relations between your models.
+// Next, use lode.Many and lode.One to define the relations between your
+// models.  lode.NewRelation infers the spec's types from its key functions.
 func (author *Author) Books(ctx context.Context, db *gorm.DB) (Books, error) {
-	return lode.Many(ctx, lode.RelationSpec[uint, *Author, *Book]{
-		CacheKey:    "books",
-		Model:       author,
-		ModelKey:    func(author *Author) (uint, bool) { return author.ID, true },
-		RelationKey: func(book *Book) uint { return *book.AuthorID },
-		Fetch:       lodegorm.Fetch[*Book, uint](db, "author_id"),
-	})
+	return lode.Many(ctx, lode.NewRelation(
+		func(author *Author) (uint, bool) { return author.ID, true },
+		func(book *Book) uint { return *book.AuthorID },
+		lodegorm.Fetch[*Book, uint](db, "author_id"),
+		"books",
+	).For(author))
 }
 
 func (book *Book) Chapters(ctx context.Context, db *gorm.DB) (Chapters, error) {
-	return lode.Many(ctx, lode.RelationSpec[uint, *Book, *Chapter]{
-		CacheKey:    "chapters",
-		Model:       book,
-		ModelKey:    func(book *Book) (uint, bool) { return book.ID, true },
-		RelationKey: func(chapter *Chapter) uint { return chapter.BookID },
-		Fetch:       lodegorm.Fetch[*Chapter, uint](db, "book_id"),
-	})
+	return lode.Many(ctx, lode.NewRelation(
+		func(book *Book) (uint, bool) { return book.ID, true },
+		func(chapter *Chapter) uint { return chapter.BookID },
+		lodegorm.Fetch[*Chapter, uint](db, "book_id"),
+		"chapters",
+	).For(book))
 }
 
 func (book *Book) Author(ctx context.Context, db *gorm.DB) (*Author, error) {
-	return lode.One(ctx, lode.RelationSpec[uint, *Book, *Author]{
-		CacheKey:    "author",
-		Model:       book,
-		ModelKey:    func(book *Book) (uint, bool) { return lode.FromPtr(book.AuthorID) },
-		RelationKey: func(author *Author) uint { return author.ID },
-		Fetch:       lodegorm.Fetch[*Author, uint](db, "id"),
-	})
+	return lode.One(ctx, lode.NewRelation(
+		func(book *Book) (uint, bool) { return lode.FromPtr(book.AuthorID) },
+		func(author *Author) uint { return author.ID },
+		lodegorm.Fetch[*Author, uint](db, "id"),
+		"author",
+	).For(book))
 }
 
 // You can create other methods that use the lode methods!  This is nice because
@@ -96,34 +94,30 @@ func (author *Author) NumChapters(ctx context.Context, db *gorm.DB) (int, error)
 // number of chapters for each author directly without loading the books and
 // chapters.
 func (author *Author) NumChaptersUsingQuery(ctx context.Context, db *gorm.DB) (int, error) {
-	return lode.Resolve(ctx, lode.ResolveSpec[*Author, int]{
-		CacheKey: "num_chapters",
-		Model:    author,
-		Build: func(ctx context.Context, models []*Author) (lode.ResolverFunc[*Author, int], error) {
-			var authorIDs []uint
-			for _, model := range models {
-				authorIDs = append(authorIDs, model.ID)
-			}
-			var rows []struct {
-				ChapterCount int
-				AuthorID     uint
-			}
-			err := db.WithContext(ctx).
-				Model(&Chapter{}).
-				Joins("JOIN books ON books.id = chapters.book_id").
-				Where("books.author_id IN ?", authorIDs).
-				Group("books.author_id").
-				Select("COUNT(*) as chapter_count, books.author_id").Find(&rows).Error
-			if err != nil {
-				return nil, err
-			}
-			grouped := make(map[uint]int)
-			for _, row := range rows {
-				grouped[row.AuthorID] = row.ChapterCount
-			}
-			return func(model *Author) int { return grouped[model.ID] }, nil
-		},
-	})
+	return lode.Resolve(ctx, lode.NewResolve("num_chapters", func(ctx context.Context, models []*Author) (func(*Author) int, error) {
+		var authorIDs []uint
+		for _, model := range models {
+			authorIDs = append(authorIDs, model.ID)
+		}
+		var rows []struct {
+			ChapterCount int
+			AuthorID     uint
+		}
+		err := db.WithContext(ctx).
+			Model(&Chapter{}).
+			Joins("JOIN books ON books.id = chapters.book_id").
+			Where("books.author_id IN ?", authorIDs).
+			Group("books.author_id").
+			Select("COUNT(*) as chapter_count, books.author_id").Find(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		grouped := make(map[uint]int)
+		for _, row := range rows {
+			grouped[row.AuthorID] = row.ChapterCount
+		}
+		return func(model *Author) int { return grouped[model.ID] }, nil
+	}).For(author))
 }
 
 var (
@@ -2,10 +2,15 @@ package main
 
 import (
 	"context"
+	"errors"
+	"maps"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/willhf/lode"
+	"github.com/willhf/lode/example/benchmarks"
 	"github.com/willhf/lode/lodegorm"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -14,13 +19,24 @@ import (
 // these tests exist here rather than in lodegorm because they rely on sqlite
 // and we don't want to add sqlite as a dependency to lodegorm
 
-func seededSetup(t *testing.T) (*gorm.DB, *lode.Engine) {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+func seededSetup(t *testing.T, opts ...lodegorm.CallbackOption) (*gorm.DB, *lode.Engine) {
+	engine := lode.NewEngine()
+	return seededSetupWith(t, engine, opts...), engine
+}
+
+// seededSetupWith is seededSetup binding through the given engine.
+func seededSetupWith(t *testing.T, engine *lode.Engine, opts ...lodegorm.CallbackOption) *gorm.DB {
+	return seededSetupAt(t, ":memory:", engine, opts...)
+}
+
+// seededSetupAt is seededSetupWith on the sqlite database at dsn, for tests
+// that need a second connection to see what the first has committed.
+func seededSetupAt(t *testing.T, dsn string, engine *lode.Engine, opts ...lodegorm.CallbackOption) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
 	if err != nil {
 		t.Fatalf("gorm.Open error: %v", err)
 	}
-	engine := lode.NewEngine()
-	lodegorm.RegisterCallback(engine, db)
+	lodegorm.RegisterCallback(engine, db, opts...)
 	for _, str := range []string{schema, seed} {
 		for _, stmt := range strings.Split(str, ";") {
 			if err := db.Exec(stmt).Error; err != nil {
@@ -28,7 +44,16 @@ func seededSetup(t *testing.T) (*gorm.DB, *lode.Engine) {
 			}
 		}
 	}
-	return db, engine
+	return db
+}
+
+// countedSetup is seededSetup on an engine configured by opts, returning a
+// session of the database whose statements are counted.  Setup statements
+// are not counted.
+func countedSetup(t *testing.T, opts ...lode.ConfigOption) (*gorm.DB, *benchmarks.QueryCounter) {
+	var counter benchmarks.QueryCounter
+	db := seededSetupWith(t, lode.NewEngine(opts...))
+	return counter.CountQueries(db), &counter
 }
 
 const knownAuthorName = "Alice Pennington"
@@ -101,8 +126,15 @@ func TestFind_AuthorStruct(t *testing.T) {
 		t.Fatal(len(authors))
 	}
 
-	var knownAuthor = findInSlice(authors, func(a Author) bool { return a.Name == knownAuthorName })
-	if knownAuthor.Name != knownAuthorName {
+	// Take the address of the element rather than copying it: a copied
+	// model's Handle no longer matches the batch it was bound with.
+	var knownAuthor *Author
+	for i := range authors {
+		if authors[i].Name == knownAuthorName {
+			knownAuthor = &authors[i]
+		}
+	}
+	if knownAuthor == nil {
 		t.Fatal("knownAuthor not found")
 	}
 
@@ -114,3 +146,810 @@ func TestFind_AuthorStruct(t *testing.T) {
 		t.Fatal(len(books))
 	}
 }
+
+func TestStream_FindInBatches(t *testing.T) {
+	ctx := context.Background()
+	db, _ := seededSetup(t)
+
+	var authors Authors
+	if err := db.Find(&authors).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	spec := lode.RelationSpec[uint, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       authors[0],
+		ModelKey:    func(author *Author) (uint, bool) { return author.ID, true },
+		RelationKey: func(book *Book) uint { return *book.AuthorID },
+		FetchStream: lodegorm.FetchStream[*Book, uint](db, "author_id", 1),
+	}
+
+	perAuthor := map[uint]int{}
+	if err := lode.Stream(ctx, spec, func(authorID uint, book *Book) error {
+		perAuthor[authorID]++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var total int
+	for _, n := range perAuthor {
+		total += n
+	}
+	if total != 4 || len(perAuthor) != 3 {
+		t.Fatalf("streamed %v; want 4 books across 3 authors", perAuthor)
+	}
+}
+
+func TestFetchOnePerKey(t *testing.T) {
+	ctx := context.Background()
+	db, _ := seededSetup(t)
+
+	var authors Authors
+	if err := db.Order("id").Find(&authors).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	books, err := lodegorm.FetchOnePerKey[*Book, uint](db, "author_id")(ctx, []uint{authors[0].ID, authors[1].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != 2 {
+		t.Fatalf("got %d books; want one per author", len(books))
+	}
+
+	// The SinglePerKey hint makes lodegorm.Fetch use the same query.
+	spec := lode.RelationSpec[uint, *Author, *Book]{
+		CacheKey:     "first_book",
+		Model:        authors[0],
+		ModelKey:     func(author *Author) (uint, bool) { return author.ID, true },
+		RelationKey:  func(book *Book) uint { return *book.AuthorID },
+		Fetch:        lodegorm.Fetch[*Book, uint](db, "author_id"),
+		SinglePerKey: true,
+	}
+	first, err := lode.One(ctx, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == nil || first.Title != "The Clockwork Harbor" {
+		t.Fatalf("first book = %+v; want The Clockwork Harbor", first)
+	}
+}
+
+// softBook is a soft-deletable book for TestFetchOnePerKey_SoftDeleteAndScopes.
+type softBook struct {
+	ID        uint
+	AuthorID  uint
+	Title     string
+	DeletedAt gorm.DeletedAt
+	lode.Handle
+}
+
+func TestFetchOnePerKey_SoftDeleteAndScopes(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&softBook{}); err != nil {
+		t.Fatal(err)
+	}
+	books := []*softBook{{AuthorID: 1, Title: "deleted"}, {AuthorID: 1, Title: "draft"}, {AuthorID: 1, Title: "kept"}}
+	if err := db.Create(books).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(books[0]).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := lodegorm.FetchOnePerKey[*softBook, uint](db, "author_id")(ctx, []uint{1})
+	if err != nil || len(got) != 1 || got[0].Title != "draft" {
+		t.Fatalf("FetchOnePerKey = %+v, %v; want the first book not deleted", got, err)
+	}
+	notDraft := lodegorm.WithScopes(func(tx *gorm.DB) *gorm.DB { return tx.Where("title <> ?", "draft") })
+	got, err = lodegorm.FetchOnePerKey[*softBook, uint](db, "author_id", notDraft, lodegorm.WithSelect("id", "author_id", "title"))(ctx, []uint{1})
+	if err != nil || len(got) != 1 || got[0].Title != "kept" {
+		t.Fatalf("FetchOnePerKey with scopes = %+v, %v; want the first book in scope", got, err)
+	}
+}
+
+func TestFetchOnePerKey_JoiningScope(t *testing.T) {
+	ctx := context.Background()
+	db, _ := seededSetup(t)
+
+	var authors Authors
+	if err := db.Order("id").Find(&authors).Error; err != nil {
+		t.Fatal(err)
+	}
+	// authors has an id column too, so unqualified columns are ambiguous.
+	named := lodegorm.WithScopes(func(tx *gorm.DB) *gorm.DB {
+		return tx.Joins("JOIN authors ON authors.id = books.author_id").Where("authors.name <> ?", "")
+	})
+	books, err := lodegorm.FetchOnePerKey[*Book, uint](db, "author_id", named, lodegorm.WithSelect("books.*"))(ctx, []uint{authors[0].ID, authors[1].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != 2 || books[0].Title != "The Clockwork Harbor" {
+		t.Fatalf("got %+v; want each author's first book", books)
+	}
+}
+
+// postgresNamed passes sqlite off as postgres, to dry-run the SQL the
+// Fetch helpers build for postgres.
+type postgresNamed struct{ gorm.Dialector }
+
+func (postgresNamed) Name() string { return "postgres" }
+
+func TestFetchOnePerKey_PostgresSQL(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(postgresNamed{sqlite.Open(":memory:")}, &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sql string
+	db.Callback().Query().After("gorm:query").Register("test:sql", func(tx *gorm.DB) {
+		sql = tx.Statement.SQL.String()
+	})
+
+	for _, tc := range []struct {
+		name       string
+		joinColumn string
+		opts       []lodegorm.FetchOption
+		want       string
+	}{{
+		name:       "every column",
+		joinColumn: "author_id",
+		want:       "SELECT DISTINCT ON (`author_id`) `books`.* FROM `books` WHERE `author_id` IN (?,?) ORDER BY `author_id`,`books`.`id`",
+	}, {
+		name:       "selected columns of a join",
+		joinColumn: "books.author_id",
+		opts: []lodegorm.FetchOption{
+			lodegorm.WithScopes(func(tx *gorm.DB) *gorm.DB {
+				return tx.Joins("JOIN authors ON authors.id = books.author_id").Where("authors.name <> ?", "")
+			}),
+			lodegorm.WithSelect("id", "title"),
+		},
+		want: "SELECT DISTINCT ON (`books`.`author_id`) `books`.`id`, `books`.`title` FROM `books` JOIN authors ON authors.id = books.author_id WHERE `books`.`author_id` IN (?,?) AND authors.name <> ? ORDER BY `books`.`author_id`,`books`.`id`",
+	}} {
+		if _, err := lodegorm.FetchOnePerKey[*Book, uint](db, tc.joinColumn, tc.opts...)(ctx, []uint{1, 2}); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if sql != tc.want {
+			t.Errorf("%s: SQL = %s\nwant %s", tc.name, sql, tc.want)
+		}
+	}
+}
+
+func TestFetch_WithDistinct(t *testing.T) {
+	ctx := context.Background()
+	db, _ := seededSetup(t)
+
+	var author Author
+	if err := db.Where("name = ?", knownAuthorName).First(&author).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Books that have chapters; each book appears once per chapter.
+	withChapters := lodegorm.WithScopes(func(tx *gorm.DB) *gorm.DB {
+		return tx.Joins("JOIN chapters ON chapters.book_id = books.id")
+	})
+
+	dups, err := lodegorm.Fetch[*Book, uint](db, "books.author_id", withChapters, lodegorm.WithSelect("books.*"))(ctx, []uint{author.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dups) != 4 {
+		t.Fatalf("join without distinct returned %d rows; want 4", len(dups))
+	}
+
+	books, err := lodegorm.Fetch[*Book, uint](db, "books.author_id", withChapters, lodegorm.WithSelect("books.*"), lodegorm.WithDistinct())(ctx, []uint{author.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != 2 {
+		t.Fatalf("distinct join returned %d rows; want 2", len(books))
+	}
+
+	ids, err := lodegorm.Fetch[*Book, uint](db, "books.author_id", withChapters, lodegorm.WithDistinct("books.id", "books.author_id", "books.title"))(ctx, []uint{author.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0].Title == "" {
+		t.Fatalf("distinct on columns returned %+v; want 2 titled books", ids)
+	}
+}
+
+type shardCtxKey struct{}
+
+func TestFetch_WithTableFn(t *testing.T) {
+	ctx := context.Background()
+	db, _ := seededSetup(t)
+
+	for _, stmt := range []string{
+		"CREATE TABLE books_2024 (id INTEGER PRIMARY KEY, author_id INTEGER, title TEXT NOT NULL)",
+		"CREATE TABLE books_2025 (id INTEGER PRIMARY KEY, author_id INTEGER, title TEXT NOT NULL)",
+		"INSERT INTO books_2024 (author_id, title) VALUES (1, 'Old One'), (1, 'Old Two')",
+		"INSERT INTO books_2025 (author_id, title) VALUES (1, 'New One')",
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fetch := lodegorm.Fetch[*Book, uint](db, "author_id", lodegorm.WithTableFn(func(ctx context.Context) string {
+		shard, _ := ctx.Value(shardCtxKey{}).(string)
+		return shard
+	}))
+
+	for shard, want := range map[string]int{"books_2024": 2, "books_2025": 1, "": 2} {
+		books, err := fetch(context.WithValue(ctx, shardCtxKey{}, shard), []uint{1})
+		if err != nil {
+			t.Fatalf("shard %q: %v", shard, err)
+		}
+		if len(books) != want {
+			t.Fatalf("shard %q: got %d books; want %d", shard, len(books), want)
+		}
+	}
+
+	books, err := lodegorm.Fetch[*Book, uint](db, "author_id", lodegorm.WithTable("books_2025"))(ctx, []uint{1})
+	if err != nil || len(books) != 1 || books[0].Title != "New One" {
+		t.Fatalf("WithTable: %+v, %v", books, err)
+	}
+
+	first, err := lodegorm.FetchOnePerKey[*Book, uint](db, "author_id", lodegorm.WithTable("books_2024"))(ctx, []uint{1})
+	if err != nil || len(first) != 1 || first[0].Title != "Old One" {
+		t.Fatalf("FetchOnePerKey WithTable: %+v, %v", first, err)
+	}
+}
+
+// Models mirroring Author/Book/Chapter with gorm association fields, so
+// lodegorm.Relation can derive the specs from schema metadata.
+type relAuthor struct {
+	ID    uint
+	Name  string
+	Books []*relBook `gorm:"foreignKey:AuthorID"`
+	lode.Handle
+}
+
+type relBook struct {
+	ID       uint
+	AuthorID *uint
+	Title    string
+	Author   *relAuthor   `gorm:"foreignKey:AuthorID"`
+	Chapters []relChapter `gorm:"foreignKey:BookID"`
+	lode.Handle
+}
+
+type relChapter struct {
+	ID     uint
+	BookID uint
+	Title  string
+	lode.Handle
+}
+
+// relReader's many-to-many books are beyond lodegorm.Relation.
+type relReader struct {
+	ID    uint
+	Books []*relBook `gorm:"many2many:reader_books"`
+	lode.Handle
+}
+
+func (*relAuthor) TableName() string  { return "authors" }
+func (*relBook) TableName() string    { return "books" }
+func (*relChapter) TableName() string { return "chapters" }
+
+func mustRelation[JoinKey comparable, Parent lode.HasHandle, Child any](t *testing.T, db *gorm.DB, association string) lode.RelationSpec[JoinKey, Parent, Child] {
+	t.Helper()
+	spec, err := lodegorm.Relation[JoinKey, Parent, Child](db, association)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+func TestRelation_MatchesHandWrittenSpecs(t *testing.T) {
+	ctx := context.Background()
+	db, _ := seededSetup(t)
+
+	authorBooks := mustRelation[uint, *relAuthor, *relBook](t, db, "Books")
+	bookAuthor := mustRelation[uint, *relBook, *relAuthor](t, db, "Author")
+	bookChapters := mustRelation[uint, *relBook, relChapter](t, db, "Chapters")
+	if authorBooks.CacheKey != "Books" {
+		t.Fatalf("CacheKey = %q; want the association name", authorBooks.CacheKey)
+	}
+
+	var authors Authors
+	if err := db.Order("id").Find(&authors).Error; err != nil {
+		t.Fatal(err)
+	}
+	var relAuthors []*relAuthor
+	if err := db.Order("id").Find(&relAuthors).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for i, author := range authors {
+		want, err := author.Books(ctx, db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := lode.Many(ctx, authorBooks.For(relAuthors[i]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("author %d: got %d books; want %d", author.ID, len(got), len(want))
+		}
+		for j := range want {
+			if got[j].ID != want[j].ID {
+				t.Fatalf("author %d book %d: got ID %d; want %d", author.ID, j, got[j].ID, want[j].ID)
+			}
+
+			wantChapters, err := want[j].Chapters(ctx, db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotChapters, err := lode.Many(ctx, bookChapters.For(got[j]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(gotChapters) != len(wantChapters) {
+				t.Fatalf("book %d: got %d chapters; want %d", got[j].ID, len(gotChapters), len(wantChapters))
+			}
+
+			wantAuthor, err := want[j].Author(ctx, db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotAuthor, err := lode.One(ctx, bookAuthor.For(got[j]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotAuthor == nil || gotAuthor.ID != wantAuthor.ID {
+				t.Fatalf("book %d: got author %+v; want %d", got[j].ID, gotAuthor, wantAuthor.ID)
+			}
+		}
+	}
+
+	// The orphaned book has a nil foreign key and therefore no author.
+	var orphan relBook
+	if err := db.Where("author_id IS NULL").First(&orphan).Error; err != nil {
+		t.Fatal(err)
+	}
+	if a, err := lode.One(ctx, bookAuthor.For(&orphan)); err != nil || a != nil {
+		t.Fatalf("orphan author = %+v, %v; want nil", a, err)
+	}
+}
+
+func TestRelation_RegistersItsKey(t *testing.T) {
+	ctx := context.Background()
+	db := seededSetupWith(t, lode.NewEngine(lode.WithStrictKeys(), lode.WithDefinedKeys()))
+	authorBooks := mustRelation[uint, *relAuthor, *relBook](t, db, "Books")
+
+	var author relAuthor
+	if err := db.Where("name = ?", knownAuthorName).First(&author).Error; err != nil {
+		t.Fatal(err)
+	}
+	if books, err := lode.Many(ctx, authorBooks.For(&author)); err != nil || len(books) != 2 {
+		t.Fatalf("Many under WithStrictKeys = %d books, %v", len(books), err)
+	}
+}
+
+func TestRelation_Errors(t *testing.T) {
+	db, _ := seededSetup(t)
+
+	if _, err := lodegorm.Relation[uint, *relAuthor, *relBook](db, "Nope"); !errors.Is(err, lode.ErrNotFound) {
+		t.Fatalf("unknown association err = %v; want ErrNotFound", err)
+	}
+	if _, err := lodegorm.Relation[uint, *relAuthor, *relChapter](db, "Books"); !errors.Is(err, lode.ErrTypeMismatch) {
+		t.Fatalf("mismatched child type err = %v; want ErrTypeMismatch", err)
+	}
+	if _, err := lodegorm.NonZero[*Book](db, "writer_id"); !errors.Is(err, lode.ErrNotFound) {
+		t.Fatalf("unknown column err = %v; want ErrNotFound", err)
+	}
+	if _, err := lodegorm.Relation[uint, *relReader, *relBook](db, "Books"); !errors.Is(err, lode.ErrMisconfigured) {
+		t.Fatalf("many-to-many association err = %v; want ErrMisconfigured", err)
+	}
+}
+
+func TestRegisterCallback_SkipsNonModelDestinations(t *testing.T) {
+	ctx := context.Background()
+	db, _ := seededSetup(t)
+
+	var n int64
+	if err := db.Model(&Author{}).Count(&n).Error; err != nil || n != 5 {
+		t.Fatalf("Count = %d, %v", n, err)
+	}
+	var names []string
+	if err := db.Model(&Author{}).Pluck("name", &names).Error; err != nil || len(names) != 5 {
+		t.Fatalf("Pluck = %v, %v", names, err)
+	}
+	var rows []map[string]any
+	if err := db.Table("authors").Find(&rows).Error; err != nil || len(rows) != 5 {
+		t.Fatalf("Find(maps) = %v, %v", rows, err)
+	}
+
+	var dry []*Author
+	db.Session(&gorm.Session{DryRun: true}).Find(&dry)
+	if len(dry) != 0 {
+		t.Fatalf("dry run returned %d authors", len(dry))
+	}
+
+	// Models are still bound after all of the above.
+	var author Author
+	if err := db.Where("name = ?", knownAuthorName).First(&author).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := author.Books(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func BenchmarkCountWithCallback(b *testing.B) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	lodegorm.RegisterCallback(lode.NewEngine(), db)
+	if err := db.Exec(schema).Error; err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var n int64
+		if err := db.Model(&Author{}).Count(&n).Error; err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestFetchHelpers_EmptyIDsSkipQuery(t *testing.T) {
+	ctx := context.Background()
+	db, _ := seededSetup(t)
+
+	var counter benchmarks.QueryCounter
+	db = counter.CountQueries(db)
+
+	books, err := lodegorm.Fetch[*Book, uint](db, "author_id")(ctx, nil)
+	if err != nil || books != nil {
+		t.Fatalf("Fetch(nil) = %v, %v", books, err)
+	}
+	books, err = lodegorm.FetchOnePerKey[*Book, uint](db, "author_id")(ctx, []uint{})
+	if err != nil || books != nil {
+		t.Fatalf("FetchOnePerKey(empty) = %v, %v", books, err)
+	}
+	err = lodegorm.FetchStream[*Book, uint](db, "author_id", 10)(ctx, nil, func(*Book) error {
+		t.Fatal("FetchStream yielded for no ids")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("FetchStream(nil) = %v", err)
+	}
+	if n := counter.Count(); n != 0 {
+		t.Fatalf("ran %d queries for empty ids; want 0", n)
+	}
+
+	if _, err := lodegorm.Fetch[*Book, uint](db, "author_id")(ctx, []uint{1}); err != nil {
+		t.Fatal(err)
+	}
+	if n := counter.Count(); n != 1 {
+		t.Fatalf("ran %d queries for one id; want 1", n)
+	}
+}
+
+func TestRegisterCallback_HintsAtUnboundModels(t *testing.T) {
+	ctx := context.Background()
+	db, _ := seededSetup(t)
+
+	var author Author
+	if err := db.Where("name = ?", knownAuthorName).First(&author).Error; err != nil {
+		t.Fatal(err)
+	}
+	unbound := &Author{ID: author.ID} // as if scanned around the callback
+	_, err := unbound.Books(ctx, db)
+	if err == nil || !strings.Contains(err.Error(), "lodegorm.RegisterCallback") {
+		t.Fatalf("err = %v; want a hint at RegisterCallback", err)
+	}
+}
+
+func TestRegisterCallback_AutoInvalidate(t *testing.T) {
+	ctx := context.Background()
+	db, _ := seededSetup(t, lodegorm.WithAutoInvalidate(map[any][]string{Book{}: {"books"}}))
+
+	var first, second Authors
+	if err := db.Where("id <= 2").Order("id").Find(&first).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Where("id > 2").Order("id").Find(&second).Error; err != nil {
+		t.Fatal(err)
+	}
+	sofia := second[0]
+
+	var counter benchmarks.QueryCounter
+	counted := counter.CountQueries(db)
+	books := func(a *Author) Books {
+		t.Helper()
+		bs, err := a.Books(ctx, counted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return bs
+	}
+	books(first[0])
+	books(sofia)
+	if n := counter.Count(); n != 2 {
+		t.Fatalf("warming ran %d queries; want 2", n)
+	}
+
+	if err := db.Create(&Book{AuthorID: &sofia.ID, Title: "Second Voyage"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	books(first[0])
+	if got := books(sofia); len(got) != 2 {
+		t.Fatalf("Sofia has %d books after the create; want 2", len(got))
+	}
+	if n := counter.Count(); n != 4 {
+		t.Fatalf("ran %d queries; want both batches to refetch once", n)
+	}
+}
+
+func TestRegisterCallback_AutoInvalidateAfterCommit(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "lode.db")
+	var (
+		other *gorm.DB // a second connection, which sees only committed rows
+		seen  []int64  // the Draft rows it saw as each key was invalidated
+	)
+	engine := lode.NewEngine(lode.WithHooks(lode.Hooks{
+		OnInvalidate: func(lode.InvalidateEvent) {
+			var n int64
+			if err := other.Model(&Book{}).Where("title = ?", "Draft").Count(&n).Error; err != nil {
+				t.Error(err)
+			}
+			seen = append(seen, n)
+		},
+	}))
+	db := seededSetupAt(t, dsn, engine, lodegorm.WithAutoInvalidate(map[any][]string{Book{}: {"books"}}))
+	other, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var authors Authors
+	if err := db.Find(&authors).Error; err != nil { // a live state to invalidate
+		t.Fatal(err)
+	}
+	if err := db.Create(&Book{AuthorID: &authors[0].ID, Title: "Draft"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) == 0 || slices.Contains(seen, 0) {
+		t.Fatalf("Draft rows seen at each invalidation = %v; want the create committed first", seen)
+	}
+}
+
+func TestFetchKeys_ExistsAll(t *testing.T) {
+	ctx := context.Background()
+	db, _ := seededSetup(t)
+
+	var authors Authors
+	if err := db.Order("id").Find(&authors).Error; err != nil {
+		t.Fatal(err)
+	}
+	var counter benchmarks.QueryCounter
+	spec := lode.ExistsSpec[uint, *Author]{
+		CacheKey:  "has_books",
+		ModelKey:  func(a *Author) (uint, bool) { return a.ID, true },
+		FetchKeys: lodegorm.FetchKeys[Book, uint](counter.CountQueries(db), "books.author_id"),
+	}
+	got, err := lode.ExistsAll(ctx, spec.For(authors[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[uint]bool{1: true, 2: true, 3: true, 4: false, 5: false}
+	if !maps.Equal(got, want) {
+		t.Fatalf("ExistsAll = %v; want %v", got, want)
+	}
+	if ok, err := lode.Exists(ctx, spec.For(authors[3])); ok || err != nil {
+		t.Fatalf("Exists(Jamal) = %v, %v; want false", ok, err)
+	}
+	if n := counter.Count(); n != 1 {
+		t.Fatalf("ran %d queries; want 1", n)
+	}
+}
+
+func TestFetchPluck_Field(t *testing.T) {
+	ctx := context.Background()
+	db, _ := seededSetup(t)
+
+	var authors Authors
+	if err := db.Order("id").Find(&authors).Error; err != nil {
+		t.Fatal(err)
+	}
+	var counter benchmarks.QueryCounter
+	spec := lode.FieldSpec[uint, *Author, string]{
+		CacheKey: "latest_title",
+		ModelKey: func(a *Author) (uint, bool) { return a.ID, true },
+		Fetch: lodegorm.FetchPluck[uint, string](counter.CountQueries(db), "books", "books.author_id", "title",
+			lodegorm.WithScopes(func(tx *gorm.DB) *gorm.DB { return tx.Order("books.id DESC") })),
+	}
+	want := []string{"Shadows in Amber", "Songs of the Ironwood", "The Long Voyage North", "", ""}
+	for i, a := range authors {
+		got, err := lode.Field(ctx, spec.For(a))
+		if err != nil || got != want[i] {
+			t.Fatalf("Field(%s) = %q, %v; want %q", a.Name, got, err, want[i])
+		}
+	}
+	if n := counter.Count(); n != 1 {
+		t.Fatalf("ran %d queries; want 1", n)
+	}
+}
+
+func TestFetch_RecordsDescriptor(t *testing.T) {
+	ctx := context.Background()
+	db, _ := countedSetup(t, lode.WithFetchDescriptors())
+
+	var author Author
+	if err := db.Where("name = ?", knownAuthorName).First(&author).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := author.Books(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := lode.Entry(&author, "books")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := entry.FetchDescriptors()
+	if len(d) != 1 || d[0] != "SELECT * FROM `books` WHERE `author_id` = ?" {
+		t.Fatalf("FetchDescriptors = %q", d)
+	}
+}
+
+func TestFetch_DescribesOnlyWhenCollecting(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		opts  []lode.ConfigOption
+		built int // statements gorm builds for the fetch, dry runs included
+	}{{nil, 1}, {[]lode.ConfigOption{lode.WithFetchDescriptors()}, 2}} {
+		db, _ := countedSetup(t, tc.opts...)
+		var author Author
+		if err := db.Where("name = ?", knownAuthorName).First(&author).Error; err != nil {
+			t.Fatal(err)
+		}
+		built := 0
+		if err := db.Callback().Query().Before("gorm:query").Register("test:count", func(*gorm.DB) { built++ }); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := author.Books(ctx, db); err != nil {
+			t.Fatal(err)
+		}
+		if built != tc.built {
+			t.Fatalf("with %d options: built %d statements; want %d", len(tc.opts), built, tc.built)
+		}
+	}
+}
+
+func TestNonZero_CatchesDroppedJoinColumn(t *testing.T) {
+	ctx := context.Background()
+	db, _ := seededSetup(t)
+
+	var author Author
+	if err := db.Where("name = ?", knownAuthorName).First(&author).Error; err != nil {
+		t.Fatal(err)
+	}
+	validate, err := lodegorm.NonZero[*Book](db, "books.author_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := lode.RelationSpec[uint, *Author, *Book]{
+		CacheKey: "books",
+		ModelKey: func(a *Author) (uint, bool) { return a.ID, true },
+		RelationKey: func(b *Book) uint {
+			id, _ := lode.FromPtr(b.AuthorID)
+			return id
+		},
+		ValidateRelation: validate,
+	}
+
+	// A select that leaves out author_id scans it as nil.
+	spec.Fetch = lodegorm.Fetch[*Book, uint](db, "author_id", lodegorm.WithSelect("id", "title"))
+	if _, err := lode.Many(ctx, spec.For(&author)); err == nil || !strings.Contains(err.Error(), "Book.AuthorID scanned as zero") {
+		t.Fatalf("Many err = %v; want the validator's error", err)
+	}
+
+	author.Reset()
+	spec.Fetch = lodegorm.Fetch[*Book, uint](db, "author_id")
+	if books, err := lode.Many(ctx, spec.For(&author)); err != nil || len(books) == 0 {
+		t.Fatalf("Many = %d books, %v", len(books), err)
+	}
+
+	if _, err := lodegorm.NonZero[*Book](db, "writer_id"); err == nil {
+		t.Fatal("NonZero accepted an unknown column")
+	}
+}
+
+func TestGraphTrace_AuthorsBooksChapters(t *testing.T) {
+	ctx := context.Background()
+	engine := lode.NewEngine(lode.WithGraphTrace())
+	db := seededSetupWith(t, engine)
+
+	var authors Authors
+	if err := db.Find(&authors).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, author := range authors {
+		if _, err := author.NumChapters(ctx, db); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dot := engine.GraphDOT()
+	for _, want := range []string{
+		`"*main.Author" -> "*main.Book" [label="books\n1 fetches`,
+		`"*main.Book" -> "*main.Chapter" [label="chapters\n1 fetches`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("GraphDOT is missing %s:\n%s", want, dot)
+		}
+	}
+	if strings.Contains(dot, "num_chapters") {
+		t.Errorf("GraphDOT has an edge for a Resolve:\n%s", dot)
+	}
+	mermaid := engine.GraphMermaid()
+	if !strings.Contains(mermaid, `n0["*main.Author"]`) || !strings.Contains(mermaid, `n1 -->|"chapters: 1 fetches`) {
+		t.Errorf("GraphMermaid:\n%s", mermaid)
+	}
+}
+
+func TestRegisterDefaultCallback(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lodegorm.RegisterDefaultCallback(db)
+	for _, str := range []string{schema, seed} {
+		for _, stmt := range strings.Split(str, ";") {
+			if err := db.Exec(stmt).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var authors Authors
+	if err := db.Find(&authors).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n, err := authors[0].NumChapters(ctx, db); err != nil || n != 4 {
+		t.Fatalf("NumChapters = %d, %v", n, err)
+	}
+	if n, err := lode.Default().InvalidateKey("books"); err != nil || n != 1 {
+		t.Fatalf("default engine cleared %d books entries, %v; want the authors' one", n, err)
+	}
+}
+
+func TestLoad2_BooksAndChapterCount(t *testing.T) {
+	ctx := context.Background()
+	db, _ := seededSetup(t)
+	// The loaders query at once; a second connection to ":memory:" would
+	// open an empty database.
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	var author Author
+	if err := db.Where("name = ?", knownAuthorName).First(&author).Error; err != nil {
+		t.Fatal(err)
+	}
+	books, n, err := lode.Load2(ctx, &author,
+		func(ctx context.Context, a *Author) (Books, error) { return a.Books(ctx, db) },
+		func(ctx context.Context, a *Author) (int, error) { return a.NumChaptersUsingQuery(ctx, db) },
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != 2 || n != 4 {
+		t.Fatalf("Load2 = %d books, %d chapters; want 2 and 4", len(books), n)
+	}
+}
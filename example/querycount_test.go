@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/willhf/lode"
+	"github.com/willhf/lode/lodegorm"
+	"gorm.io/gorm"
+)
+
+// These tests hold the example to its promise that nested accessor calls in
+// loops make one query per relation per batch, not one per model.
+
+// walkChapters loads every author's books and every book's chapters through
+// the accessors, as main does, and returns how many of each it saw.
+func walkChapters(ctx context.Context, db *gorm.DB, authors []*Author) (books, chapters int, err error) {
+	for _, author := range authors {
+		bs, err := author.Books(ctx, db)
+		if err != nil {
+			return 0, 0, err
+		}
+		books += len(bs)
+		for _, book := range bs {
+			cs, err := book.Chapters(ctx, db)
+			if err != nil {
+				return 0, 0, err
+			}
+			chapters += len(cs)
+		}
+	}
+	return books, chapters, nil
+}
+
+func TestQueryCount_AuthorsBooksChapters(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name string
+		opts []lode.ConfigOption
+		load func(db *gorm.DB) ([]*Author, error)
+		// The seed has 4 books with an author and 6 chapters, over the
+		// first 3 of its 5 authors.
+		wantBooks, wantChapters, wantQueries int
+	}{
+		{
+			name: "named slice",
+			load: func(db *gorm.DB) ([]*Author, error) {
+				var authors Authors
+				err := db.Find(&authors).Error
+				return authors, err
+			},
+			wantBooks: 4, wantChapters: 6, wantQueries: 1 + 1 + 1,
+		},
+		{
+			name: "pointer slice",
+			load: func(db *gorm.DB) ([]*Author, error) {
+				var authors []*Author
+				err := db.Find(&authors).Error
+				return authors, err
+			},
+			wantBooks: 4, wantChapters: 6, wantQueries: 1 + 1 + 1,
+		},
+		{
+			name: "value slice",
+			load: func(db *gorm.DB) ([]*Author, error) {
+				var authors []Author
+				err := db.Find(&authors).Error
+				var ptrs []*Author
+				for i := range authors {
+					ptrs = append(ptrs, &authors[i])
+				}
+				return ptrs, err
+			},
+			wantBooks: 4, wantChapters: 6, wantQueries: 1 + 1 + 1,
+		},
+		{
+			// Each First binds a batch of its own, so every level takes a
+			// query per author.
+			name: "First per author",
+			load: func(db *gorm.DB) ([]*Author, error) {
+				var alice, marcus Author
+				if err := db.Where("name = ?", knownAuthorName).First(&alice).Error; err != nil {
+					return nil, err
+				}
+				err := db.Where("name = ?", "Marcus Vellum").First(&marcus).Error
+				return []*Author{&alice, &marcus}, err
+			},
+			wantBooks: 3, wantChapters: 6, wantQueries: 2 + 2 + 2,
+		},
+		{
+			// 5 authors make 3 batches, each fetching its books; the 4
+			// books come back as 2+1 from the first batch's fetch and 1
+			// from the second's, and the third's fetch finds none, so 3
+			// batches fetch chapters.
+			name: "WithBatchSize(2)",
+			opts: []lode.ConfigOption{lode.WithBatchSize(2)},
+			load: func(db *gorm.DB) ([]*Author, error) {
+				var authors []*Author
+				err := db.Find(&authors).Error
+				return authors, err
+			},
+			wantBooks: 4, wantChapters: 6, wantQueries: 1 + 3 + 3,
+		},
+		{
+			// One batch, but its 5 author keys are fetched 2 at a time,
+			// and its 4 book keys likewise.
+			name: "WithFetchChunkSize(2)",
+			opts: []lode.ConfigOption{lode.WithFetchChunkSize(2)},
+			load: func(db *gorm.DB) ([]*Author, error) {
+				var authors []*Author
+				err := db.Find(&authors).Error
+				return authors, err
+			},
+			wantBooks: 4, wantChapters: 6, wantQueries: 1 + 3 + 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db, counter := countedSetup(t, tc.opts...)
+			authors, err := tc.load(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			books, chapters, err := walkChapters(ctx, db, authors)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if books != tc.wantBooks || chapters != tc.wantChapters {
+				t.Fatalf("saw %d books and %d chapters; want %d and %d", books, chapters, tc.wantBooks, tc.wantChapters)
+			}
+			if n := counter.Count(); n != tc.wantQueries {
+				t.Fatalf("ran %d queries; want %d:\n%s", n, tc.wantQueries, strings.Join(counter.Queries(), "\n"))
+			}
+
+			// Walking again is served from the cache.
+			counter.Reset()
+			if _, _, err := walkChapters(ctx, db, authors); err != nil {
+				t.Fatal(err)
+			}
+			if n := counter.Count(); n != 0 {
+				t.Fatalf("second walk ran %d queries; want 0:\n%s", n, strings.Join(counter.Queries(), "\n"))
+			}
+		})
+	}
+}
+
+func TestQueryCount_LodegormChunkSize(t *testing.T) {
+	ctx := context.Background()
+	db, counter := countedSetup(t)
+	var authors []*Author
+	if err := db.Find(&authors).Error; err != nil {
+		t.Fatal(err)
+	}
+	counter.Reset()
+
+	spec := lode.NewRelation(
+		func(author *Author) (uint, bool) { return author.ID, true },
+		func(book *Book) uint { return *book.AuthorID },
+		lodegorm.Fetch[*Book, uint](db, "author_id", lodegorm.WithChunkSize(2)),
+		"books_chunked",
+	)
+	books := 0
+	for _, author := range authors {
+		bs, err := lode.Many(ctx, spec.For(author))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, b := range bs {
+			if *b.AuthorID != author.ID {
+				t.Fatalf("author %d got book %q of author %d", author.ID, b.Title, *b.AuthorID)
+			}
+		}
+		books += len(bs)
+	}
+	if books != 4 {
+		t.Fatalf("saw %d books; want 4", books)
+	}
+	if n := counter.Count(); n != 3 {
+		t.Fatalf("ran %d queries; want one per chunk of 2 of the 5 keys:\n%s", n, strings.Join(counter.Queries(), "\n"))
+	}
+}
@@ -0,0 +1,95 @@
+package lode
+
+import "context"
+
+// ExistsSpec describes a yes-or-no relation, such as "has any grant" or
+// "has any unread message", answered for a whole batch by one fetch of the
+// keys that have it.  That is cheaper than loading the relations with Many
+// or counting them: the query can be a SELECT DISTINCT of the join column
+// (see lodegorm.FetchKeys).
+type ExistsSpec[JoinKey comparable, Model hasState] struct {
+	CacheKey string
+	Model    Model
+	ModelKey func(Model) (key JoinKey, ok bool)
+	// FetchKeys returns those of keys that have the relation, in any order;
+	// duplicates are harmless.
+	FetchKeys func(ctx context.Context, keys []JoinKey) ([]JoinKey, error)
+
+	// NilModel says what to do when Model is nil; see NilModelPolicy.
+	NilModel NilModelPolicy
+}
+
+// For returns a copy of the spec with Model set to m.
+func (spec ExistsSpec[JoinKey, Model]) For(m Model) ExistsSpec[JoinKey, Model] {
+	spec.Model = m
+	return spec
+}
+
+// existsSet is the result cached for an ExistsSpec: the batch's keys and
+// those of them that have the relation.
+type existsSet[JoinKey comparable] struct {
+	keys    []JoinKey
+	present map[JoinKey]struct{}
+}
+
+// Exists reports whether spec.Model has the relation, fetching the answer
+// for all of its siblings at once.  Models without a key (ModelKey reports
+// !ok) have none.
+func Exists[JoinKey comparable, Model hasState](ctx context.Context, spec ExistsSpec[JoinKey, Model]) (bool, error) {
+	if isNil(spec.Model) {
+		return false, spec.NilModel.nilModel(spec.CacheKey, nil)
+	}
+	key, ok := spec.ModelKey(spec.Model)
+	if !ok {
+		return false, nil
+	}
+	set, err := spec.resolve(ctx)
+	if err != nil {
+		return false, err
+	}
+	_, present := set.present[key]
+	return present, nil
+}
+
+// ExistsAll is Exists for every model bound with spec.Model: it returns,
+// for the key of each of them, whether that key has the relation.  Models
+// without a key are left out.  The map is the caller's to keep or modify.
+func ExistsAll[JoinKey comparable, Model hasState](ctx context.Context, spec ExistsSpec[JoinKey, Model]) (map[JoinKey]bool, error) {
+	if isNil(spec.Model) {
+		return nil, spec.NilModel.nilModel(spec.CacheKey, nil)
+	}
+	set, err := spec.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[JoinKey]bool, len(set.keys))
+	for _, k := range set.keys {
+		_, out[k] = set.present[k]
+	}
+	return out, nil
+}
+
+// resolve returns the cached existsSet for spec.Model's batch, building it
+// with one FetchKeys call.
+func (spec ExistsSpec[JoinKey, Model]) resolve(ctx context.Context) (*existsSet[JoinKey], error) {
+	return Resolve(ctx, ResolveSpec[Model, *existsSet[JoinKey]]{
+		CacheKey: spec.CacheKey,
+		Model:    spec.Model,
+		Build: func(ctx context.Context, models []Model) (ResolverFunc[Model, *existsSet[JoinKey]], error) {
+			set := &existsSet[JoinKey]{
+				keys:    RelationSpec[JoinKey, Model, JoinKey]{ModelKey: spec.ModelKey}.modelKeys(models),
+				present: make(map[JoinKey]struct{}),
+			}
+			if len(set.keys) > 0 { // see Many on empty key sets
+				found, err := spec.FetchKeys(ctx, set.keys)
+				if err != nil {
+					return nil, err
+				}
+				for _, k := range found {
+					set.present[k] = struct{}{}
+				}
+			}
+			return func(Model) *existsSet[JoinKey] { return set }, nil
+		},
+	})
+}
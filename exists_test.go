@@ -0,0 +1,64 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestExistsAll(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+	authors := []*Author{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 0}}
+	eng.InitHandles(authors)
+
+	var calls [][]int
+	spec := ExistsSpec[int, *Author]{
+		CacheKey: "has_books",
+		ModelKey: func(a *Author) (int, bool) { return a.ID, a.ID != 0 },
+		FetchKeys: func(_ context.Context, keys []int) ([]int, error) {
+			calls = append(calls, slices.Sorted(slices.Values(keys)))
+			return []int{3, 1, 3}, nil
+		},
+	}
+
+	got, err := ExistsAll(ctx, spec.For(authors[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[int]bool{1: true, 2: false, 3: true}; !maps.Equal(got, want) {
+		t.Fatalf("ExistsAll = %v; want %v", got, want)
+	}
+	for _, a := range authors {
+		ok, err := Exists(ctx, spec.For(a))
+		if err != nil || ok != (a.ID == 1 || a.ID == 3) {
+			t.Fatalf("Exists(%d) = %v, %v", a.ID, ok, err)
+		}
+	}
+	if len(calls) != 1 || !slices.Equal(calls[0], []int{1, 2, 3}) {
+		t.Fatalf("FetchKeys calls = %v; want one for [1 2 3]", calls)
+	}
+
+	got[2] = true // the map is the caller's
+	if again, _ := ExistsAll(ctx, spec.For(authors[0])); again[2] {
+		t.Fatal("ExistsAll returned a shared map")
+	}
+}
+
+func TestExistsAll_NoKeysSkipsFetch(t *testing.T) {
+	t.Parallel()
+	a := &Author{}
+	NewEngine().InitHandles(a)
+	got, err := ExistsAll(context.Background(), ExistsSpec[int, *Author]{
+		CacheKey:  "has_books",
+		Model:     a,
+		ModelKey:  func(a *Author) (int, bool) { return a.ID, a.ID != 0 },
+		FetchKeys: func(context.Context, []int) ([]int, error) { return nil, errors.New("fetched") },
+	})
+	if err != nil || len(got) != 0 {
+		t.Fatalf("ExistsAll = %v, %v; want an empty map", got, err)
+	}
+}
@@ -0,0 +1,59 @@
+package lode
+
+import (
+	"reflect"
+	"sync"
+)
+
+// WithFallbackFetch makes Resolve, Many, and One serve models that were
+// never bound instead of failing with ErrNotInitialized, for code paths that
+// have yet to bind their models: Many and One fetch just the model's key and
+// Resolve builds over just the model, every call, since there is no state
+// to cache in.  Each such call is reported through Hooks.OnFallback, so the
+// unbound paths can be found and fixed.  An unbound model has no engine, so
+// the option applies to specs that name the engine as their FallbackEngine,
+// and to all specs if set on the default engine (see SetDefault); specs can
+// also opt in on their own with RelationSpec.FallbackFetch and
+// ResolveSpec.FallbackBuild, served by a package-private engine, with no
+// hooks, if they name no engine and no default engine is set.
+func WithFallbackFetch() ConfigOption {
+	return func(c *Config) { c.fallbackFetch = true }
+}
+
+// FallbackEvent describes a call served for an unbound model; see
+// WithFallbackFetch.
+type FallbackEvent struct {
+	CacheKey  string
+	ModelType string
+}
+
+func (e *Engine) onFallback(ev FallbackEvent) {
+	for _, h := range e.config.hooks {
+		if h.OnFallback != nil {
+			h.OnFallback(ev)
+		}
+	}
+}
+
+// fallback returns the engine to serve the unbound model m with, reporting
+// the call, or false if neither that engine nor the spec (specOptIn) allows
+// the fallback.  The engine is the spec's FallbackEngine, else the default
+// engine if set, else fallbackEngine.
+func fallback(m any, cacheKey string, e *Engine, specOptIn bool) (*Engine, bool) {
+	if e == nil {
+		e = defaultIfSet()
+	}
+	if !specOptIn && (e == nil || !e.config.fallbackFetch) {
+		return nil, false
+	}
+	if e == nil {
+		e = fallbackEngine()
+	}
+	e.onFallback(FallbackEvent{CacheKey: cacheKey, ModelType: reflect.TypeOf(m).String()})
+	return e, true
+}
+
+// fallbackEngine serves the specs that opt in to the fallback when there is
+// no engine for them, rather than Default, which would keep SetDefault from
+// setting the default engine later.
+var fallbackEngine = sync.OnceValue(func() *Engine { return NewEngine() })
@@ -0,0 +1,177 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+type ledger struct {
+	Handle
+	ID int
+}
+
+type ledgerEntry struct {
+	LedgerID int
+}
+
+func TestFallbackFetch_Engine(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	var (
+		mu     sync.Mutex
+		events []FallbackEvent
+	)
+	eng := NewEngine(WithFallbackFetch(), WithHooks(Hooks{OnFallback: func(ev FallbackEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, ev)
+	}}))
+	bound := []*ledger{{ID: 1}, {ID: 2}}
+	eng.InitHandles(bound)
+
+	var fetched [][]int
+	spec := RelationSpec[int, *ledger, *ledgerEntry]{
+		CacheKey:       "entries",
+		ModelKey:       func(l *ledger) (int, bool) { return l.ID, true },
+		RelationKey:    func(e *ledgerEntry) int { return e.LedgerID },
+		FallbackEngine: eng,
+		Fetch: func(_ context.Context, ids []int) ([]*ledgerEntry, error) {
+			fetched = append(fetched, ids)
+			var out []*ledgerEntry
+			for _, id := range ids {
+				out = append(out, &ledgerEntry{LedgerID: id})
+			}
+			return out, nil
+		},
+	}
+
+	for _, l := range bound {
+		if entries, err := Many(ctx, spec.For(l)); err != nil || len(entries) != 1 {
+			t.Fatalf("ledger %d: %d entries, %v", l.ID, len(entries), err)
+		}
+	}
+	if len(events) != 0 {
+		t.Fatalf("bound ledgers reported %v", events)
+	}
+
+	unbound := &ledger{ID: 3}
+	for range 2 {
+		entries, err := Many(ctx, spec.For(unbound))
+		if err != nil || len(entries) != 1 || entries[0].LedgerID != 3 {
+			t.Fatalf("unbound ledger: %v, %v", entries, err)
+		}
+	}
+	if !slices.EqualFunc(fetched, [][]int{{1, 2}, {3}, {3}}, slices.Equal) {
+		t.Fatalf("fetched %v; want the bound batch, then the unbound key on each call", fetched)
+	}
+	if unbound.lodeState() != nil {
+		t.Fatal("fallback bound the model")
+	}
+
+	var built [][]*ledger
+	size, err := Resolve(ctx, ResolveSpec[*ledger, int]{
+		CacheKey:       "size",
+		Model:          unbound,
+		FallbackEngine: eng,
+		Build: func(_ context.Context, models []*ledger) (ResolverFunc[*ledger, int], error) {
+			built = append(built, models)
+			return func(*ledger) int { return len(models) }, nil
+		},
+	})
+	if err != nil || size != 1 || len(built) != 1 || built[0][0] != unbound {
+		t.Fatalf("Resolve = %d, %v after builds over %v", size, err, built)
+	}
+
+	want := []FallbackEvent{
+		{CacheKey: "entries", ModelType: "*lode.ledger"},
+		{CacheKey: "entries", ModelType: "*lode.ledger"},
+		{CacheKey: "size", ModelType: "*lode.ledger"},
+	}
+	if !slices.Equal(events, want) {
+		t.Fatalf("events = %v; want %v", events, want)
+	}
+}
+
+func TestFallbackFetch_EngineOfTheSpec(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	var strictEvents, fallbackEvents int
+	strict := NewEngine(WithHooks(Hooks{OnFallback: func(FallbackEvent) { strictEvents++ }}))
+	strict.InitHandles([]*ledger{{ID: 1}}) // binds the type first
+	eng := NewEngine(WithFallbackFetch(), WithHooks(Hooks{OnFallback: func(FallbackEvent) { fallbackEvents++ }}))
+	eng.InitHandles([]*ledger{{ID: 2}})
+
+	spec := RelationSpec[int, *ledger, *ledgerEntry]{
+		CacheKey:    "entries",
+		ModelKey:    func(l *ledger) (int, bool) { return l.ID, true },
+		RelationKey: func(e *ledgerEntry) int { return e.LedgerID },
+		Fetch: func(_ context.Context, ids []int) ([]*ledgerEntry, error) {
+			return []*ledgerEntry{{LedgerID: ids[0]}}, nil
+		},
+	}
+	unbound := &ledger{ID: 3}
+	if _, err := Many(ctx, spec.For(unbound)); !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("Many without a fallback engine = %v; want ErrNotInitialized", err)
+	}
+	spec.FallbackEngine = eng
+	if entries, err := Many(ctx, spec.For(unbound)); err != nil || len(entries) != 1 {
+		t.Fatalf("Many through the fallback engine = %v, %v", entries, err)
+	}
+	if strictEvents != 0 || fallbackEvents != 1 {
+		t.Fatalf("fallbacks reported %d times to the first engine, %d to the spec's; want 0 and 1", strictEvents, fallbackEvents)
+	}
+}
+
+func TestFallbackFetch_StrictByDefault(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	if _, err := Many(ctx, threeBooks(map[int]int{}).For(&Author{ID: 1})); !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("Many on an unbound author = %v; want ErrNotInitialized", err)
+	}
+	_, err := Resolve(ctx, ResolveSpec[*Author, int]{
+		CacheKey: "one",
+		Model:    &Author{ID: 1},
+		Build: func(context.Context, []*Author) (ResolverFunc[*Author, int], error) {
+			return func(*Author) int { return 1 }, nil
+		},
+	})
+	if !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("Resolve on an unbound author = %v; want ErrNotInitialized", err)
+	}
+}
+
+func TestFallbackFetch_Spec(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fetches := map[int]int{}
+	spec := threeBooks(fetches)
+	spec.FallbackFetch = true
+	books, err := Many(ctx, spec.For(&Author{ID: 7}))
+	if err != nil || len(books) != 3 || fetches[7] != 1 {
+		t.Fatalf("Many = %d books, %v after %d fetches", len(books), err, fetches[7])
+	}
+	for _, b := range books {
+		if b.lodeState() == nil {
+			t.Fatal("relations of a fallback fetch are unbound")
+		}
+	}
+}
+
+func TestFallbackFetch_SpecLeavesDefaultUnset(t *testing.T) {
+	resetDefault()
+	t.Cleanup(resetDefault)
+	spec := threeBooks(map[int]int{})
+	spec.FallbackFetch = true
+	if books, err := Many(context.Background(), spec.For(&Author{ID: 7})); err != nil || len(books) != 3 {
+		t.Fatalf("Many = %d books, %v", len(books), err)
+	}
+	if defaultIfSet() != nil {
+		t.Fatal("the fallback created the default engine")
+	}
+	if err := SetDefault(NewEngine()); err != nil {
+		t.Fatalf("SetDefault after a fallback fetch: %v", err)
+	}
+}
@@ -0,0 +1,238 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// DefaultMaxPages is the FetchPage call limit used when neither
+// RelationSpec.MaxPages nor SpecDefaults.MaxPages is set.
+const DefaultMaxPages = 1000
+
+var errTooManyPages = &kindError{"too many pages", ErrBudgetExceeded}
+
+var (
+	errFetchAndPage   = &kindError{"spec sets both Fetch and FetchPage", ErrMisconfigured}
+	errGroupedAndList = &kindError{"spec sets FetchGrouped and Fetch or FetchPage", ErrMisconfigured}
+)
+
+// ErrTooManyRelations is returned (wrapped with the cache key, the count, and
+// the limit) by builds that fetched more relations than allowed; see
+// WithMaxRelationsPerBuild.
+var ErrTooManyRelations = errors.New("too many relations")
+
+// WithMaxRelationsPerBuild makes Many fail a build, rather than cache it, once
+// its fetch has returned more than n relations.  Paged and streamed fetches
+// are stopped as soon as the limit is passed.  RelationSpec.MaxRelations
+// overrides the limit per spec.  Zero, the default, means no limit.
+func WithMaxRelationsPerBuild(n int) ConfigOption {
+	return func(c *Config) { c.maxRelations = n }
+}
+
+// maxRelations returns the relation limit for the spec, or 0 for none.
+func (args RelationSpec[JoinKey, Model, Relation]) maxRelations(e *Engine) int {
+	if args.MaxRelations != 0 {
+		return max(args.MaxRelations, 0)
+	}
+	return e.config.maxRelations
+}
+
+// WithFetchChunkSize makes Many, One, and Stream fetch the keys of a build
+// in chunks of at most n, one fetch call per chunk, for specs that leave
+// RelationSpec.MaxKeysPerFetch zero: a batch of tens of thousands of models
+// otherwise makes one IN clause beyond what the database takes.  Zero, the
+// default, fetches all keys at once.  It sets SpecDefaults.MaxKeysPerFetch.
+func WithFetchChunkSize(n int) ConfigOption {
+	return func(c *Config) { c.specDefaults.MaxKeysPerFetch = n }
+}
+
+// maxKeysPerFetch returns the chunk size for the spec's fetches, or 0 for
+// none.
+func (args RelationSpec[JoinKey, Model, Relation]) maxKeysPerFetch(e *Engine) int {
+	if args.MaxKeysPerFetch != 0 {
+		return max(args.MaxKeysPerFetch, 0)
+	}
+	return max(e.config.specDefaults.MaxKeysPerFetch, 0)
+}
+
+// maxPages returns the FetchPage call limit for the spec.
+func (args RelationSpec[JoinKey, Model, Relation]) maxPages(e *Engine) int {
+	switch {
+	case args.MaxPages > 0:
+		return args.MaxPages
+	case e.config.specDefaults.MaxPages > 0:
+		return e.config.specDefaults.MaxPages
+	}
+	return DefaultMaxPages
+}
+
+func (args RelationSpec[JoinKey, Model, Relation]) tooManyRelations(count, limit int) error {
+	return fmt.Errorf("%s: key %q: %w: fetched %d, limit %d", packagePrefix, args.CacheKey, ErrTooManyRelations, count, limit)
+}
+
+// fetch calls the spec's Fetch, FetchPage, FetchGrouped or FetchStream for
+// keys of models bound to s, in KeyOrder and in chunks of MaxKeysPerFetch,
+// and reports the result through the engine's hooks.  grouped is only set
+// for FetchGrouped, in which case relations holds its groups flattened.
+func (args RelationSpec[JoinKey, Model, Relation]) fetch(ctx context.Context, s *loaderState, keys []JoinKey) (relations []Relation, grouped map[JoinKey][]Relation, err error) {
+	e := s.engine
+	start := e.now()
+	limit := args.maxRelations(e)
+	maxPages := args.maxPages(e)
+	var pages int
+	switch {
+	case args.Fetch != nil && args.FetchPage != nil:
+		err = fmt.Errorf("%s: key %q: %w", packagePrefix, args.CacheKey, errFetchAndPage)
+	case args.FetchGrouped != nil && (args.Fetch != nil || args.FetchPage != nil):
+		err = fmt.Errorf("%s: key %q: %w", packagePrefix, args.CacheKey, errGroupedAndList)
+	default:
+		keys = args.orderKeys(keys)
+		if n := e.config.fetchConcurrency; n > 1 {
+			if ranges := chunkRanges(len(keys), args.maxKeysPerFetch(e)); len(ranges) > 1 {
+				relations, grouped, pages, err = args.fetchConcurrently(ctx, e, keys, ranges, n, maxPages, limit)
+				break
+			}
+		}
+		for from, to := range ChunkRanges(len(keys), args.maxKeysPerFetch(e)) {
+			var n int
+			err = args.limited(ctx, e, func() (err error) {
+				relations, grouped, n, err = args.fetchChunk(ctx, keys[from:to], relations, grouped, pages, maxPages, limit)
+				return err
+			})
+			pages += n
+			if err != nil {
+				break
+			}
+		}
+	}
+	ev := FetchEvent{
+		CacheKey:  args.CacheKey,
+		ModelType: s.modelType(),
+		Keys:      len(keys),
+		Relations: len(relations),
+		Pages:     pages,
+		Duration:  e.now().Sub(start),
+		Err:       err,
+	}
+	e.onFetch(ev)
+	if e.graph != nil {
+		e.graph.record(ev, reflect.TypeFor[Relation]().String())
+	}
+	if info, ok := ctx.Value(buildInfoKey{}).(*Info); ok {
+		info.KeyCount += ev.Keys
+	}
+	return relations, grouped, err
+}
+
+// validate runs ValidateRelation, if set, over the non-nil relations.
+func (args RelationSpec[JoinKey, Model, Relation]) validate(relations []Relation) error {
+	if args.ValidateRelation == nil {
+		return nil
+	}
+	for i, r := range relations {
+		if isNil(r) {
+			continue
+		}
+		if err := args.ValidateRelation(r); err != nil {
+			return fmt.Errorf("%s: key %q: relation %d: %w", packagePrefix, args.CacheKey, i, err)
+		}
+	}
+	return nil
+}
+
+// orderKeys returns keys sorted by KeyOrder, if set.
+func (args RelationSpec[JoinKey, Model, Relation]) orderKeys(keys []JoinKey) []JoinKey {
+	if args.KeyOrder == nil {
+		return keys
+	}
+	return slices.SortedFunc(slices.Values(keys), args.KeyOrder)
+}
+
+// fetchChunk fetches one chunk of keys, appending to the relations (and
+// for FetchGrouped, the groups) fetched so far, and returns how many calls
+// (pages) it took.  maxPages and limit apply to the whole fetch, of which
+// earlier chunks took usedPages pages.
+func (args RelationSpec[JoinKey, Model, Relation]) fetchChunk(ctx context.Context, keys []JoinKey, relations []Relation, grouped map[JoinKey][]Relation, usedPages, maxPages, limit int) ([]Relation, map[JoinKey][]Relation, int, error) {
+	var err error
+	switch {
+	case args.FetchGrouped != nil:
+		var g map[JoinKey][]Relation
+		if g, err = args.FetchGrouped(ctx, keys); err != nil {
+			return relations, grouped, 1, err
+		}
+		// g is the caller's, perhaps a cache's, so it is copied rather
+		// than added to.  Relations are flattened in key order, then those
+		// of groups under keys that were not asked for.
+		if grouped == nil {
+			grouped = make(map[JoinKey][]Relation, len(g))
+		}
+		for _, k := range keys {
+			if rs, ok := g[k]; ok {
+				grouped[k] = rs
+				relations = append(relations, rs...)
+			}
+		}
+		for k, rs := range g {
+			if _, ok := grouped[k]; !ok {
+				grouped[k] = rs
+				relations = append(relations, rs...)
+			}
+		}
+	case args.FetchPage != nil:
+		var pages int
+		relations, pages, err = args.fetchPages(ctx, keys, relations, usedPages, maxPages, limit)
+		return relations, grouped, pages, err
+	case args.Fetch == nil && args.FetchStream != nil:
+		err = args.FetchStream(ctx, keys, func(r Relation) error {
+			if limit > 0 && len(relations) == limit {
+				return args.tooManyRelations(limit+1, limit)
+			}
+			relations = append(relations, r)
+			return nil
+		})
+		return relations, grouped, 1, err
+	default:
+		var rs []Relation
+		if rs, err = args.Fetch(ctx, keys); err != nil {
+			return relations, grouped, 1, err
+		}
+		if relations == nil {
+			relations = slices.Clip(rs) // later chunks must not write into rs
+		} else {
+			relations = append(relations, rs...)
+		}
+	}
+	if limit > 0 && len(relations) > limit {
+		err = args.tooManyRelations(len(relations), limit)
+	}
+	return relations, grouped, 1, err
+}
+
+// fetchPages calls FetchPage for keys until it runs out of pages, appending
+// to relations.
+func (args RelationSpec[JoinKey, Model, Relation]) fetchPages(ctx context.Context, keys []JoinKey, relations []Relation, usedPages, maxPages, limit int) ([]Relation, int, error) {
+	var cursor string
+	for pages := 1; ; pages++ {
+		if usedPages+pages > maxPages {
+			return nil, pages - 1, fmt.Errorf("%s: key %q: %w: more than %d", packagePrefix, args.CacheKey, errTooManyPages, maxPages)
+		}
+		items, next, err := args.FetchPage(ctx, keys, cursor)
+		if err != nil {
+			return nil, pages, err
+		}
+		relations = append(relations, items...)
+		if limit > 0 && len(relations) > limit {
+			return nil, pages, args.tooManyRelations(len(relations), limit)
+		}
+		if next == "" {
+			return relations, pages, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, pages, err
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,793 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// pagedBooks serves books two at a time, like an API with a page size cap.
+func pagedBooks(all []*Book, calls *int, onPage func(page int)) func(context.Context, []int, string) ([]*Book, string, error) {
+	const pageSize = 2
+	return func(_ context.Context, keys []int, cursor string) ([]*Book, string, error) {
+		*calls++
+		start := 0
+		if cursor != "" {
+			start, _ = strconv.Atoi(cursor)
+		}
+		if onPage != nil {
+			onPage(start/pageSize + 1)
+		}
+		end := min(start+pageSize, len(all))
+		next := ""
+		if end < len(all) {
+			next = strconv.Itoa(end)
+		}
+		return all[start:end], next, nil
+	}
+}
+
+func TestMany_FetchPage(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	var events []FetchEvent
+	eng := NewEngine(WithHooks(Hooks{OnFetch: func(ev FetchEvent) { events = append(events, ev) }}))
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles([]*Author{a1, a2})
+
+	all := []*Book{
+		{ID: 1, AuthorID: 1, Title: "A1-1"},
+		{ID: 2, AuthorID: 2, Title: "A2-1"},
+		{ID: 3, AuthorID: 1, Title: "A1-2"},
+		{ID: 4, AuthorID: 2, Title: "A2-2"},
+		{ID: 5, AuthorID: 1, Title: "A1-3"},
+	}
+	calls := 0
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       a1,
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		FetchPage:   pagedBooks(all, &calls, nil),
+	}
+
+	got1, err := Many(ctx, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Model = a2
+	got2, err := Many(ctx, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"A1-1", "A1-2", "A1-3"}; !equalStrings(titles(got1), want) {
+		t.Fatalf("Many(a1) = %v; want %v", titles(got1), want)
+	}
+	if want := []string{"A2-1", "A2-2"}; !equalStrings(titles(got2), want) {
+		t.Fatalf("Many(a2) = %v; want %v", titles(got2), want)
+	}
+	if calls != 3 {
+		t.Fatalf("FetchPage calls = %d; want 3", calls)
+	}
+	if len(events) != 1 || events[0].Pages != 3 || events[0].Relations != 5 || events[0].Keys != 2 || events[0].CacheKey != "books" {
+		t.Fatalf("events = %+v; want one fetch of 3 pages", events)
+	}
+}
+
+func TestMany_FetchPageMaxPages(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine()
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+
+	calls := 0
+	_, err := Many(context.Background(), RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       a,
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		FetchPage:   pagedBooks(make([]*Book, 5), &calls, nil),
+		MaxPages:    2,
+	})
+	if !errors.Is(err, errTooManyPages) {
+		t.Fatalf("err = %v; want errTooManyPages", err)
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d; want 2", calls)
+	}
+}
+
+func TestMany_MaxPagesPrecedence(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name             string
+		engine, spec     int
+		wantCalls        int
+		wantTooManyPages bool
+	}{
+		{name: "package default", wantCalls: 3},
+		{name: "engine default", engine: 2, wantCalls: 2, wantTooManyPages: true},
+		{name: "spec over engine", engine: 2, spec: 3, wantCalls: 3},
+		{name: "spec only", spec: 1, wantCalls: 1, wantTooManyPages: true},
+	}
+	for _, tc := range cases {
+		eng := NewEngine(WithSpecDefaults(SpecDefaults{MaxPages: tc.engine}))
+		a := &Author{ID: 1}
+		eng.InitHandles(a)
+
+		calls := 0
+		_, err := Many(context.Background(), RelationSpec[int, *Author, *Book]{
+			CacheKey:    "books",
+			Model:       a,
+			ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+			RelationKey: func(b *Book) int { return b.AuthorID },
+			FetchPage:   pagedBooks(make([]*Book, 5), &calls, nil),
+			MaxPages:    tc.spec,
+		})
+		if got := errors.Is(err, errTooManyPages); got != tc.wantTooManyPages {
+			t.Fatalf("%s: err = %v; want errTooManyPages %v", tc.name, err, tc.wantTooManyPages)
+		}
+		if calls != tc.wantCalls {
+			t.Fatalf("%s: calls = %d; want %d", tc.name, calls, tc.wantCalls)
+		}
+	}
+}
+
+func TestMany_FetchPageStopsOnCancel(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	eng := NewEngine()
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+
+	calls := 0
+	all := []*Book{{AuthorID: 1}, {AuthorID: 1}, {AuthorID: 1}, {AuthorID: 1}, {AuthorID: 1}}
+	_, err := Many(ctx, RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       a,
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		FetchPage: pagedBooks(all, &calls, func(page int) {
+			if page == 2 {
+				cancel()
+			}
+		}),
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v; want context.Canceled", err)
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d; want 2 (no page after cancel)", calls)
+	}
+}
+
+func TestMany_FetchAndFetchPageConflict(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine()
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+
+	_, err := Many(context.Background(), RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       a,
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch:       func(context.Context, []int) ([]*Book, error) { return nil, nil },
+		FetchPage: func(context.Context, []int, string) ([]*Book, string, error) {
+			return nil, "", nil
+		},
+	})
+	if err == nil {
+		t.Fatal("want error when both Fetch and FetchPage are set")
+	}
+}
+
+func TestMany_SkipsNilAndUnplacedRelations(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	var events []SkipEvent
+	eng := NewEngine(WithHooks(Hooks{OnSkipped: func(ev SkipEvent) { events = append(events, ev) }}))
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles([]*Author{a1, a2})
+
+	b1 := &Book{ID: 1, AuthorID: 1, Title: "b1"}
+	b2 := &Book{ID: 2, AuthorID: 2, Title: "b2"}
+	orphan := &Book{ID: 3, Title: "orphan"}
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey: "books",
+		Model:    a1,
+		ModelKey: func(a *Author) (int, bool) { return a.ID, true },
+		RelationKeyOK: func(b *Book) (int, bool) {
+			return b.AuthorID, b.AuthorID != 0
+		},
+		Fetch: func(context.Context, []int) ([]*Book, error) {
+			return []*Book{b1, nil, orphan, b2, nil}, nil
+		},
+	}
+
+	got1, err := Many(ctx, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec.Model = a2
+	got2, err := Many(ctx, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalStrings(titles(got1), []string{"b1"}) || !equalStrings(titles(got2), []string{"b2"}) {
+		t.Fatalf("got %v and %v", titles(got1), titles(got2))
+	}
+	if _, ok := StateOf(b1); !ok {
+		t.Fatal("non-nil relations should still be bound")
+	}
+	if len(events) != 1 || events[0] != (SkipEvent{CacheKey: "books", Nil: 2, Unplaced: 1}) {
+		t.Fatalf("events = %+v", events)
+	}
+	if got := eng.Stats().SkippedRelations; got != 3 {
+		t.Fatalf("SkippedRelations = %d; want 3", got)
+	}
+}
+
+func TestDropNil(t *testing.T) {
+	t.Parallel()
+	b := &Book{}
+	in := []*Book{b, b}
+	if got, n := dropNil(in); n != 0 || &got[0] != &in[0] {
+		t.Fatal("slice without nils should be returned as-is")
+	}
+	if got, n := dropNil([]*Book{nil, b, nil}); n != 2 || len(got) != 1 || got[0] != b {
+		t.Fatalf("got %v, %d", got, n)
+	}
+	if got, n := dropNil([]Book{{}, {}}); n != 0 || len(got) != 2 {
+		t.Fatal("value relations are never nil")
+	}
+}
+
+func TestMany_MaxRelations(t *testing.T) {
+	t.Parallel()
+	books := func(n int) []*Book {
+		out := make([]*Book, n)
+		for i := range out {
+			out[i] = &Book{ID: i, AuthorID: 1}
+		}
+		return out
+	}
+	many := func(eng *Engine, spec RelationSpec[int, *Author, *Book]) ([]*Book, error) {
+		a := &Author{ID: 1}
+		eng.InitHandles(a)
+		spec.CacheKey = "books"
+		spec.Model = a
+		spec.ModelKey = func(a *Author) (int, bool) { return a.ID, true }
+		spec.RelationKey = func(b *Book) int { return b.AuthorID }
+		return Many(context.Background(), spec)
+	}
+	fetchN := func(n int) func(context.Context, []int) ([]*Book, error) {
+		return func(context.Context, []int) ([]*Book, error) { return books(n), nil }
+	}
+	eng := NewEngine(WithMaxRelationsPerBuild(4))
+
+	if got, err := many(eng, RelationSpec[int, *Author, *Book]{Fetch: fetchN(4)}); err != nil || len(got) != 4 {
+		t.Fatalf("exactly the limit: %d, %v", len(got), err)
+	}
+	_, err := many(eng, RelationSpec[int, *Author, *Book]{Fetch: fetchN(5)})
+	if !errors.Is(err, ErrTooManyRelations) || !strings.Contains(err.Error(), `key "books": too many relations: fetched 5, limit 4`) {
+		t.Fatalf("one over: err = %v", err)
+	}
+
+	// Paged fetches stop at the page that passes the limit.
+	calls := 0
+	_, err = many(eng, RelationSpec[int, *Author, *Book]{FetchPage: pagedBooks(books(10), &calls, nil)})
+	if !errors.Is(err, ErrTooManyRelations) || calls != 3 {
+		t.Fatalf("paged: err = %v after %d pages; want ErrTooManyRelations after 3", err, calls)
+	}
+
+	// Streamed fetches stop at the first relation over the limit.
+	yielded := 0
+	_, err = many(eng, RelationSpec[int, *Author, *Book]{
+		FetchStream: func(ctx context.Context, keys []int, yield func(*Book) error) error {
+			for _, b := range books(10) {
+				yielded++
+				if err := yield(b); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	})
+	if !errors.Is(err, ErrTooManyRelations) || yielded != 5 {
+		t.Fatalf("stream: err = %v after %d relations; want ErrTooManyRelations after 5", err, yielded)
+	}
+
+	// The spec overrides the engine: raised, or disabled.
+	if _, err := many(eng, RelationSpec[int, *Author, *Book]{Fetch: fetchN(6), MaxRelations: 6}); err != nil {
+		t.Fatalf("raised limit: %v", err)
+	}
+	if _, err := many(eng, RelationSpec[int, *Author, *Book]{Fetch: fetchN(100), MaxRelations: -1}); err != nil {
+		t.Fatalf("disabled limit: %v", err)
+	}
+	if _, err := many(NewEngine(), RelationSpec[int, *Author, *Book]{Fetch: fetchN(100)}); err != nil {
+		t.Fatalf("off by default: %v", err)
+	}
+}
+
+func TestMany_EmptyKeySetSkipsFetch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	var fetches []FetchEvent
+	eng := NewEngine(WithHooks(Hooks{OnFetch: func(ev FetchEvent) { fetches = append(fetches, ev) }}))
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles([]*Author{a1, a2})
+
+	// The caller's model has a key when Many checks it, but by the time the
+	// batch is built none does (e.g. the foreign key was cleared).
+	checked := false
+	fetchCalled := false
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey: "books",
+		Model:    a1,
+		ModelKey: func(a *Author) (int, bool) {
+			if !checked {
+				checked = true
+				return a.ID, true
+			}
+			return 0, false
+		},
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(_ context.Context, ids []int) ([]*Book, error) {
+			fetchCalled = true
+			return nil, nil
+		},
+	}
+
+	got, info, err := ManyInfo(ctx, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil || info.KeyCount != 0 {
+		t.Fatalf("got %v, %+v; want nil and no keys", got, info)
+	}
+	if fetchCalled || len(fetches) != 0 {
+		t.Fatalf("Fetch called = %v, fetch events = %+v; want neither", fetchCalled, fetches)
+	}
+	if got := eng.Stats().EmptyBuilds; got != 1 {
+		t.Fatalf("EmptyBuilds = %d; want 1", got)
+	}
+
+	// The empty result is cached like any other.
+	checked = false
+	if _, info, err = ManyInfo(ctx, spec); err != nil || !info.CacheHit {
+		t.Fatalf("second call: info = %+v, err = %v; want a cache hit", info, err)
+	}
+	if got := eng.Stats().EmptyBuilds; got != 1 {
+		t.Fatalf("EmptyBuilds = %d after cache hit; want 1", got)
+	}
+}
+
+// groupedBooks serves books already grouped by author, like a cache
+// multi-get.  Every requested key gets an entry, empty or not.
+func groupedBooks(all []*Book, calls *int) func(context.Context, []int) (map[int][]*Book, error) {
+	return func(_ context.Context, keys []int) (map[int][]*Book, error) {
+		*calls++
+		out := make(map[int][]*Book, len(keys))
+		for _, k := range keys {
+			out[k] = []*Book{}
+		}
+		for _, b := range all {
+			if _, ok := out[b.AuthorID]; ok {
+				out[b.AuthorID] = append(out[b.AuthorID], b)
+			}
+		}
+		return out, nil
+	}
+}
+
+func TestMany_FetchGrouped(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	var events []FetchEvent
+	eng := NewEngine(WithHooks(Hooks{OnFetch: func(ev FetchEvent) { events = append(events, ev) }}))
+	a1, a2, a3 := &Author{ID: 1}, &Author{ID: 2}, &Author{ID: 3}
+	eng.InitHandles([]*Author{a1, a2, a3})
+
+	all := []*Book{
+		{ID: 1, AuthorID: 1, Title: "A1-1"},
+		{ID: 2, AuthorID: 2, Title: "A2-1"},
+		{ID: 3, AuthorID: 1, Title: "A1-2"},
+	}
+	calls := 0
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:     "books",
+		ModelKey:     func(a *Author) (int, bool) { return a.ID, true },
+		FetchGrouped: groupedBooks(all, &calls),
+	}
+
+	got1, err := Many(ctx, spec.For(a1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got2, err := Many(ctx, spec.For(a2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got3, err := Many(ctx, spec.For(a3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"A1-1", "A1-2"}; !equalStrings(titles(got1), want) {
+		t.Fatalf("Many(a1) = %v; want %v", titles(got1), want)
+	}
+	if want := []string{"A2-1"}; !equalStrings(titles(got2), want) {
+		t.Fatalf("Many(a2) = %v; want %v", titles(got2), want)
+	}
+	if got3 == nil || len(got3) != 0 {
+		t.Fatalf("Many(a3) = %#v; want the empty group as returned", got3)
+	}
+	if calls != 1 {
+		t.Fatalf("FetchGrouped calls = %d; want 1", calls)
+	}
+	if len(events) != 1 || events[0].Relations != 3 || events[0].Keys != 3 {
+		t.Fatalf("events = %+v; want one fetch of 3 keys and 3 relations", events)
+	}
+	s1, ok1 := StateOf(all[0])
+	s2, ok2 := StateOf(all[1])
+	if !ok1 || !ok2 || s1 != s2 {
+		t.Fatal("relations from all groups should be bound together")
+	}
+}
+
+func TestMany_FetchGroupedChunksKeepCallersMaps(t *testing.T) {
+	t.Parallel()
+	var bound []*Book
+	eng := NewEngine(WithFetchChunkSize(2), WithHooks(Hooks{
+		OnRelationsBound: func(_ string, rs any) { bound = rs.([]*Book) },
+	}))
+	authors := []*Author{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}
+	eng.InitHandles(authors)
+
+	var returned []map[int][]*Book
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey: "books",
+		ModelKey: func(a *Author) (int, bool) { return a.ID, true },
+		FetchGrouped: func(_ context.Context, ids []int) (map[int][]*Book, error) {
+			g := map[int][]*Book{}
+			for _, id := range ids {
+				g[id] = []*Book{{ID: id, AuthorID: id}}
+			}
+			returned = append(returned, g)
+			return g, nil
+		},
+	}
+	if _, err := Many(context.Background(), spec.For(authors[0])); err != nil {
+		t.Fatal(err)
+	}
+	for i, g := range returned {
+		if len(g) != 2 {
+			t.Fatalf("chunk %d's map has %d groups after the fetch; want its own 2", i, len(g))
+		}
+	}
+	var ids []int
+	for _, b := range bound {
+		ids = append(ids, b.ID)
+	}
+	if !slices.Equal(ids, []int{1, 2, 3, 4}) {
+		t.Fatalf("relations bound in order %v; want key order", ids)
+	}
+}
+
+func TestMany_FetchGroupedNilAndSinglePerKey(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	eng := NewEngine()
+	a1 := &Author{ID: 1}
+	eng.InitHandles([]*Author{a1})
+
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey: "books",
+		Model:    a1,
+		ModelKey: func(a *Author) (int, bool) { return a.ID, true },
+		FetchGrouped: func(context.Context, []int) (map[int][]*Book, error) {
+			return map[int][]*Book{1: {nil, {ID: 1, AuthorID: 1, Title: "b1"}, {ID: 2, AuthorID: 1, Title: "b2"}}}, nil
+		},
+	}
+
+	got, err := Many(ctx, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"b1", "b2"}; !equalStrings(titles(got), want) {
+		t.Fatalf("Many = %v; want %v", titles(got), want)
+	}
+	one, err := One(ctx, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if one == nil || one.Title != "b1" {
+		t.Fatalf("One = %v; want b1", one)
+	}
+}
+
+func TestMany_FetchGroupedConflictAndLimit(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	eng := NewEngine(WithMaxRelationsPerBuild(2))
+	a1 := &Author{ID: 1}
+	eng.InitHandles([]*Author{a1})
+
+	calls := 0
+	all := []*Book{{ID: 1, AuthorID: 1}, {ID: 2, AuthorID: 1}, {ID: 3, AuthorID: 1}}
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:     "books",
+		Model:        a1,
+		ModelKey:     func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey:  func(b *Book) int { return b.AuthorID },
+		Fetch:        func(context.Context, []int) ([]*Book, error) { return all, nil },
+		FetchGrouped: groupedBooks(all, &calls),
+	}
+	if _, err := Many(ctx, spec); err == nil || !strings.Contains(err.Error(), "FetchGrouped") {
+		t.Fatalf("err = %v; want a FetchGrouped conflict", err)
+	}
+	if calls != 0 {
+		t.Fatalf("FetchGrouped calls = %d; want 0", calls)
+	}
+
+	spec.CacheKey, spec.Fetch = "books2", nil
+	if _, err := Many(ctx, spec); !errors.Is(err, ErrTooManyRelations) {
+		t.Fatalf("err = %v; want ErrTooManyRelations", err)
+	}
+}
+
+func TestMany_KeysInFirstSeenOrder(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	var authors []*Author
+	for _, id := range []int{5, 3, 5, 9, 1, 3, 9, 7, 1} {
+		authors = append(authors, &Author{ID: id})
+	}
+	NewEngine().InitHandles(authors)
+
+	var calls [][]int
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(_ context.Context, ids []int) ([]*Book, error) {
+			calls = append(calls, slices.Clone(ids))
+			return nil, nil
+		},
+	}
+	for _, a := range authors {
+		if _, err := Many(ctx, spec.For(a)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := [][]int{{5, 3, 9, 1, 7}}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("Fetch calls = %v; want %v", calls, want)
+	}
+}
+
+func TestMany_KeyOrderAndMaxKeysPerFetch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	var events []FetchEvent
+	eng := NewEngine(WithHooks(Hooks{OnFetch: func(ev FetchEvent) { events = append(events, ev) }}))
+	authors := make([]*Author, 7)
+	for i := range authors {
+		authors[i] = &Author{ID: 70 - 10*i} // bound in descending order
+	}
+	eng.InitHandles(authors)
+
+	var calls [][]int
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(_ context.Context, ids []int) ([]*Book, error) {
+			calls = append(calls, slices.Clone(ids))
+			var out []*Book
+			for _, id := range ids {
+				out = append(out, &Book{ID: id, AuthorID: id, Title: strconv.Itoa(id)})
+			}
+			return out, nil
+		},
+		KeyOrder:        func(a, b int) int { return a - b },
+		MaxKeysPerFetch: 3,
+	}
+	for _, a := range authors {
+		got, err := Many(ctx, spec.For(a))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{strconv.Itoa(a.ID)}; !equalStrings(titles(got), want) {
+			t.Fatalf("Many(%d) = %v; want %v", a.ID, titles(got), want)
+		}
+	}
+
+	want := [][]int{{10, 20, 30}, {40, 50, 60}, {70}}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("Fetch calls = %v; want %v", calls, want)
+	}
+	if len(events) != 1 || events[0].Keys != 7 || events[0].Pages != 3 || events[0].Relations != 7 {
+		t.Fatalf("events = %+v; want one fetch of 7 keys in 3 calls", events)
+	}
+}
+
+func TestWithFetchChunkSize(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine(WithFetchChunkSize(2))
+	authors := make([]*Author, 5)
+	for i := range authors {
+		authors[i] = &Author{ID: i + 1}
+	}
+	eng.InitHandles(authors)
+
+	errBoom := errors.New("boom")
+	var calls [][]int
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(_ context.Context, ids []int) ([]*Book, error) {
+			calls = append(calls, slices.Clone(ids))
+			if slices.Contains(ids, 99) {
+				return nil, errBoom
+			}
+			var out []*Book
+			for _, id := range ids {
+				for j := range id {
+					out = append(out, &Book{AuthorID: id, Title: strconv.Itoa(10*id + j)})
+				}
+			}
+			return out, nil
+		},
+	}
+	for _, a := range authors {
+		got, err := Many(ctx, spec.For(a))
+		if err != nil || len(got) != a.ID || got[0].AuthorID != a.ID {
+			t.Fatalf("Many(%d) = %v, %v", a.ID, titles(got), err)
+		}
+	}
+	if want := [][]int{{1, 2}, {3, 4}, {5}}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("Fetch calls = %v; want %v", calls, want)
+	}
+
+	// A spec's MaxKeysPerFetch wins; negative turns chunking off.
+	calls = nil
+	spec.CacheKey, spec.MaxKeysPerFetch = "books_whole", -1
+	if _, err := Many(ctx, spec.For(authors[0])); err != nil || len(calls) != 1 {
+		t.Fatalf("Fetch calls = %v, %v; want one", calls, err)
+	}
+
+	// A failing chunk fails the build without fetching the rest.
+	calls = nil
+	failing := []*Author{{ID: 1}, {ID: 99}, {ID: 3}}
+	eng.InitHandles(failing)
+	spec.CacheKey, spec.MaxKeysPerFetch = "books_failing", 1
+	if _, err := Many(ctx, spec.For(failing[0])); !errors.Is(err, errBoom) {
+		t.Fatalf("err = %v; want boom", err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("Fetch calls = %v; want to stop at the failing chunk", calls)
+	}
+}
+
+func TestMany_MaxKeysPerFetchLimitsSpanChunks(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine(WithMaxRelationsPerBuild(3))
+	authors := []*Author{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}
+	eng.InitHandles(authors)
+
+	calls := 0
+	_, err := Many(ctx, RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       authors[0],
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(_ context.Context, ids []int) ([]*Book, error) {
+			calls++
+			return []*Book{{AuthorID: ids[0]}, {AuthorID: ids[0]}}, nil
+		},
+		MaxKeysPerFetch: 1,
+	})
+	if !errors.Is(err, ErrTooManyRelations) || !strings.Contains(err.Error(), "fetched 4, limit 3") {
+		t.Fatalf("err = %v; want ErrTooManyRelations after 4 relations", err)
+	}
+	if calls != 2 {
+		t.Fatalf("Fetch calls = %d; want 2, stopping once over the limit", calls)
+	}
+}
+
+func TestMany_ValidateRelation(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	errZeroAuthor := errors.New("zero AuthorID")
+	validate := func(b *Book) error {
+		if b.AuthorID == 0 {
+			return errZeroAuthor
+		}
+		return nil
+	}
+	for _, tc := range []struct {
+		name    string
+		fetched []*Book
+		wantErr string
+	}{
+		{"pass", []*Book{{ID: 1, AuthorID: 1}, nil, {ID: 2, AuthorID: 2}}, ""},
+		{"fail", []*Book{{ID: 1, AuthorID: 1}, nil, {ID: 2}}, `lode: key "books": relation 2: zero AuthorID`},
+	} {
+		authors := []*Author{{ID: 1}, {ID: 2}}
+		NewEngine().InitHandles(authors)
+		spec := RelationSpec[int, *Author, *Book]{
+			CacheKey:    "books",
+			ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+			RelationKey: func(b *Book) int { return b.AuthorID },
+			Fetch: func(context.Context, []int) ([]*Book, error) {
+				return tc.fetched, nil
+			},
+			ValidateRelation: validate,
+		}
+		books, err := Many(ctx, spec.For(authors[0]))
+		if tc.wantErr == "" {
+			if err != nil || len(books) != 1 {
+				t.Fatalf("%s: Many = %v, %v", tc.name, titles(books), err)
+			}
+			continue
+		}
+		if !errors.Is(err, errZeroAuthor) || err.Error() != tc.wantErr {
+			t.Fatalf("%s: err = %v; want %q wrapping the validator's error", tc.name, err, tc.wantErr)
+		}
+		if _, err2 := Many(ctx, spec.For(authors[1])); !errors.Is(err2, errZeroAuthor) {
+			t.Fatalf("%s: sibling err = %v; want the cached validation error", tc.name, err2)
+		}
+	}
+}
+
+func TestMany_OnRelationsBound(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	type call struct {
+		cacheKey  string
+		relations any
+	}
+	var calls []call
+	eng := NewEngine(WithHooks(Hooks{OnRelationsBound: func(cacheKey string, relations any) {
+		calls = append(calls, call{cacheKey, relations})
+	}}))
+	authors := []*Author{{ID: 1}, {ID: 2}, {ID: 3}}
+	eng.InitHandles(authors)
+	spec := threeBooks(map[int]int{})
+	spec.MaxKeysPerFetch = 1
+
+	for _, a := range authors {
+		if _, err := Many(ctx, spec.For(a)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(calls) != 1 || calls[0].cacheKey != "books" {
+		t.Fatalf("OnRelationsBound calls = %+v; want one for books", calls)
+	}
+	books, ok := calls[0].relations.([]*Book)
+	if !ok || len(books) != 9 {
+		t.Fatalf("relations = %T of %d; want the 9 books of all chunks as []*Book", calls[0].relations, len(books))
+	}
+	for _, b := range books {
+		if b.lodeState() == nil {
+			t.Fatalf("book %d was not bound before the hook", b.ID)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package lode
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+	"sync/atomic"
+)
+
+// WithFetchConcurrency lets up to n fetch calls of one build run at once
+// when its keys are split into chunks (see WithFetchChunkSize and
+// RelationSpec.MaxKeysPerFetch), each chunk being an independent query.
+// The first failing chunk cancels the ctx of the others and fails the
+// build; the relations of the chunks are combined in chunk order, as they
+// are fetched one after another.  MaxPages and MaxRelations still apply to
+// the total: as soon as the chunks done exceed either, the chunks in flight
+// are cancelled and no more are started.  A Fetch that panics in a chunk
+// cancels the others, and the panic is raised again on the build's
+// goroutine once they are done, as it would be fetching the chunks one
+// after another.  Under WithMaxConcurrentBuilds each chunk in flight counts
+// as a build: the first runs on its build's slot, and the others wait for
+// slots of their own.  n <= 1, the default, fetches the chunks one after
+// another.  Stream always does.
+func WithFetchConcurrency(n int) ConfigOption {
+	return func(c *Config) { c.fetchConcurrency = n }
+}
+
+// chunkRanges collects ChunkRanges.
+func chunkRanges(n, size int) [][2]int {
+	var ranges [][2]int
+	for from, to := range ChunkRanges(n, size) {
+		ranges = append(ranges, [2]int{from, to})
+	}
+	return ranges
+}
+
+// fetchedChunk is what fetchChunk returned for one chunk of keys.
+type fetchedChunk[JoinKey comparable, Relation any] struct {
+	relations []Relation
+	grouped   map[JoinKey][]Relation
+	pages     int
+}
+
+// fetchConcurrently is fetch's loop over the chunks of keys, given by
+// ranges, for up to n chunks at once.
+func (args RelationSpec[JoinKey, Model, Relation]) fetchConcurrently(ctx context.Context, e *Engine, keys []JoinKey, ranges [][2]int, n, maxPages, limit int) (relations []Relation, grouped map[JoinKey][]Relation, pages int, err error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	var (
+		wg         sync.WaitGroup
+		once       sync.Once
+		first      error
+		panics     sync.Once
+		panicValue any // the first chunk's panic, re-raised on this goroutine
+		panicked   bool
+		chunks     = make([]fetchedChunk[JoinKey, Relation], len(ranges))
+		slots      = make(chan struct{}, n)
+		builds     = e.chunkSlots()
+		// The relations and pages of the chunks done so far, checked
+		// against limit and maxPages as each chunk is done.
+		fetched, usedPages atomic.Int64
+	)
+launch:
+	for i, r := range ranges {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			once.Do(func() { first = context.Cause(ctx) })
+			break launch
+		}
+		release, err := builds.acquire(ctx, args.CacheKey)
+		if err != nil {
+			<-slots
+			once.Do(func() { first = err })
+			break launch
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { release(); <-slots; wg.Done() }()
+			defer func() {
+				if r := recover(); r != nil {
+					panics.Do(func() { panicValue, panicked = r, true })
+					once.Do(func() {
+						first = errPanicked
+						cancel(errPanicked)
+					})
+				}
+			}()
+			c := &chunks[i]
+			err := args.limited(ctx, e, func() (err error) {
+				c.relations, c.grouped, c.pages, err = args.fetchChunk(ctx, keys[r[0]:r[1]], nil, nil, int(usedPages.Load()), maxPages, limit)
+				return err
+			})
+			if err == nil {
+				total, used := fetched.Add(int64(len(c.relations))), usedPages.Add(int64(c.pages))
+				switch {
+				case args.FetchPage != nil && used > int64(maxPages):
+					err = fmt.Errorf("%s: key %q: %w: more than %d", packagePrefix, args.CacheKey, errTooManyPages, maxPages)
+				case limit > 0 && total > int64(limit):
+					err = args.tooManyRelations(int(total), limit)
+				}
+			}
+			if err != nil {
+				once.Do(func() {
+					first = err
+					cancel(err)
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	if panicked {
+		panic(panicValue)
+	}
+	for _, c := range chunks {
+		pages += c.pages
+	}
+	if first != nil {
+		return nil, nil, pages, first
+	}
+	for _, c := range chunks {
+		relations = append(relations, c.relations...)
+		if c.grouped != nil {
+			if grouped == nil {
+				grouped = make(map[JoinKey][]Relation)
+			}
+			maps.Copy(grouped, c.grouped)
+		}
+	}
+	return relations, grouped, pages, nil
+}
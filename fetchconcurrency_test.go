@@ -0,0 +1,230 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithFetchConcurrency(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	for _, tc := range []struct {
+		concurrency int
+		wantMax     int32
+	}{{1, 1}, {3, 3}} {
+		var bound [][]*Book
+		eng := NewEngine(WithFetchChunkSize(1), WithFetchConcurrency(tc.concurrency),
+			WithHooks(Hooks{OnRelationsBound: func(_ string, rs any) { bound = append(bound, rs.([]*Book)) }}))
+		authors := make([]*Author, 8)
+		for i := range authors {
+			authors[i] = &Author{ID: i + 1}
+		}
+		eng.InitHandles(authors)
+
+		var inFlight, maxInFlight atomic.Int32
+		spec := RelationSpec[int, *Author, *Book]{
+			CacheKey:    "books",
+			ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+			RelationKey: func(b *Book) int { return b.AuthorID },
+			Fetch: func(_ context.Context, ids []int) ([]*Book, error) {
+				n := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for m := maxInFlight.Load(); n > m && !maxInFlight.CompareAndSwap(m, n); m = maxInFlight.Load() {
+				}
+				time.Sleep(time.Duration(10-ids[0]) * time.Millisecond) // later chunks finish first
+				return []*Book{{ID: ids[0], AuthorID: ids[0]}}, nil
+			},
+		}
+		for _, a := range authors {
+			got, err := Many(ctx, spec.For(a))
+			if err != nil || len(got) != 1 || got[0].AuthorID != a.ID {
+				t.Fatalf("concurrency %d: Many(%d) = %v, %v", tc.concurrency, a.ID, got, err)
+			}
+		}
+		if m := maxInFlight.Load(); m != tc.wantMax {
+			t.Fatalf("concurrency %d: %d fetches in flight at most; want %d", tc.concurrency, m, tc.wantMax)
+		}
+		var ids []int
+		for _, b := range bound[0] {
+			ids = append(ids, b.ID)
+		}
+		if len(bound) != 1 || !slices.Equal(ids, []int{1, 2, 3, 4, 5, 6, 7, 8}) {
+			t.Fatalf("concurrency %d: bound %d fetches of %v; want one in chunk order", tc.concurrency, len(bound), ids)
+		}
+	}
+}
+
+func TestWithFetchConcurrency_ErrorCancelsOtherChunks(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine(WithFetchChunkSize(1), WithFetchConcurrency(3))
+	authors := []*Author{{ID: 1}, {ID: 2}, {ID: 3}}
+	eng.InitHandles(authors)
+
+	errBoom := errors.New("boom")
+	var (
+		started sync.WaitGroup
+		mu      sync.Mutex
+		causes  = map[int]error{}
+	)
+	started.Add(3)
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(ctx context.Context, ids []int) ([]*Book, error) {
+			started.Done()
+			started.Wait()
+			switch ids[0] {
+			case 2:
+				return nil, errBoom
+			case 3:
+				select {
+				case <-ctx.Done():
+					mu.Lock()
+					defer mu.Unlock()
+					causes[3] = context.Cause(ctx)
+					return nil, ctx.Err()
+				case <-time.After(time.Second):
+					return nil, nil
+				}
+			}
+			return nil, nil
+		},
+	}
+	if _, err := Many(ctx, spec.For(authors[0])); !errors.Is(err, errBoom) {
+		t.Fatalf("err = %v; want boom", err)
+	}
+	if want := map[int]error{3: errBoom}; !reflect.DeepEqual(causes, want) {
+		t.Fatalf("cancellation causes = %v; want chunk 3 cancelled by chunk 2's error", causes)
+	}
+}
+
+func TestWithFetchConcurrency_CanceledLaunchIsNotCached(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine(WithFetchChunkSize(1), WithFetchConcurrency(2))
+	authors := []*Author{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}
+	eng.InitHandles(authors)
+
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(_ context.Context, ids []int) ([]*Book, error) {
+			return []*Book{{ID: ids[0], AuthorID: ids[0]}}, nil // ignores ctx
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Many(ctx, spec.For(authors[3])); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v; want Canceled", err)
+	}
+	for _, a := range authors {
+		got, err := Many(context.Background(), spec.For(a))
+		if err != nil || len(got) != 1 {
+			t.Fatalf("Many(%d) after a canceled fetch = %v, %v", a.ID, got, err)
+		}
+	}
+}
+
+func TestWithFetchConcurrency_PanicReachesCaller(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine(WithFetchChunkSize(1), WithFetchConcurrency(3))
+	authors := []*Author{{ID: 1}, {ID: 2}, {ID: 3}}
+	eng.InitHandles(authors)
+
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(_ context.Context, ids []int) ([]*Book, error) {
+			if ids[0] == 2 {
+				panic("kaboom")
+			}
+			return []*Book{{ID: ids[0], AuthorID: ids[0]}}, nil
+		},
+	}
+	func() {
+		defer func() {
+			if r := recover(); r != "kaboom" {
+				t.Fatalf("recovered %v; want the chunk's panic on the calling goroutine", r)
+			}
+		}()
+		Many(context.Background(), spec.For(authors[0]))
+	}()
+}
+
+func TestWithFetchConcurrency_ChunksTakeBuildSlots(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine(WithFetchChunkSize(1), WithFetchConcurrency(4), WithMaxConcurrentBuilds(2))
+	authors := make([]*Author, 8)
+	for i := range authors {
+		authors[i] = &Author{ID: i + 1}
+	}
+	eng.InitHandles(authors)
+
+	var inFlight, maxInFlight atomic.Int32
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(_ context.Context, ids []int) ([]*Book, error) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for m := maxInFlight.Load(); n > m && !maxInFlight.CompareAndSwap(m, n); m = maxInFlight.Load() {
+			}
+			time.Sleep(5 * time.Millisecond)
+			return []*Book{{ID: ids[0], AuthorID: ids[0]}}, nil
+		},
+	}
+	for _, a := range authors {
+		if got, err := Many(context.Background(), spec.For(a)); err != nil || len(got) != 1 {
+			t.Fatalf("Many(%d) = %v, %v", a.ID, got, err)
+		}
+	}
+	if m := maxInFlight.Load(); m != 2 {
+		t.Fatalf("%d chunks in flight at most; want 2, the build limit", m)
+	}
+}
+
+func TestWithFetchConcurrency_MaxRelations(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	for _, tc := range []struct {
+		max     int
+		wantErr bool
+	}{{8, false}, {7, true}, {2, true}} {
+		eng := NewEngine(WithFetchChunkSize(1), WithFetchConcurrency(2))
+		authors := make([]*Author, 8)
+		for i := range authors {
+			authors[i] = &Author{ID: i + 1}
+		}
+		eng.InitHandles(authors)
+
+		var fetches atomic.Int32
+		spec := RelationSpec[int, *Author, *Book]{
+			CacheKey:     "books",
+			ModelKey:     func(a *Author) (int, bool) { return a.ID, true },
+			RelationKey:  func(b *Book) int { return b.AuthorID },
+			MaxRelations: tc.max,
+			Fetch: func(_ context.Context, ids []int) ([]*Book, error) {
+				fetches.Add(1)
+				return []*Book{{ID: ids[0], AuthorID: ids[0]}}, nil
+			},
+		}
+		_, err := Many(ctx, spec.For(authors[0]))
+		if got := errors.Is(err, ErrTooManyRelations); got != tc.wantErr {
+			t.Fatalf("MaxRelations %d: err = %v; want ErrTooManyRelations %v", tc.max, err, tc.wantErr)
+		}
+		// Past the limit, only the chunks already in flight finish.
+		if n := fetches.Load(); tc.max == 2 && n > 4 {
+			t.Fatalf("MaxRelations 2: %d chunks fetched; want the fetch stopped early", n)
+		}
+	}
+}
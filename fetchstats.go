@@ -0,0 +1,120 @@
+package lode
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// WithFetchStats makes the engine record how many join keys the fetches of
+// each cache key and model type are called with, reported as Stats.Fetches.
+// Stats.Anomalies then flags a cache key whose fetches have all had a single
+// key once there have been more than singleKeyFetches of them (batching is
+// not happening for it: typically a per-model CacheKey or models bound one at
+// a time), and a cache key whose largest fetch exceeded keyCeiling keys (it
+// should be chunked).  A zero keyCeiling disables the second check.
+func WithFetchStats(singleKeyFetches, keyCeiling int) ConfigOption {
+	return func(c *Config) {
+		c.fetchStats = true
+		c.singleKeyFetches = singleKeyFetches
+		c.fetchKeyCeiling = keyCeiling
+	}
+}
+
+// FetchStats summarizes the key counts of one cache key's fetches.
+type FetchStats struct {
+	Fetches   int
+	MinKeys   int
+	MaxKeys   int
+	TotalKeys int
+}
+
+// AvgKeys returns the mean number of keys per fetch, or 0 if there were none.
+func (s FetchStats) AvgKeys() float64 {
+	if s.Fetches == 0 {
+		return 0
+	}
+	return float64(s.TotalKeys) / float64(s.Fetches)
+}
+
+// AnomalyKind says what looks wrong with a cache key's fetches.
+type AnomalyKind int
+
+const (
+	// AnomalyUnbatched means every fetch was for a single key.
+	AnomalyUnbatched AnomalyKind = iota + 1
+	// AnomalyOverBroad means a fetch exceeded the configured key ceiling.
+	AnomalyOverBroad
+)
+
+func (k AnomalyKind) String() string {
+	switch k {
+	case AnomalyUnbatched:
+		return "unbatched"
+	case AnomalyOverBroad:
+		return "over-broad"
+	}
+	return fmt.Sprintf("AnomalyKind(%d)", int(k))
+}
+
+// Anomaly is one cache key flagged by Stats.Anomalies.
+type Anomaly struct {
+	CacheKey  string
+	ModelType string
+	Kind      AnomalyKind
+	Stats     FetchStats
+}
+
+// Anomalies returns the cache keys whose fetches look unbatched or over-broad
+// under the thresholds given to WithFetchStats, sorted by cache key and model
+// type.  It
+// returns nil if the engine was not created WithFetchStats.
+func (s Stats) Anomalies() []Anomaly {
+	var out []Anomaly
+	for key, fs := range s.Fetches {
+		if fs.Fetches > s.singleKeyFetches && fs.MaxKeys == 1 {
+			out = append(out, Anomaly{CacheKey: key.CacheKey, ModelType: key.ModelType, Kind: AnomalyUnbatched, Stats: fs})
+		}
+		if s.fetchKeyCeiling > 0 && fs.MaxKeys > s.fetchKeyCeiling {
+			out = append(out, Anomaly{CacheKey: key.CacheKey, ModelType: key.ModelType, Kind: AnomalyOverBroad, Stats: fs})
+		}
+	}
+	slices.SortFunc(out, func(a, b Anomaly) int {
+		return cmp.Or(cmp.Compare(a.CacheKey, b.CacheKey), cmp.Compare(a.ModelType, b.ModelType), cmp.Compare(a.Kind, b.Kind))
+	})
+	return out
+}
+
+type fetchStats struct {
+	mu   sync.Mutex
+	keys map[KeyLabel]*FetchStats
+}
+
+func newFetchStats() *fetchStats {
+	return &fetchStats{keys: make(map[KeyLabel]*FetchStats)}
+}
+
+func (f *fetchStats) record(label KeyLabel, keys int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.keys[label]
+	if s == nil {
+		s = &FetchStats{MinKeys: keys}
+		f.keys[label] = s
+	}
+	s.Fetches++
+	s.TotalKeys += keys
+	s.MinKeys = min(s.MinKeys, keys)
+	s.MaxKeys = max(s.MaxKeys, keys)
+}
+
+func (f *fetchStats) snapshot() map[KeyLabel]FetchStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make(map[KeyLabel]FetchStats, len(f.keys))
+	for k, s := range f.keys {
+		out[k] = *s
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package lode
+
+import (
+	"context"
+	"testing"
+)
+
+func fetchBooksFor(t *testing.T, eng *Engine, cacheKey string, authors ...*Author) {
+	t.Helper()
+	eng.InitHandles(authors)
+	_, err := Many(context.Background(), RelationSpec[int, *Author, *Book]{
+		CacheKey:    cacheKey,
+		Model:       authors[0],
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch:       func(context.Context, []int) ([]*Book, error) { return nil, nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFetchStats_Anomalies(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine(WithFetchStats(3, 100))
+
+	// "unbatched" is only ever fetched one author at a time.
+	for i := 0; i < 4; i++ {
+		fetchBooksFor(t, eng, "unbatched", &Author{ID: i})
+	}
+	// "broad" is fetched once with more keys than the ceiling.
+	var many []*Author
+	for i := 0; i < 150; i++ {
+		many = append(many, &Author{ID: i})
+	}
+	fetchBooksFor(t, eng, "broad", many...)
+	// "healthy" is batched and within the ceiling.
+	fetchBooksFor(t, eng, "healthy", &Author{ID: 1}, &Author{ID: 2})
+	fetchBooksFor(t, eng, "healthy", &Author{ID: 3}, &Author{ID: 4}, &Author{ID: 5}, &Author{ID: 6})
+
+	stats := eng.Stats()
+	want := FetchStats{Fetches: 2, MinKeys: 2, MaxKeys: 4, TotalKeys: 6}
+	if got := stats.Fetches[KeyLabel{ModelType: "*lode.Author", CacheKey: "healthy"}]; got != want {
+		t.Fatalf("healthy = %+v; want %+v", got, want)
+	}
+	if avg := stats.Fetches[KeyLabel{ModelType: "*lode.Author", CacheKey: "healthy"}].AvgKeys(); avg != 3 {
+		t.Fatalf("AvgKeys = %v; want 3", avg)
+	}
+
+	got := stats.Anomalies()
+	if len(got) != 2 ||
+		got[0].CacheKey != "broad" || got[0].Kind != AnomalyOverBroad || got[0].Stats.MaxKeys != 150 ||
+		got[1].CacheKey != "unbatched" || got[1].Kind != AnomalyUnbatched || got[1].Stats.Fetches != 4 {
+		t.Fatalf("Anomalies() = %+v", got)
+	}
+}
+
+func TestFetchStats_UnbatchedNeedsEnoughFetches(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine(WithFetchStats(3, 0))
+	for i := 0; i < 3; i++ {
+		fetchBooksFor(t, eng, "books", &Author{ID: i})
+	}
+	if got := eng.Stats().Anomalies(); got != nil {
+		t.Fatalf("Anomalies() = %+v after only 3 fetches", got)
+	}
+	fetchBooksFor(t, eng, "books", &Author{ID: 9})
+	if got := eng.Stats().Anomalies(); len(got) != 1 || got[0].Kind != AnomalyUnbatched {
+		t.Fatalf("Anomalies() = %+v", got)
+	}
+}
+
+func TestFetchStats_Disabled(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine()
+	fetchBooksFor(t, eng, "books", &Author{ID: 1})
+	if s := eng.Stats(); s.Fetches != nil || s.Anomalies() != nil {
+		t.Fatalf("stats recorded without WithFetchStats: %+v", s)
+	}
+}
@@ -0,0 +1,57 @@
+package lode
+
+import "context"
+
+// FieldSpec describes one scalar per model, such as the title of an author's
+// latest book, fetched for a whole batch as a map from join key to value.
+// Only the map is cached, not the rows it was read from.
+type FieldSpec[JoinKey comparable, Model hasState, V any] struct {
+	CacheKey string
+	Model    Model
+	ModelKey func(Model) (key JoinKey, ok bool)
+	// Fetch returns the value for each of keys that has one; see
+	// lodegorm.FetchPluck.
+	Fetch func(ctx context.Context, keys []JoinKey) (map[JoinKey]V, error)
+
+	// NilModel says what to do when Model is nil; see NilModelPolicy.
+	NilModel NilModelPolicy
+}
+
+// For returns a copy of the spec with Model set to m.
+func (spec FieldSpec[JoinKey, Model, V]) For(m Model) FieldSpec[JoinKey, Model, V] {
+	spec.Model = m
+	return spec
+}
+
+// Field returns spec.Model's value, fetching the values of all of its
+// siblings at once.  Models without a key, or whose key Fetch left out of
+// its map, get the zero V.
+func Field[JoinKey comparable, Model hasState, V any](ctx context.Context, spec FieldSpec[JoinKey, Model, V]) (V, error) {
+	var zero V
+	if isNil(spec.Model) {
+		return zero, spec.NilModel.nilModel(spec.CacheKey, nil)
+	}
+	if _, ok := spec.ModelKey(spec.Model); !ok {
+		return zero, nil
+	}
+	return Resolve(ctx, ResolveSpec[Model, V]{
+		CacheKey: spec.CacheKey,
+		Model:    spec.Model,
+		Build: func(ctx context.Context, models []Model) (ResolverFunc[Model, V], error) {
+			keys := RelationSpec[JoinKey, Model, V]{ModelKey: spec.ModelKey}.modelKeys(models)
+			var values map[JoinKey]V
+			if len(keys) > 0 { // see Many on empty key sets
+				var err error
+				if values, err = spec.Fetch(ctx, keys); err != nil {
+					return nil, err
+				}
+			}
+			return func(m Model) V {
+				if k, ok := spec.ModelKey(m); ok {
+					return values[k]
+				}
+				return zero
+			}, nil
+		},
+	})
+}
@@ -0,0 +1,62 @@
+package lode
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestField_String(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+	authors := []*Author{{ID: 1}, {ID: 2}, {ID: 0}}
+	eng.InitHandles(authors)
+
+	fetches := 0
+	spec := FieldSpec[int, *Author, string]{
+		CacheKey: "latest_title",
+		ModelKey: func(a *Author) (int, bool) { return a.ID, a.ID != 0 },
+		Fetch: func(_ context.Context, keys []int) (map[int]string, error) {
+			fetches++
+			if len(keys) != 2 {
+				t.Errorf("fetched keys %v; want 1 and 2", keys)
+			}
+			return map[int]string{1: "Dune"}, nil
+		},
+	}
+	for _, tc := range []struct {
+		a    *Author
+		want string
+	}{{authors[0], "Dune"}, {authors[1], ""}, {authors[2], ""}} {
+		got, err := Field(ctx, spec.For(tc.a))
+		if err != nil || got != tc.want {
+			t.Fatalf("Field(%d) = %q, %v; want %q", tc.a.ID, got, err, tc.want)
+		}
+	}
+	if fetches != 1 {
+		t.Fatalf("fetched %d times; want 1", fetches)
+	}
+}
+
+func TestField_Time(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	NewEngine().InitHandles([]*Author{a1, a2})
+
+	published := time.Date(1965, 8, 1, 0, 0, 0, 0, time.UTC)
+	spec := FieldSpec[int, *Author, time.Time]{
+		CacheKey: "last_published",
+		ModelKey: func(a *Author) (int, bool) { return a.ID, true },
+		Fetch: func(context.Context, []int) (map[int]time.Time, error) {
+			return map[int]time.Time{1: published}, nil
+		},
+	}
+	if got, err := Field(ctx, spec.For(a1)); err != nil || !got.Equal(published) {
+		t.Fatalf("Field(1) = %v, %v; want %v", got, err, published)
+	}
+	if got, err := Field(ctx, spec.For(a2)); err != nil || !got.IsZero() {
+		t.Fatalf("Field(2) = %v, %v; want the zero time", got, err)
+	}
+}
@@ -0,0 +1,21 @@
+package lode
+
+import "context"
+
+// Global returns the engine-wide value for key, building it once with build
+// and sharing it with every state the engine binds: a lookup that is the same
+// for every batch (the current user's permissions, say) is then built once
+// rather than once per batch.  Like per-state resolvers, the first caller's
+// build is shared by concurrent callers, a failed build is retried, and keys
+// are namespaced and guarded by the circuit breaker.  WithImmutableKeys does
+// the same for Resolve keys.  Builds of per-state resolvers may call Global.
+// Scopes have their own globals.
+func Global[T any](ctx context.Context, e *Engine, key string, build func(ctx context.Context) (T, error)) (T, error) {
+	return GetOrBuildAs(ctx, e.entry(&e.globals, e.key(key)), build)
+}
+
+// InvalidateGlobal drops the engine's cached value for key, so the next
+// Global call builds it again.
+func (e *Engine) InvalidateGlobal(key string) {
+	e.globals.Delete(e.key(key))
+}
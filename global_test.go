@@ -0,0 +1,111 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestGlobal_SharedAcrossBatches(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+
+	var globalBuilds int
+	perms := func(ctx context.Context) (map[int]bool, error) {
+		globalBuilds++
+		return map[int]bool{1: true, 3: true}, nil
+	}
+	canSee := func(a *Author) bool {
+		t.Helper()
+		got, err := Resolve(ctx, ResolveSpec[*Author, bool]{
+			CacheKey: "visible",
+			Model:    a,
+			Build: func(ctx context.Context, models []*Author) (ResolverFunc[*Author, bool], error) {
+				// Reading a global from inside a build must not deadlock.
+				p, err := Global(ctx, eng, "perms", perms)
+				if err != nil {
+					return nil, err
+				}
+				return func(a *Author) bool { return p[a.ID] }, nil
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	batch1 := []*Author{{ID: 1}, {ID: 2}}
+	batch2 := []*Author{{ID: 3}, {ID: 4}}
+	eng.InitHandles(batch1)
+	eng.InitHandles(batch2)
+
+	if !canSee(batch1[0]) || canSee(batch1[1]) || !canSee(batch2[0]) || canSee(batch2[1]) {
+		t.Fatal("wrong visibility")
+	}
+	if globalBuilds != 1 {
+		t.Fatalf("global builds = %d; want 1", globalBuilds)
+	}
+
+	eng.InvalidateGlobal("perms")
+	if _, err := Global(ctx, eng, "perms", perms); err != nil || globalBuilds != 2 {
+		t.Fatalf("after InvalidateGlobal: builds = %d, err = %v", globalBuilds, err)
+	}
+}
+
+func TestGlobal_ErrorsAndTypes(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+
+	errBoom := errors.New("boom")
+	calls := 0
+	failing := func(context.Context) (int, error) { calls++; return 0, errBoom }
+	for range 2 {
+		if _, err := Global(ctx, eng, "settings", failing); !errors.Is(err, errBoom) {
+			t.Fatalf("err = %v; want boom", err)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d; the failed build should be retried", calls)
+	}
+
+	if _, err := Global(ctx, eng, "n", func(context.Context) (int, error) { return 1, nil }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Global(ctx, eng, "n", func(context.Context) (string, error) { return "", nil }); err == nil {
+		t.Fatal("want an incompatible type error")
+	}
+
+	// Engines, and scopes, keep their own globals.
+	other := eng.Scope()
+	if v, err := Global(ctx, other, "n", func(context.Context) (int, error) { return 2, nil }); err != nil || v != 2 {
+		t.Fatalf("scope global = %d, %v", v, err)
+	}
+}
+
+func TestGlobal_ConcurrentCallersShareOneBuild(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine()
+	var mu sync.Mutex
+	builds := 0
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = Global(context.Background(), eng, "k", func(context.Context) (int, error) {
+				mu.Lock()
+				builds++
+				mu.Unlock()
+				return 1, nil
+			})
+		}()
+	}
+	wg.Wait()
+	if builds != 1 {
+		t.Fatalf("builds = %d; want 1", builds)
+	}
+}
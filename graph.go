@@ -0,0 +1,120 @@
+package lode
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithGraphTrace makes the engine record the relation graph its fetches walk:
+// an edge from a model type to the relation type fetched for it under a cache
+// key, with how many fetches, keys, and relations went over it and how long
+// they took.  Engine.Graph returns the edges, and Engine.GraphDOT and
+// Engine.GraphMermaid render them, which is a quick way to see what a request
+// actually loaded.  Scopes share their parent's trace.
+func WithGraphTrace() ConfigOption {
+	return func(c *Config) { c.graphTrace = true }
+}
+
+// GraphEdge is one edge of the relation graph recorded by WithGraphTrace.
+type GraphEdge struct {
+	From     string // the model type, e.g. "*app.Author"
+	To       string // the relation type, e.g. "*app.Book"
+	CacheKey string
+	// Fetches is the number of fetches made over the edge, and Keys and
+	// Relations their total join keys and relations.
+	Fetches   int
+	Keys      int
+	Relations int
+	Duration  time.Duration // total fetch time
+}
+
+type graphTrace struct {
+	mu    sync.Mutex
+	edges map[[3]string]*GraphEdge // from, cache key, to
+}
+
+func (g *graphTrace) record(ev FetchEvent, to string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	k := [3]string{ev.ModelType, ev.CacheKey, to}
+	edge := g.edges[k]
+	if edge == nil {
+		edge = &GraphEdge{From: ev.ModelType, To: to, CacheKey: ev.CacheKey}
+		g.edges[k] = edge
+	}
+	edge.Fetches++
+	edge.Keys += ev.Keys
+	edge.Relations += ev.Relations
+	edge.Duration += ev.Duration
+}
+
+// Graph returns the edges recorded so far, sorted by model type, cache key,
+// and relation type.  It returns nil if the engine was not created
+// WithGraphTrace.
+func (e *Engine) Graph() []GraphEdge {
+	g := e.graph
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	out := make([]GraphEdge, 0, len(g.edges))
+	for _, edge := range g.edges {
+		out = append(out, *edge)
+	}
+	g.mu.Unlock()
+	slices.SortFunc(out, func(a, b GraphEdge) int {
+		return cmp.Or(cmp.Compare(a.From, b.From), cmp.Compare(a.CacheKey, b.CacheKey), cmp.Compare(a.To, b.To))
+	})
+	return out
+}
+
+// ResetGraph clears the recorded edges, say between requests sharing an
+// engine.
+func (e *Engine) ResetGraph() {
+	if g := e.graph; g != nil {
+		g.mu.Lock()
+		clear(g.edges)
+		g.mu.Unlock()
+	}
+}
+
+// GraphDOT renders Graph in Graphviz DOT, one node per type and one edge per
+// cache key.
+func (e *Engine) GraphDOT() string {
+	var b strings.Builder
+	b.WriteString("digraph lode {\n")
+	for _, edge := range e.Graph() {
+		fmt.Fprintf(&b, "\t%q -> %q [label=%q];\n", edge.From, edge.To, edge.CacheKey+"\n"+edge.summary())
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// GraphMermaid renders Graph as a Mermaid flowchart.
+func (e *Engine) GraphMermaid() string {
+	edges := e.Graph()
+	ids := make(map[string]string)
+	var types []string
+	for _, edge := range edges {
+		types = append(types, edge.From, edge.To)
+	}
+	slices.Sort(types)
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, t := range slices.Compact(types) {
+		ids[t] = fmt.Sprintf("n%d", len(ids))
+		fmt.Fprintf(&b, "\t%s[\"%s\"]\n", ids[t], t)
+	}
+	for _, edge := range edges {
+		fmt.Fprintf(&b, "\t%s -->|\"%s: %s\"| %s\n", ids[edge.From], edge.CacheKey, edge.summary(), ids[edge.To])
+	}
+	return b.String()
+}
+
+func (edge GraphEdge) summary() string {
+	return fmt.Sprintf("%d fetches, %d relations, %v", edge.Fetches, edge.Relations, edge.Duration.Round(time.Microsecond))
+}
@@ -0,0 +1,79 @@
+package lode
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGraphTrace(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine(WithGraphTrace(), WithBatchSize(1))
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles([]*Author{a1, a2})
+	books := threeBooks(map[int]int{})
+	bookAuthor := RelationSpec[int, *Book, *Author]{
+		CacheKey:    "author",
+		ModelKey:    func(b *Book) (int, bool) { return b.AuthorID, true },
+		RelationKey: func(a *Author) int { return a.ID },
+		Fetch: func(_ context.Context, ids []int) ([]*Author, error) {
+			out := make([]*Author, len(ids))
+			for i, id := range ids {
+				out[i] = &Author{ID: id}
+			}
+			return out, nil
+		},
+	}
+
+	for _, a := range []*Author{a1, a2, a1} {
+		bs, err := Many(ctx, books.For(a))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := One(ctx, bookAuthor.For(bs[0])); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	graph := eng.Graph()
+	if len(graph) != 2 {
+		t.Fatalf("Graph = %+v; want 2 edges", graph)
+	}
+	if e := graph[0]; e.From != "*lode.Author" || e.To != "*lode.Book" || e.CacheKey != "books" || e.Fetches != 2 || e.Keys != 2 || e.Relations != 6 {
+		t.Errorf("books edge = %+v", e)
+	}
+	if e := graph[1]; e.From != "*lode.Book" || e.To != "*lode.Author" || e.CacheKey != "author" || e.Fetches != 2 || e.Relations != 2 {
+		t.Errorf("author edge = %+v", e)
+	}
+
+	dot := eng.GraphDOT()
+	for _, want := range []string{
+		"digraph lode {\n",
+		`"*lode.Author" -> "*lode.Book" [label="books\n2 fetches, 6 relations, `,
+		`"*lode.Book" -> "*lode.Author" [label="author\n2 fetches, 2 relations, `,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("GraphDOT is missing %s:\n%s", want, dot)
+		}
+	}
+	mermaid := eng.GraphMermaid()
+	for _, want := range []string{
+		`n0["*lode.Author"]`,
+		`n1["*lode.Book"]`,
+		`n0 -->|"books: 2 fetches, 6 relations, `,
+		`n1 -->|"author: 2 fetches, 2 relations, `,
+	} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("GraphMermaid is missing %s:\n%s", want, mermaid)
+		}
+	}
+
+	eng.ResetGraph()
+	if g := eng.Graph(); len(g) != 0 {
+		t.Fatalf("Graph after ResetGraph = %+v", g)
+	}
+	if g := NewEngine().Graph(); g != nil || !eng.Config().GraphTrace() {
+		t.Fatalf("untraced Graph = %+v", g)
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !lodestrict
+
+package lode
+
+// handleComparison leaves Handle comparable; see Handle.
+type handleComparison struct{}
@@ -0,0 +1,6 @@
+//go:build lodestrict
+
+package lode
+
+// handleComparison makes Handle non-comparable; see Handle.
+type handleComparison [0]func()
@@ -0,0 +1,107 @@
+package lode
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Registry binds models that do not embed Handle.  It keeps a Ref for each
+// registered model in a side table keyed by the model's address; *Ref[T]
+// embeds Handle, so specs over *Ref[T] work with Resolve, Many, One, and
+// the rest exactly as specs over embedding models do, batching included:
+//
+//	reg := lode.NewRegistry()
+//	lode.Register(reg, authors) // authors is []*Author
+//
+//	var authorBooks = lode.RelationSpec[uint, *lode.Ref[Author], *Book]{
+//		CacheKey: "books",
+//		ModelKey: func(r *lode.Ref[Author]) (uint, bool) { return r.Model.ID, true },
+//		...
+//	}
+//
+//	ref, err := lode.Lookup(reg, author)
+//	books, err := lode.Many(ctx, authorBooks.For(ref))
+//
+// Go methods cannot have type parameters, which is why Register and Lookup
+// are functions taking the registry.
+//
+// The registry holds its refs, and through their states every model of their
+// batches, until Release: entries are not dropped when a model becomes
+// otherwise unreachable.  Weak references cannot do it, because a state
+// must hold its models for builds, so a weakly keyed entry would keep its
+// own key alive.  Release models when the request or job that loaded them
+// ends, as with Engine.ResetAll; embedding Handle remains the way to get
+// caches that live exactly as long as their models.
+type Registry struct {
+	engine *Engine
+	refs   sync.Map // model pointer -> *Ref[T]
+}
+
+// Ref is a registered model's stand-in for an embedded Handle; see Registry.
+type Ref[T any] struct {
+	Handle
+	Model *T
+}
+
+// NewRegistry returns a registry binding through a new engine configured by
+// opts.
+func NewRegistry(opts ...ConfigOption) *Registry {
+	return &Registry{engine: NewEngine(opts...)}
+}
+
+// Engine returns the engine the registry binds through, for its resets,
+// hooks, and stats.
+func (r *Registry) Engine() *Engine { return r.engine }
+
+// Register is Engine.InitHandles for models that do not embed Handle: it
+// gives each a Ref, reusing the one it has if already registered, and binds
+// the refs together (in batches of WithBatchSize).  It returns the refs,
+// nil for nil models.
+func Register[T any](r *Registry, models []*T) []*Ref[T] {
+	refs := make([]*Ref[T], len(models))
+	for i, m := range models {
+		if m == nil {
+			continue
+		}
+		v, _ := r.refs.LoadOrStore(m, &Ref[T]{Model: m})
+		refs[i] = v.(*Ref[T])
+	}
+	r.engine.InitHandles(refs)
+	return refs
+}
+
+// Lookup returns m's Ref, or an error if m is not registered with r.
+func Lookup[T any](r *Registry, m *T) (*Ref[T], error) {
+	if v, ok := r.refs.Load(m); ok && m != nil {
+		return v.(*Ref[T]), nil
+	}
+	return nil, fmt.Errorf("%s: %w: %T at %p is not registered", packagePrefix, ErrNotInitialized, m, m)
+}
+
+// Release drops the registry's entries for models, a model pointer or a
+// slice of them, so they (and, once no other entry refers to it, their
+// batch's state) can be collected.  Refs already looked up keep working.
+func (r *Registry) Release(models any) {
+	v := reflect.ValueOf(models)
+	switch v.Kind() {
+	case reflect.Ptr:
+		r.refs.Delete(models)
+	case reflect.Slice:
+		for i := range v.Len() {
+			if el := v.Index(i); el.Kind() == reflect.Ptr && !el.IsNil() {
+				r.refs.Delete(el.Interface())
+			}
+		}
+	}
+}
+
+// Len returns the number of registered models.
+func (r *Registry) Len() int {
+	n := 0
+	r.refs.Range(func(any, any) bool {
+		n++
+		return true
+	})
+	return n
+}
@@ -0,0 +1,61 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// plainAuthor does not embed Handle.
+type plainAuthor struct{ ID int }
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	reg := NewRegistry(WithBatchSize(2))
+	authors := []*plainAuthor{{ID: 1}, {ID: 2}, nil, {ID: 3}}
+	refs := Register(reg, authors)
+	if refs[2] != nil || refs[0].Model != authors[0] || reg.Len() != 3 {
+		t.Fatalf("Register = %v, Len = %d", refs, reg.Len())
+	}
+
+	var fetched [][]int
+	spec := RelationSpec[int, *Ref[plainAuthor], *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(r *Ref[plainAuthor]) (int, bool) { return r.Model.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(_ context.Context, ids []int) ([]*Book, error) {
+			fetched = append(fetched, ids)
+			return []*Book{{ID: 10, AuthorID: 2}}, nil
+		},
+	}
+	for _, a := range []*plainAuthor{authors[0], authors[1]} {
+		ref, err := Lookup(reg, a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		books, err := Many(ctx, spec.For(ref))
+		if err != nil || len(books) != a.ID-1 {
+			t.Fatalf("Many(%d) = %v, %v", a.ID, titles(books), err)
+		}
+	}
+	if len(fetched) != 1 || len(fetched[0]) != 2 {
+		t.Fatalf("fetched %v; want one fetch for the first batch of 2", fetched)
+	}
+
+	// Registering again reuses the refs, and so their caches.
+	if again := Register(reg, authors[:2]); again[1] != refs[1] {
+		t.Fatal("Register made a new Ref for a registered model")
+	}
+	if _, err := Many(ctx, spec.For(refs[1])); err != nil || len(fetched) != 1 {
+		t.Fatalf("Many after re-Register err = %v, %d fetches", err, len(fetched))
+	}
+
+	reg.Release(authors)
+	if _, err := Lookup(reg, authors[0]); !errors.Is(err, ErrNotInitialized) || reg.Len() != 0 {
+		t.Fatalf("Lookup after Release err = %v, Len = %d", err, reg.Len())
+	}
+	if _, err := Many(ctx, spec.For(refs[0])); err != nil {
+		t.Fatalf("released Ref: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package lode
+
+import "time"
+
+// Hooks are callbacks the engine invokes as it works, for logging, metrics,
+// and tracing.  Every field is optional, and hooks may be called from several
+// goroutines at once.
+type Hooks struct {
+	// OnFetch is called after each relation fetch made by Many.
+	OnFetch func(FetchEvent)
+	// OnSkipped is called when a Many build drops fetched relations that
+	// are nil or that RelationKeyOK cannot place.  It is not called for
+	// builds that drop nothing.
+	OnSkipped func(SkipEvent)
+	// OnReset is called when a state is reset, by Handle.Reset, State.Reset,
+	// BindResult.ResetAll, or Engine.ResetAll.
+	OnReset func(ResetEvent)
+	// OnInvalidate is called for each cache key cleared by Handle.Invalidate,
+	// Handle.ResetPrefix, or Engine.InvalidateKey (once per live state).
+	OnInvalidate func(InvalidateEvent)
+	// OnWarning is called with the problems found by WithDebug checks and
+	// reported with Warn.
+	OnWarning func(WarningEvent)
+	// OnBuildWait is called as each build gets (or gives up on) a slot
+	// under WithMaxConcurrentBuilds, with how long it waited.
+	OnBuildWait func(BuildWaitEvent)
+	// OnRelationsBound is called once per Many fetch, after the fetched
+	// relations are bound, with the cache key and the relations as a
+	// []Relation (nil ones dropped), say to enrich them or register them in
+	// an identity map.  Chunked fetches report their combined relations;
+	// each partition of a PartitionBy spec is a fetch of its own.  The
+	// relations are passed even if they are not bound (see
+	// RelationSpec.BindRelations).
+	OnRelationsBound func(cacheKey string, relations any)
+	// OnBuild is called as each build of a cache key finishes, successful
+	// or not.  Cache hits do not build.
+	OnBuild func(BuildEvent)
+	// OnFallback is called for each call served for an unbound model
+	// under WithFallbackFetch.
+	OnFallback func(FallbackEvent)
+	// OnLimiterWait is called as each fetch call gets (or gives up on) its
+	// spec's Limiter, with how long it queued.
+	OnLimiterWait func(LimiterWaitEvent)
+	// OnWarmError is called for each warm build started by Engine.WarmFrom
+	// that fails or panics; no caller is waiting to see its error.
+	OnWarmError func(WarmErrorEvent)
+}
+
+// SkipEvent describes the relations dropped by one Many build.
+type SkipEvent struct {
+	CacheKey string
+	// Nil is the number of nil relations dropped.
+	Nil int
+	// Unplaced is the number of relations RelationKeyOK had no key for.
+	Unplaced int
+}
+
+// ResetEvent describes one state reset.
+type ResetEvent struct {
+	// ModelType is the bound model type, e.g. "*app.Author".
+	ModelType string
+	// KeysCleared is the number of cached resolvers the reset dropped.
+	KeysCleared int
+	// Generation is the state's generation after the reset; see
+	// State.Generation.
+	Generation uint64
+}
+
+// InvalidateEvent describes one cache key cleared on a state.
+type InvalidateEvent struct {
+	CacheKey   string
+	ModelType  string
+	Generation uint64
+}
+
+// FetchEvent describes one relation fetch.
+type FetchEvent struct {
+	CacheKey  string
+	ModelType string // the type the fetch was for, e.g. "*app.Author"
+	// Keys is the number of join keys fetched.
+	Keys int
+	// Relations is the number of relations returned.
+	Relations int
+	// Pages is the number of FetchPage calls made, or 1 for Fetch.
+	Pages    int
+	Duration time.Duration
+	Err      error
+}
+
+// WithHooks registers hooks on the engine.  It may be given several times;
+// every registered hook is called, in registration order.
+func WithHooks(h Hooks) ConfigOption {
+	return func(c *Config) { c.hooks = append(c.hooks, h) }
+}
+
+func (e *Engine) onFetch(ev FetchEvent) {
+	if e.fetches != nil {
+		e.fetches.record(KeyLabel{ModelType: ev.ModelType, CacheKey: ev.CacheKey}, ev.Keys)
+	}
+	for _, h := range e.config.hooks {
+		if h.OnFetch != nil {
+			h.OnFetch(ev)
+		}
+	}
+}
+
+func (e *Engine) onRelationsBound(cacheKey string, relations any) {
+	for _, h := range e.config.hooks {
+		if h.OnRelationsBound != nil {
+			h.OnRelationsBound(cacheKey, relations)
+		}
+	}
+}
+
+func (e *Engine) onSkipped(ev SkipEvent) {
+	if ev.Nil == 0 && ev.Unplaced == 0 {
+		return
+	}
+	e.skipped.Add(uint64(ev.Nil + ev.Unplaced))
+	for _, h := range e.config.hooks {
+		if h.OnSkipped != nil {
+			h.OnSkipped(ev)
+		}
+	}
+}
+
+func (e *Engine) onReset(ev ResetEvent) {
+	for _, h := range e.config.hooks {
+		if h.OnReset != nil {
+			h.OnReset(ev)
+		}
+	}
+}
+
+func (e *Engine) onInvalidate(ev InvalidateEvent) {
+	for _, h := range e.config.hooks {
+		if h.OnInvalidate != nil {
+			h.OnInvalidate(ev)
+		}
+	}
+}
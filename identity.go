@@ -0,0 +1,23 @@
+package lode
+
+// KeyFor returns id(m), m's identity for map keys, or the zero K for a nil m.
+// Key maps of models by it rather than by the models themselves; see Handle.
+func KeyFor[T any, K comparable](m *T, id func(*T) K) K {
+	if m == nil {
+		var zero K
+		return zero
+	}
+	return id(m)
+}
+
+// IndexBy returns models keyed by KeyFor(m, id), skipping nil models; of
+// models with the same key, the last wins.
+func IndexBy[T any, K comparable](models []*T, id func(*T) K) map[K]*T {
+	out := make(map[K]*T, len(models))
+	for _, m := range models {
+		if m != nil {
+			out[id(m)] = m
+		}
+	}
+	return out
+}
@@ -0,0 +1,45 @@
+package lode
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestKeyForAndIndexBy(t *testing.T) {
+	t.Parallel()
+	id := func(a *Author) int { return a.ID }
+	a1, a1again, a2 := &Author{ID: 1}, &Author{ID: 1}, &Author{ID: 2}
+	NewEngine().InitHandles([]*Author{a1, a2})
+	NewEngine().InitHandles(a1again)
+
+	if KeyFor(a1, id) != KeyFor(a1again, id) || KeyFor[Author](nil, id) != 0 {
+		t.Fatal("KeyFor does not key by identity")
+	}
+	index := IndexBy([]*Author{a1, nil, a2, a1again}, id)
+	if len(index) != 2 || index[1] != a1again || index[2] != a2 {
+		t.Fatalf("IndexBy = %v", index)
+	}
+}
+
+// TestHandle_StrictNotComparable builds testdata/mapkey, which keys a map by
+// models, with and without the lodestrict tag.
+func TestHandle_StrictNotComparable(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go build")
+	}
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+	if out, err := exec.Command(gobin, "build", "./testdata/mapkey").CombinedOutput(); err != nil {
+		t.Fatalf("build without lodestrict failed: %v\n%s", err, out)
+	}
+	out, err := exec.Command(gobin, "build", "-tags", "lodestrict", "./testdata/mapkey").CombinedOutput()
+	if err == nil {
+		t.Fatal("build with lodestrict passed; want an invalid map key error")
+	}
+	if !strings.Contains(string(out), "invalid map key type") {
+		t.Fatalf("build output lacks the map key error:\n%s", out)
+	}
+}
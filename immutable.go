@@ -0,0 +1,64 @@
+package lode
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+var errImmutableRelation = &kindError{"immutable key used with Many or One", ErrMisconfigured}
+
+// WithImmutableKeys makes Resolve cache the given keys once per model type
+// for the engine and its scopes, rather than once per state, for reference
+// data that never changes within a deploy: country lists, currencies, plan
+// catalogs.  The first batch to resolve such a key builds it, and every
+// later batch of the same model type, in any request, is served that
+// resolver, so Build must return one that serves models it was not given.
+// Many and One fetch per batch, so they fail for immutable keys.
+//
+// The values outlive Reset, Invalidate, ResetPrefix, and Engine.ResetAll;
+// only Engine.InvalidateKey and, for values cached under a replaced key
+// version, Engine.PurgeOtherVersions remove them.
+func WithImmutableKeys(keys ...string) ConfigOption {
+	return func(c *Config) {
+		c.immutableKeys = append(slices.Clone(c.immutableKeys), keys...)
+	}
+}
+
+// immutableEntries returns the entries of the immutable keys of modelType,
+// if cacheKey, a stored key, is one.
+func (e *Engine) immutableEntries(cacheKey, modelType string) (*sync.Map, bool) {
+	if len(e.config.immutableKeys) == 0 || !slices.Contains(e.config.immutableKeys, e.callerKey(cacheKey)) {
+		return nil, false
+	}
+	v, _ := e.immutable.LoadOrStore(modelType, new(sync.Map))
+	return v.(*sync.Map), true
+}
+
+// checkRelationKey returns an error if key, as written in a RelationSpec, is
+// immutable: its relations are fetched for one batch and would be served to
+// every other.
+func (e *Engine) checkRelationKey(key string) error {
+	if slices.Contains(e.config.immutableKeys, key) {
+		return fmt.Errorf("%s: key %q: %w", packagePrefix, key, errImmutableRelation)
+	}
+	return nil
+}
+
+// deleteImmutable removes the immutable entries, of every model type, whose
+// stored key matches, and returns how many it removed.
+func (e *Engine) deleteImmutable(match func(cacheKey string) bool) int {
+	n := 0
+	e.immutable.Range(func(_, entries any) bool {
+		entries.(*sync.Map).Range(func(k, _ any) bool {
+			if match(k.(string)) {
+				if _, ok := entries.(*sync.Map).LoadAndDelete(k); ok {
+					n++
+				}
+			}
+			return true
+		})
+		return true
+	})
+	return n
+}
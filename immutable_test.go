@@ -0,0 +1,118 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestImmutableKeys_OneBuildPerProcess(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine(WithImmutableKeys("countries"))
+	builds := map[string]int{}
+	countries := func(key string) ResolveSpec[*Author, []string] {
+		return ResolveSpec[*Author, []string]{
+			CacheKey: key,
+			Build: func(context.Context, []*Author) (ResolverFunc[*Author, []string], error) {
+				builds[key]++
+				list := []string{"FR", "JP"}
+				return func(*Author) []string { return list }, nil
+			},
+		}
+	}
+
+	// Two batches of one request, and one of another request's scope.
+	first := []*Author{{ID: 1}, {ID: 2}}
+	second := []*Author{{ID: 3}}
+	other := []*Author{{ID: 4}}
+	eng.InitHandles(first)
+	eng.InitHandles(second)
+	eng.Scope().InitHandles(other)
+	for _, a := range [][]*Author{first, second, other} {
+		for _, key := range []string{"countries", "perBatch"} {
+			if got, err := Resolve(ctx, countries(key).For(a[0])); err != nil || len(got) != 2 {
+				t.Fatalf("%s: %v, %v", key, got, err)
+			}
+		}
+	}
+	if builds["countries"] != 1 || builds["perBatch"] != 3 {
+		t.Fatalf("builds = %v; want countries once and perBatch per batch", builds)
+	}
+
+	// Resets of a state leave the value; InvalidateKey drops it.
+	if err := first[0].Reset(); err != nil {
+		t.Fatal(err)
+	}
+	Resolve(ctx, countries("countries").For(first[0]))
+	if builds["countries"] != 1 {
+		t.Fatal("Reset dropped an immutable value")
+	}
+	if n, err := eng.InvalidateKey("countries"); n != 1 || err != nil {
+		t.Fatalf("InvalidateKey = %d, %v; want 1 entry", n, err)
+	}
+	Resolve(ctx, countries("countries").For(second[0]))
+	Resolve(ctx, countries("countries").For(other[0]))
+	if builds["countries"] != 2 {
+		t.Fatalf("builds after InvalidateKey = %d; want 2", builds["countries"])
+	}
+
+	// Each model type has its own value.
+	book := &Book{ID: 1}
+	eng.InitHandles(book)
+	n, err := Resolve(ctx, ResolveSpec[*Book, int]{
+		CacheKey: "countries",
+		Model:    book,
+		Build: func(context.Context, []*Book) (ResolverFunc[*Book, int], error) {
+			return func(*Book) int { return 7 }, nil
+		},
+	})
+	if err != nil || n != 7 {
+		t.Fatalf("books' countries = %d, %v", n, err)
+	}
+}
+
+func TestImmutableKeys_PurgeOtherVersions(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine(WithImmutableKeys("plans"), WithKeyVersion("v1"))
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+	warm(t, a, "plans")
+
+	eng.SetKeyVersion("v2")
+	warm(t, a, "plans")
+	if n, err := eng.PurgeOtherVersions(); n != 1 || err != nil {
+		t.Fatalf("PurgeOtherVersions = %d, %v; want the v1 value", n, err)
+	}
+	entries, _ := eng.immutableEntries(eng.key("plans"), "*lode.Author")
+	if _, ok := entries.Load("plans@v2"); !ok {
+		t.Fatal("the current version's value was purged")
+	}
+	if _, err := Resolve(ctx, ResolveSpec[*Author, int]{CacheKey: "plans", Model: a}); err != nil {
+		t.Fatal(err) // served from the cache: Build is nil
+	}
+}
+
+func TestImmutableKeys_RejectedForRelations(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine(WithImmutableKeys("books"))
+	first, second := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles(first)
+	eng.InitHandles(second)
+
+	fetches := map[int]int{}
+	spec := threeBooks(fetches)
+	for _, a := range []*Author{first, second} {
+		if _, err := Many(ctx, spec.For(a)); !errors.Is(err, errImmutableRelation) {
+			t.Fatalf("Many for author %d = %v; want errImmutableRelation", a.ID, err)
+		}
+		if _, err := One(ctx, spec.For(a)); !errors.Is(err, errImmutableRelation) {
+			t.Fatalf("One for author %d = %v; want errImmutableRelation", a.ID, err)
+		}
+	}
+	if len(fetches) != 0 {
+		t.Fatalf("fetched %v for an immutable key", fetches)
+	}
+}
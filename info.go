@@ -0,0 +1,40 @@
+package lode
+
+import "time"
+
+// Info describes how ResolveInfo, ManyInfo, or OneInfo obtained a result.
+type Info struct {
+	// CacheHit is true when the result came from a resolver this call did
+	// not build, including one built concurrently by a sibling it waited on.
+	CacheHit bool
+	// BuildDuration is how long the build took; zero on a cache hit.
+	BuildDuration time.Duration
+	// KeyCount is the number of join keys fetched by the build that
+	// produced the resolver, whether or not this call ran it.  It is zero
+	// for plain Resolve specs.
+	KeyCount int
+	// Stale is true when the result is the one cached before an
+	// invalidation, served while the rebuild runs; see
+	// ServeStaleDuringRebuild.  CacheHit is true too.
+	Stale bool
+	// Size is the size charged for the result under WithMemoryBudget,
+	// whether or not this call built it.
+	Size int
+}
+
+// buildInfoKey carries the *Info of the build in progress, for fetch to
+// record its key count in.
+type buildInfoKey struct{}
+
+func applyResolverInfo[Model any, Result any](h *resolverHolder, cacheKey string, model Model, hit bool) (Result, Info, error) {
+	var info Info
+	if h != nil {
+		info = h.info
+	}
+	if hit {
+		info.CacheHit = true
+		info.BuildDuration = 0
+	}
+	result, err := applyResolver[Model, Result](h, cacheKey, model)
+	return result, info, err
+}
@@ -0,0 +1,79 @@
+package lode
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/willhf/lode/lodetest"
+)
+
+func TestResolveInfo(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	clock := lodetest.NewFakeClock(time.Unix(0, 0))
+	eng := NewEngine(WithClock(clock))
+	a1, a2 := &Author{ID: 1, Name: "A"}, &Author{ID: 2, Name: "B"}
+	eng.InitHandles([]*Author{a1, a2})
+
+	spec := ResolveSpec[*Author, string]{
+		CacheKey: "name",
+		Model:    a1,
+		Build: func(context.Context, []*Author) (ResolverFunc[*Author, string], error) {
+			clock.Advance(3 * time.Millisecond)
+			return func(a *Author) string { return a.Name }, nil
+		},
+	}
+	got, info, err := ResolveInfo(ctx, spec)
+	if err != nil || got != "A" {
+		t.Fatalf("ResolveInfo = %q, %v", got, err)
+	}
+	if want := (Info{BuildDuration: 3 * time.Millisecond}); info != want {
+		t.Fatalf("miss info = %+v; want %+v", info, want)
+	}
+
+	spec.Model = a2
+	got, info, err = ResolveInfo(ctx, spec)
+	if err != nil || got != "B" {
+		t.Fatalf("ResolveInfo = %q, %v", got, err)
+	}
+	if want := (Info{CacheHit: true}); info != want {
+		t.Fatalf("hit info = %+v; want %+v", info, want)
+	}
+}
+
+func TestManyInfoAndOneInfo(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	clock := lodetest.NewFakeClock(time.Unix(0, 0))
+	eng := NewEngine(WithClock(clock))
+	authors := []*Author{{ID: 1}, {ID: 2}, {ID: 3}}
+	eng.InitHandles(authors)
+
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       authors[0],
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(context.Context, []int) ([]*Book, error) {
+			clock.Advance(time.Second)
+			return []*Book{{AuthorID: 1, Title: "x"}, {AuthorID: 2, Title: "y"}}, nil
+		},
+	}
+	books, info, err := ManyInfo(ctx, spec)
+	if err != nil || len(books) != 1 {
+		t.Fatalf("ManyInfo = %v, %v", titles(books), err)
+	}
+	if want := (Info{BuildDuration: time.Second, KeyCount: 3}); info != want {
+		t.Fatalf("miss info = %+v; want %+v", info, want)
+	}
+
+	spec.Model = authors[1]
+	book, info, err := OneInfo(ctx, spec)
+	if err != nil || book.Title != "y" {
+		t.Fatalf("OneInfo = %v, %v", book, err)
+	}
+	if want := (Info{CacheHit: true, KeyCount: 3}); info != want {
+		t.Fatalf("hit info = %+v; want %+v", info, want)
+	}
+}
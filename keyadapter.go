@@ -0,0 +1,101 @@
+package lode
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+)
+
+// KeyAdapter maps join keys of a type that is not comparable, such as
+// []byte, to a comparable projection and back, so RelationSpecA can batch and
+// group on them.  Keys with equal projections are treated as the same key.
+type KeyAdapter[K any, C comparable] struct {
+	ToComparable   func(K) C
+	FromComparable func(C) K
+}
+
+// BytesKey adapts []byte keys through string conversion.
+func BytesKey() KeyAdapter[[]byte, string] {
+	return KeyAdapter[[]byte, string]{
+		ToComparable:   func(b []byte) string { return string(b) },
+		FromComparable: func(s string) []byte { return []byte(s) },
+	}
+}
+
+// BinaryKey adapts keys of pointer type K through their binary encoding.
+// MarshalBinary must not fail for a key; BinaryKey panics if it does, or if
+// UnmarshalBinary rejects what MarshalBinary produced.
+func BinaryKey[T any, K interface {
+	*T
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}]() KeyAdapter[K, string] {
+	return KeyAdapter[K, string]{
+		ToComparable: func(k K) string {
+			b, err := k.MarshalBinary()
+			if err != nil {
+				panic(fmt.Sprintf("%s: BinaryKey: %v", packagePrefix, err))
+			}
+			return string(b)
+		},
+		FromComparable: func(s string) K {
+			k := K(new(T))
+			if err := k.UnmarshalBinary([]byte(s)); err != nil {
+				panic(fmt.Sprintf("%s: BinaryKey: %v", packagePrefix, err))
+			}
+			return k
+		},
+	}
+}
+
+// RelationSpecA is a RelationSpec over join keys of a type K that is not
+// comparable: keys are deduplicated and relations grouped on their
+// projection through Adapter, while Fetch still receives K.  Use it through
+// its RelationSpec:
+//
+//	books, err := lode.Many(ctx, authorBooks.For(a).RelationSpec())
+type RelationSpecA[K any, C comparable, Model hasState, Relation any] struct {
+	CacheKey    string
+	Model       Model
+	ModelKey    func(Model) (key K, ok bool)
+	RelationKey func(Relation) K
+	Fetch       func(context.Context, []K) ([]Relation, error)
+	Adapter     KeyAdapter[K, C]
+
+	SinglePerKey bool           // see RelationSpec.SinglePerKey
+	NilModel     NilModelPolicy // see RelationSpec.NilModel
+}
+
+// For returns a copy of the spec with Model set to m.
+func (s RelationSpecA[K, C, Model, Relation]) For(m Model) RelationSpecA[K, C, Model, Relation] {
+	s.Model = m
+	return s
+}
+
+// RelationSpec returns the equivalent RelationSpec, keyed by the comparable
+// projection.  Options without a counterpart here, such as MaxRelations or
+// KeyOrder, may be set on the result.
+func (s RelationSpecA[K, C, Model, Relation]) RelationSpec() RelationSpec[C, Model, Relation] {
+	return RelationSpec[C, Model, Relation]{
+		CacheKey: s.CacheKey,
+		Model:    s.Model,
+		ModelKey: func(m Model) (C, bool) {
+			k, ok := s.ModelKey(m)
+			if !ok {
+				var zero C
+				return zero, false
+			}
+			return s.Adapter.ToComparable(k), true
+		},
+		RelationKey: func(r Relation) C { return s.Adapter.ToComparable(s.RelationKey(r)) },
+		Fetch: func(ctx context.Context, keys []C) ([]Relation, error) {
+			adapted := make([]K, len(keys))
+			for i, k := range keys {
+				adapted[i] = s.Adapter.FromComparable(k)
+			}
+			return s.Fetch(ctx, adapted)
+		},
+		SinglePerKey: s.SinglePerKey,
+		NilModel:     s.NilModel,
+	}
+}
@@ -0,0 +1,80 @@
+package lode
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+type device struct {
+	Handle
+	UUID []byte
+}
+
+type reading struct {
+	DeviceUUID []byte
+	Value      int
+}
+
+func TestRelationSpecA_BytesKey(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	uuid := func(b byte) []byte { return bytes.Repeat([]byte{b}, 16) }
+	devices := []*device{{UUID: uuid(1)}, {UUID: uuid(2)}, {UUID: uuid(1)}, {UUID: uuid(3)}}
+	NewEngine().InitHandles(devices)
+
+	var fetched [][][]byte
+	spec := RelationSpecA[[]byte, string, *device, *reading]{
+		CacheKey:    "readings",
+		ModelKey:    func(d *device) ([]byte, bool) { return d.UUID, true },
+		RelationKey: func(r *reading) []byte { return r.DeviceUUID },
+		Fetch: func(_ context.Context, keys [][]byte) ([]*reading, error) {
+			fetched = append(fetched, keys)
+			var out []*reading
+			for _, k := range keys {
+				for v := range int(k[0]) {
+					out = append(out, &reading{DeviceUUID: slices.Clone(k), Value: 10*int(k[0]) + v})
+				}
+			}
+			return out, nil
+		},
+		Adapter: BytesKey(),
+	}
+
+	for i, d := range devices {
+		readings, err := Many(ctx, spec.For(d).RelationSpec())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(readings) != int(d.UUID[0]) {
+			t.Fatalf("device %d: %d readings; want %d", i, len(readings), d.UUID[0])
+		}
+		for _, r := range readings {
+			if !bytes.Equal(r.DeviceUUID, d.UUID) {
+				t.Fatalf("device %d got a reading for %x", i, r.DeviceUUID)
+			}
+		}
+	}
+	if len(fetched) != 1 || len(fetched[0]) != 3 {
+		t.Fatalf("fetched %d batches, first with %d keys; want one batch of the 3 distinct keys", len(fetched), len(fetched[0]))
+	}
+}
+
+func TestBinaryKey(t *testing.T) {
+	t.Parallel()
+	adapter := BinaryKey[time.Time]()
+	a := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	b := a
+	if adapter.ToComparable(&a) != adapter.ToComparable(&b) {
+		t.Fatal("equal times have different projections")
+	}
+	if got := adapter.FromComparable(adapter.ToComparable(&a)); !got.Equal(a) {
+		t.Fatalf("round trip = %v; want %v", got, a)
+	}
+	c := a.Add(time.Second)
+	if adapter.ToComparable(&a) == adapter.ToComparable(&c) {
+		t.Fatal("different times share a projection")
+	}
+}
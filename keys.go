@@ -0,0 +1,138 @@
+package lode
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var errUnknownKey = &kindError{"unknown cache key", ErrNotFound}
+
+// WithStrictKeys makes Resolve, Many, and One fail for cache keys not
+// registered with Engine.RegisterKeys, suggesting the closest registered key.
+// Without it, an engine in debug mode (see WithDebug) that has registered
+// keys warns once about each unregistered key instead.
+func WithStrictKeys() ConfigOption {
+	return func(c *Config) { c.strictKeys = true }
+}
+
+// WithDefinedKeys makes the engine consider the keys given to DefineKeys
+// registered too, as well as those it registers itself, so that the specs
+// of NewRelation, NewResolve, and lodegorm.Relation pass WithStrictKeys
+// without further registration.  The defined keys are those of every
+// package in the program, so engines that must only accept their own keys
+// leave it unset.
+func WithDefinedKeys() ConfigOption {
+	return func(c *Config) { c.definedKeys = true }
+}
+
+// RegisterKeys adds keys to the set of cache keys the engine (and its
+// scopes) consider legal; see WithStrictKeys.  Keys are given as written in
+// specs, without the key namespace or SinglePerKeySuffix, and are legal for
+// every model type.  Registering a key twice is harmless.
+func (e *Engine) RegisterKeys(keys ...string) {
+	e.keys.mu.Lock()
+	defer e.keys.mu.Unlock()
+	if e.keys.known == nil {
+		e.keys.known = make(map[string]struct{}, len(keys))
+	}
+	for _, k := range keys {
+		e.keys.known[k] = struct{}{}
+	}
+}
+
+// DefineKeys registers keys as legal on every engine created
+// WithDefinedKeys, as Engine.RegisterKeys does for one, for specs defined
+// once at init and shared by all engines.  NewRelation, NewResolve, and
+// lodegorm.Relation call it for the specs they build.
+func DefineKeys(keys ...string) {
+	for _, k := range keys {
+		definedKeys.Store(k, struct{}{})
+	}
+}
+
+// definedKeys holds the keys given to DefineKeys.
+var definedKeys sync.Map // key -> struct{}
+
+// keyRegistry is the set of registered cache keys.
+type keyRegistry struct {
+	mu     sync.RWMutex
+	known  map[string]struct{}
+	warned sync.Map // unregistered key -> struct{}, in non-strict debug mode
+}
+
+// checkKey reports key, as written in a spec, if it is not registered: as an
+// error with WithStrictKeys, else as a once-per-key warning in debug mode.
+// Engines with neither option skip the check.
+func (e *Engine) checkKey(key string) error {
+	if !e.config.strictKeys && !e.config.debug {
+		return nil
+	}
+	key = strings.TrimSuffix(key, SinglePerKeySuffix)
+	if _, ok := definedKeys.Load(key); ok && e.config.definedKeys {
+		return nil
+	}
+	e.keys.mu.RLock()
+	_, ok := e.keys.known[key]
+	registered := len(e.keys.known) > 0
+	e.keys.mu.RUnlock()
+	if ok {
+		return nil
+	}
+	if !e.config.strictKeys {
+		if registered {
+			if _, warned := e.keys.warned.LoadOrStore(key, struct{}{}); !warned {
+				e.warn(WarningEvent{CacheKey: e.key(key), Message: errUnknownKey.Error() + e.suggestKey(key)})
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%s: key %q: %w%s", packagePrefix, e.key(key), errUnknownKey, e.suggestKey(key))
+}
+
+// suggestKey returns a "did you mean" hint naming the registered key closest
+// to key, or "" if none is close.
+func (e *Engine) suggestKey(key string) string {
+	e.keys.mu.RLock()
+	defer e.keys.mu.RUnlock()
+	best, bestDist := "", len(key)/3+2 // a typo or two, more for long keys
+	consider := func(k string) {
+		if d := editDistance(key, k); d < bestDist || (d == bestDist && best != "" && k < best) {
+			best, bestDist = k, d
+		}
+	}
+	for k := range e.keys.known {
+		consider(k)
+	}
+	if best == "" && e.config.definedKeys { // the engine's own keys are the likelier meaning
+		definedKeys.Range(func(k, _ any) bool {
+			consider(k.(string))
+			return true
+		})
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf("; did you mean %q?", best)
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,146 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStrictKeys(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine(WithStrictKeys(), WithKeyNamespace("ns"))
+	eng.RegisterKeys("books", "greet")
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "bookz",
+		Model:       a,
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(context.Context, []int) ([]*Book, error) {
+			t.Fatal("Fetch called for an unknown key")
+			return nil, nil
+		},
+	}
+	_, err := Many(ctx, spec)
+	if !errors.Is(err, errUnknownKey) || !strings.Contains(err.Error(), `key "ns:bookz"`) || !strings.Contains(err.Error(), `did you mean "books"?`) {
+		t.Fatalf("err = %v; want errUnknownKey for ns:bookz suggesting books", err)
+	}
+	// A scope reports the key in the namespace too.
+	scoped := &Author{ID: 2}
+	eng.Scope().InitHandles(scoped)
+	_, err = Many(ctx, spec.For(scoped))
+	if !errors.Is(err, errUnknownKey) || !strings.Contains(err.Error(), `key "ns:bookz"`) || !strings.Contains(err.Error(), `did you mean "books"?`) {
+		t.Fatalf("scoped engine: err = %v; want errUnknownKey for ns:bookz suggesting books", err)
+	}
+
+	_, err = Resolve(ctx, ResolveSpec[*Author, int]{CacheKey: "something else entirely", Model: a})
+	if !errors.Is(err, errUnknownKey) || strings.Contains(err.Error(), "did you mean") {
+		t.Fatalf("err = %v; want errUnknownKey without a suggestion", err)
+	}
+
+	// Registered keys work, SinglePerKey ones included, and scopes share the
+	// registrations.
+	spec.CacheKey = "books"
+	spec.Fetch = func(context.Context, []int) ([]*Book, error) { return nil, nil }
+	if _, err := Many(ctx, spec); err != nil {
+		t.Fatal(err)
+	}
+	spec.SinglePerKey = true
+	if _, err := One(ctx, spec); err != nil {
+		t.Fatal(err)
+	}
+	b := &Author{ID: 2}
+	eng.Scope().InitHandles(b)
+	if _, err := Many(ctx, spec.For(b)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStrictKeys_DefinedSpecs(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine(WithStrictKeys(), WithDefinedKeys())
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+
+	books := NewRelation(
+		func(a *Author) (int, bool) { return a.ID, true },
+		func(b *Book) int { return b.AuthorID },
+		func(context.Context, []int) ([]*Book, error) { return nil, nil },
+		"defined_books",
+	)
+	count := NewResolve("defined_count", func(_ context.Context, models []*Author) (func(*Author) int, error) {
+		return func(*Author) int { return len(models) }, nil
+	})
+	if _, err := Many(ctx, books.For(a)); err != nil {
+		t.Fatalf("Many with a NewRelation spec: %v", err)
+	}
+	if n, err := Resolve(ctx, count.For(a)); err != nil || n != 1 {
+		t.Fatalf("Resolve with a NewResolve spec = %d, %v", n, err)
+	}
+
+	books.CacheKey = "defined_bookz"
+	if _, err := Many(ctx, books.For(a)); !errors.Is(err, errUnknownKey) || !strings.Contains(err.Error(), `did you mean "defined_books"?`) {
+		t.Fatalf("err = %v; want errUnknownKey suggesting the defined key", err)
+	}
+
+	// Engines that do not opt in accept only the keys they register.
+	other := NewEngine(WithStrictKeys())
+	b := &Author{ID: 2}
+	other.InitHandles(b)
+	if _, err := Resolve(ctx, count.For(b)); !errors.Is(err, errUnknownKey) || strings.Contains(err.Error(), "did you mean") {
+		t.Fatalf("defined key on an engine without WithDefinedKeys: err = %v; want errUnknownKey, suggesting nothing", err)
+	}
+}
+
+func TestRegisterKeys_NonStrictWarnsInDebug(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	var warnings []WarningEvent
+	eng := NewEngine(WithDebug(), WithHooks(Hooks{OnWarning: func(ev WarningEvent) { warnings = append(warnings, ev) }}))
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+
+	build := func(context.Context, []*Author) (ResolverFunc[*Author, int], error) {
+		return func(*Author) int { return 1 }, nil
+	}
+	resolve := func(key string) {
+		t.Helper()
+		if _, err := Resolve(ctx, ResolveSpec[*Author, int]{CacheKey: key, Model: a, Build: build}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resolve("countz") // nothing registered: no check
+	eng.RegisterKeys("count")
+	resolve("countz")
+	resolve("countz")
+	resolve("count")
+	if len(warnings) != 1 || warnings[0].CacheKey != "countz" || !strings.Contains(warnings[0].Message, `did you mean "count"?`) {
+		t.Fatalf("warnings = %+v; want one for countz", warnings)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"books", "books", 0},
+		{"bookz", "books", 1},
+		{"boks", "books", 1},
+		{"author", "authors", 1},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	} {
+		if got := editDistance(tc.a, tc.b); got != tc.want {
+			t.Errorf("editDistance(%q, %q) = %d; want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
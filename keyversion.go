@@ -0,0 +1,112 @@
+package lode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithKeyVersion makes the engine store every cache key with "@"+v
+// appended (after the key namespace and SinglePerKeySuffix, if any), and
+// report it that way in errors, hooks, and stats.  Bump v when the shape of
+// a cached Result changes, e.g. per deploy, so values cached under the old
+// shape are never mistaken for new ones; see also Engine.SetKeyVersion.
+func WithKeyVersion(v string) ConfigOption {
+	return func(c *Config) { c.keyVersion = v }
+}
+
+// keyVersionSep separates a stored key from its key version.
+const keyVersionSep = "@"
+
+// SetKeyVersion changes the key version of e and its scopes, as set by
+// WithKeyVersion, for values cached from now on; an empty v drops it.
+// Values cached under the previous version stay in their states, unreachable
+// but alive, until the states are reset or collected or PurgeOtherVersions
+// removes them.
+func (e *Engine) SetKeyVersion(v string) {
+	e.keyVersionMu.Lock()
+	defer e.keyVersionMu.Unlock()
+	if old := e.KeyVersion(); old != "" && old != v {
+		e.retiredVersions[old] = struct{}{}
+	}
+	delete(e.retiredVersions, v)
+	e.keyVersion.Store(&v)
+}
+
+// KeyVersion returns e's current key version.
+func (e *Engine) KeyVersion() string {
+	if v := e.keyVersion.Load(); v != nil {
+		return *v
+	}
+	return ""
+}
+
+// versionSuffix returns what key appends for the current key version.
+func (e *Engine) versionSuffix() string {
+	if v := e.keyVersion.Load(); v != nil && *v != "" {
+		return keyVersionSep + *v
+	}
+	return ""
+}
+
+// namespaced returns cacheKey in the engine's namespace, without the key
+// version.
+func (e *Engine) namespaced(cacheKey string) string {
+	if e.config.keyNamespace == "" {
+		return cacheKey
+	}
+	return e.config.keyNamespace + ":" + cacheKey
+}
+
+// callerKey returns a stored key as call sites write it: without the key
+// namespace, SinglePerKeySuffix, or the current key version.
+func (e *Engine) callerKey(stored string) string {
+	stored = strings.TrimSuffix(stored, e.versionSuffix())
+	if ns := e.config.keyNamespace; ns != "" {
+		stored = strings.TrimPrefix(stored, ns+":")
+	}
+	return strings.TrimSuffix(stored, SinglePerKeySuffix)
+}
+
+// hasKeyPrefix reports whether stored is a key of the current version that
+// starts with prefix, as call sites write it.
+func (e *Engine) hasKeyPrefix(stored, prefix string) bool {
+	suffix := e.versionSuffix()
+	return strings.HasSuffix(stored, suffix) && strings.HasPrefix(stored[:len(stored)-len(suffix)], e.namespaced(prefix))
+}
+
+// PurgeOtherVersions removes the values cached under key versions that
+// SetKeyVersion replaced from every live state bound by e, and from the
+// engine's WithImmutableKeys values, and returns how many it removed.  Frozen
+// states are left alone and reported in an error wrapping ErrFrozen.
+func (e *Engine) PurgeOtherVersions() (int, error) {
+	e.keyVersionMu.Lock()
+	var suffixes []string
+	for v := range e.retiredVersions {
+		suffixes = append(suffixes, keyVersionSep+v)
+	}
+	e.keyVersionMu.Unlock()
+	if len(suffixes) == 0 {
+		return 0, nil
+	}
+	current := e.versionSuffix()
+	other := func(stored string) bool {
+		for _, s := range suffixes {
+			if strings.HasSuffix(stored, s) && (current == "" || !strings.HasSuffix(stored, current)) {
+				return true
+			}
+		}
+		return false
+	}
+	purged, frozen := e.deleteImmutable(other), 0
+	for _, s := range e.states.live() {
+		if s.checkFrozen() != nil {
+			frozen++
+			continue
+		}
+		purged += s.deleteEntries(other)
+	}
+	if frozen > 0 {
+		return purged, fmt.Errorf("%s: %w: skipped %d frozen states", packagePrefix, ErrFrozen, frozen)
+	}
+	return purged, nil
+}
@@ -0,0 +1,85 @@
+package lode
+
+import (
+	"context"
+	"testing"
+)
+
+func TestKeyVersion(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	var fetched []string
+	eng := NewEngine(WithKeyNamespace("ns"), WithKeyVersion("v1"), WithHooks(Hooks{
+		OnFetch: func(ev FetchEvent) { fetched = append(fetched, ev.CacheKey) },
+	}))
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+	var sourced []string
+	eng.SetVersionSource(func(k string) uint64 {
+		sourced = append(sourced, k)
+		return 0
+	})
+
+	fetches := 0
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(context.Context, []int) ([]*Book, error) {
+			fetches++
+			return []*Book{{ID: fetches, AuthorID: 1}}, nil
+		},
+	}
+	book := func(ctx context.Context) int {
+		t.Helper()
+		b, err := One(ctx, spec.For(a))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b.ID
+	}
+
+	if got := book(ctx); got != 1 {
+		t.Fatalf("v1 book = %d", got)
+	}
+	if entry, _ := Entry(a, "books"); entry.Key() != "ns:books@v1" {
+		t.Fatalf("stored key = %q", entry.Key())
+	}
+	if len(fetched) != 1 || fetched[0] != "ns:books@v1" || sourced[0] != "books" {
+		t.Fatalf("hooks saw %q, version source %q", fetched, sourced)
+	}
+
+	// A new version never sees the old one's values...
+	eng.SetKeyVersion("v2")
+	if got := book(ctx); got != 2 {
+		t.Fatalf("v2 book = %d; want a fresh fetch", got)
+	}
+	// ...which are still there for the old version.
+	eng.SetKeyVersion("v1")
+	if got := book(ctx); got != 1 {
+		t.Fatalf("v1 book again = %d; want the v1 value", got)
+	}
+
+	// Invalidation and prefixes apply to the current version only.
+	eng.SetKeyVersion("v2")
+	if err := a.ResetPrefix("bo"); err != nil {
+		t.Fatal(err)
+	}
+	if got := book(ctx); got != 3 {
+		t.Fatalf("v2 book after ResetPrefix = %d; want 3", got)
+	}
+	if n, err := eng.InvalidateKey("books"); err != nil || n != 1 {
+		t.Fatalf("InvalidateKey = %d, %v; want the SinglePerKey entry of v2", n, err)
+	}
+
+	if n, err := eng.PurgeOtherVersions(); err != nil || n != 1 {
+		t.Fatalf("PurgeOtherVersions = %d, %v; want the v1 entry", n, err)
+	}
+	eng.SetKeyVersion("v1")
+	if got := book(ctx); got != 4 {
+		t.Fatalf("v1 book after purge = %d; want a fresh fetch", got)
+	}
+	if v := eng.KeyVersion(); v != "v1" || eng.Config().KeyVersion() != "v1" {
+		t.Fatalf("KeyVersion = %q", v)
+	}
+}
@@ -0,0 +1,55 @@
+package lode
+
+import (
+	"reflect"
+	"time"
+)
+
+// WithLeakTracking records when each state is bound so LiveStates can report
+// batches that outlive the work they were bound for, e.g. a model kept in a
+// long-lived map, which keeps its whole batch and resolver cache alive.
+func WithLeakTracking() ConfigOption {
+	return func(c *Config) { c.leakTracking = true }
+}
+
+// LiveState describes a state that is still reachable; see LiveStates.
+type LiveState struct {
+	ModelType string
+	Models    int
+	CacheKeys int // entries currently cached on the state
+	BindID    uint64
+	Age       time.Duration
+}
+
+// LiveStates reports the states bound by e (not by its parent or scopes) at
+// least minAge ago that are still reachable, oldest first.  It returns nil
+// unless the engine was created WithLeakTracking.  Reachability is as of the
+// last garbage collection: a released state is reported until it is
+// collected.
+func (e *Engine) LiveStates(minAge time.Duration) []LiveState {
+	if !e.config.leakTracking {
+		return nil
+	}
+	now := e.now()
+	var out []LiveState
+	for _, s := range e.states.live() {
+		age := now.Sub(s.boundAt)
+		if age < minAge {
+			continue
+		}
+		keys := 0
+		s.resolverEntries.Range(func(any, any) bool {
+			keys++
+			return true
+		})
+		out = append(out, LiveState{
+			ModelType: s.modelType(),
+			Models:    reflect.ValueOf(s.models).Len(),
+			CacheKeys: keys,
+			BindID:    s.bindID,
+			Age:       age,
+		})
+	}
+	// The registry keeps states in bind order.
+	return out
+}
@@ -0,0 +1,108 @@
+package lode
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Limiter gates the fetch calls of the specs naming it in
+// RelationSpec.Limiter, e.g. those of several relations served by one
+// rate-limited API.  Wait blocks until a call may start, or ctx ends, and
+// returns the function to call when the call is done.
+type Limiter interface {
+	Wait(ctx context.Context) (done func(), err error)
+}
+
+// WithLimiter registers l under name for RelationSpec.Limiter.  Scopes share
+// their engine's limiters.
+func WithLimiter(name string, l Limiter) ConfigOption {
+	return func(c *Config) {
+		if c.limiters == nil {
+			c.limiters = make(map[string]Limiter)
+		}
+		c.limiters[name] = l
+	}
+}
+
+// Semaphore returns a Limiter letting at most n calls run at once; n = 1
+// serializes them.
+func Semaphore(n int) Limiter {
+	return semaphore(make(chan struct{}, max(n, 1)))
+}
+
+type semaphore chan struct{}
+
+func (s semaphore) Wait(ctx context.Context) (func(), error) {
+	select {
+	case s <- struct{}{}:
+		return func() { <-s }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// RateLimiter adapts a token bucket such as golang.org/x/time/rate's
+// *rate.Limiter, whose Wait takes a token, to a Limiter.
+func RateLimiter(l interface {
+	Wait(ctx context.Context) error
+}) Limiter {
+	return rateLimiter{l}
+}
+
+type rateLimiter struct {
+	l interface {
+		Wait(ctx context.Context) error
+	}
+}
+
+func (r rateLimiter) Wait(ctx context.Context) (func(), error) {
+	if err := r.l.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return func() {}, nil
+}
+
+// LimiterWaitEvent describes one fetch call's wait for its spec's Limiter.
+type LimiterWaitEvent struct {
+	Limiter  string
+	CacheKey string
+	Wait     time.Duration
+	// Err is set if the call gave up waiting.
+	Err error
+}
+
+func (e *Engine) onLimiterWait(ev LimiterWaitEvent) {
+	for _, h := range e.config.hooks {
+		if h.OnLimiterWait != nil {
+			h.OnLimiterWait(ev)
+		}
+	}
+}
+
+// errNoLimiter is reported for a spec's Limiter not registered with
+// WithLimiter.
+var errNoLimiter = &kindError{"not registered with WithLimiter", ErrMisconfigured}
+
+// limited runs fetch, one fetch call (or, for FetchPage, the calls of one
+// chunk), under the spec's Limiter, if any.
+func (args RelationSpec[JoinKey, Model, Relation]) limited(ctx context.Context, e *Engine, fetch func() error) error {
+	if args.Limiter == "" {
+		return fetch()
+	}
+	l, ok := e.config.limiters[args.Limiter]
+	if !ok {
+		return fmt.Errorf("%s: key %q: no limiter %q: %w", packagePrefix, args.CacheKey, args.Limiter, errNoLimiter)
+	}
+	start := e.now()
+	done, err := l.Wait(ctx)
+	if err != nil {
+		err = fmt.Errorf("%s: key %q: waiting for limiter %q: %w", packagePrefix, args.CacheKey, args.Limiter, err)
+	}
+	e.onLimiterWait(LimiterWaitEvent{Limiter: args.Limiter, CacheKey: args.CacheKey, Wait: e.now().Sub(start), Err: err})
+	if err != nil {
+		return err
+	}
+	defer done()
+	return fetch()
+}
@@ -0,0 +1,112 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimiter_SerializesRelations(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	var (
+		mu    sync.Mutex
+		waits []LimiterWaitEvent
+	)
+	eng := NewEngine(WithFetchChunkSize(1), WithFetchConcurrency(4), WithLimiter("catalog-api", Semaphore(1)),
+		WithHooks(Hooks{OnLimiterWait: func(ev LimiterWaitEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			waits = append(waits, ev)
+		}}))
+	authors := []*Author{{ID: 1}, {ID: 2}}
+	eng.InitHandles(authors)
+
+	var inFlight, maxInFlight atomic.Int32
+	fetch := func(_ context.Context, ids []int) ([]*Book, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for m := maxInFlight.Load(); n > m && !maxInFlight.CompareAndSwap(m, n); m = maxInFlight.Load() {
+		}
+		time.Sleep(5 * time.Millisecond)
+		return []*Book{{AuthorID: ids[0]}}, nil
+	}
+	spec := func(key string) RelationSpec[int, *Author, *Book] {
+		return RelationSpec[int, *Author, *Book]{
+			CacheKey:    key,
+			ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+			RelationKey: func(b *Book) int { return b.AuthorID },
+			Fetch:       fetch,
+			Limiter:     "catalog-api",
+		}
+	}
+	err := Parallel(ctx,
+		func(ctx context.Context) error { _, err := Many(ctx, spec("books").For(authors[0])); return err },
+		func(ctx context.Context) error { _, err := Many(ctx, spec("drafts").For(authors[0])); return err },
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := maxInFlight.Load(); m != 1 {
+		t.Fatalf("%d fetches in flight at once; want the limiter to serialize them", m)
+	}
+	if len(waits) != 4 {
+		t.Fatalf("reported %d waits; want one per chunk of each relation", len(waits))
+	}
+	var queued time.Duration
+	for _, w := range waits {
+		if w.Limiter != "catalog-api" || w.Err != nil {
+			t.Fatalf("wait = %+v", w)
+		}
+		queued += w.Wait
+	}
+	if queued < 5*time.Millisecond {
+		t.Fatalf("queued %v in all; want the waits behind other fetches reported", queued)
+	}
+}
+
+func TestLimiter_Errors(t *testing.T) {
+	t.Parallel()
+	a := &Author{ID: 1}
+	eng := NewEngine(WithLimiter("busy", Semaphore(1)))
+	eng.InitHandles(a)
+	spec := threeBooks(map[int]int{})
+
+	spec.Limiter = "missing"
+	if _, err := Many(context.Background(), spec.For(a)); err == nil || !strings.Contains(err.Error(), `no limiter "missing"`) {
+		t.Fatalf("err = %v; want the unknown limiter reported", err)
+	}
+
+	// A wait ends with the ctx.
+	done, _ := eng.config.limiters["busy"].Wait(context.Background())
+	defer done()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	spec.Limiter, spec.CacheKey = "busy", "books_busy"
+	if _, err := Many(ctx, spec.For(a)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v; want DeadlineExceeded", err)
+	}
+}
+
+type tokens struct{ n atomic.Int32 }
+
+func (t *tokens) Wait(context.Context) error { t.n.Add(1); return nil }
+
+func TestRateLimiter(t *testing.T) {
+	t.Parallel()
+	var tb tokens
+	a := &Author{ID: 1}
+	NewEngine(WithFetchChunkSize(1), WithLimiter("api", RateLimiter(&tb))).InitHandles([]*Author{a, {ID: 2}})
+	spec := threeBooks(map[int]int{})
+	spec.Limiter = "api"
+	if _, err := Many(context.Background(), spec.For(a)); err != nil {
+		t.Fatal(err)
+	}
+	if n := tb.n.Load(); n != 2 {
+		t.Fatalf("took %d tokens; want one per fetch call", n)
+	}
+}
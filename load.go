@@ -0,0 +1,102 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Loader loads one value of type T for a model.  Create one from a spec
+// with LoadMany, LoadOne, or LoadResolve, or write one around an accessor,
+// and run several for one model with Load2 or Load3.
+type Loader[Model hasState, T any] func(ctx context.Context, m Model) (T, error)
+
+// LoadMany returns a Loader calling Many with spec for the model.
+func LoadMany[JoinKey comparable, Model hasState, Relation any](spec RelationSpec[JoinKey, Model, Relation]) Loader[Model, []Relation] {
+	return func(ctx context.Context, m Model) ([]Relation, error) { return Many(ctx, spec.For(m)) }
+}
+
+// LoadOne returns a Loader calling One with spec for the model.
+func LoadOne[JoinKey comparable, Model hasState, Relation any](spec RelationSpec[JoinKey, Model, Relation]) Loader[Model, Relation] {
+	return func(ctx context.Context, m Model) (Relation, error) { return One(ctx, spec.For(m)) }
+}
+
+// LoadResolve returns a Loader calling Resolve with spec for the model.
+func LoadResolve[Model hasState, Result any](spec ResolveSpec[Model, Result]) Loader[Model, Result] {
+	return func(ctx context.Context, m Model) (Result, error) { return Resolve(ctx, spec.For(m)) }
+}
+
+// LoadOption configures Load2 and Load3.
+type LoadOption func(*loadConfig)
+
+type loadConfig struct {
+	aggregate bool
+}
+
+// WithAggregateErrors makes Load2 and Load3 run every loader to completion
+// and return all their errors, joined in argument order, instead of
+// cancelling the others at the first error.
+func WithAggregateErrors() LoadOption {
+	return func(c *loadConfig) { c.aggregate = true }
+}
+
+// Load2 runs a and b for m concurrently, as Parallel does, and returns both
+// results:
+//
+//	books, publisher, err := lode.Load2(ctx, author,
+//		lode.LoadMany(authorBooks), lode.LoadOne(authorPublisher))
+//
+// By default the first error cancels the other loader and is returned once
+// both have; see WithAggregateErrors.  On an error the results of the
+// loaders that succeeded are still returned.
+func Load2[Model hasState, A, B any](ctx context.Context, m Model, a Loader[Model, A], b Loader[Model, B], opts ...LoadOption) (A, B, error) {
+	var (
+		ra A
+		rb B
+	)
+	err := loadAll(ctx, "Load2", opts,
+		func(ctx context.Context) (err error) { ra, err = a(ctx, m); return },
+		func(ctx context.Context) (err error) { rb, err = b(ctx, m); return },
+	)
+	return ra, rb, err
+}
+
+// Load3 is Load2 for three loaders.
+func Load3[Model hasState, A, B, C any](ctx context.Context, m Model, a Loader[Model, A], b Loader[Model, B], c Loader[Model, C], opts ...LoadOption) (A, B, C, error) {
+	var (
+		ra A
+		rb B
+		rc C
+	)
+	err := loadAll(ctx, "Load3", opts,
+		func(ctx context.Context) (err error) { ra, err = a(ctx, m); return },
+		func(ctx context.Context) (err error) { rb, err = b(ctx, m); return },
+		func(ctx context.Context) (err error) { rc, err = c(ctx, m); return },
+	)
+	return ra, rb, rc, err
+}
+
+// loadAll runs the loaders of name, Load2 or Load3, naming each in panics
+// by its index.
+func loadAll(ctx context.Context, name string, opts []LoadOption, fns ...func(ctx context.Context) error) error {
+	var c loadConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	label := name + ": loader %d"
+	if !c.aggregate {
+		return parallel(ctx, label, fns)
+	}
+	errs := make([]error, len(fns))
+	var wg sync.WaitGroup
+	for i, fn := range fns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = callRecovered(ctx, fmt.Sprintf(label, i), fn)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
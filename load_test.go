@@ -0,0 +1,142 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoad3_ManyOneResolve(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	books := []*Book{{ID: 1, AuthorID: 10}, {ID: 2, AuthorID: 20}}
+	NewEngine().InitHandles(books)
+
+	// Each loader waits for the other two to start, so they must run at once.
+	var started sync.WaitGroup
+	started.Add(3)
+	await := func() error {
+		started.Done()
+		done := make(chan struct{})
+		go func() { started.Wait(); close(done) }()
+		select {
+		case <-done:
+			return nil
+		case <-time.After(time.Second):
+			return errors.New("loaders ran one after another")
+		}
+	}
+
+	siblings := RelationSpec[int, *Book, *Book]{
+		CacheKey:    "siblings",
+		ModelKey:    func(b *Book) (int, bool) { return b.AuthorID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(_ context.Context, ids []int) ([]*Book, error) {
+			if err := await(); err != nil {
+				return nil, err
+			}
+			var out []*Book
+			for _, id := range ids {
+				out = append(out, &Book{AuthorID: id, Title: "x"}, &Book{AuthorID: id, Title: "y"})
+			}
+			return out, nil
+		},
+	}
+	author := RelationSpec[int, *Book, *Author]{
+		CacheKey:    "author",
+		ModelKey:    func(b *Book) (int, bool) { return b.AuthorID, true },
+		RelationKey: func(a *Author) int { return a.ID },
+		Fetch: func(_ context.Context, ids []int) ([]*Author, error) {
+			if err := await(); err != nil {
+				return nil, err
+			}
+			var out []*Author
+			for _, id := range ids {
+				out = append(out, &Author{ID: id})
+			}
+			return out, nil
+		},
+	}
+	title := ResolveSpec[*Book, string]{
+		CacheKey: "title",
+		Build: func(context.Context, []*Book) (ResolverFunc[*Book, string], error) {
+			if err := await(); err != nil {
+				return nil, err
+			}
+			return func(b *Book) string { return strings.Repeat("t", b.ID) }, nil
+		},
+	}
+
+	sibs, a, tt, err := Load3(ctx, books[1], LoadMany(siblings), LoadOne(author), LoadResolve(title))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sibs) != 2 || a.ID != 20 || tt != "tt" {
+		t.Fatalf("Load3 = %d siblings, author %d, %q", len(sibs), a.ID, tt)
+	}
+
+	// The results are cached for the batch like any other call's.
+	a, tt, err = Load2(ctx, books[0], LoadOne(author), LoadResolve(title))
+	if err != nil || a.ID != 10 || tt != "t" {
+		t.Fatalf("Load2 = author %d, %q, %v", a.ID, tt, err)
+	}
+}
+
+func TestLoad2_Errors(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	b := &Book{ID: 1}
+	NewEngine().InitHandles(b)
+	errBoom := errors.New("boom")
+	failing := func(context.Context, *Book) (int, error) { return 0, errBoom }
+	waiting := func(ctx context.Context, _ *Book) (string, error) {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(time.Second):
+			return "late", nil
+		}
+	}
+
+	start := time.Now()
+	_, _, err := Load2(ctx, b, failing, waiting)
+	if !errors.Is(err, errBoom) || errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v; want only boom", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("the first error did not cancel the other loader")
+	}
+
+	ok := func(context.Context, *Book) (string, error) { return "ok", nil }
+	errOther := errors.New("other")
+	other := func(context.Context, *Book) (string, error) { return "", errOther }
+	if _, s, err := Load2(ctx, b, failing, ok, WithAggregateErrors()); !errors.Is(err, errBoom) || s != "ok" {
+		t.Fatalf("Load2 = %q, %v; want ok and boom", s, err)
+	}
+	if _, _, _, err := Load3(ctx, b, failing, ok, other, WithAggregateErrors()); !errors.Is(err, errBoom) || !errors.Is(err, errOther) {
+		t.Fatalf("err = %v; want both errors", err)
+	}
+}
+
+func TestLoad_PanicNamesLoader(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	b := &Book{ID: 1}
+	NewEngine().InitHandles(b)
+	ok := func(context.Context, *Book) (int, error) { return 1, nil }
+	panicking := func(context.Context, *Book) (int, error) { panic("kaboom") }
+
+	for _, opts := range [][]LoadOption{nil, {WithAggregateErrors()}} {
+		_, _, err := Load2(ctx, b, ok, panicking, opts...)
+		if !errors.Is(err, errPanicked) || !strings.Contains(err.Error(), "Load2: loader 1 panicked: kaboom") {
+			t.Fatalf("Load2 %d options: err = %v; want loader 1's panic", len(opts), err)
+		}
+		_, _, _, err = Load3(ctx, b, ok, ok, panicking, opts...)
+		if !errors.Is(err, errPanicked) || !strings.Contains(err.Error(), "Load3: loader 2 panicked: kaboom") {
+			t.Fatalf("Load3 %d options: err = %v; want loader 2's panic", len(opts), err)
+		}
+	}
+}
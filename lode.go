@@ -5,68 +5,565 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"slices"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type Config struct {
-	batchSize int
+	batchSize        int
+	membershipCheck  bool
+	keyNamespace     string
+	keyVersion       string
+	debug            bool
+	buildBase        context.Context // see WithDetachedBuildContext
+	maxRelations     int
+	specDefaults     SpecDefaults
+	leakTracking     bool
+	strictKeys       bool
+	definedKeys      bool
+	fetchDedup       bool
+	bindingHint      string
+	maxBuilds        int
+	rebuildPolicy    RebuildPolicy
+	batchStrategy    BatchStrategy
+	fallbackFetch    bool
+	immutableKeys    []string
+	fetchConcurrency int
+	limiters         map[string]Limiter
+	fetchDescriptors bool
+
+	memAccounting bool
+	memBudget     int
+	memPolicy     BudgetPolicy
+
+	breakerThreshold int
+	breakerCooldown  time.Duration
+
+	hooks []Hooks
+
+	fetchStats       bool
+	singleKeyFetches int
+	fetchKeyCeiling  int
+
+	graphTrace bool
+
+	clock Clock
 }
 
 type ConfigOption func(*Config)
 
+// NewConfig returns the default configuration with opts applied.
+func NewConfig(opts ...ConfigOption) Config {
+	c := Config{
+		batchSize: 5000,
+		clock:     SystemClock(),
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
 func WithBatchSize(batchSize int) ConfigOption {
 	return func(c *Config) { c.batchSize = batchSize }
 }
 
-type Engine struct{ config Config }
+// WithKeyNamespace makes the engine store every cache key as
+// prefix+":"+key, and report it that way in errors, hooks, and stats, without
+// call sites changing.  Keys are namespaced by the engine that bound the
+// model, so bounded contexts sharing literal keys stay apart.
+func WithKeyNamespace(prefix string) ConfigOption {
+	return func(c *Config) { c.keyNamespace = prefix }
+}
+
+// key returns cacheKey as stored: in the engine's namespace and with its key
+// version.
+func (e *Engine) key(cacheKey string) string {
+	return e.namespaced(cacheKey) + e.versionSuffix()
+}
+
+// WithMembershipCheck makes Resolve (and therefore Many and One) verify that
+// the model it is called with is one of the pointers its state was bound
+// with.  A model that carries a state but is not in the batch has usually
+// been copied by value after InitHandles (e.g. ranging over []Author), and
+// its results would silently come from a different pointer.  The check is
+// O(1) per call; the pointer set is built lazily once per state.
+func WithMembershipCheck() ConfigOption {
+	return func(c *Config) { c.membershipCheck = true }
+}
+
+type Engine struct {
+	*engineCore
+	states  stateRegistry
+	globals sync.Map // cache key -> *resolverEntry; see Global
+}
+
+// engineCore is the part of an Engine shared with its scopes.
+type engineCore struct {
+	config  Config
+	breaker *circuitBreaker // nil unless WithCircuitBreaker
+	skipped atomic.Uint64   // see Stats.SkippedRelations
+	empty   atomic.Uint64   // see Stats.EmptyBuilds
+	binds   atomic.Uint64   // last BindID handed out
+	fetches *fetchStats     // nil unless WithFetchStats
+	graph   *graphTrace     // nil unless WithGraphTrace
+	keys    keyRegistry     // see RegisterKeys
+	mem     *memAccount     // nil unless WithMemoryBudget
+
+	buildSlots chan struct{}                                // nil unless WithMaxConcurrentBuilds
+	versions   atomic.Pointer[func(cacheKey string) uint64] // see SetVersionSource
+
+	bindingHint atomic.Pointer[string] // see WithBindingHint
+
+	keyVersion      atomic.Pointer[string] // see WithKeyVersion
+	keyVersionMu    sync.Mutex
+	retiredVersions map[string]struct{} // versions replaced by SetKeyVersion
+
+	warm atomic.Pointer[warmPlan] // see WarmFrom
+
+	immutable sync.Map // model type -> *sync.Map of cache key -> *resolverEntry; see WithImmutableKeys
+}
 
 func NewEngine(opts ...ConfigOption) *Engine {
-	c := Config{
-		batchSize: 5000,
-	}
+	return NewEngineFrom(NewConfig(), opts...)
+}
+
+// NewEngineFrom creates an engine from a copy of cfg with opts applied, so a
+// base configuration can be shared by engines specialized per workload.
+func NewEngineFrom(cfg Config, opts ...ConfigOption) *Engine {
+	c := cfg.Clone()
 	for _, opt := range opts {
 		opt(&c)
 	}
-	return &Engine{config: c}
+	if c.clock == nil {
+		c.clock = SystemClock()
+	}
+	e := &Engine{engineCore: &engineCore{config: c}}
+	if c.breakerThreshold > 0 {
+		e.breaker = newCircuitBreaker(c.breakerThreshold, c.breakerCooldown)
+	}
+	if c.fetchStats {
+		e.fetches = newFetchStats()
+	}
+	if c.graphTrace {
+		e.graph = &graphTrace{edges: make(map[[3]string]*GraphEdge)}
+	}
+	if c.memAccounting {
+		e.mem = &memAccount{}
+	}
+	if c.maxBuilds > 0 {
+		e.buildSlots = make(chan struct{}, c.maxBuilds)
+	}
+	e.retiredVersions = make(map[string]struct{})
+	if c.keyVersion != "" {
+		e.keyVersion.Store(&c.keyVersion)
+	}
+	if c.bindingHint != "" {
+		e.SetDefaultBindingHint(c.bindingHint)
+	}
+	return e
+}
+
+// Scope returns a child engine that shares e's configuration, hooks,
+// circuit breakers, and stats but binds its own states, so a subrequest (say,
+// an embedded widget render) can be reset without touching the parent's
+// batches.  Scopes are cheap: nothing is copied.
+func (e *Engine) Scope() *Engine {
+	return &Engine{engineCore: e.engineCore}
+}
+
+// ResetAll resets every live state bound by e (but not by its parent or
+// scopes), like calling Reset on a model of each batch.  Frozen states are
+// left alone and reported in an error wrapping ErrFrozen.
+func (e *Engine) ResetAll() error {
+	frozen := 0
+	for _, s := range e.states.live() {
+		if s.reset() != nil {
+			frozen++
+		}
+	}
+	if frozen > 0 {
+		return fmt.Errorf("%s: %w: skipped %d frozen states", packagePrefix, ErrFrozen, frozen)
+	}
+	return nil
+}
+
+// InvalidateKey clears the cached resolvers for cacheKey (and its
+// SinglePerKey variant) on every live state bound by e, of any model type, or
+// for the engine if it is one of WithImmutableKeys, and returns how many
+// entries it cleared.  Use it after bulk writes that may have
+// changed the relation for models you hold no reference to.  Frozen states are
+// left alone and reported in an error wrapping ErrFrozen.
+func (e *Engine) InvalidateKey(cacheKey string) (int, error) {
+	key, single := e.key(cacheKey), e.key(cacheKey+SinglePerKeySuffix)
+	cleared := e.deleteImmutable(func(stored string) bool { return stored == key || stored == single })
+	frozen := 0
+	for _, s := range e.states.live() {
+		n, err := s.invalidate([]string{cacheKey})
+		if err != nil {
+			frozen++
+		}
+		cleared += n
+	}
+	if frozen > 0 {
+		return cleared, fmt.Errorf("%s: %w: skipped %d frozen states", packagePrefix, ErrFrozen, frozen)
+	}
+	return cleared, nil
+}
+
+// Handle carries a model's loader state.  Embed it in every model type, and
+// pass models by pointer: a copied Handle shares its state with the original
+// and resolves as if it were the original, which go vet's copylocks check
+// reports and WithDebug turns into an error.
+//
+// Models are comparable, so a map[Author]bool compiles, but its keys compare
+// the Handle's state pointer along with the model's fields: equal models
+// from different batches are different keys.  Key such maps by identity
+// instead, with KeyFor or IndexBy.  Building with -tags lodestrict makes
+// Handle, and so every model embedding it, non-comparable, so that those
+// maps fail to compile; that will be the default in the next major version.
+type Handle struct {
+	_      noCopy
+	_      handleComparison
+	core   *loaderState
+	origin *Handle // the Handle's address at bind time, in debug mode
+}
+
+func (h *Handle) lodeState() *loaderState { return h.core }
+func (h *Handle) handle() *Handle         { return h }
+func (h *Handle) setLodeState(s *loaderState) {
+	h.core = s
+	if s != nil && s.engine.config.debug {
+		h.origin = h
+	}
 }
 
-type Handle struct{ core *loaderState }
+// noCopy makes go vet's copylocks check flag copies of a Handle.
+type noCopy struct{}
 
-func (h *Handle) lodeState() *loaderState     { return h.core }
-func (h *Handle) setLodeState(s *loaderState) { h.core = s }
+func (*noCopy) Lock()   {}
+func (*noCopy) Unlock() {}
 
-func (h *Handle) Reset() {
+// Reset clears every cached resolver on the model's state, so the next
+// Resolve, Many, or One for any model of the batch rebuilds.
+//
+// Reset may race with builds.  A build in flight when its entry is cleared
+// still completes and is returned to the callers already waiting on it, but
+// its result is discarded: a Resolve that starts after Reset returns builds
+// afresh.  The same holds for Invalidate, ResetPrefix, and Engine.ResetAll.
+//
+// Reset on a frozen state returns an error wrapping ErrFrozen; see Freeze.
+func (h *Handle) Reset() error {
 	if h.core == nil {
-		return
+		return nil
+	}
+	return h.core.reset()
+}
+
+// Invalidate clears the cached resolvers for the given cache keys (and their
+// SinglePerKey variants) on the model's state, keeping the others, e.g. to
+// refetch an author's books after creating one without dropping a costly
+// aggregate.  Like Reset it affects every model of the batch, and the next
+// Resolve, Many, or One for a cleared key rebuilds once for all of them.
+// Invalidate on an unbound model does nothing.
+func (h *Handle) Invalidate(cacheKeys ...string) error {
+	if h.core == nil {
+		return nil
+	}
+	_, err := h.core.invalidate(cacheKeys)
+	return err
+}
+
+// ResetPrefix clears the cached resolvers whose cache key starts with prefix
+// on the model's state.
+func (h *Handle) ResetPrefix(prefix string) error {
+	if h.core == nil {
+		return nil
+	}
+	return h.core.resetPrefix(prefix)
+}
+
+// Freeze marks the model's state read-only until Unfreeze: Reset,
+// Invalidate, and ResetPrefix on any model of the batch then fail with
+// ErrFrozen instead of clearing anything, while resolves, first builds
+// included, work as usual.  Freeze a batch before fanning its models out to
+// goroutines that must not disturb each other's caches.
+func (h *Handle) Freeze() {
+	if h.core != nil {
+		h.core.frozen.Store(true)
+	}
+}
+
+// Unfreeze undoes Freeze.
+func (h *Handle) Unfreeze() {
+	if h.core != nil {
+		h.core.frozen.Store(false)
 	}
-	h.core.resolverEntries.Clear()
 }
 
 type hasState interface {
 	lodeState() *loaderState
 	setLodeState(*loaderState)
+	handle() *Handle
 }
 
 var _ hasState = (*Handle)(nil)
 
+// HasHandle is implemented by every type that embeds a Handle.  Embed it in
+// your own interfaces to write specs against interface-typed models:
+//
+//	type OrgScoped interface {
+//		lode.HasHandle
+//		OrgID() uint
+//	}
+type HasHandle interface{ hasState }
+
 type loaderState struct {
 	models          any
 	engine          *Engine
 	resolverEntries sync.Map
+
+	membersOnce sync.Once
+	members     map[uintptr]struct{} // pointers in models; see isMember
+
+	converted sync.Map // reflect.Type -> []Model for interface Models; see modelsAs
+	shapes    sync.Map // cache key -> relationShape, in debug mode; see checkShape
+
+	generation atomic.Uint64 // see State.Generation
+	bindID     uint64        // see State.BindID
+	frozen     atomic.Bool   // see Handle.Freeze
+	boundAt    time.Time     // with WithLeakTracking; see LiveStates
+	overrides  sync.Map      // overrideKey -> Result; see Override
+
+	sharedFetches sync.Map // fingerprint -> *sharedFetch; see WithFetchDedup
+
+	mem atomic.Pointer[stateCharge] // see WithMemoryBudget
+
+	subsetKeys sync.Map    // cache key -> struct{}; see ResolveSpec.Subset
+	hasSubsets atomic.Bool // subsetKeys is not empty
+}
+
+// modelsAs returns the state's models as a []Model.  When Model is an
+// interface implemented by the bound element type, the models are converted
+// once per state and Model type and the result is cached.
+func modelsAs[Model any](s *loaderState) ([]Model, bool) {
+	if models, ok := s.models.([]Model); ok {
+		return models, true
+	}
+	t := reflect.TypeFor[Model]()
+	if t.Kind() != reflect.Interface {
+		return nil, false
+	}
+	if v, ok := s.converted.Load(t); ok {
+		return v.([]Model), true
+	}
+	ps := reflect.ValueOf(s.models)
+	if !ps.Type().Elem().Implements(t) {
+		return nil, false
+	}
+	out := make([]Model, ps.Len())
+	for i := range out {
+		out[i] = ps.Index(i).Interface().(Model)
+	}
+	v, _ := s.converted.LoadOrStore(t, out)
+	return v.([]Model), true
+}
+
+// isMember reports whether model is one of the pointers in s.models.  Models
+// that are not pointers cannot be checked and are reported as members.
+func (s *loaderState) isMember(model any) bool {
+	rv := reflect.ValueOf(model)
+	if rv.Kind() != reflect.Ptr {
+		return true
+	}
+	s.membersOnce.Do(func() {
+		ps := reflect.ValueOf(s.models)
+		s.members = make(map[uintptr]struct{}, ps.Len())
+		for i := 0; i < ps.Len(); i++ {
+			if el := ps.Index(i); el.Kind() == reflect.Ptr && !el.IsNil() {
+				s.members[el.Pointer()] = struct{}{}
+			}
+		}
+	})
+	_, ok := s.members[rv.Pointer()]
+	return ok
 }
 
-// InitHandles initializes the loader state for a slice of models.
-// ChatGPT prefers the name "Bind".  What do you think?
+// InitHandles initializes the loader state for a slice of models.  It is Bind
+// without the result; inputs that cannot be bound are ignored.
 func (e *Engine) InitHandles(models any) {
-	if models == nil {
-		return
+	_, _ = e.Bind(models)
+}
+
+// InitHandlesAll initializes the loader state for each of its arguments, as
+// BindAll does, and returns its error.
+func (e *Engine) InitHandlesAll(models ...any) error {
+	_, err := e.BindAll(models...)
+	return err
+}
+
+// State is an opaque reference to the loader state shared by one batch of
+// bound models.  States are comparable: two models share a batch exactly when
+// their States are equal.
+type State struct{ s *loaderState }
+
+// StateOf returns the state m is bound to, or false if m is nil or unbound.
+func StateOf(m hasState) (State, bool) {
+	if isNil(m) || m.lodeState() == nil {
+		return State{}, false
+	}
+	return State{s: m.lodeState()}, true
+}
+
+// ModelsOf returns every model bound alongside m, m included, as the batch a
+// Resolve build for m would see.  Model may be an interface type, as with
+// Resolve.  The slice is shared with the state (and with builds) and must be
+// treated as read-only.
+func ModelsOf[Model hasState](m Model) ([]Model, error) {
+	if isNil(m) || m.lodeState() == nil {
+		return nil, notInitialized(m)
+	}
+	return typedModels[Model](m.lodeState())
+}
+
+// typedModels is modelsAs with the error Resolve and friends report.
+func typedModels[Model any](s *loaderState) ([]Model, error) {
+	models, ok := modelsAs[Model](s)
+	if !ok {
+		return nil, typeMismatch[[]Model]("", reflect.TypeOf(s.models))
+	}
+	return models, nil
+}
+
+// Len returns the number of models bound to the state.
+func (s State) Len() int {
+	if s.s == nil {
+		return 0
+	}
+	return reflect.ValueOf(s.s.models).Len()
+}
+
+// Models returns the models bound to the state as a []*T.  The slice is
+// shared with the state and must not be modified.
+func (s State) Models() any {
+	if s.s == nil {
+		return nil
+	}
+	return s.s.models
+}
+
+// Reset clears every cached resolver on the state, like Handle.Reset.
+func (s State) Reset() error {
+	if s.s == nil {
+		return nil
+	}
+	return s.s.reset()
+}
+
+// Frozen reports whether the state is frozen; see Handle.Freeze.
+func (s State) Frozen() bool {
+	return s.s != nil && s.s.frozen.Load()
+}
+
+// BindID identifies the Bind (or BindAll) call that created the state.  IDs
+// are unique per engine, scopes included, and increase with every call.
+func (s State) BindID() uint64 {
+	if s.s == nil {
+		return 0
+	}
+	return s.s.bindID
+}
+
+// Generation returns the number of times the state has been reset or had
+// keys invalidated.  Hooks report it so logs can tell which generation of
+// cached results a resolve was served from.
+func (s State) Generation() uint64 {
+	if s.s == nil {
+		return 0
+	}
+	return s.s.generation.Load()
+}
+
+// BindBatch describes one state touched by a Bind call.
+type BindBatch struct {
+	State State
+	// Size is the number of models from this Bind call that are bound to
+	// State.
+	Size int
+}
+
+// BindResult describes the outcome of Engine.Bind.
+type BindResult struct {
+	// Batches lists the states the models are bound to, in model order.
+	// Models that were already bound to a single shared state are reported
+	// as one batch with that existing state.
+	Batches []BindBatch
+}
+
+// ResetAll resets every state in the result.  Frozen states are skipped and
+// their errors joined.
+func (r BindResult) ResetAll() error {
+	var errs []error
+	for _, b := range r.Batches {
+		if err := b.State.Reset(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+var (
+	hasStateType   = reflect.TypeFor[hasState]()
+	errNotBindable = &kindError{"value cannot be bound", ErrTypeMismatch}
+)
+
+// Bind initializes the loader state for a model or a slice of models (see
+// InitHandles for the accepted shapes) and reports the resulting states.
+// Binding nil or an empty slice is a no-op.
+func (e *Engine) Bind(models any) (BindResult, error) {
+	batches, err := e.bind(models, e.binds.Add(1))
+	if err != nil {
+		return BindResult{}, fmt.Errorf("%s: %w", packagePrefix, err)
+	}
+	return BindResult{Batches: batches}, nil
+}
+
+// BindAll binds each of its arguments as Bind would, so one call can bind
+// several slices, of the same or different model types.  Every state it
+// creates shares one BindID.  Arguments that cannot be bound are reported
+// together in the error, and do not stop the others from being bound; the
+// result lists the batches of the arguments that were.
+func (e *Engine) BindAll(models ...any) (BindResult, error) {
+	id := e.binds.Add(1)
+	var (
+		result BindResult
+		errs   []error
+	)
+	for i, m := range models {
+		batches, err := e.bind(m, id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: argument %d: %w", packagePrefix, i, err))
+			continue
+		}
+		result.Batches = append(result.Batches, batches...)
+	}
+	return result, errors.Join(errs...)
+}
+
+func (e *Engine) bind(models any, id uint64) ([]BindBatch, error) {
+	if isNil(models) {
+		return nil, nil
 	}
 	ptrSlice, ok := toPtrSlice(models)
-	if !ok || ptrSlice.Len() == 0 {
-		return
+	if !ok || !ptrSlice.Type().Elem().Implements(hasStateType) {
+		return nil, fmt.Errorf("%w: %T is not a model, a slice of models, or a pointer to either", errNotBindable, models)
 	}
-	e.bindPtrSlice(ptrSlice)
+	if ptrSlice.Len() == 0 {
+		return nil, nil
+	}
+	return e.bindPtrSlice(ptrSlice, id), nil
 }
 
 func toPtrSlice(models any) (reflect.Value, bool) {
@@ -128,7 +625,7 @@ func toPtrSlice(models any) (reflect.Value, bool) {
 
 // bindPtrSlice expects a slice of pointers (e.g. []*T). It decides whether a
 // (re)bind is needed, batches, and sets the shared loaderState on each element.
-func (e *Engine) bindPtrSlice(ps reflect.Value) {
+func (e *Engine) bindPtrSlice(ps reflect.Value, id uint64) []BindBatch {
 	// Detect whether we need to bind (nil or mixed state).
 	var first *loaderState
 	need := false
@@ -154,15 +651,23 @@ func (e *Engine) bindPtrSlice(ps reflect.Value) {
 		}
 	}
 	if !need {
-		return
+		if first == nil {
+			return nil
+		}
+		return []BindBatch{{State: State{s: first}, Size: ps.Len()}}
 	}
 
 	// Bind in batches; store models as []*T so Resolve's type assertion works.
-	for _, br := range batchRanges(ps.Len(), e.config.batchSize) {
-		sub := ps.Slice(br.StartInclusive, br.EndExclusive)
+	e.noteBound(ps.Type().Elem())
+	var batches []BindBatch
+	for _, sub := range e.batches(ps) {
 		state := &loaderState{
 			models: sub.Interface(), // always []*T
 			engine: e,
+			bindID: id,
+		}
+		if e.config.leakTracking {
+			state.boundAt = e.now()
 		}
 		for i := 0; i < sub.Len(); i++ {
 			el := sub.Index(i)
@@ -173,7 +678,33 @@ func (e *Engine) bindPtrSlice(ps reflect.Value) {
 				hl.setLodeState(state)
 			}
 		}
+		e.states.add(state)
+		batches = append(batches, BindBatch{State: State{s: state}, Size: sub.Len()})
+		e.warmState(state)
 	}
+	return batches
+}
+
+// build runs a resolver build for key, consulting the circuit breaker.
+func (e *Engine) build(key KeyLabel, fn func() (any, error)) (any, error) {
+	if e.breaker == nil {
+		return fn()
+	}
+	if err := e.breaker.allow(key, e.now()); err != nil {
+		return nil, err
+	}
+	panicked := true
+	defer func() {
+		// A panicking build counts as failed, and must not leave a probe
+		// in flight forever.
+		if panicked {
+			e.breaker.record(key, errPanicked, e.now())
+		}
+	}()
+	res, err := fn()
+	panicked = false
+	e.breaker.record(key, err, e.now())
+	return res, err
 }
 
 type ResolverFunc[Model any, Relation any] func(Model) Relation
@@ -183,14 +714,36 @@ type BuildResolverFunc[Model any, Relation any] func(context.Context, []Model) (
 type resolverHolder struct {
 	resolver any // holds Resolver[Model, Result]
 	err      error
+	info     Info // as reported to the caller that built it
+
+	descriptors []string // see SetFetchDescriptor
+	version     uint64   // see SetVersionSource
 }
 
 type resolverEntry struct {
 	once  sync.Once
 	ready atomic.Pointer[resolverHolder] // nil until built
+
+	// stale, if set, is served while the entry builds; see
+	// ServeStaleDuringRebuild.  claimed is set by the caller that builds.
+	stale   *resolverHolder
+	claimed atomic.Bool
+
+	// building is set once a build of the entry has started, so callers
+	// that will only wait for it take no build slot.
+	building atomic.Bool
+
+	// See WithMemoryBudget.
+	lastUsed atomic.Int64
+	released atomic.Bool
 }
 
-var errNoLoader = errors.New("model not initialized with loader")
+// errNoModelKey is reported under RequireModelKey for a model ModelKey
+// reports no key for.
+var errNoModelKey = &kindError{"model has no key", ErrNotFound}
+
+// errNotMember is reported for a model its state does not list.
+var errNotMember = &kindError{"model is not a member of its bound batch", ErrNotInitialized}
 
 const packagePrefix = "lode"
 
@@ -198,6 +751,27 @@ type ResolveSpec[Model hasState, Result any] struct {
 	CacheKey string
 	Model    Model
 	Build    BuildResolverFunc[Model, Result]
+	// Applies, if set, limits the spec to the models it accepts: others
+	// resolve to the zero Result at once, bound or not, and are left out of
+	// the models passed to Build.
+	Applies func(Model) bool
+	// Subset, if set, builds the resolver over only the models it accepts,
+	// leaving the state and its other resolvers alone; models it rejects
+	// resolve to the zero Result.  It works as Applies does, for resolvers
+	// kept beside full-batch ones on the same state, so the CacheKey must
+	// differ from theirs: a key used both with and without Subset on one
+	// state is an error.
+	Subset func(Model) bool
+	// NilModel says what to do when Model is nil, overriding
+	// WithNilModelPolicy; see NilModelPolicy.
+	NilModel NilModelPolicy
+	// FallbackBuild makes Resolve build over just Model, uncached, when
+	// it was never bound; see WithFallbackFetch.
+	FallbackBuild bool
+	// FallbackEngine is the engine whose WithFallbackFetch and hooks apply
+	// when Model was never bound, and whose WithNilModelPolicy applies when
+	// Model is nil; the default engine, if set, when nil.
+	FallbackEngine *Engine
 }
 
 func applyResolver[Model any, Result any](h *resolverHolder, cacheKey string, model Model) (Result, error) {
@@ -210,45 +784,80 @@ func applyResolver[Model any, Result any](h *resolverHolder, cacheKey string, mo
 	}
 	fn, ok := h.resolver.(ResolverFunc[Model, Result])
 	if !ok {
-		return zero, fmt.Errorf("%s: key %q used with incompatible result type", packagePrefix, cacheKey)
+		return zero, typeMismatch[ResolverFunc[Model, Result]](cacheKey, reflect.TypeOf(h.resolver))
 	}
 	return fn(model), nil
 }
 
+// Resolve builds (once per state and CacheKey) a resolver over every model
+// bound alongside spec.Model and applies it to spec.Model.
+//
+// Model may be an interface type (embedding HasHandle) implemented by the
+// bound concrete type, so one spec can serve several model types.  The first
+// Resolve for such a Model on a state converts the bound []*T into a []Model,
+// which costs one allocation and one interface conversion per model; the
+// converted slice is cached on the state and shared by later builds.
 func Resolve[Model hasState, Result any](ctx context.Context, spec ResolveSpec[Model, Result]) (Result, error) {
+	result, _, err := ResolveInfo(ctx, spec)
+	return result, err
+}
+
+// ResolveInfo is Resolve that also reports how the result was obtained.
+func ResolveInfo[Model hasState, Result any](ctx context.Context, spec ResolveSpec[Model, Result]) (Result, Info, error) {
 	var emptyResult Result
 	if isNil(spec.Model) {
-		// question: should we return an error here?
-		return emptyResult, nil
+		return emptyResult, Info{}, spec.NilModel.nilModel(spec.CacheKey, spec.FallbackEngine)
 	}
-
-	loader := spec.Model.lodeState()
-	if loader == nil {
-		return emptyResult, errNoLoader
+	accepts := both(spec.Applies, spec.Subset)
+	if accepts != nil && !accepts(spec.Model) {
+		return emptyResult, Info{}, nil
 	}
 
-	pmi, _ := loader.resolverEntries.LoadOrStore(spec.CacheKey, &resolverEntry{})
-	pm := pmi.(*resolverEntry)
-
-	if h := pm.ready.Load(); h != nil {
-		return applyResolver[Model, Result](h, spec.CacheKey, spec.Model)
+	if spec.Model.lodeState() == nil {
+		if _, ok := fallback(spec.Model, spec.CacheKey, spec.FallbackEngine, spec.FallbackBuild); ok {
+			fn, err := spec.Build(ctx, []Model{spec.Model})
+			if err != nil {
+				return emptyResult, Info{}, err
+			}
+			return fn(spec.Model), Info{}, nil
+		}
 	}
 
-	pm.once.Do(func() {
-		var res any
-		var err error
-		if models, ok := loader.models.([]Model); !ok {
-			err = fmt.Errorf("%s: models is not a slice of %T", packagePrefix, spec.Model)
-		} else {
-			res, err = spec.Build(ctx, models)
+	entry, err := Entry(spec.Model, spec.CacheKey)
+	if err != nil {
+		return emptyResult, Info{}, err
+	}
+	loader := spec.Model.lodeState()
+	if v, ok, err := override[Result](loader, entry.cacheKey, spec.Model); ok {
+		return v, Info{}, err
+	}
+	h, built := entry.getOrBuild(ctx, func(ctx context.Context) (any, error) {
+		if spec.Subset != nil {
+			loader.subsetBuilt(entry.cacheKey)
+		}
+		models, err := typedModels[Model](loader)
+		if err != nil {
+			return nil, err
 		}
-		pm.ready.Store(&resolverHolder{resolver: res, err: err})
+		return spec.Build(ctx, applicable(models, accepts))
 	})
-
-	return applyResolver[Model, Result](pm.ready.Load(), spec.CacheKey, spec.Model)
-
+	if err := loader.checkSubset(entry.cacheKey, spec.Subset != nil); err != nil {
+		return emptyResult, Info{}, err
+	}
+	return applyResolverInfo[Model, Result](h, entry.cacheKey, spec.Model, !built)
 }
 
+// RelationSpec describes a relation loaded by Many and One.  Calls on sibling
+// models share one fetch when they use the same CacheKey, whether they go
+// through Many or One; the first call's spec builds the resolver for all of
+// them.  Specs sharing a CacheKey must therefore agree on the JoinKey and
+// Relation types and on ModelKey and RelationKey (or RelationKeyOK), or later
+// callers get groups keyed the way the first caller meant.  WithDebug warns
+// when they visibly disagree.
+//
+// Caches are per state, so specs for different model types may reuse a
+// CacheKey freely; engine-wide bookkeeping such as circuit breakers and fetch
+// stats is kept per cache key and model type (see KeyLabel).
 type RelationSpec[JoinKey comparable, Model hasState, Relation any] struct {
 	CacheKey string
 	Model    Model
@@ -257,7 +866,277 @@ type RelationSpec[JoinKey comparable, Model hasState, Relation any] struct {
 	// Unlike the model key, the relation key should be present (because only
 	// joined relations should be fetched!)
 	RelationKey func(Relation) JoinKey
-	Fetch       func(context.Context, []JoinKey) ([]Relation, error)
+	// RelationKeyOK may be set instead of RelationKey when some fetched
+	// relations may lack a key.  Relations it reports !ok for are dropped
+	// and counted (see Hooks.OnSkipped and Stats.SkippedRelations).
+	RelationKeyOK func(Relation) (key JoinKey, ok bool)
+	Fetch         func(context.Context, []JoinKey) ([]Relation, error)
+
+	// SizeOf, if set, sizes a fetched relation for WithMemoryBudget; unset,
+	// each relation counts as one.
+	SizeOf func(Relation) int
+
+	// ValidateRelation, if set, checks each fetched relation before
+	// grouping, to catch a backend that silently changed (a renamed column
+	// scanning as zero, say) before its results are cached.  The first
+	// error fails the build, wrapped with the cache key and the relation's
+	// index in fetch order.  Nil relations are not checked.  Specs sharing
+	// a fetch under WithFetchDedup share the first spec's validation.
+	ValidateRelation func(Relation) error
+
+	// FetchPage may be set instead of Fetch for backends that return results
+	// a page at a time.  Many calls it with an empty cursor first and keeps
+	// calling it with the returned cursor until that is empty, concatenating
+	// the pages before grouping.
+	FetchPage func(ctx context.Context, keys []JoinKey, cursor string) (items []Relation, next string, err error)
+	// MaxPages caps the number of FetchPage calls per build; exceeding it
+	// fails the build.  Zero means the engine's SpecDefaults.MaxPages, or
+	// DefaultMaxPages if that is zero too.
+	MaxPages int
+	// MaxRelations overrides the engine's WithMaxRelationsPerBuild limit
+	// for this spec; negative means no limit.
+	MaxRelations int
+	// KeyOrder, if set, sorts the keys passed to the fetch functions, for
+	// backends that want them in order.  Otherwise they are in the order of
+	// the batch's models, each key where it is first seen, so a batch's
+	// fetches are the same on every run.
+	KeyOrder func(a, b JoinKey) int
+	// MaxKeysPerFetch, if positive, splits a build's keys into chunks of at
+	// most that many, each fetched by its own call (following KeyOrder
+	// across chunks).  Relations from all chunks are combined in chunk
+	// order before grouping, and MaxPages and MaxRelations apply to their
+	// total; the first failing chunk fails the build.  Zero means the
+	// engine's WithFetchChunkSize; negative means one call for all keys.
+	MaxKeysPerFetch int
+	// FetchStream is the fetch form used by Stream: it calls yield for each
+	// relation as it arrives and stops when yield returns an error.  Many
+	// can use it too, collecting the relations, when Fetch is not set.
+	FetchStream func(ctx context.Context, keys []JoinKey, yield func(Relation) error) error
+	// FetchGrouped may be set instead of Fetch or FetchPage for backends
+	// that return relations already grouped by join key, such as a cache
+	// multi-get.  Many uses the groups as returned (RelationKey is not
+	// consulted) but still drops nil relations and binds the rest.
+	FetchGrouped func(ctx context.Context, keys []JoinKey) (map[JoinKey][]Relation, error)
+
+	// SinglePerKey hints that only the first relation per join key is
+	// needed, as with One.  Many keeps at most one relation per key, and the
+	// fetch ctx is marked (see IsSinglePerKey) so fetch helpers such as
+	// lodegorm.Fetch can limit the query to one row per key.  Hinted specs
+	// are cached under CacheKey+SinglePerKeySuffix so they never share a
+	// resolver with an unhinted Many using the same CacheKey.
+	SinglePerKey bool
+	// PickFirst, if set, reports whether a should be picked over b as the
+	// first relation of a key (e.g. lower ID, or later CreatedAt).  One and
+	// SinglePerKey use it to pick a key's relation deterministically; unset,
+	// they keep the first in fetch order, which is unspecified.  Fetch
+	// helpers honouring the SinglePerKey hint pick before PickFirst sees the
+	// rows, so the two should agree.
+	PickFirst func(a, b Relation) bool
+	// FetchID names what Fetch fetches, so that under WithFetchDedup specs
+	// with the same FetchID share one fetch per state and key set.  Specs
+	// may only share a FetchID if their fetches return the same relations.
+	FetchID string
+	// Limiter names a Limiter registered with WithLimiter that each fetch
+	// call, or each chunk's calls of FetchPage, waits for, so specs hitting
+	// one rate-limited backend share its quota.
+	Limiter string
+
+	// CompactGroups makes Many store its groups as ranges of one slice of
+	// the fetched relations, ordered by key, instead of a slice per key.
+	// That saves memory and allocations for relations with many keys; each
+	// group still holds its relations in fetch order.  It has no effect on
+	// SinglePerKey specs or with FetchGrouped.
+	CompactGroups bool
+
+	// SortRelations, if set, orders each key's relations, which are
+	// otherwise in fetch order.  SinglePerKey and One keep the first in
+	// that order unless PickFirst is set.
+	SortRelations func(a, b Relation) int
+	// StableSort, if set to true, keeps relations SortRelations finds equal
+	// in fetch order.
+	StableSort *bool
+	// CopyResults, if set to true, makes Many return a copy of the cached
+	// slice, for callers that sort or append to their results.
+	CopyResults *bool
+	// StrictFetch, if set to true, fails the build with
+	// ErrUnexpectedRelation when the fetch returns relations Many would
+	// otherwise drop: nil ones, ones without a key, and ones under keys that
+	// were not asked for.
+	StrictFetch *bool
+	// RequireModelKey, if set to true, makes Many and One fail with
+	// ErrNotFound when ModelKey reports no key for Model, rather than
+	// return no relations.
+	RequireModelKey *bool
+
+	// BackRef, when its CacheKey is set, makes Many hand the fetched
+	// relations a ready-made resolver back to their parents; see BackRef.
+	BackRef BackRef[JoinKey, Relation]
+
+	// Applies, if set, limits the relation to the models it accepts: others
+	// get no relations at once, bound or not, and their keys are not
+	// fetched.
+	Applies func(Model) bool
+
+	// BindRelations, if set to false, stops Many from binding the fetched
+	// relations to the engine (see Engine.InitHandles), for relations that
+	// are never resolved from.  Relation types that cannot be models are
+	// never bound.
+	BindRelations *bool
+
+	// PartitionBy, if set, splits a build's models by the value it returns
+	// for each, e.g. their tenant, and fetches each partition separately
+	// with only its own keys; see PartitionOf.  Values must be comparable.
+	PartitionBy func(Model) any
+
+	// NilModel says what to do when Model is nil, overriding
+	// WithNilModelPolicy; see NilModelPolicy.
+	NilModel NilModelPolicy
+	// FallbackFetch makes Many and One fetch just Model's key, uncached,
+	// when it was never bound; see WithFallbackFetch.
+	FallbackFetch bool
+	// FallbackEngine is the engine whose WithFallbackFetch and hooks apply
+	// when Model was never bound, and which binds the relations fetched for
+	// it, and whose WithNilModelPolicy applies when Model is nil; the
+	// default engine, if set, when nil.
+	FallbackEngine *Engine
+}
+
+// SinglePerKeySuffix is appended to the cache key of SinglePerKey specs.
+const SinglePerKeySuffix = "#single"
+
+type singlePerKeyCtxKey struct{}
+
+// IsSinglePerKey reports whether ctx belongs to a fetch for a SinglePerKey
+// spec, in which case returning more than one relation per key is wasted
+// work.
+func IsSinglePerKey(ctx context.Context) bool {
+	v, _ := ctx.Value(singlePerKeyCtxKey{}).(bool)
+	return v
+}
+
+// For returns a copy of the spec with Model set to m, for specs that are
+// defined once (e.g. by lodegorm.Relation) and used for many models.
+func (args RelationSpec[JoinKey, Model, Relation]) For(m Model) RelationSpec[JoinKey, Model, Relation] {
+	args.Model = m
+	return args
+}
+
+func (args RelationSpec[JoinKey, Model, Relation]) relationKey(r Relation) (JoinKey, bool) {
+	if args.RelationKeyOK != nil {
+		return args.RelationKeyOK(r)
+	}
+	return args.RelationKey(r), true
+}
+
+// dropNil returns relations without its nil elements, and how many there
+// were.  The input is returned as-is when it has none.
+func dropNil[Relation any](relations []Relation) ([]Relation, int) {
+	switch reflect.TypeFor[Relation]().Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+	default:
+		return relations, 0
+	}
+	var kept []Relation
+	for i, r := range relations {
+		if !isNilValue(r) {
+			if kept != nil {
+				kept = append(kept, r)
+			}
+			continue
+		}
+		if kept == nil {
+			kept = append(make([]Relation, 0, len(relations)-1), relations[:i]...)
+		}
+	}
+	if kept == nil {
+		return relations, 0
+	}
+	return kept, len(relations) - len(kept)
+}
+
+// group groups relations by their join key, returning how many had none.
+func (args RelationSpec[JoinKey, Model, Relation]) group(relations []Relation) (map[JoinKey][]Relation, int) {
+	grouped := make(map[JoinKey][]Relation)
+	unplaced := 0
+	for _, relation := range relations {
+		parentID, ok := args.relationKey(relation)
+		if !ok {
+			unplaced++
+			continue
+		}
+		if args.SinglePerKey && len(grouped[parentID]) > 0 {
+			if args.PickFirst != nil && args.PickFirst(relation, grouped[parentID][0]) {
+				grouped[parentID][0] = relation
+			}
+			continue
+		}
+		grouped[parentID] = append(grouped[parentID], relation)
+	}
+	return grouped, unplaced
+}
+
+// trimGroups applies to FetchGrouped's groups what group applies while
+// grouping: nil relations are dropped and SinglePerKey keeps only the first.
+// Groups, empty ones included, are otherwise used as returned.
+func (args RelationSpec[JoinKey, Model, Relation]) trimGroups(fetched map[JoinKey][]Relation) map[JoinKey][]Relation {
+	grouped := make(map[JoinKey][]Relation, len(fetched))
+	for key, rs := range fetched {
+		rs, _ = dropNil(rs)
+		rs = args.sorted(rs)
+		if args.SinglePerKey && len(rs) > 1 {
+			rs = []Relation{args.first(rs)}
+		}
+		grouped[key] = rs
+	}
+	return grouped
+}
+
+// sorted returns relations in SortRelations order.  They are sorted as a
+// copy, since they may be shared with other specs (see WithFetchDedup) or
+// held by FetchGrouped's caller.
+func (args RelationSpec[JoinKey, Model, Relation]) sorted(relations []Relation) []Relation {
+	if args.SortRelations == nil || len(relations) < 2 {
+		return relations
+	}
+	relations = slices.Clone(relations)
+	if isSet(args.StableSort) {
+		slices.SortStableFunc(relations, args.SortRelations)
+	} else {
+		slices.SortFunc(relations, args.SortRelations)
+	}
+	return relations
+}
+
+// strayRelations counts the relations of grouped, whose groups size
+// measures, under keys other than keys.
+func strayRelations[JoinKey comparable, Group any](keys []JoinKey, grouped map[JoinKey]Group, size func(Group) int) int {
+	requested := make(map[JoinKey]struct{}, len(keys))
+	for _, k := range keys {
+		requested[k] = struct{}{}
+	}
+	n := 0
+	for k, g := range grouped {
+		if _, ok := requested[k]; !ok {
+			n += size(g)
+		}
+	}
+	return n
+}
+
+// modelKeys returns the distinct join keys of models in first-seen order.
+// Keys reports the same set.
+func (args RelationSpec[JoinKey, Model, Relation]) modelKeys(models []Model) []JoinKey {
+	var modelKeySet = make(map[JoinKey]struct{})
+	var modelKeys []JoinKey
+	for _, model := range models {
+		if key, ok := args.ModelKey(model); ok {
+			if _, seen := modelKeySet[key]; !seen {
+				modelKeySet[key] = struct{}{}
+				modelKeys = append(modelKeys, key)
+			}
+		}
+	}
+	return modelKeys
 }
 
 func isNil[T any](v T) bool {
@@ -265,76 +1144,251 @@ func isNil[T any](v T) bool {
 	return !rv.IsValid() || (rv.Kind() == reflect.Ptr && rv.IsNil())
 }
 
+// isNilValue is like isNil but also treats nil maps, slices, funcs, and
+// channels as nil.
+func isNilValue[T any](v T) bool {
+	rv := reflect.ValueOf(any(v))
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	}
+	return false
+}
+
 func Many[JoinKey comparable, Model hasState, Relation any](ctx context.Context, args RelationSpec[JoinKey, Model, Relation]) ([]Relation, error) {
+	result, _, err := ManyInfo(ctx, args)
+	return result, err
+}
+
+// ManyInfo is Many that also reports how the result was obtained.
+func ManyInfo[JoinKey comparable, Model hasState, Relation any](ctx context.Context, args RelationSpec[JoinKey, Model, Relation]) ([]Relation, Info, error) {
 	if isNil(args.Model) {
-		return nil, nil
+		return nil, Info{}, args.NilModel.nilModel(args.CacheKey, args.FallbackEngine)
 	}
+	if args.Applies != nil && !args.Applies(args.Model) {
+		return nil, Info{}, nil
+	}
+	args = args.withDefaults(specEngine(args.Model, args.FallbackEngine))
 	_, ok := args.ModelKey(args.Model)
 	if !ok {
-		return nil, nil
+		if isSet(args.RequireModelKey) {
+			return nil, Info{}, fmt.Errorf("%s: key %q: %w", packagePrefix, args.CacheKey, errNoModelKey)
+		}
+		return nil, Info{}, nil
 	}
 	loader := args.Model.lodeState()
-	if loader == nil {
-		return nil, errNoLoader
+	unbound := loader == nil
+	if unbound {
+		e, ok := fallback(args.Model, args.CacheKey, args.FallbackEngine, args.FallbackFetch)
+		if !ok {
+			return nil, Info{}, notInitialized(args.Model)
+		}
+		loader = &loaderState{models: []Model{args.Model}, engine: e}
+	}
+	if err := loader.engine.checkRelationKey(args.CacheKey); err != nil {
+		return nil, Info{}, err
+	}
+
+	cacheKey := args.CacheKey
+	if args.SinglePerKey {
+		cacheKey += SinglePerKeySuffix
+	}
+	args.CacheKey = loader.engine.key(args.CacheKey) // as reported by hooks and errors
+	if loader.engine.config.debug {
+		args.checkShape(loader, loader.engine.key(cacheKey))
 	}
 
 	queryFunc := func(ctx context.Context, models []Model) (ResolverFunc[Model, []Relation], error) {
-		var modelKeySet = make(map[JoinKey]struct{})
-		for _, model := range models {
-			if key, ok := args.ModelKey(model); ok {
-				modelKeySet[key] = struct{}{}
-			}
+		if args.SinglePerKey {
+			ctx = context.WithValue(ctx, singlePerKeyCtxKey{}, true)
 		}
-		var modelKeys = make([]JoinKey, 0, len(modelKeySet))
-		for key := range modelKeySet {
-			modelKeys = append(modelKeys, key)
+		models = applicable(models, args.Applies)
+		if args.PartitionBy != nil {
+			return args.loadPartitioned(ctx, loader, models)
 		}
-
-		relations, err := args.Fetch(ctx, modelKeys)
+		lookup, err := args.load(ctx, loader, models)
 		if err != nil {
 			return nil, err
 		}
-
-		// note that this setup code is not necessary in the gorm case because
-		// SetupLoaders has likely already been called by the gorm callback,
-		// but I left this here because I think it will be useful in other cases
-		loader.engine.InitHandles(relations)
-
-		grouped := make(map[JoinKey][]Relation)
-		for _, relation := range relations {
-			parentID := args.RelationKey(relation)
-			grouped[parentID] = append(grouped[parentID], relation)
-		}
 		return func(m Model) []Relation {
 			if id, ok := args.ModelKey(m); ok {
-				return grouped[id]
+				return lookup(id)
 			}
 			return nil
 		}, nil
 	}
-	result, err := Resolve(ctx, ResolveSpec[Model, []Relation]{
-		CacheKey: args.CacheKey,
+	if unbound {
+		fn, err := queryFunc(ctx, []Model{args.Model})
+		if err != nil {
+			return nil, Info{}, err
+		}
+		return args.result(fn(args.Model)), Info{}, nil
+	}
+	result, info, err := ResolveInfo(ctx, ResolveSpec[Model, []Relation]{
+		CacheKey: cacheKey,
 		Model:    args.Model,
 		Build:    queryFunc,
 	})
+	if err != nil {
+		return nil, info, err
+	}
+	return args.result(result), info, nil
+}
+
+// result returns a key's cached relations as Many returns them: copied under
+// CopyResults.
+func (args RelationSpec[JoinKey, Model, Relation]) result(relations []Relation) []Relation {
+	if isSet(args.CopyResults) && relations != nil {
+		return slices.Clone(relations)
+	}
+	return relations
+}
+
+// load fetches and groups the relations of models, returning the lookup
+// from join key to relations.
+func (args RelationSpec[JoinKey, Model, Relation]) load(ctx context.Context, loader *loaderState, models []Model) (func(JoinKey) []Relation, error) {
+	modelKeys := args.modelKeys(models)
+	if len(modelKeys) == 0 {
+		// Nothing to fetch; some Fetch implementations mishandle an
+		// empty key set (e.g. IN () or IN (NULL)), so don't call it.
+		loader.engine.empty.Add(1)
+		return func(JoinKey) []Relation { return nil }, nil
+	}
+
+	relations, fetched, nils, err := args.fetchBound(ctx, loader, modelKeys)
 	if err != nil {
 		return nil, err
 	}
-	return result, nil
+	relations = args.sorted(relations)
+
+	var (
+		lookup   func(JoinKey) []Relation
+		unplaced int
+		stray    int // relations under keys not asked for, under StrictFetch
+		strict   = isSet(args.StrictFetch)
+		values   = args.valueRelations()
+		bind     []Relation // the grouped relations, when values
+	)
+	switch {
+	case fetched != nil:
+		grouped := args.trimGroups(fetched)
+		lookup = func(id JoinKey) []Relation { return grouped[id] }
+		if strict {
+			stray = strayRelations(modelKeys, grouped, func(rs []Relation) int { return len(rs) })
+		}
+		if values {
+			bind = packGroups(grouped, modelKeys)
+		}
+		if loader.engine.config.debug {
+			checkGrouping(loader.engine, args.CacheKey, modelKeys, grouped, len(relations))
+		}
+	case args.CompactGroups && !args.SinglePerKey:
+		var c compactGroups[JoinKey, Relation]
+		c, unplaced = args.compactGroup(relations)
+		lookup = c.lookup
+		bind = c.backing
+		if strict {
+			stray = strayRelations(modelKeys, c.index, func(r rangeIndex) int { return r.EndExclusive - r.StartInclusive })
+		}
+		if loader.engine.config.debug {
+			checkGrouping(loader.engine, args.CacheKey, modelKeys, c.index, len(relations)-unplaced)
+		}
+	default:
+		var grouped map[JoinKey][]Relation
+		grouped, unplaced = args.group(relations)
+		lookup = func(id JoinKey) []Relation { return grouped[id] }
+		if strict {
+			stray = strayRelations(modelKeys, grouped, func(rs []Relation) int { return len(rs) })
+		}
+		if values {
+			bind = packGroups(grouped, modelKeys)
+		}
+		if loader.engine.config.debug {
+			checkGrouping(loader.engine, args.CacheKey, modelKeys, grouped, len(relations)-unplaced)
+		}
+	}
+	if strict && nils+unplaced+stray > 0 {
+		return nil, fmt.Errorf("%s: key %q: %w: %d nil, %d without a key, %d under keys not fetched",
+			packagePrefix, args.CacheKey, ErrUnexpectedRelation, nils, unplaced, stray)
+	}
+	if values {
+		loader.engine.InitHandles(bind)
+		loader.engine.onRelationsBound(args.CacheKey, bind)
+	}
+	loader.engine.onSkipped(SkipEvent{CacheKey: args.CacheKey, Nil: nils, Unplaced: unplaced})
+	if err := loader.engine.charge(ctx, loader, args.CacheKey, args.sizeOf(relations)); err != nil {
+		return nil, err
+	}
+	if args.BackRef.CacheKey != "" {
+		args.bindBackRef(loader.engine, models, relations)
+	}
+	return lookup, nil
 }
 
 func One[JoinKey comparable, Model hasState, Relation any](ctx context.Context, args RelationSpec[JoinKey, Model, Relation]) (Relation, error) {
+	result, _, err := OneInfo(ctx, args)
+	return result, err
+}
+
+// OneInfo is One that also reports how the result was obtained.
+func OneInfo[JoinKey comparable, Model hasState, Relation any](ctx context.Context, args RelationSpec[JoinKey, Model, Relation]) (Relation, Info, error) {
 	var emptyResult Relation
-	relations, err := Many(ctx, args)
+	relations, info, err := ManyInfo(ctx, args)
 	if err != nil {
-		return emptyResult, err
+		return emptyResult, info, err
 	}
 	if len(relations) == 0 {
-		return emptyResult, nil
+		return emptyResult, info, nil
+	}
+	return args.first(relations), info, nil
+}
+
+// OneOK is One that also reports whether the model has a relation, for value
+// Relation types (a Book, not a *Book), whose zero value could be a real
+// relation.  Like One's, the relation it returns is a copy when Relation is
+// a value type: resolve nested relations through Many's results instead,
+// whose values are the bound ones.
+func OneOK[JoinKey comparable, Model hasState, Relation any](ctx context.Context, args RelationSpec[JoinKey, Model, Relation]) (Relation, bool, error) {
+	var emptyResult Relation
+	relations, err := Many(ctx, args)
+	if err != nil || len(relations) == 0 {
+		return emptyResult, false, err
+	}
+	return args.first(relations), true, nil
+}
+
+// OneStrict is One for relations that must exist exactly once per model: it
+// fails with ErrNotFound if the model has none and with a *MultipleError
+// (ErrMultiple) if it has more than one.
+func OneStrict[JoinKey comparable, Model hasState, Relation any](ctx context.Context, args RelationSpec[JoinKey, Model, Relation]) (Relation, error) {
+	var emptyResult Relation
+	relations, err := Many(ctx, args)
+	switch {
+	case err != nil:
+		return emptyResult, err
+	case len(relations) == 0:
+		return emptyResult, fmt.Errorf("%s: key %q: %w", packagePrefix, args.CacheKey, ErrNotFound)
+	case len(relations) > 1:
+		return emptyResult, fmt.Errorf("%s: %w", packagePrefix, &MultipleError{CacheKey: args.CacheKey, Count: len(relations)})
 	}
 	return relations[0], nil
 }
 
+// first returns the relation PickFirst prefers, or relations[0] without it.
+func (args RelationSpec[JoinKey, Model, Relation]) first(relations []Relation) Relation {
+	best := relations[0]
+	if args.PickFirst != nil {
+		for _, r := range relations[1:] {
+			if args.PickFirst(r, best) {
+				best = r
+			}
+		}
+	}
+	return best
+}
+
 func FromPtr[T any](t *T) (T, bool) {
 	var zero T
 	if t == nil {
@@ -349,17 +1403,9 @@ type rangeIndex struct {
 }
 
 func batchRanges(n, batchSize int) []rangeIndex {
-	if n == 0 {
-		return nil
-	}
-	nb := (n + batchSize - 1) / batchSize
-	out := make([]rangeIndex, 0, nb)
-	for i := 0; i < n; i += batchSize {
-		j := i + batchSize
-		if j > n {
-			j = n
-		}
-		out = append(out, rangeIndex{StartInclusive: i, EndExclusive: j})
+	var out []rangeIndex
+	for start, end := range ChunkRanges(n, batchSize) {
+		out = append(out, rangeIndex{StartInclusive: start, EndExclusive: end})
 	}
 	return out
 }
@@ -2,8 +2,15 @@ package lode
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
+
+	"github.com/willhf/lode/lodetest"
 )
 
 type Author struct {
@@ -89,19 +96,7 @@ func TestMany_Basic(t *testing.T) {
 		{ID: 3, AuthorID: 2, Title: "A2-Only"},
 	}
 
-	fetch := func(_ context.Context, keys []int) ([]*Book, error) {
-		set := map[int]struct{}{}
-		for _, k := range keys {
-			set[k] = struct{}{}
-		}
-		var out []*Book
-		for _, b := range all {
-			if _, ok := set[b.AuthorID]; ok {
-				out = append(out, b)
-			}
-		}
-		return out, nil
-	}
+	fetch := lodetest.FetchFromSlice(all, func(b *Book) int { return b.AuthorID })
 
 	spec := RelationSpec[int, *Author, *Book]{
 		CacheKey:    "author:books",
@@ -155,19 +150,7 @@ func TestOne_BasicAndEmpty(t *testing.T) {
 		{ID: 2, AuthorID: 1, Title: "SecondPick"},
 	}
 
-	fetch := func(_ context.Context, keys []int) ([]*Book, error) {
-		set := map[int]struct{}{}
-		for _, k := range keys {
-			set[k] = struct{}{}
-		}
-		var out []*Book
-		for _, b := range all {
-			if _, ok := set[b.AuthorID]; ok {
-				out = append(out, b)
-			}
-		}
-		return out, nil
-	}
+	fetch := lodetest.FetchFromSlice(all, func(b *Book) int { return b.AuthorID })
 
 	spec := RelationSpec[int, *Author, *Book]{
 		CacheKey:    "author:firstBook",
@@ -197,6 +180,93 @@ func TestOne_BasicAndEmpty(t *testing.T) {
 	}
 }
 
+func TestOneAndMany_ShareFetch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+
+	a1, a2, a3 := &Author{ID: 1}, &Author{ID: 2}, &Author{ID: 3}
+	eng.InitHandles([]*Author{a1, a2, a3})
+
+	all := []*Book{
+		{ID: 1, AuthorID: 1, Title: "A1"},
+		{ID: 2, AuthorID: 1, Title: "A2"},
+		{ID: 3, AuthorID: 2, Title: "B1"},
+	}
+	rec := lodetest.FetchFunc(lodetest.FetchFromSlice(all, func(b *Book) int { return b.AuthorID }))
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch:       rec.Fetch,
+	}
+	with := func(a *Author) RelationSpec[int, *Author, *Book] {
+		s := spec
+		s.Model = a
+		return s
+	}
+
+	// Interleave One and Many across the siblings, starting with One.
+	first, err := One(ctx, with(a1))
+	if err != nil || first.Title != "A1" {
+		t.Fatalf("One(a1) = %v, %v", first, err)
+	}
+	if got, err := Many(ctx, with(a2)); err != nil || !equalStrings(titles(got), []string{"B1"}) {
+		t.Fatalf("Many(a2) = %v, %v", titles(got), err)
+	}
+	if got, err := One(ctx, with(a3)); err != nil || got != nil {
+		t.Fatalf("One(a3) = %v, %v; want nil", got, err)
+	}
+	if got, err := Many(ctx, with(a1)); err != nil || !equalStrings(titles(got), []string{"A1", "A2"}) {
+		t.Fatalf("Many(a1) = %v, %v", titles(got), err)
+	}
+
+	if rec.Calls() != 1 {
+		t.Fatalf("fetch calls = %d; want 1 shared by One and Many", rec.Calls())
+	}
+}
+
+func TestOne_PickFirst(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	all := []*Book{
+		{ID: 7, AuthorID: 1, Title: "seven"},
+		{ID: 3, AuthorID: 1, Title: "three"},
+		{ID: 5, AuthorID: 1, Title: "five"},
+		{ID: 9, AuthorID: 2, Title: "nine"},
+		{ID: 4, AuthorID: 2, Title: "four"},
+	}
+	lowestID := func(a, b *Book) bool { return a.ID < b.ID }
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := 0; i < 20; i++ {
+		for _, single := range []bool{false, true} {
+			shuffled := slices.Clone(all)
+			rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+			eng := NewEngine()
+			a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+			eng.InitHandles([]*Author{a1, a2})
+			spec := RelationSpec[int, *Author, *Book]{
+				CacheKey:     "firstBook",
+				ModelKey:     func(a *Author) (int, bool) { return a.ID, true },
+				RelationKey:  func(b *Book) int { return b.AuthorID },
+				Fetch:        func(context.Context, []int) ([]*Book, error) { return shuffled, nil },
+				SinglePerKey: single,
+				PickFirst:    lowestID,
+			}
+			got1, err := One(ctx, spec.For(a1))
+			if err != nil || got1.Title != "three" {
+				t.Fatalf("single=%v order %v: One(a1) = %v, %v; want three", single, titles(shuffled), got1, err)
+			}
+			got2, err := One(ctx, spec.For(a2))
+			if err != nil || got2.Title != "four" {
+				t.Fatalf("single=%v order %v: One(a2) = %v, %v; want four", single, titles(shuffled), got2, err)
+			}
+		}
+	}
+}
+
 // --- tiny helpers ---
 
 func titles(bs []*Book) []string {
@@ -219,22 +289,31 @@ func equalStrings(a, b []string) bool {
 	return true
 }
 
-func sameState(t *testing.T, as ...*Author) *loaderState { // same pkg to inspect state
+func sameState(t *testing.T, as ...*Author) State {
 	t.Helper()
-	var first *loaderState
+	var first State
 	for i, a := range as {
-		if a == nil || a.lodeState() == nil {
+		st, ok := StateOf(a)
+		if !ok {
 			t.Fatalf("author[%d] has nil state", i)
 		}
-		if first == nil {
-			first = a.lodeState()
-		} else if a.lodeState() != first {
+		if i == 0 {
+			first = st
+		} else if st != first {
 			t.Fatalf("author[%d] has different state", i)
 		}
 	}
 	return first
 }
 
+// copyModel copies *m by value, as ranging over a []T would, without
+// tripping go vet's copylocks check.
+func copyModel[T any](m *T) *T {
+	cp := new(T)
+	reflect.ValueOf(cp).Elem().Set(reflect.ValueOf(m).Elem())
+	return cp
+}
+
 func ptrsEq(a, b []*Author) bool {
 	if len(a) != len(b) {
 		return false
@@ -381,12 +460,12 @@ func TestInitHandles_VariousInputs(t *testing.T) {
 			}
 
 			state := sameState(t, want...)
-			ps, ok := state.models.([]*Author)
+			ps, ok := state.Models().([]*Author)
 			if !ok {
-				t.Fatalf("state.models not []*Author, got %T", state.models)
+				t.Fatalf("state.Models() not []*Author, got %T", state.Models())
 			}
 			if !ptrsEq(ps, want) {
-				t.Fatalf("state.models mismatch\n got: %#v\nwant: %#v", ps, want)
+				t.Fatalf("state.Models() mismatch\n got: %#v\nwant: %#v", ps, want)
 			}
 		})
 	}
@@ -403,7 +482,7 @@ func TestInitHandles_Idempotent(t *testing.T) {
 
 	// Second call should not rebind / change state.
 	e.InitHandles(in)
-	if a1.lodeState() != s1 || a2.lodeState() != s1 {
+	if sameState(t, a1, a2) != s1 {
 		t.Fatal("state changed on second InitHandles")
 	}
 }
@@ -411,14 +490,28 @@ func TestInitHandles_Idempotent(t *testing.T) {
 func TestInitHandles_Batching(t *testing.T) {
 	t.Parallel()
 	e := NewEngine(WithBatchSize(2))
-	a1, a2, a3, a4 := &Author{ID: 1}, &Author{ID: 2}, &Author{ID: 3}, &Author{ID: 4}
-	e.InitHandles([]*Author{a1, a2, a3, a4})
+	a1, a2, a3, a4, a5 := &Author{ID: 1}, &Author{ID: 2}, &Author{ID: 3}, &Author{ID: 4}, &Author{ID: 5}
+	res, err := e.Bind([]*Author{a1, a2, a3, a4, a5})
+	if err != nil {
+		t.Fatalf("Bind error: %v", err)
+	}
 
+	if len(res.Batches) != 3 {
+		t.Fatalf("got %d batches; want 3", len(res.Batches))
+	}
+	for i, want := range []int{2, 2, 1} {
+		if got := res.Batches[i].Size; got != want || res.Batches[i].State.Len() != want {
+			t.Fatalf("batch %d size=%d len=%d; want %d", i, got, res.Batches[i].State.Len(), want)
+		}
+	}
 	sA := sameState(t, a1, a2)
 	sB := sameState(t, a3, a4)
 	if sA == sB {
 		t.Fatal("different batches should have distinct states")
 	}
+	if sA != res.Batches[0].State || sB != res.Batches[1].State || sameState(t, a5) != res.Batches[2].State {
+		t.Fatal("BindResult states do not match the models' states")
+	}
 }
 
 func TestInitHandles_Invalid_NoPanic(t *testing.T) {
@@ -456,13 +549,13 @@ func TestInitHandles_ModelsAlwaysPtrSlice(t *testing.T) {
 	e := NewEngine()
 	v := []Author{{ID: 1}}
 	e.InitHandles(v)
-	st := (&v[0]).lodeState()
-	if st == nil {
+	st, ok := StateOf(&v[0])
+	if !ok {
 		t.Fatal("nil state")
 	}
-	rv := reflect.ValueOf(st.models)
+	rv := reflect.ValueOf(st.Models())
 	if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() != reflect.Ptr {
-		t.Fatalf("models not a slice of pointers: %T", st.models)
+		t.Fatalf("models not a slice of pointers: %T", st.Models())
 	}
 }
 
@@ -503,9 +596,9 @@ func TestHandleReset_ClearsCacheAndAllowsRebuild(t *testing.T) {
 	}
 
 	// 2) Reset via one handle; shared state should be the same object, but empty cache.
-	before := a1.lodeState()
+	before, _ := StateOf(a1)
 	a1.Reset()
-	after := a1.lodeState()
+	after, _ := StateOf(a1)
 	if before != after {
 		t.Fatalf("Reset should not replace loaderState pointer")
 	}
@@ -540,7 +633,7 @@ func TestHandleReset_UninitializedIsSafe(t *testing.T) {
 	// Should not panic:
 	u.Reset()
 	// Still uninitialized:
-	if u.lodeState() != nil {
+	if _, ok := StateOf(&u); ok {
 		t.Fatal("unexpected non-nil state after Reset on uninitialized handle")
 	}
 }
@@ -574,100 +667,587 @@ func TestInitHandles_EmptyNamedSlices_NoPanic(t *testing.T) {
 	}
 }
 
+// checkNilPolicy checks err against what policy says a nil model returns.
+func checkNilPolicy(t *testing.T, policy NilModelPolicy, err error) {
+	t.Helper()
+	switch policy {
+	case NilReturnsZero:
+		if err != nil {
+			t.Fatalf("%v: error = %v; want nil", policy, err)
+		}
+	case NilReturnsError:
+		if !errors.Is(err, ErrNilModel) {
+			t.Fatalf("%v: error = %v; want ErrNilModel", policy, err)
+		}
+	}
+}
+
 func TestMany_NilModel(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
 
+	for _, policy := range []NilModelPolicy{NilReturnsZero, NilReturnsError} {
+		var nilAuthor *Author
+		fetchCalled := false
+
+		spec := RelationSpec[int, *Author, *Book]{
+			CacheKey: "booksByAuthor",
+			Model:    nilAuthor,
+			ModelKey: func(a *Author) (int, bool) {
+				if a == nil {
+					return 0, false
+				}
+				return a.ID, true
+			},
+			RelationKey: func(b *Book) int { return b.AuthorID },
+			Fetch: func(context.Context, []int) ([]*Book, error) {
+				fetchCalled = true
+				return []*Book{{AuthorID: 1, Title: "should not be fetched"}}, nil
+			},
+			NilModel: policy,
+		}
+
+		got, err := Many(ctx, spec)
+		checkNilPolicy(t, policy, err)
+		if err != nil && !strings.Contains(err.Error(), `"booksByAuthor"`) {
+			t.Fatalf("%v: error %q does not name the cache key", policy, err)
+		}
+		if got != nil {
+			t.Fatalf("%v: Many(nil model) = %#v; want nil slice", policy, got)
+		}
+		if fetchCalled {
+			t.Fatalf("%v: Many(nil model) called Fetch; want not called", policy)
+		}
+	}
+}
+
+func TestOne_NilModel(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	for _, policy := range []NilModelPolicy{NilReturnsZero, NilReturnsError} {
+		var nilAuthor *Author
+		fetchCalled := false
+
+		spec := RelationSpec[int, *Author, *Book]{
+			CacheKey: "booksByAuthor",
+			Model:    nilAuthor,
+			ModelKey: func(a *Author) (int, bool) {
+				if a == nil {
+					return 0, false
+				}
+				return a.ID, true
+			},
+			RelationKey: func(b *Book) int { return b.AuthorID },
+			Fetch: func(context.Context, []int) ([]*Book, error) {
+				fetchCalled = true
+				return []*Book{{AuthorID: 1, Title: "should not be fetched"}}, nil
+			},
+			NilModel: policy,
+		}
+
+		got, err := One(ctx, spec)
+		checkNilPolicy(t, policy, err)
+		if got != nil { // zero value for *Book is nil
+			t.Fatalf("%v: One(nil model) = %#v; want nil (*Book zero value)", policy, got)
+		}
+		if fetchCalled {
+			t.Fatalf("%v: One(nil model) called Fetch; want not called", policy)
+		}
+	}
+}
+
+func TestResolve_NilModel(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	for _, policy := range []NilModelPolicy{NilReturnsZero, NilReturnsError} {
+		var nilAuthor *Author
+		buildCalled := false
+
+		spec := ResolveSpec[*Author, int]{
+			CacheKey: "answerForAuthor",
+			Model:    nilAuthor,
+			Build: func(context.Context, []*Author) (ResolverFunc[*Author, int], error) {
+				buildCalled = true
+				return func(*Author) int { return 42 }, nil
+			},
+			NilModel: policy,
+		}
+
+		got, err := Resolve(ctx, spec)
+		checkNilPolicy(t, policy, err)
+		if got != 0 { // zero value for int is 0
+			t.Fatalf("%v: Resolve(nil model) = %v; want 0 (zero Result)", policy, got)
+		}
+		if buildCalled {
+			t.Fatalf("%v: Resolve(nil model) called Build; want not called", policy)
+		}
+	}
+}
+
+func TestNilModelPolicy_Engine(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	for _, policy := range []NilModelPolicy{NilReturnsZero, NilReturnsError} {
+		eng := NewEngine(WithNilModelPolicy(policy))
+		if got := eng.Config().NilModelPolicy(); got != policy {
+			t.Fatalf("NilModelPolicy() = %v; want %v", got, policy)
+		}
+		var nilAuthor *Author
+		spec := threeBooks(map[int]int{}).For(nilAuthor)
+		spec.FallbackEngine = eng
+		_, err := Many(ctx, spec)
+		checkNilPolicy(t, policy, err)
+		_, err = Resolve(ctx, ResolveSpec[*Author, int]{CacheKey: "n", Model: nilAuthor, FallbackEngine: eng})
+		checkNilPolicy(t, policy, err)
+
+		// The spec's policy wins over the engine's.
+		override := NilReturnsError
+		if policy == NilReturnsError {
+			override = NilReturnsZero
+		}
+		spec.NilModel = override
+		_, err = Many(ctx, spec)
+		checkNilPolicy(t, override, err)
+	}
+}
+
+func TestNilModelPolicy_DefaultEngine(t *testing.T) {
+	resetDefault()
+	t.Cleanup(resetDefault)
+	ctx := context.Background()
 	var nilAuthor *Author
-	fetchCalled := false
+	spec := threeBooks(map[int]int{}).For(nilAuthor)
+	_, err := Many(ctx, spec)
+	checkNilPolicy(t, NilReturnsZero, err)
+	if defaultIfSet() != nil {
+		t.Fatal("a nil model created the default engine")
+	}
 
-	spec := RelationSpec[int, *Author, *Book]{
-		CacheKey: "booksByAuthor",
-		Model:    nilAuthor,
-		ModelKey: func(a *Author) (int, bool) {
-			if a == nil {
-				return 0, false
-			}
-			return a.ID, true
+	if err := SetDefault(NewEngine(WithNilModelPolicy(NilReturnsError))); err != nil {
+		t.Fatal(err)
+	}
+	_, err = Many(ctx, spec)
+	checkNilPolicy(t, NilReturnsError, err)
+	// Another engine's policy does not leak into specs that do not name it.
+	NewEngine(WithNilModelPolicy(NilReturnsZero))
+	_, err = Resolve(ctx, ResolveSpec[*Author, int]{CacheKey: "n", Model: nilAuthor})
+	checkNilPolicy(t, NilReturnsError, err)
+}
+
+func TestResolve_MembershipCheck(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	build := func(ctx context.Context, models []*Author) (ResolverFunc[*Author, string], error) {
+		return func(a *Author) string { return a.Name }, nil
+	}
+
+	for _, check := range []bool{false, true} {
+		var opts []ConfigOption
+		if check {
+			opts = append(opts, WithMembershipCheck())
+		}
+		eng := NewEngine(opts...)
+		authors := []Author{{ID: 1, Name: "Alice"}, {ID: 2, Name: "Bob"}}
+		eng.InitHandles(authors)
+
+		// Bound pointer: always fine.
+		got, err := Resolve(ctx, ResolveSpec[*Author, string]{CacheKey: "name", Model: &authors[0], Build: build})
+		if err != nil || got != "Alice" {
+			t.Fatalf("check=%v: Resolve(bound) = %q, %v", check, got, err)
+		}
+
+		// Copy carries the state but is not in the batch.
+		cp := copyModel(&authors[1])
+		_, err = Resolve(ctx, ResolveSpec[*Author, string]{CacheKey: "name", Model: cp, Build: build})
+		if check && !errors.Is(err, errNotMember) {
+			t.Fatalf("check=%v: Resolve(copy) err = %v; want errNotMember", check, err)
+		}
+		if !check && err != nil {
+			t.Fatalf("check=%v: Resolve(copy) unexpected err: %v", check, err)
+		}
+	}
+}
+
+func TestBind_InvalidInputsError(t *testing.T) {
+	t.Parallel()
+	e := NewEngine()
+
+	cases := []any{
+		42,
+		Author{ID: 1},     // single value (non-addressable via interface)
+		[]int{1, 2, 3},    // wrong elem type
+		&[]int{1, 2},      // wrong *slice type
+		[2]*Author{},      // array, not slice
+		map[int]*Author{}, // map, not slice
+	}
+	for i, in := range cases {
+		res, err := e.Bind(in)
+		if !errors.Is(err, errNotBindable) {
+			t.Fatalf("case %d (%T): err = %v; want errNotBindable", i, in, err)
+		}
+		if len(res.Batches) != 0 {
+			t.Fatalf("case %d (%T): got %d batches; want 0", i, in, len(res.Batches))
+		}
+	}
+
+	for i, in := range []any{nil, (*Author)(nil), (*[]Author)(nil), Authors{}, []Author{}} {
+		res, err := e.Bind(in)
+		if err != nil || len(res.Batches) != 0 {
+			t.Fatalf("empty case %d (%T): res=%+v err=%v; want no batches, no error", i, in, res, err)
+		}
+	}
+}
+
+func TestBind_AlreadyBoundReportsExistingState(t *testing.T) {
+	t.Parallel()
+	e := NewEngine()
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+
+	first, err := e.Bind([]*Author{a1, a2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := e.Bind([]*Author{a1, a2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second.Batches) != 1 || second.Batches[0].State != first.Batches[0].State || second.Batches[0].Size != 2 {
+		t.Fatalf("rebind result = %+v; want the existing state with size 2", second)
+	}
+}
+
+func TestBindResult_ResetAll(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	e := NewEngine(WithBatchSize(1))
+	a1, a2 := &Author{ID: 1, Name: "Alice"}, &Author{ID: 2, Name: "Bob"}
+	res, err := e.Bind([]*Author{a1, a2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	builds := 0
+	spec := ResolveSpec[*Author, string]{
+		CacheKey: "name",
+		Build: func(ctx context.Context, models []*Author) (ResolverFunc[*Author, string], error) {
+			builds++
+			return func(a *Author) string { return a.Name }, nil
 		},
-		RelationKey: func(b *Book) int { return b.AuthorID },
-		Fetch: func(context.Context, []int) ([]*Book, error) {
-			fetchCalled = true
-			return []*Book{{AuthorID: 1, Title: "should not be fetched"}}, nil
+	}
+	resolveBoth := func() {
+		for _, a := range []*Author{a1, a2} {
+			spec.Model = a
+			if _, err := Resolve(ctx, spec); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	resolveBoth()
+	resolveBoth()
+	if builds != 2 {
+		t.Fatalf("builds=%d; want 2 (one per batch)", builds)
+	}
+	res.ResetAll()
+	resolveBoth()
+	if builds != 4 {
+		t.Fatalf("builds=%d after ResetAll; want 4", builds)
+	}
+}
+
+type Publisher struct {
+	ID    int
+	OrgID int
+	Handle
+}
+
+type orgScoped interface {
+	HasHandle
+	Org() int
+}
+
+func (a *Author) Org() int    { return a.ID * 10 }
+func (p *Publisher) Org() int { return p.OrgID }
+
+func TestResolve_InterfaceModel(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+
+	authors := []*Author{{ID: 1}, {ID: 2}}
+	publishers := []*Publisher{{ID: 1, OrgID: 7}, {ID: 2, OrgID: 8}}
+	eng.InitHandles(authors)
+	eng.InitHandles(publishers)
+
+	var buildInputs [][]orgScoped
+	spec := ResolveSpec[orgScoped, string]{
+		CacheKey: "org:name",
+		Build: func(ctx context.Context, models []orgScoped) (ResolverFunc[orgScoped, string], error) {
+			buildInputs = append(buildInputs, models)
+			return func(m orgScoped) string { return fmt.Sprintf("org-%d", m.Org()) }, nil
 		},
 	}
 
-	got, err := Many(ctx, spec)
-	if err != nil {
-		t.Fatalf("Many(nil model) error = %v; want nil", err)
+	for _, tc := range []struct {
+		model orgScoped
+		want  string
+	}{
+		{authors[0], "org-10"},
+		{authors[1], "org-20"},
+		{publishers[0], "org-7"},
+		{publishers[1], "org-8"},
+	} {
+		spec.Model = tc.model
+		got, err := Resolve(ctx, spec)
+		if err != nil {
+			t.Fatalf("Resolve(%T) error: %v", tc.model, err)
+		}
+		if got != tc.want {
+			t.Fatalf("Resolve(%T) = %q; want %q", tc.model, got, tc.want)
+		}
+	}
+
+	if len(buildInputs) != 2 {
+		t.Fatalf("build called %d times; want 2 (one per state)", len(buildInputs))
 	}
-	if got != nil {
-		t.Fatalf("Many(nil model) = %#v; want nil slice", got)
+	if buildInputs[0][0] != orgScoped(authors[0]) || buildInputs[1][1] != orgScoped(publishers[1]) {
+		t.Fatalf("unexpected build inputs: %v", buildInputs)
 	}
-	if fetchCalled {
-		t.Fatalf("Many(nil model) called Fetch; want not called")
+
+	// The converted slice is cached per state and Model type.
+	st, _ := StateOf(authors[0])
+	again, ok := modelsAs[orgScoped](st.s)
+	if !ok || &again[0] != &buildInputs[0][0] {
+		t.Fatal("converted models were not cached on the state")
 	}
 }
 
-func TestOne_NilModel(t *testing.T) {
+type orgBook struct{ *Book }
+
+func (orgBook) Org() int { return 0 }
+
+func TestResolve_InterfaceModelNotImplemented(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
+	eng := NewEngine()
 
-	var nilAuthor *Author
-	fetchCalled := false
+	b := &Book{ID: 1}
+	eng.InitHandles([]*Book{b})
 
-	spec := RelationSpec[int, *Author, *Book]{
-		CacheKey: "booksByAuthor",
-		Model:    nilAuthor,
-		ModelKey: func(a *Author) (int, bool) {
-			if a == nil {
-				return 0, false
-			}
-			return a.ID, true
+	// HasHandle is implemented by every bound type.
+	anySpec := ResolveSpec[HasHandle, int]{
+		CacheKey: "any",
+		Model:    b,
+		Build: func(context.Context, []HasHandle) (ResolverFunc[HasHandle, int], error) {
+			return func(HasHandle) int { return 1 }, nil
+		},
+	}
+	if got, err := Resolve(ctx, anySpec); err != nil || got != 1 {
+		t.Fatalf("Resolve(HasHandle) = %v, %v; want 1, nil", got, err)
+	}
+
+	// orgBook shares the *Book's state, but []*Book cannot be converted to
+	// []orgScoped, so the build must fail rather than panic.
+	orgSpec := ResolveSpec[orgScoped, int]{
+		CacheKey: "org",
+		Model:    orgBook{b},
+		Build: func(context.Context, []orgScoped) (ResolverFunc[orgScoped, int], error) {
+			t.Fatal("build should not be called")
+			return nil, nil
 		},
+	}
+	if _, err := Resolve(ctx, orgSpec); err == nil {
+		t.Fatal("Resolve(orgScoped) on []*Book state: want error")
+	}
+}
+
+func TestSinglePerKey(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles([]*Author{a1, a2})
+
+	var fetches, hinted int
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       a1,
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
 		RelationKey: func(b *Book) int { return b.AuthorID },
-		Fetch: func(context.Context, []int) ([]*Book, error) {
-			fetchCalled = true
-			return []*Book{{AuthorID: 1, Title: "should not be fetched"}}, nil
+		Fetch: func(ctx context.Context, keys []int) ([]*Book, error) {
+			fetches++
+			if IsSinglePerKey(ctx) {
+				hinted++
+			}
+			return []*Book{
+				{ID: 1, AuthorID: 1, Title: "first"},
+				{ID: 2, AuthorID: 1, Title: "second"},
+				{ID: 3, AuthorID: 2, Title: "only"},
+			}, nil
 		},
 	}
 
-	got, err := One(ctx, spec)
-	if err != nil {
-		t.Fatalf("One(nil model) error = %v; want nil", err)
+	all, err := Many(ctx, spec)
+	if err != nil || len(all) != 2 {
+		t.Fatalf("unhinted Many = %v, %v; want 2 books", titles(all), err)
 	}
-	if got != nil { // zero value for *Book is nil
-		t.Fatalf("One(nil model) = %#v; want nil (*Book zero value)", got)
+
+	spec.SinglePerKey = true
+	single, err := Many(ctx, spec)
+	if err != nil || !equalStrings(titles(single), []string{"first"}) {
+		t.Fatalf("hinted Many = %v, %v; want [first]", titles(single), err)
 	}
-	if fetchCalled {
-		t.Fatalf("One(nil model) called Fetch; want not called")
+	first, err := One(ctx, spec)
+	if err != nil || first.Title != "first" {
+		t.Fatalf("hinted One = %v, %v", first, err)
+	}
+	spec.Model = a2
+	if got, err := One(ctx, spec); err != nil || got.Title != "only" {
+		t.Fatalf("hinted One(a2) = %v, %v", got, err)
+	}
+
+	// The hinted and unhinted resolvers are cached separately, and neither
+	// clobbered the other.
+	spec.SinglePerKey = false
+	spec.Model = a1
+	if again, _ := Many(ctx, spec); len(again) != 2 {
+		t.Fatalf("unhinted Many after hinted = %v; want 2 books", titles(again))
+	}
+	if fetches != 2 || hinted != 1 {
+		t.Fatalf("fetches=%d hinted=%d; want 2 and 1", fetches, hinted)
 	}
 }
 
-func TestResolve_NilModel(t *testing.T) {
+func TestKeyNamespace(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
 
-	var nilAuthor *Author
-	buildCalled := false
-
-	spec := ResolveSpec[*Author, int]{
-		CacheKey: "answerForAuthor",
-		Model:    nilAuthor,
-		Build: func(context.Context, []*Author) (ResolverFunc[*Author, int], error) {
-			buildCalled = true
-			return func(*Author) int { return 42 }, nil
-		},
+	var fetched []string
+	hooks := WithHooks(Hooks{OnFetch: func(ev FetchEvent) { fetched = append(fetched, ev.CacheKey) }})
+	engA := NewEngine(WithKeyNamespace("catalog"), hooks)
+	engB := NewEngine(WithKeyNamespace("billing"), hooks)
+
+	a := &Author{ID: 1, Name: "A"}
+	b := &Author{ID: 1, Name: "B"}
+	engA.InitHandles(a)
+	engB.InitHandles(b)
+
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+	}
+	specA := spec
+	specA.Model = a
+	specA.Fetch = lodetest.FetchFromSlice([]*Book{{AuthorID: 1, Title: "catalog"}}, spec.RelationKey)
+	specB := spec
+	specB.Model = b
+	specB.Fetch = lodetest.FetchFromSlice([]*Book{{AuthorID: 1, Title: "billing"}}, spec.RelationKey)
+
+	gotA, errA := Many(ctx, specA)
+	gotB, errB := Many(ctx, specB)
+	if errA != nil || errB != nil {
+		t.Fatal(errA, errB)
+	}
+	if !equalStrings(titles(gotA), []string{"catalog"}) || !equalStrings(titles(gotB), []string{"billing"}) {
+		t.Fatalf("got %v and %v", titles(gotA), titles(gotB))
+	}
+	if !equalStrings(fetched, []string{"catalog:books", "billing:books"}) {
+		t.Fatalf("hook keys = %v", fetched)
+	}
+	if _, ok := a.core.resolverEntries.Load("catalog:books"); !ok {
+		t.Fatal("entry not stored under the namespaced key")
 	}
 
-	got, err := Resolve(ctx, spec)
-	if err != nil {
-		t.Fatalf("Resolve(nil model) error = %v; want nil", err)
+	// A resolver with the same literal key but another result type reports
+	// the namespaced key in its error.
+	_, err := Resolve(ctx, ResolveSpec[*Author, int]{
+		CacheKey: "books",
+		Model:    a,
+		Build:    func(context.Context, []*Author) (ResolverFunc[*Author, int], error) { return nil, nil },
+	})
+	if err == nil || !strings.Contains(err.Error(), `"catalog:books"`) {
+		t.Fatalf("err = %v; want it to name \"catalog:books\"", err)
+	}
+}
+
+func TestModelsOf(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine()
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles([]*Author{a1, a2})
+
+	got, err := ModelsOf(a2)
+	if err != nil || !ptrsEq(got, []*Author{a1, a2}) {
+		t.Fatalf("ModelsOf = %v, %v", got, err)
+	}
+	asIface, err := ModelsOf[HasHandle](a1)
+	if err != nil || len(asIface) != 2 || asIface[1] != HasHandle(a2) {
+		t.Fatalf("ModelsOf[HasHandle] = %v, %v", asIface, err)
+	}
+
+	if _, err := ModelsOf(&Author{}); !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("unbound: err = %v", err)
+	}
+	if _, err := ModelsOf[*Author](nil); !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("nil: err = %v", err)
+	}
+
+	// The mismatch names both the bound and the requested types.
+	b := &Book{ID: 1}
+	eng.InitHandles(b)
+	_, err = ModelsOf[orgScoped](orgBook{b})
+	if err == nil || !strings.Contains(err.Error(), "[]*lode.Book") || !strings.Contains(err.Error(), "lode.orgScoped") {
+		t.Fatalf("mismatch: err = %v", err)
+	}
+}
+
+func TestBindAll_MixedTypes(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine(WithBatchSize(2))
+
+	authors := []*Author{{ID: 1}, {ID: 2}, {ID: 3}}
+	books := []Book{{ID: 1}, {ID: 2}}
+	pub := &Publisher{ID: 1}
+
+	res, err := eng.BindAll(authors, 42, books, nil, pub, "nope")
+	if !errors.Is(err, errNotBindable) {
+		t.Fatalf("err = %v; want errNotBindable", err)
+	}
+	for _, want := range []string{"argument 1: ", "argument 5: "} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("err = %v; want it to name %q", err, want)
+		}
+	}
+
+	// The valid arguments are bound despite the invalid ones: authors in two
+	// batches, books and the publisher in one each, all with one BindID.
+	if len(res.Batches) != 4 {
+		t.Fatalf("batches = %+v; want 4", res.Batches)
+	}
+	id := res.Batches[0].State.BindID()
+	for i, b := range res.Batches {
+		if b.State.BindID() != id {
+			t.Fatalf("batch %d BindID = %d; want %d", i, b.State.BindID(), id)
+		}
+	}
+	if st, _ := StateOf(&books[1]); st != res.Batches[2].State {
+		t.Fatal("books not bound")
 	}
-	if got != 0 { // zero value for int is 0
-		t.Fatalf("Resolve(nil model) = %v; want 0 (zero Result)", got)
+	if st, _ := StateOf(pub); st != res.Batches[3].State {
+		t.Fatal("publisher not bound")
+	}
+
+	// A later bind gets a new ID.
+	next, _ := eng.Bind(&Author{ID: 9})
+	if next.Batches[0].State.BindID() <= id {
+		t.Fatalf("next BindID = %d; want > %d", next.Batches[0].State.BindID(), id)
 	}
-	if buildCalled {
-		t.Fatalf("Resolve(nil model) called Build; want not called")
+	if err := eng.InitHandlesAll([]*Author{{ID: 10}}, []*Book{{ID: 10}}); err != nil {
+		t.Fatal(err)
 	}
 }
@@ -2,30 +2,362 @@ package lodegorm
 
 import (
 	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
 
 	"github.com/willhf/lode"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
 )
 
-func RegisterCallback(engine *lode.Engine, db *gorm.DB) {
+// RegisterDefaultCallback is RegisterCallback with lode.Default's engine.
+func RegisterDefaultCallback(db *gorm.DB, opts ...CallbackOption) {
+	RegisterCallback(lode.Default(), db, opts...)
+}
+
+// RegisterCallback binds the models loaded or created through db to engine.
+// It also sets engine's default binding hint (see lode.WithBindingHint),
+// since a model this misses was loaded around db.
+func RegisterCallback(engine *lode.Engine, db *gorm.DB, opts ...CallbackOption) {
 	const cbName = "lodegorm:init"
-	var initFunc = func(tx *gorm.DB) { engine.InitHandles(tx.Statement.Dest) }
+	engine.SetDefaultBindingHint("lodegorm.RegisterCallback only binds models loaded through the *gorm.DB it was registered on")
+	var initFunc = func(tx *gorm.DB) {
+		if shouldBind(tx) {
+			engine.InitHandles(tx.Statement.Dest)
+		}
+	}
 	db.Callback().Query().After("gorm:query").Register(cbName, initFunc)
 	db.Callback().Create().After("gorm:create").Register(cbName, initFunc)
+
+	var cfg callbackConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if len(cfg.invalidate) > 0 {
+		registerInvalidate(engine, db, cfg.invalidate)
+	}
+}
+
+// CallbackOption customizes RegisterCallback.
+type CallbackOption func(*callbackConfig)
+
+type callbackConfig struct {
+	invalidate map[reflect.Type][]string
+}
+
+// WithAutoInvalidate makes every successful Create, Update, or Delete through
+// db invalidate cache keys engine-wide (see lode.Engine.InvalidateKey).  keys
+// maps a model, given as a value such as Book{} or &Book{}, to the cache keys
+// that writes to its table may make stale, e.g.
+//
+//	lodegorm.WithAutoInvalidate(map[any][]string{Book{}: {"books"}})
+//
+// Keys are invalidated once the write's own transaction commits.  Writes
+// inside a transaction the caller manages, with db.Transaction or db.Begin,
+// are invalidated when each statement runs, before that transaction
+// commits, so an accessor racing it can cache the old rows again; call
+// Engine.InvalidateKey after such a commit.  Writes that name no model
+// (db.Table, raw SQL) invalidate nothing, and frozen states are skipped.
+func WithAutoInvalidate(keys map[any][]string) CallbackOption {
+	return func(c *callbackConfig) {
+		if c.invalidate == nil {
+			c.invalidate = make(map[reflect.Type][]string, len(keys))
+		}
+		for model, cacheKeys := range keys {
+			t := reflect.TypeOf(model)
+			for t != nil && t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			c.invalidate[t] = append(c.invalidate[t], cacheKeys...)
+		}
+	}
+}
+
+func registerInvalidate(engine *lode.Engine, db *gorm.DB, keys map[reflect.Type][]string) {
+	const cbName = "lodegorm:invalidate"
+	invalidateFunc := func(tx *gorm.DB) {
+		if tx.Error != nil || tx.DryRun || tx.Statement.DryRun || tx.Statement.Schema == nil {
+			return
+		}
+		for _, k := range keys[tx.Statement.Schema.ModelType] {
+			engine.InvalidateKey(k) // frozen states are skipped by design
+		}
+	}
+	// After the commit, or an accessor racing the write could refetch the
+	// old rows and cache them until the next write.
+	const commit = "gorm:commit_or_rollback_transaction"
+	db.Callback().Create().After(commit).Register(cbName, invalidateFunc)
+	db.Callback().Update().After(commit).Register(cbName, invalidateFunc)
+	db.Callback().Delete().After(commit).Register(cbName, invalidateFunc)
+}
+
+// shouldBind cheaply rules out statements whose destination cannot hold
+// models: dry runs, Count/Pluck-style scalar destinations, and empty results.
+func shouldBind(tx *gorm.DB) bool {
+	if tx.DryRun || tx.Statement.DryRun || tx.Statement.Dest == nil {
+		return false
+	}
+	t := reflect.TypeOf(tx.Statement.Dest)
+	if !bindableType(t) {
+		return false
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return tx.RowsAffected != 0 || t.Kind() == reflect.Slice
+}
+
+var (
+	hasHandleType = reflect.TypeFor[lode.HasHandle]()
+	bindableTypes sync.Map // reflect.Type -> bool
+)
+
+// bindableType reports whether a destination of type t (a model, a slice of
+// models, or pointers to either) can hold models, caching the answer.
+func bindableType(t reflect.Type) bool {
+	if v, ok := bindableTypes.Load(t); ok {
+		return v.(bool)
+	}
+	base := t
+	for base.Kind() == reflect.Ptr {
+		base = base.Elem()
+	}
+	if base.Kind() == reflect.Slice {
+		base = base.Elem()
+		for base.Kind() == reflect.Ptr {
+			base = base.Elem()
+		}
+	}
+	ok := reflect.PointerTo(base).Implements(hasHandleType)
+	bindableTypes.Store(t, ok)
+	return ok
+}
+
+// Fetch is a helper function to fetch models by their keys.  joinColumn may
+// be table-qualified ("books.author_id").  For specs with the lode
+// SinglePerKey hint it fetches one row per key, like FetchOnePerKey.  Like
+// the other Fetch helpers, it does not query at all for an empty ids slice,
+// and it records the SQL it runs with lode.SetFetchDescriptor.
+func Fetch[Model any, Key any](db *gorm.DB, joinColumn string, opts ...FetchOption) func(context.Context, []Key) ([]Model, error) {
+	cfg := newFetchConfig(opts)
+	onePerKey := FetchOnePerKey[Model, Key](db, joinColumn, opts...)
+	return chunked(cfg.chunkSize, func(ctx context.Context, ids []Key) ([]Model, error) {
+		if len(ids) == 0 {
+			return nil, nil
+		}
+		if lode.IsSinglePerKey(ctx) {
+			return onePerKey(ctx, ids)
+		}
+		var models []Model
+		tx, _, err := cfg.apply(ctx, db.WithContext(ctx), &models)
+		if err != nil {
+			return nil, err
+		}
+		err = describe(ctx, tx.Where(inClause(joinColumn, ids)), func(tx *gorm.DB) *gorm.DB {
+			return tx.Find(&models)
+		})
+		return models, err
+	})
 }
 
-// Fetch is a helper function to fetch models by their keys.
-func Fetch[Model any, Key any](db *gorm.DB, joinColumn string) func(context.Context, []Key) ([]Model, error) {
-	return func(ctx context.Context, ids []Key) ([]Model, error) {
-		idInterfaceSlice := make([]interface{}, len(ids))
-		for i, id := range ids {
-			idInterfaceSlice[i] = id
+// FetchOnePerKey fetches at most one model per key: the one with the lowest
+// primary key.  On Postgres it uses DISTINCT ON, over the columns WithSelect
+// names or all of the model's; elsewhere it selects the minimum primary key
+// per join key in a subquery.  Options apply to the outer query only.
+func FetchOnePerKey[Model any, Key any](db *gorm.DB, joinColumn string, opts ...FetchOption) func(context.Context, []Key) ([]Model, error) {
+	cfg := newFetchConfig(opts)
+	return chunked(cfg.chunkSize, func(ctx context.Context, ids []Key) ([]Model, error) {
+		if len(ids) == 0 {
+			return nil, nil
+		}
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(new(Model)); err != nil {
+			return nil, err
+		}
+		if stmt.Schema.PrioritizedPrimaryField == nil {
+			return nil, fmt.Errorf("lodegorm: %s has no primary key: %w", stmt.Schema.Name, lode.ErrNotFound)
 		}
+		pk := clause.Column{Name: stmt.Schema.PrioritizedPrimaryField.DBName}
+		col := column(joinColumn)
+
 		var models []Model
-		err := db.WithContext(ctx).
-			Where(clause.IN{Column: clause.Column{Name: joinColumn}, Values: idInterfaceSlice}).
-			Find(&models).Error
+		tx, table, err := cfg.apply(ctx, db.WithContext(ctx), &models)
+		if err != nil {
+			return nil, err
+		}
+		if table == "" {
+			table = stmt.Schema.Table
+		}
+		// Columns are qualified with the table, which the caller's scopes
+		// may join to others.
+		pk.Table = table
+		if db.Dialector.Name() == "postgres" {
+			// The scope runs after theirs, so it keeps the columns they
+			// select.
+			tx = tx.Scopes(func(tx *gorm.DB) *gorm.DB {
+				return distinctOn(tx, stmt.Schema, table, col)
+			}).
+				Where(inClause(joinColumn, ids)).
+				Order(clause.OrderBy{Columns: []clause.OrderByColumn{{Column: col}, {Column: pk}}})
+		} else {
+			// The subquery picks among the rows the outer query can see, so
+			// it takes the model (for soft deletes) and the caller's scopes,
+			// applied now so that its own Select wins over theirs.
+			firsts := db.Session(&gorm.Session{NewDB: true}).WithContext(ctx).Model(new(Model)).Table(table)
+			for _, scope := range cfg.scopes {
+				firsts = scope(firsts)
+			}
+			key := col
+			if key.Table == "" {
+				key.Table = table
+			}
+			in := inClause(joinColumn, ids)
+			in.Column = key
+			firsts = firsts.Select("MIN(?)", pk).
+				Where(in).
+				Clauses(clause.GroupBy{Columns: []clause.Column{key}})
+			tx = tx.Where("? IN (?)", pk, firsts)
+		}
+		err = describe(ctx, tx, func(tx *gorm.DB) *gorm.DB { return tx.Find(&models) })
 		return models, err
+	})
+}
+
+// distinctOn makes tx select the columns its Select named, or all of
+// table's, DISTINCT ON col.
+func distinctOn(tx *gorm.DB, sch *schema.Schema, table string, col clause.Column) *gorm.DB {
+	if len(tx.Statement.Selects) == 0 {
+		return tx.Select("DISTINCT ON (?) ?.*", col, clause.Table{Name: table})
+	}
+	vars := []interface{}{col}
+	for _, name := range tx.Statement.Selects {
+		if f := sch.LookUpField(name); f != nil {
+			vars = append(vars, clause.Column{Table: table, Name: f.DBName})
+		} else {
+			vars = append(vars, clause.Column{Name: name, Raw: true})
+		}
+	}
+	tx.Statement.Selects = nil
+	return tx.Select("DISTINCT ON (?) ?"+strings.Repeat(", ?", len(vars)-2), vars...)
+}
+
+// FetchKeys is a fetch for lode.ExistsSpec: it returns the distinct values of
+// joinColumn among ids that have at least one Model row, without loading the
+// rows.  Options apply as for Fetch, so WithScopes can add conditions such as
+// the current user.
+func FetchKeys[Model any, Key any](db *gorm.DB, joinColumn string, opts ...FetchOption) func(context.Context, []Key) ([]Key, error) {
+	cfg := newFetchConfig(opts)
+	return chunked(cfg.chunkSize, func(ctx context.Context, ids []Key) ([]Key, error) {
+		if len(ids) == 0 {
+			return nil, nil
+		}
+		var keys []Key
+		tx, _, err := cfg.apply(ctx, db.WithContext(ctx).Model(new(Model)), new(Model))
+		if err != nil {
+			return nil, err
+		}
+		err = describe(ctx, tx.Distinct().Where(inClause(joinColumn, ids)), func(tx *gorm.DB) *gorm.DB {
+			return tx.Pluck(joinColumn, &keys)
+		})
+		return keys, err
+	})
+}
+
+// FetchPluck is a fetch for lode.FieldSpec: it reads joinColumn and
+// valueColumn from table for the rows whose join column is among ids, and
+// maps each key to its value.  When several rows share a key the first one
+// wins, so order them with WithScopes to pick e.g. the latest.
+func FetchPluck[Key comparable, V any](db *gorm.DB, table, joinColumn, valueColumn string, opts ...FetchOption) func(context.Context, []Key) (map[Key]V, error) {
+	cfg := newFetchConfig(opts)
+	fetchRows := chunked(cfg.chunkSize, func(ctx context.Context, ids []Key) ([]pluckRow[Key, V], error) {
+		var rows []pluckRow[Key, V]
+		tx, _, err := cfg.apply(ctx, db.WithContext(ctx).Table(table), nil)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Select("? AS lode_key, ? AS lode_value", column(joinColumn), column(valueColumn)).
+			Where(inClause(joinColumn, ids))
+		err = describe(ctx, tx, func(tx *gorm.DB) *gorm.DB { return tx.Scan(&rows) })
+		return rows, err
+	})
+	return func(ctx context.Context, ids []Key) (map[Key]V, error) {
+		if len(ids) == 0 {
+			return nil, nil
+		}
+		rows, err := fetchRows(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[Key]V, len(rows))
+		for _, r := range rows {
+			if _, ok := values[r.Key]; !ok {
+				values[r.Key] = r.Value
+			}
+		}
+		return values, nil
+	}
+}
+
+// pluckRow is a row read by FetchPluck.
+type pluckRow[Key, V any] struct {
+	Key   Key `gorm:"column:lode_key"`
+	Value V   `gorm:"column:lode_value"`
+}
+
+// describe runs finish on tx and returns its error, first reporting the SQL
+// it will run as the fetch's descriptor (see lode.SetFetchDescriptor) if the
+// build collects descriptors.  gorm discards the SQL once a query has run,
+// so it is built by a silent dry run.  Placeholders are kept, so argument
+// values are not recorded.
+func describe(ctx context.Context, tx *gorm.DB, finish func(*gorm.DB) *gorm.DB) error {
+	if !lode.CollectsFetchDescriptors(ctx) {
+		return finish(tx).Error
+	}
+	dry := finish(tx.Session(&gorm.Session{DryRun: true, Logger: logger.Discard}))
+	lode.SetFetchDescriptor(ctx, dry.Statement.SQL.String())
+	return finish(tx).Error
+}
+
+func inClause[Key any](name string, ids []Key) clause.IN {
+	idInterfaceSlice := make([]interface{}, len(ids))
+	for i, id := range ids {
+		idInterfaceSlice[i] = id
+	}
+	return clause.IN{Column: column(name), Values: idInterfaceSlice}
+}
+
+// FetchStream is the streaming counterpart of Fetch for use with lode.Stream.
+// It loads models with FindInBatches, batchSize rows at a time, so only one
+// batch is held in memory while yield runs.
+func FetchStream[Model any, Key any](db *gorm.DB, joinColumn string, batchSize int, opts ...FetchOption) func(context.Context, []Key, func(Model) error) error {
+	cfg := newFetchConfig(opts)
+	return func(ctx context.Context, ids []Key, yield func(Model) error) error {
+		if len(ids) == 0 {
+			return nil
+		}
+		for chunk := range lode.Chunks(ids, cfg.chunkSize) {
+			var batch []Model
+			tx, _, err := cfg.apply(ctx, db.WithContext(ctx), &batch)
+			if err != nil {
+				return err
+			}
+			err = tx.Where(inClause(joinColumn, chunk)).
+				FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+					for _, m := range batch {
+						if err := yield(m); err != nil {
+							return err
+						}
+					}
+					return nil
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 }
@@ -0,0 +1,126 @@
+package lodegorm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/willhf/lode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FetchOption customizes the query built by the Fetch helpers.  Options are
+// applied in order before the join-key condition is added.
+type FetchOption func(*fetchConfig)
+
+type fetchConfig struct {
+	scopes    []func(*gorm.DB) *gorm.DB
+	tableFn   func(context.Context) string
+	chunkSize int
+}
+
+func newFetchConfig(opts []FetchOption) fetchConfig {
+	var c fetchConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// apply applies the options to tx, returning the table the query reads from
+// (empty for the model's default table).
+func (c fetchConfig) apply(ctx context.Context, tx *gorm.DB, model any) (*gorm.DB, string, error) {
+	tx = tx.Scopes(c.scopes...)
+	if c.tableFn == nil {
+		return tx, "", nil
+	}
+	table := c.tableFn(ctx)
+	if table == "" {
+		stmt := &gorm.Statement{DB: tx}
+		if err := stmt.Parse(model); err != nil || stmt.Schema.Table == "" {
+			return nil, "", fmt.Errorf("lodegorm: table override for %T is empty and the model has no default table: %w", model, lode.ErrMisconfigured)
+		}
+		table = stmt.Schema.Table
+	}
+	return tx.Table(table), table, nil
+}
+
+// WithScopes applies gorm scopes (joins, extra conditions, preloads, ...) to
+// the fetch query.
+func WithScopes(scopes ...func(*gorm.DB) *gorm.DB) FetchOption {
+	return func(c *fetchConfig) { c.scopes = append(c.scopes, scopes...) }
+}
+
+// WithSelect restricts the selected columns.
+func WithSelect(columns ...string) FetchOption {
+	return WithScopes(func(tx *gorm.DB) *gorm.DB { return tx.Select(columns) })
+}
+
+// WithUnscoped includes soft-deleted rows.
+func WithUnscoped() FetchOption {
+	return WithScopes(func(tx *gorm.DB) *gorm.DB { return tx.Unscoped() })
+}
+
+// WithTable reads from the named table instead of the model's default, e.g.
+// for sharded tables such as "books_2025".
+func WithTable(name string) FetchOption {
+	return WithTableFn(func(context.Context) string { return name })
+}
+
+// WithTableFn is like WithTable but picks the table per fetch from the fetch
+// ctx, so the tenant or shard can travel in the context.  If fn returns ""
+// the model's default table is used.
+func WithTableFn(fn func(ctx context.Context) string) FetchOption {
+	return func(c *fetchConfig) { c.tableFn = fn }
+}
+
+// WithDistinct makes the fetch SELECT DISTINCT, on the given columns if any
+// or on the selected columns (see WithSelect) otherwise.  It is meant for
+// joins through views or link tables that repeat rows.
+func WithDistinct(columns ...string) FetchOption {
+	return WithScopes(func(tx *gorm.DB) *gorm.DB {
+		args := make([]interface{}, len(columns))
+		for i, col := range columns {
+			args[i] = col
+		}
+		return tx.Distinct(args...)
+	})
+}
+
+// WithChunkSize makes the fetch query at most n keys at a time, one query
+// per chunk with the results concatenated in chunk order, to stay within
+// limits on bound parameters such as SQLite's 999 whatever key sets lode
+// passes it.  lode.WithFetchChunkSize chunks the keys before they reach the
+// fetch, for every spec of an engine.  Zero or negative means one query.
+func WithChunkSize(n int) FetchOption {
+	return func(c *fetchConfig) { c.chunkSize = n }
+}
+
+// chunked returns fetch calling itself once per chunk of at most size ids.
+// The first error stops the fetch.
+func chunked[Key, T any](size int, fetch func(context.Context, []Key) ([]T, error)) func(context.Context, []Key) ([]T, error) {
+	if size <= 0 {
+		return fetch
+	}
+	return func(ctx context.Context, ids []Key) ([]T, error) {
+		var out []T
+		for chunk := range lode.Chunks(ids, size) {
+			got, err := fetch(ctx, chunk)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, got...)
+		}
+		return out, nil
+	}
+}
+
+// column turns a possibly table-qualified name ("books.author_id") into a
+// clause.Column so it stays unambiguous in joined queries.
+func column(name string) clause.Column {
+	if table, col, ok := strings.Cut(name, "."); ok {
+		return clause.Column{Table: table, Name: col}
+	}
+	return clause.Column{Name: name}
+}
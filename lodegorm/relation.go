@@ -0,0 +1,124 @@
+package lodegorm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/willhf/lode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Relation derives a lode.RelationSpec from the gorm association named
+// association on Parent, so the foreign key facts gorm already knows from
+// struct tags don't have to be repeated.  The spec's CacheKey defaults to
+// the association name, ModelKey and RelationKey read the association's key
+// fields, and Fetch is Fetch(db, column, opts...).  Set Model with For:
+//
+//	var authorBooks = must(lodegorm.Relation[uint, *Author, *Book](db, "Books"))
+//
+//	func (a *Author) Books(ctx context.Context) ([]*Book, error) {
+//		return lode.Many(ctx, authorBooks.For(a))
+//	}
+//
+// Has-many, has-one, and belongs-to associations over a single key column
+// are supported; use lode.One for the latter two.  Key fields may be
+// pointers (nil means no key) and must be convertible to JoinKey.  The
+// association name is registered with lode.DefineKeys, for engines created
+// lode.WithDefinedKeys; register any other CacheKey set on the spec
+// yourself.
+func Relation[JoinKey comparable, Parent lode.HasHandle, Child any](db *gorm.DB, association string, opts ...FetchOption) (lode.RelationSpec[JoinKey, Parent, Child], error) {
+	var spec lode.RelationSpec[JoinKey, Parent, Child]
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(Parent)); err != nil {
+		return spec, err
+	}
+	rel, ok := stmt.Schema.Relationships.Relations[association]
+	if !ok {
+		return spec, fmt.Errorf("lodegorm: %s has no association %q: %w", stmt.Schema.Name, association, lode.ErrNotFound)
+	}
+	if want := indirectType(reflect.TypeFor[Child]()); rel.FieldSchema.ModelType != want {
+		return spec, fmt.Errorf("lodegorm: association %s.%s: %w", stmt.Schema.Name, association, &lode.TypeMismatchError{Got: rel.FieldSchema.ModelType, Want: want})
+	}
+	if len(rel.References) != 1 || rel.References[0].PrimaryKey == nil || rel.Polymorphic != nil {
+		return spec, fmt.Errorf("lodegorm: association %s.%s: only single-column, non-polymorphic keys are supported: %w", stmt.Schema.Name, association, lode.ErrMisconfigured)
+	}
+	ref := rel.References[0]
+
+	var parentField, childField *schema.Field
+	switch rel.Type {
+	case schema.HasMany, schema.HasOne:
+		parentField, childField = ref.PrimaryKey, ref.ForeignKey
+	case schema.BelongsTo:
+		parentField, childField = ref.ForeignKey, ref.PrimaryKey
+	default:
+		return spec, fmt.Errorf("lodegorm: association %s.%s: %s relationships are not supported: %w", stmt.Schema.Name, association, rel.Type, lode.ErrMisconfigured)
+	}
+
+	spec.CacheKey = association
+	spec.ModelKey = func(p Parent) (JoinKey, bool) { return fieldKey[JoinKey](parentField, p) }
+	spec.RelationKey = func(c Child) JoinKey {
+		k, _ := fieldKey[JoinKey](childField, c)
+		return k
+	}
+	spec.Fetch = Fetch[Child, JoinKey](db, childField.DBName, opts...)
+	lode.DefineKeys(association)
+	return spec, nil
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// fieldKey reads f from model as a JoinKey, dereferencing pointer fields.
+func fieldKey[JoinKey comparable](f *schema.Field, model any) (JoinKey, bool) {
+	var zero JoinKey
+	rv := reflect.ValueOf(model)
+	if rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return zero, false
+	}
+	v, _ := f.ValueOf(context.Background(), rv)
+	fv := reflect.ValueOf(v)
+	for fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return zero, false
+		}
+		fv = fv.Elem()
+	}
+	if !fv.IsValid() {
+		return zero, false
+	}
+	kt := reflect.TypeFor[JoinKey]()
+	if !fv.Type().ConvertibleTo(kt) {
+		return zero, false
+	}
+	return fv.Convert(kt).Interface().(JoinKey), true
+}
+
+// NonZero returns a validator for lode.RelationSpec.ValidateRelation that
+// fails for relations whose column, usually the join column, scanned as its
+// zero value (or nil).  Fetch only returns rows whose join column matched a
+// key, so a zero there means the column no longer maps onto Relation's field:
+// renamed, or left out by WithSelect.  column may be table-qualified.
+func NonZero[Relation any](db *gorm.DB, column string) (func(Relation) error, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(Relation)); err != nil {
+		return nil, err
+	}
+	name := column[strings.LastIndexByte(column, '.')+1:]
+	f := stmt.Schema.LookUpField(name)
+	if f == nil {
+		return nil, fmt.Errorf("lodegorm: %s has no column %q: %w", stmt.Schema.Name, name, lode.ErrNotFound)
+	}
+	return func(r Relation) error {
+		if _, zero := f.ValueOf(context.Background(), reflect.ValueOf(r)); zero {
+			return fmt.Errorf("lodegorm: %s.%s scanned as zero", stmt.Schema.Name, f.Name)
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,92 @@
+// Package lodesqlc adapts sqlc-generated query methods into lode fetch
+// functions.  A query taking a slice of keys is a fetch as it stands:
+//
+//	-- name: GetBooksByAuthorIDs :many
+//	SELECT * FROM books WHERE author_id = ANY(@author_ids::bigint[]);
+//
+//	var authorBooks = lode.RelationSpec[int64, *Author, db.Book]{
+//		CacheKey: "books",
+//		Fetch:    lodesqlc.Fetch(queries.GetBooksByAuthorIDs),
+//		...
+//	}
+//
+// Project converts the row structs sqlc generates into models, and
+// FetchEach and FetchEachOne let a relation adopt lode before its query
+// takes a slice, at the cost of a query per key, which they report through
+// lode.Warn.
+package lodesqlc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/willhf/lode"
+)
+
+// Fetch returns fn, a query taking every key at once, as a fetch function.
+// It exists to document the fit and to pin the types.
+func Fetch[Model, Key any](fn func(context.Context, []Key) ([]Model, error)) func(context.Context, []Key) ([]Model, error) {
+	return fn
+}
+
+// Project returns fetch with each row it returns converted by project, for
+// queries returning row structs (say, GetBooksByAuthorIDsRow) rather than
+// the models the relation holds.
+func Project[Row, Model, Key any](fetch func(context.Context, []Key) ([]Row, error), project func(Row) Model) func(context.Context, []Key) ([]Model, error) {
+	return func(ctx context.Context, keys []Key) ([]Model, error) {
+		rows, err := fetch(ctx, keys)
+		if err != nil {
+			return nil, err
+		}
+		models := make([]Model, len(rows))
+		for i, r := range rows {
+			models[i] = project(r)
+		}
+		return models, nil
+	}
+}
+
+// FetchEach adapts a :many query taking a single key into a fetch function
+// that calls it once per key, in order, stopping at the first error.  That
+// is the N+1 lode exists to avoid, so each fetch of more than one key warns
+// through lode.Warn; it is a stopgap until the query takes a slice.
+func FetchEach[Model, Key any](fn func(context.Context, Key) ([]Model, error)) func(context.Context, []Key) ([]Model, error) {
+	return func(ctx context.Context, keys []Key) ([]Model, error) {
+		warnEach(ctx, len(keys))
+		var out []Model
+		for _, k := range keys {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			models, err := fn(ctx, k)
+			if err != nil {
+				return nil, fmt.Errorf("lodesqlc: key %v: %w", k, err)
+			}
+			out = append(out, models...)
+		}
+		return out, nil
+	}
+}
+
+// FetchEachOne is FetchEach for :one queries: a key whose query returns
+// sql.ErrNoRows has no relation.
+func FetchEachOne[Model, Key any](fn func(context.Context, Key) (Model, error)) func(context.Context, []Key) ([]Model, error) {
+	return FetchEach(func(ctx context.Context, k Key) ([]Model, error) {
+		m, err := fn(ctx, k)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []Model{m}, nil
+	})
+}
+
+func warnEach(ctx context.Context, keys int) {
+	if keys > 1 {
+		lode.Warn(ctx, fmt.Sprintf("lodesqlc: fetching %d keys with a query each; give the query a slice of keys and use Fetch", keys))
+	}
+}
@@ -0,0 +1,144 @@
+package lodesqlc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/willhf/lode"
+)
+
+type author struct {
+	ID int64
+	lode.Handle
+}
+
+// bookRow stands in for a row struct sqlc generates.
+type bookRow struct {
+	ID, AuthorID int64
+	Title        string
+}
+
+type book struct {
+	ID, AuthorID int64
+	Title        string
+}
+
+// queries stands in for a sqlc Queries, counting its calls.
+type queries struct {
+	books map[int64][]bookRow
+	calls int
+}
+
+func (q *queries) GetBooksByAuthorIDs(_ context.Context, ids []int64) ([]bookRow, error) {
+	q.calls++
+	var out []bookRow
+	for _, id := range ids {
+		out = append(out, q.books[id]...)
+	}
+	return out, nil
+}
+
+func (q *queries) GetBooksByAuthorID(ctx context.Context, id int64) ([]bookRow, error) {
+	return q.GetBooksByAuthorIDs(ctx, []int64{id})
+}
+
+func (q *queries) GetFirstBook(_ context.Context, id int64) (bookRow, error) {
+	q.calls++
+	if len(q.books[id]) == 0 {
+		return bookRow{}, sql.ErrNoRows
+	}
+	return q.books[id][0], nil
+}
+
+func newQueries() *queries {
+	return &queries{books: map[int64][]bookRow{
+		1: {{ID: 10, AuthorID: 1, Title: "a"}, {ID: 11, AuthorID: 1, Title: "b"}},
+		2: {{ID: 20, AuthorID: 2, Title: "c"}},
+	}}
+}
+
+func toBook(r bookRow) *book { return &book{ID: r.ID, AuthorID: r.AuthorID, Title: r.Title} }
+
+func spec(fetch func(context.Context, []int64) ([]*book, error)) lode.RelationSpec[int64, *author, *book] {
+	return lode.RelationSpec[int64, *author, *book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *author) (int64, bool) { return a.ID, true },
+		RelationKey: func(b *book) int64 { return b.AuthorID },
+		Fetch:       fetch,
+	}
+}
+
+func TestFetchAndProject(t *testing.T) {
+	ctx := context.Background()
+	q := newQueries()
+	authors := []*author{{ID: 1}, {ID: 2}, {ID: 3}}
+	lode.NewEngine().InitHandles(authors)
+
+	s := spec(Project(Fetch(q.GetBooksByAuthorIDs), toBook))
+	for i, want := range []int{2, 1, 0} {
+		books, err := lode.Many(ctx, s.For(authors[i]))
+		if err != nil || len(books) != want {
+			t.Fatalf("author %d: %d books, %v; want %d", authors[i].ID, len(books), err, want)
+		}
+	}
+	if q.calls != 1 {
+		t.Fatalf("%d queries; want 1", q.calls)
+	}
+}
+
+func TestFetchEach_Warns(t *testing.T) {
+	ctx := context.Background()
+	q := newQueries()
+	var warnings []lode.WarningEvent
+	eng := lode.NewEngine(lode.WithHooks(lode.Hooks{OnWarning: func(ev lode.WarningEvent) {
+		warnings = append(warnings, ev)
+	}}))
+	authors := []*author{{ID: 1}, {ID: 2}, {ID: 3}}
+	eng.InitHandles(authors)
+
+	s := spec(Project(FetchEach(q.GetBooksByAuthorID), toBook))
+	for i, want := range []int{2, 1, 0} {
+		books, err := lode.Many(ctx, s.For(authors[i]))
+		if err != nil || len(books) != want {
+			t.Fatalf("author %d: %d books, %v; want %d", authors[i].ID, len(books), err, want)
+		}
+	}
+	if q.calls != 3 {
+		t.Fatalf("%d queries; want one per key", q.calls)
+	}
+	if len(warnings) != 1 || warnings[0].CacheKey != "books" || !strings.Contains(warnings[0].Message, "fetching 3 keys with a query each") {
+		t.Fatalf("warnings = %+v; want one for the 3-key fetch", warnings)
+	}
+
+	// A single key is not worth a warning.
+	lone := &author{ID: 1}
+	eng.InitHandles(lone)
+	if _, err := lode.Many(ctx, s.For(lone)); err != nil || len(warnings) != 1 {
+		t.Fatalf("single-key fetch: err = %v, %d warnings", err, len(warnings))
+	}
+}
+
+func TestFetchEachOne(t *testing.T) {
+	ctx := context.Background()
+	q := newQueries()
+	eng := lode.NewEngine(lode.WithHooks(lode.Hooks{OnWarning: func(lode.WarningEvent) {}}))
+	authors := []*author{{ID: 1}, {ID: 3}}
+	eng.InitHandles(authors)
+
+	s := spec(Project(FetchEachOne(q.GetFirstBook), toBook))
+	if b, err := lode.One(ctx, s.For(authors[0])); err != nil || b == nil || b.ID != 10 {
+		t.Fatalf("One(1) = %+v, %v; want book 10", b, err)
+	}
+	if b, err := lode.One(ctx, s.For(authors[1])); err != nil || b != nil {
+		t.Fatalf("One(3) = %+v, %v; want none for sql.ErrNoRows", b, err)
+	}
+
+	boom := errors.New("boom")
+	failing := FetchEachOne(func(context.Context, int64) (bookRow, error) { return bookRow{}, boom })
+	if _, err := failing(ctx, []int64{7}); !errors.Is(err, boom) || !strings.Contains(err.Error(), "key 7") {
+		t.Fatalf("err = %v; want boom wrapped with the key", err)
+	}
+}
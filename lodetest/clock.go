@@ -0,0 +1,78 @@
+package lodetest
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// FakeClock is a manually advanced clock satisfying lode.Clock, for testing
+// time-dependent engine features deterministically.  It is safe for
+// concurrent use.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewFakeClock returns a FakeClock reading start.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now returns the clock's current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel that receives the clock's time once Advance has
+// moved it d past the current time.  A non-positive d fires immediately.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward by d and fires every After channel whose
+// time has come, in deadline order.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	var due []fakeWaiter
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+		} else {
+			due = append(due, w)
+		}
+	}
+	c.waiters = pending
+	slices.SortStableFunc(due, func(a, b fakeWaiter) int { return a.at.Compare(b.at) })
+	for _, w := range due {
+		w.ch <- c.now
+	}
+}
+
+// Waiters returns the number of After channels that have not fired yet, so
+// a test can wait for the code under test to start waiting before it
+// advances the clock.
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
@@ -0,0 +1,73 @@
+package lodetest
+
+import (
+	"testing"
+	"time"
+)
+
+func fired(ch <-chan time.Time) (time.Time, bool) {
+	select {
+	case t := <-ch:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFakeClock_AdvanceWakesTimers(t *testing.T) {
+	t.Parallel()
+	start := time.Unix(1000, 0)
+	c := NewFakeClock(start)
+
+	short, long := c.After(time.Second), c.After(time.Minute)
+	if c.Waiters() != 2 {
+		t.Fatalf("Waiters = %d; want 2", c.Waiters())
+	}
+
+	c.Advance(999 * time.Millisecond)
+	if _, ok := fired(short); ok {
+		t.Fatal("fired before its deadline")
+	}
+	c.Advance(time.Millisecond)
+	if got, ok := fired(short); !ok || !got.Equal(start.Add(time.Second)) {
+		t.Fatalf("short = %v, %v; want fired at start+1s", got, ok)
+	}
+	if _, ok := fired(long); ok || c.Waiters() != 1 {
+		t.Fatal("long fired early")
+	}
+
+	// One big jump fires everything due, reporting the new time.
+	c.Advance(time.Hour)
+	if got, ok := fired(long); !ok || !got.Equal(c.Now()) {
+		t.Fatalf("long = %v, %v", got, ok)
+	}
+	if c.Waiters() != 0 {
+		t.Fatalf("Waiters = %d; want 0", c.Waiters())
+	}
+}
+
+func TestFakeClock_NonPositiveFiresImmediately(t *testing.T) {
+	t.Parallel()
+	c := NewFakeClock(time.Unix(0, 0))
+	if _, ok := fired(c.After(0)); !ok {
+		t.Fatal("After(0) did not fire")
+	}
+	if _, ok := fired(c.After(-time.Second)); !ok {
+		t.Fatal("After(-1s) did not fire")
+	}
+}
+
+func TestFakeClock_WakesBlockedGoroutine(t *testing.T) {
+	t.Parallel()
+	c := NewFakeClock(time.Unix(0, 0))
+	done := make(chan time.Time)
+	go func() { done <- <-c.After(time.Minute) }()
+
+	for c.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	c.Advance(time.Minute)
+	if got := <-done; !got.Equal(time.Unix(60, 0)) {
+		t.Fatalf("woke at %v", got)
+	}
+}
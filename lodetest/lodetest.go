@@ -0,0 +1,73 @@
+// Package lodetest provides fixtures for testing code built on lode.
+package lodetest
+
+import (
+	"context"
+	"sync"
+)
+
+// FetchFromSlice returns a Fetch function backed by a fixture slice: it
+// returns the elements of all whose key is among the requested keys, in
+// fixture order, regardless of the order of the requested keys.
+func FetchFromSlice[Relation any, Key comparable](all []Relation, key func(Relation) Key) func(context.Context, []Key) ([]Relation, error) {
+	return func(_ context.Context, keys []Key) ([]Relation, error) {
+		want := make(map[Key]struct{}, len(keys))
+		for _, k := range keys {
+			want[k] = struct{}{}
+		}
+		var out []Relation
+		for _, r := range all {
+			if _, ok := want[key(r)]; ok {
+				out = append(out, r)
+			}
+		}
+		return out, nil
+	}
+}
+
+// FetchError returns a Fetch function that always fails with err.
+func FetchError[Relation any, Key any](err error) func(context.Context, []Key) ([]Relation, error) {
+	return func(context.Context, []Key) ([]Relation, error) {
+		return nil, err
+	}
+}
+
+// FetchRecorder wraps a Fetch function and records its calls.  It is safe
+// for concurrent use.
+type FetchRecorder[Relation any, Key any] struct {
+	fn func(context.Context, []Key) ([]Relation, error)
+
+	mu    sync.Mutex
+	calls [][]Key
+}
+
+// FetchFunc wraps fn in a FetchRecorder.  Pass its Fetch method to the spec.
+func FetchFunc[Relation any, Key any](fn func(context.Context, []Key) ([]Relation, error)) *FetchRecorder[Relation, Key] {
+	return &FetchRecorder[Relation, Key]{fn: fn}
+}
+
+// Fetch records the call and forwards it to the wrapped function.
+func (r *FetchRecorder[Relation, Key]) Fetch(ctx context.Context, keys []Key) ([]Relation, error) {
+	r.mu.Lock()
+	r.calls = append(r.calls, append([]Key(nil), keys...))
+	r.mu.Unlock()
+	return r.fn(ctx, keys)
+}
+
+// Calls returns the number of calls made so far.
+func (r *FetchRecorder[Relation, Key]) Calls() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.calls)
+}
+
+// Keys returns a copy of the key set passed to each call, in call order.
+func (r *FetchRecorder[Relation, Key]) Keys() [][]Key {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([][]Key, len(r.calls))
+	for i, keys := range r.calls {
+		out[i] = append([]Key(nil), keys...)
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+package lodetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+type book struct {
+	AuthorID int
+	Title    string
+}
+
+func TestFetchFromSlice_FixtureOrder(t *testing.T) {
+	t.Parallel()
+	all := []book{{1, "a"}, {2, "b"}, {1, "c"}, {3, "d"}}
+	fetch := FetchFromSlice(all, func(b book) int { return b.AuthorID })
+
+	got, err := fetch(context.Background(), []int{3, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []book{{1, "a"}, {1, "c"}, {3, "d"}}; !slices.Equal(got, want) {
+		t.Fatalf("got %v; want %v", got, want)
+	}
+	if got, _ := fetch(context.Background(), nil); got != nil {
+		t.Fatalf("no keys: got %v; want nil", got)
+	}
+}
+
+func TestFetchError(t *testing.T) {
+	t.Parallel()
+	errBoom := errors.New("boom")
+	got, err := FetchError[book, int](errBoom)(context.Background(), []int{1})
+	if !errors.Is(err, errBoom) || got != nil {
+		t.Fatalf("got %v, %v; want nil, boom", got, err)
+	}
+}
+
+func TestFetchRecorder(t *testing.T) {
+	t.Parallel()
+	rec := FetchFunc(FetchFromSlice([]book{{1, "a"}}, func(b book) int { return b.AuthorID }))
+
+	keys := []int{1, 2}
+	if _, err := rec.Fetch(context.Background(), keys); err != nil {
+		t.Fatal(err)
+	}
+	keys[0] = 99 // the recorder must have copied the keys
+	if _, err := rec.Fetch(context.Background(), []int{3}); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.Calls() != 2 {
+		t.Fatalf("Calls() = %d; want 2", rec.Calls())
+	}
+	if got := rec.Keys(); !slices.EqualFunc(got, [][]int{{1, 2}, {3}}, slices.Equal) {
+		t.Fatalf("Keys() = %v", got)
+	}
+}
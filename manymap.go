@@ -0,0 +1,56 @@
+package lode
+
+import (
+	"context"
+	"fmt"
+)
+
+var errPartitionedMap = &kindError{"PartitionBy spec cannot be keyed by model key", ErrMisconfigured}
+
+// ManyMap returns spec's relations for each of models, by model key.  It
+// loads the same way Many does for each model, so a batch of models sharing a
+// state is fetched once and later Many calls for any of them hit the cache;
+// spec.Model is ignored.  Nil models, models without a key, and models
+// Applies rejects are left out.  Specs with PartitionBy are an error, since
+// models of different partitions may share a key.  The slices are shared
+// with the cache and must be treated as read-only; the map is the caller's.
+func ManyMap[JoinKey comparable, Model hasState, Relation any](ctx context.Context, models []Model, spec RelationSpec[JoinKey, Model, Relation]) (map[JoinKey][]Relation, error) {
+	if spec.PartitionBy != nil {
+		return nil, fmt.Errorf("%s: key %q: %w", packagePrefix, spec.CacheKey, errPartitionedMap)
+	}
+	out := make(map[JoinKey][]Relation, len(models))
+	for _, m := range models {
+		if isNil(m) || (spec.Applies != nil && !spec.Applies(m)) {
+			continue
+		}
+		k, ok := spec.ModelKey(m)
+		if !ok {
+			continue
+		}
+		if _, done := out[k]; done {
+			continue
+		}
+		rs, err := Many(ctx, spec.For(m))
+		if err != nil {
+			return nil, err
+		}
+		out[k] = rs
+	}
+	return out, nil
+}
+
+// OneMap is ManyMap for one relation per model: it holds the relation One
+// would return, for the model keys that have one.
+func OneMap[JoinKey comparable, Model hasState, Relation any](ctx context.Context, models []Model, spec RelationSpec[JoinKey, Model, Relation]) (map[JoinKey]Relation, error) {
+	many, err := ManyMap(ctx, models, spec)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[JoinKey]Relation, len(many))
+	for k, rs := range many {
+		if len(rs) > 0 {
+			out[k] = spec.first(rs)
+		}
+	}
+	return out, nil
+}
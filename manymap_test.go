@@ -0,0 +1,71 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestManyMap(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	authors := []*Author{{ID: 1}, {ID: 2}, {ID: 0}, {ID: 3}}
+	NewEngine().InitHandles(authors)
+
+	fetches := 0
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, a.ID != 0 },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(context.Context, []int) ([]*Book, error) {
+			fetches++
+			return []*Book{{ID: 10, AuthorID: 1, Title: "a"}, {ID: 11, AuthorID: 1, Title: "b"}, {ID: 20, AuthorID: 2, Title: "c"}}, nil
+		},
+	}
+	m, err := ManyMap(ctx, authors, spec)
+	if err != nil || fetches != 1 {
+		t.Fatalf("ManyMap err = %v after %d fetches", err, fetches)
+	}
+	if len(m) != 3 || len(m[1]) != 2 || len(m[2]) != 1 || len(m[3]) != 0 {
+		t.Fatalf("ManyMap = %v", m)
+	}
+	if _, ok := m[0]; ok {
+		t.Fatal("model without a key in the map")
+	}
+	if books, err := Many(ctx, spec.For(authors[1])); err != nil || len(books) != 1 || fetches != 1 {
+		t.Fatalf("Many after ManyMap = %v, %v after %d fetches", titles(books), err, fetches)
+	}
+
+	one, err := OneMap(ctx, authors, spec)
+	if err != nil || fetches != 1 {
+		t.Fatalf("OneMap err = %v after %d fetches", err, fetches)
+	}
+	if len(one) != 2 || one[1].Title != "a" || one[2].Title != "c" {
+		t.Fatalf("OneMap = %v", one)
+	}
+}
+
+func TestManyMap_AppliesAndPartitionBy(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	authors := []*Author{{ID: 1}, {ID: 2}}
+	NewEngine().InitHandles(authors)
+
+	spec := threeBooks(map[int]int{})
+	spec.Applies = func(a *Author) bool { return a.ID != 2 }
+	m, err := ManyMap(ctx, authors, spec)
+	if err != nil || len(m) != 1 || len(m[1]) != 3 {
+		t.Fatalf("ManyMap = %v, %v; want only author 1", m, err)
+	}
+	if _, ok := m[2]; ok {
+		t.Fatal("a model Applies rejects is in the map")
+	}
+
+	spec.PartitionBy = func(a *Author) any { return a.Name }
+	if _, err := ManyMap(ctx, authors, spec); !errors.Is(err, errPartitionedMap) {
+		t.Fatalf("ManyMap with PartitionBy = %v; want errPartitionedMap", err)
+	}
+	if _, err := OneMap(ctx, authors, spec); !errors.Is(err, errPartitionedMap) {
+		t.Fatalf("OneMap with PartitionBy = %v; want errPartitionedMap", err)
+	}
+}
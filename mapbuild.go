@@ -0,0 +1,83 @@
+package lode
+
+import (
+	"context"
+	"fmt"
+)
+
+// errNoResult is returned by strict map specs for models their Build left
+// out of the map.
+var errNoResult = &kindError{"no result for model", ErrNotFound}
+
+// ResolveSpecMap is a ResolveSpec whose Build returns the results as a map
+// keyed by model; ResolveMap supplies the lookup closure.
+type ResolveSpecMap[Model interface {
+	comparable
+	hasState
+}, Result any] struct {
+	CacheKey string
+	Model    Model
+	Build    func(ctx context.Context, models []Model) (map[Model]Result, error)
+	// Strict makes a model missing from the map an error instead of the
+	// zero Result.
+	Strict bool
+}
+
+// ResolveSpecKeyed is a ResolveSpec whose Build returns the results keyed by
+// Key(model), e.g. by primary key; ResolveKeyed supplies the lookup closure.
+type ResolveSpecKeyed[K comparable, Model hasState, Result any] struct {
+	CacheKey string
+	Model    Model
+	Key      func(Model) K
+	Build    func(ctx context.Context, models []Model) (map[K]Result, error)
+	// Strict makes a key missing from the map an error instead of the zero
+	// Result.
+	Strict bool
+}
+
+// mapResult is what map-built resolvers return: the looked-up value and
+// whether it was present, so strictness is decided per call.
+type mapResult[Result any] struct {
+	value Result
+	ok    bool
+}
+
+// ResolveMap is Resolve for specs whose Build returns a map.
+func ResolveMap[Model interface {
+	comparable
+	hasState
+}, Result any](ctx context.Context, spec ResolveSpecMap[Model, Result]) (Result, error) {
+	return ResolveKeyed(ctx, ResolveSpecKeyed[Model, Model, Result]{
+		CacheKey: spec.CacheKey,
+		Model:    spec.Model,
+		Key:      func(m Model) Model { return m },
+		Build:    spec.Build,
+		Strict:   spec.Strict,
+	})
+}
+
+// ResolveKeyed is Resolve for specs whose Build returns a map keyed by
+// spec.Key.
+func ResolveKeyed[K comparable, Model hasState, Result any](ctx context.Context, spec ResolveSpecKeyed[K, Model, Result]) (Result, error) {
+	r, err := Resolve(ctx, ResolveSpec[Model, mapResult[Result]]{
+		CacheKey: spec.CacheKey,
+		Model:    spec.Model,
+		Build: func(ctx context.Context, models []Model) (ResolverFunc[Model, mapResult[Result]], error) {
+			m, err := spec.Build(ctx, models)
+			if err != nil {
+				return nil, err
+			}
+			return func(model Model) mapResult[Result] {
+				v, ok := m[spec.Key(model)]
+				return mapResult[Result]{value: v, ok: ok}
+			}, nil
+		},
+	})
+	if err != nil {
+		return r.value, err
+	}
+	if spec.Strict && !r.ok && !isNil(spec.Model) {
+		return r.value, fmt.Errorf("%s: key %q: %w %T at %p", packagePrefix, spec.CacheKey, errNoResult, spec.Model, any(spec.Model))
+	}
+	return r.value, nil
+}
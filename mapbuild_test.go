@@ -0,0 +1,85 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestResolveMap_Greeting is TestResolve_Basic with a map-returning Build.
+func TestResolveMap_Greeting(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+
+	a1 := &Author{ID: 1, Name: "Alice"}
+	a2 := &Author{ID: 2, Name: "Bob"}
+	a3 := &Author{ID: 3}
+	eng.InitHandles([]*Author{a1, a2, a3})
+
+	buildCalls := 0
+	spec := ResolveSpecMap[*Author, string]{
+		CacheKey: "author:greeting",
+		Build: func(ctx context.Context, models []*Author) (map[*Author]string, error) {
+			buildCalls++
+			m := make(map[*Author]string, len(models))
+			for _, a := range models {
+				if a.Name != "" {
+					m[a] = a.Name + "!"
+				}
+			}
+			return m, nil
+		},
+	}
+
+	for _, tc := range []struct {
+		a    *Author
+		want string
+	}{{a1, "Alice!"}, {a2, "Bob!"}, {a3, ""}} {
+		spec.Model = tc.a
+		if got, err := ResolveMap(ctx, spec); err != nil || got != tc.want {
+			t.Fatalf("ResolveMap(%d) = %q, %v; want %q", tc.a.ID, got, err, tc.want)
+		}
+	}
+	if buildCalls != 1 {
+		t.Fatalf("build called %d times; want 1", buildCalls)
+	}
+
+	// Strict is decided per call, against the same cached map.
+	spec.Strict = true
+	if _, err := ResolveMap(ctx, spec); !errors.Is(err, errNoResult) {
+		t.Fatalf("strict missing: err = %v; want errNoResult", err)
+	}
+	spec.Model = a1
+	if got, err := ResolveMap(ctx, spec); err != nil || got != "Alice!" {
+		t.Fatalf("strict present: %q, %v", got, err)
+	}
+}
+
+func TestResolveKeyed(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles([]*Author{a1, a2})
+
+	spec := ResolveSpecKeyed[int, *Author, int]{
+		CacheKey: "bookCount",
+		Model:    a2,
+		Key:      func(a *Author) int { return a.ID },
+		Build: func(ctx context.Context, models []*Author) (map[int]int, error) {
+			return map[int]int{1: 3, 2: 5}, nil
+		},
+	}
+	if got, err := ResolveKeyed(ctx, spec); err != nil || got != 5 {
+		t.Fatalf("ResolveKeyed = %d, %v; want 5", got, err)
+	}
+
+	errBoom := errors.New("boom")
+	spec.CacheKey = "failing"
+	spec.Build = func(context.Context, []*Author) (map[int]int, error) { return nil, errBoom }
+	if _, err := ResolveKeyed(ctx, spec); !errors.Is(err, errBoom) {
+		t.Fatalf("err = %v; want the build error", err)
+	}
+}
@@ -0,0 +1,53 @@
+package lode
+
+import "context"
+
+// MappedRelationSpec describes a relation whose fetch returns raw rows of
+// type Raw (say, a bookRow) that callers should see as Relation (a Book).
+// Rows are grouped by RelationKey and then converted by Map once per build,
+// so the cached result holds Relation values; those, not the rows, are bound
+// when they embed a Handle.  Use it through its RelationSpec:
+//
+//	books, err := lode.Many(ctx, authorBooks.For(a).RelationSpec())
+type MappedRelationSpec[JoinKey comparable, Model hasState, Raw, Relation any] struct {
+	CacheKey    string
+	Model       Model
+	ModelKey    func(Model) (key JoinKey, ok bool)
+	RelationKey func(Raw) JoinKey
+	Fetch       func(context.Context, []JoinKey) ([]Raw, error)
+	Map         func(Raw) Relation
+
+	SinglePerKey bool           // see RelationSpec.SinglePerKey
+	NilModel     NilModelPolicy // see RelationSpec.NilModel
+}
+
+// For returns a copy of the spec with Model set to m.
+func (s MappedRelationSpec[JoinKey, Model, Raw, Relation]) For(m Model) MappedRelationSpec[JoinKey, Model, Raw, Relation] {
+	s.Model = m
+	return s
+}
+
+// RelationSpec returns the equivalent RelationSpec, which fetches through
+// FetchGrouped.  Options without a counterpart here, such as MaxRelations or
+// KeyOrder, may be set on the result.
+func (s MappedRelationSpec[JoinKey, Model, Raw, Relation]) RelationSpec() RelationSpec[JoinKey, Model, Relation] {
+	return RelationSpec[JoinKey, Model, Relation]{
+		CacheKey: s.CacheKey,
+		Model:    s.Model,
+		ModelKey: s.ModelKey,
+		FetchGrouped: func(ctx context.Context, keys []JoinKey) (map[JoinKey][]Relation, error) {
+			rows, err := s.Fetch(ctx, keys)
+			if err != nil {
+				return nil, err
+			}
+			grouped := make(map[JoinKey][]Relation)
+			for _, row := range rows {
+				k := s.RelationKey(row)
+				grouped[k] = append(grouped[k], s.Map(row))
+			}
+			return grouped, nil
+		},
+		SinglePerKey: s.SinglePerKey,
+		NilModel:     s.NilModel,
+	}
+}
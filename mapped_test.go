@@ -0,0 +1,84 @@
+package lode
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// bookRow is a raw row that is mapped to a Book.
+type bookRow struct {
+	ID       int
+	AuthorID int
+	Title    string
+}
+
+func TestMappedRelationSpec(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles([]*Author{a1, a2})
+
+	fetches, maps := 0, 0
+	spec := MappedRelationSpec[int, *Author, bookRow, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(r bookRow) int { return r.AuthorID },
+		Fetch: func(context.Context, []int) ([]bookRow, error) {
+			fetches++
+			return []bookRow{{1, 1, "a"}, {2, 2, "b"}, {3, 1, "c"}}, nil
+		},
+		Map: func(r bookRow) *Book {
+			maps++
+			return &Book{ID: r.ID, AuthorID: r.AuthorID, Title: strings.ToUpper(r.Title)}
+		},
+	}
+
+	got1, err := Many(ctx, spec.For(a1).RelationSpec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got2, err := Many(ctx, spec.For(a2).RelationSpec())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalStrings(titles(got1), []string{"A", "C"}) || !equalStrings(titles(got2), []string{"B"}) {
+		t.Fatalf("got %v and %v", titles(got1), titles(got2))
+	}
+	if fetches != 1 || maps != 3 {
+		t.Fatalf("fetches = %d, maps = %d; want 1 and 3", fetches, maps)
+	}
+
+	// The mapped books are bound together, so their own relations batch.
+	s1, ok1 := StateOf(got1[0])
+	s2, ok2 := StateOf(got2[0])
+	if !ok1 || !ok2 || s1 != s2 {
+		t.Fatal("mapped relations should share one state")
+	}
+	builds := 0
+	wordCount := ResolveSpec[*Book, int]{
+		CacheKey: "words",
+		Build: func(_ context.Context, books []*Book) (ResolverFunc[*Book, int], error) {
+			builds++
+			if len(books) != 3 {
+				t.Errorf("nested build saw %d books; want 3", len(books))
+			}
+			return func(b *Book) int { return len(strings.Fields(b.Title)) }, nil
+		},
+	}
+	for _, b := range append(got1, got2...) {
+		wordCount.Model = b
+		if n, err := Resolve(ctx, wordCount); err != nil || n != 1 {
+			t.Fatalf("Resolve(%s) = %d, %v", b.Title, n, err)
+		}
+	}
+	if builds != 1 {
+		t.Fatalf("nested builds = %d; want 1", builds)
+	}
+
+	first, err := One(ctx, spec.For(a1).RelationSpec())
+	if err != nil || first.Title != "A" {
+		t.Fatalf("One = %v, %v; want A", first, err)
+	}
+}
@@ -0,0 +1,184 @@
+package lode
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"runtime"
+	"slices"
+	"sync/atomic"
+)
+
+// BudgetPolicy says what a build that would exceed the memory budget does.
+type BudgetPolicy int
+
+const (
+	// BudgetFail fails the build with ErrBudgetExceeded.  Like any build
+	// error, it is retried by the next call.
+	BudgetFail BudgetPolicy = iota
+	// BudgetEvictLRU first evicts the least recently used built entries,
+	// across the engine's states, until the build fits, and fails as
+	// BudgetFail only if it cannot fit even then.  Entries of frozen states
+	// are not evicted.
+	BudgetEvictLRU
+)
+
+func (p BudgetPolicy) String() string {
+	switch p {
+	case BudgetFail:
+		return "BudgetFail"
+	case BudgetEvictLRU:
+		return "BudgetEvictLRU"
+	}
+	return fmt.Sprintf("BudgetPolicy(%d)", int(p))
+}
+
+// WithMemoryBudget turns on size accounting for the relations Many caches:
+// each build's relations are sized with RelationSpec.SizeOf (one unit per
+// relation by default), and the sizes are summed across the engine's states
+// and scopes and reported in Stats.  A build that would take the total over
+// n is handled according to policy.  Zero or negative n accounts without a
+// limit.  Sizes are released as entries are reset, invalidated, evicted, or
+// collected with their state, so for a per-request budget give each request
+// its own engine.  Resolve results other than Many's are not sized.
+func WithMemoryBudget(n int, policy BudgetPolicy) ConfigOption {
+	return func(c *Config) {
+		c.memAccounting = true
+		c.memBudget = max(n, 0)
+		c.memPolicy = policy
+	}
+}
+
+// memAccount is the engine-wide size accounting of WithMemoryBudget.
+type memAccount struct {
+	used       atomic.Int64
+	tick       atomic.Int64 // clock for resolverEntry.lastUsed
+	evictions  atomic.Uint64
+	rejections atomic.Uint64
+	states     stateRegistry // states with charges, for eviction
+}
+
+// stateCharge is the size charged to one state's entries.  It is kept apart
+// from the state so it can be released when the state is collected.
+type stateCharge struct {
+	size atomic.Int64
+	acct *memAccount
+}
+
+// charge reserves size for a build on s, evicting under BudgetEvictLRU, and
+// adds it to the build's Info so the entry releases it when removed.
+func (e *Engine) charge(ctx context.Context, s *loaderState, cacheKey string, size int) error {
+	acct := e.mem
+	if acct == nil || size <= 0 {
+		return nil
+	}
+	n := int64(size)
+	for budget := int64(e.config.memBudget); budget > 0; {
+		used := acct.used.Load()
+		if used+n <= budget {
+			if acct.used.CompareAndSwap(used, used+n) {
+				break
+			}
+			continue
+		}
+		if e.config.memPolicy != BudgetEvictLRU || !acct.evict(used+n-budget) {
+			acct.rejections.Add(1)
+			return fmt.Errorf("%s: key %q: %w: build of size %d, %d of %d in use", packagePrefix, cacheKey, ErrBudgetExceeded, n, used, budget)
+		}
+	}
+	if e.config.memBudget <= 0 {
+		acct.used.Add(n)
+	}
+	c := s.mem.Load()
+	if c == nil {
+		c = &stateCharge{acct: acct}
+		if s.mem.CompareAndSwap(nil, c) {
+			runtime.AddCleanup(s, (*stateCharge).release, c)
+			acct.states.add(s)
+		} else {
+			c = s.mem.Load()
+		}
+	}
+	c.size.Add(n)
+	if info, ok := ctx.Value(buildInfoKey{}).(*Info); ok {
+		info.Size += size
+	}
+	return nil
+}
+
+// release gives back what is still charged to a collected state.
+func (c *stateCharge) release() {
+	c.acct.used.Add(-c.size.Swap(0))
+}
+
+// release gives back the size charged for entry's value on s, once.
+func (s *loaderState) release(entry *resolverEntry) {
+	if s == nil || s.engine.mem == nil {
+		return
+	}
+	h := entry.ready.Load()
+	if h == nil || h.info.Size == 0 || !entry.released.CompareAndSwap(false, true) {
+		return
+	}
+	n := int64(h.info.Size)
+	if c := s.mem.Load(); c != nil {
+		c.size.Add(-n)
+	}
+	s.engine.mem.used.Add(-n)
+}
+
+// touch records a use of c's entry for BudgetEvictLRU.
+func (c *CacheEntry) touch() {
+	if acct := c.e.mem; acct != nil && c.e.config.memPolicy == BudgetEvictLRU {
+		c.entry.lastUsed.Store(acct.tick.Add(1))
+	}
+}
+
+// evict removes least recently used sized entries until at least need has
+// been released, reporting whether it released anything.
+func (acct *memAccount) evict(need int64) bool {
+	type candidate struct {
+		s     *loaderState
+		key   any
+		entry *resolverEntry
+		used  int64
+	}
+	var candidates []candidate
+	for _, s := range acct.states.live() {
+		if s.frozen.Load() {
+			continue
+		}
+		s.resolverEntries.Range(func(k, v any) bool {
+			entry := v.(*resolverEntry)
+			if h := entry.ready.Load(); h != nil && h.info.Size > 0 && !entry.released.Load() {
+				candidates = append(candidates, candidate{s, k, entry, entry.lastUsed.Load()})
+			}
+			return true
+		})
+	}
+	slices.SortFunc(candidates, func(a, b candidate) int { return cmp.Compare(a.used, b.used) })
+	var freed int64
+	for _, c := range candidates {
+		if freed >= need {
+			break
+		}
+		if c.s.resolverEntries.CompareAndDelete(c.key, c.entry) {
+			freed += int64(c.entry.ready.Load().info.Size)
+			c.s.release(c.entry)
+			acct.evictions.Add(1)
+		}
+	}
+	return freed > 0
+}
+
+// sizeOf returns the accounted size of relations.
+func (args RelationSpec[JoinKey, Model, Relation]) sizeOf(relations []Relation) int {
+	if args.SizeOf == nil {
+		return len(relations)
+	}
+	n := 0
+	for _, r := range relations {
+		n += args.SizeOf(r)
+	}
+	return n
+}
@@ -0,0 +1,128 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// threeBooks returns a spec fetching three books per author, counting
+// fetches per author ID.
+func threeBooks(fetches map[int]int) RelationSpec[int, *Author, *Book] {
+	return RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(_ context.Context, ids []int) ([]*Book, error) {
+			var out []*Book
+			for _, id := range ids {
+				fetches[id]++
+				for j := range 3 {
+					out = append(out, &Book{ID: 10*id + j, AuthorID: id, Title: "abcd"[:j+1]})
+				}
+			}
+			return out, nil
+		},
+	}
+}
+
+func TestMemoryBudget_Fail(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine(WithBatchSize(1), WithMemoryBudget(5, BudgetFail))
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles([]*Author{a1, a2})
+	spec := threeBooks(map[int]int{})
+
+	if _, info, err := ManyInfo(ctx, spec.For(a1)); err != nil || info.Size != 3 {
+		t.Fatalf("Many(1) info = %+v, err = %v; want size 3", info, err)
+	}
+	if _, err := Many(ctx, spec.For(a2)); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("Many(2) err = %v; want ErrBudgetExceeded", err)
+	}
+	if s := eng.Stats(); s.MemoryUsed != 3 || s.BudgetRejections != 1 || s.Evictions != 0 {
+		t.Fatalf("Stats = %+v", s)
+	}
+
+	if err := a1.Invalidate("books"); err != nil {
+		t.Fatal(err)
+	}
+	if s := eng.Stats(); s.MemoryUsed != 0 {
+		t.Fatalf("MemoryUsed after Invalidate = %d; want 0", s.MemoryUsed)
+	}
+	if err := a2.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if books, err := Many(ctx, spec.For(a2)); err != nil || len(books) != 3 {
+		t.Fatalf("Many(2) after freeing = %d books, %v", len(books), err)
+	}
+	if n, p := eng.Config().MemoryBudget(); n != 5 || p != BudgetFail {
+		t.Fatalf("MemoryBudget() = %d, %v", n, p)
+	}
+}
+
+func TestMemoryBudget_EvictLRU(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine(WithBatchSize(1), WithMemoryBudget(7, BudgetEvictLRU))
+	a1, a2, a3 := &Author{ID: 1}, &Author{ID: 2}, &Author{ID: 3}
+	eng.InitHandles([]*Author{a1, a2, a3})
+	fetches := map[int]int{}
+	spec := threeBooks(fetches)
+
+	for _, a := range []*Author{a1, a2, a1, a3} {
+		if books, err := Many(ctx, spec.For(a)); err != nil || len(books) != 3 {
+			t.Fatalf("Many(%d) = %d books, %v", a.ID, len(books), err)
+		}
+	}
+	// a2 was the least recently used when a3 needed room.
+	if s := eng.Stats(); s.MemoryUsed != 6 || s.Evictions != 1 {
+		t.Fatalf("Stats = %+v; want 6 used after 1 eviction", s)
+	}
+	Many(ctx, spec.For(a1))
+	if fetches[1] != 1 || fetches[3] != 1 {
+		t.Fatalf("fetches = %v; want a1 and a3 still cached", fetches)
+	}
+	Many(ctx, spec.For(a2))
+	if fetches[2] != 2 {
+		t.Fatalf("fetches = %v; want a2 fetched again", fetches)
+	}
+
+	// A build larger than the whole budget still fails.
+	big := spec
+	big.CacheKey = "big"
+	big.SizeOf = func(*Book) int { return 3 }
+	if _, err := Many(ctx, big.For(a1)); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("oversized build err = %v; want ErrBudgetExceeded", err)
+	}
+}
+
+func TestMemoryBudget_AccountingOnly(t *testing.T) {
+	ctx := context.Background()
+	eng := NewEngine(WithMemoryBudget(0, BudgetFail))
+	spec := threeBooks(map[int]int{})
+	spec.SizeOf = func(b *Book) int { return len(b.Title) }
+
+	func() {
+		a := &Author{ID: 1}
+		eng.InitHandles(a)
+		if _, info, err := ManyInfo(ctx, spec.For(a)); err != nil || info.Size != 6 {
+			t.Fatalf("Many info = %+v, %v; want size 1+2+3", info, err)
+		}
+	}()
+	if used := eng.Stats().MemoryUsed; used != 6 {
+		t.Fatalf("MemoryUsed = %d; want 6", used)
+	}
+
+	// The charge is released once the state is collected.
+	deadline := time.Now().Add(5 * time.Second)
+	for eng.Stats().MemoryUsed != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("MemoryUsed = %d after the state was collected; want 0", eng.Stats().MemoryUsed)
+		}
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,57 @@
+package lode
+
+import "fmt"
+
+// NilModelPolicy says what Resolve, Many, One, and Stream do when the spec's
+// Model is nil.  It is set for all specs with WithNilModelPolicy and per spec
+// with their NilModel field.
+type NilModelPolicy int
+
+const (
+	// NilModelDefault, the zero value, leaves the choice to the engine's
+	// WithNilModelPolicy, NilReturnsZero without one.
+	NilModelDefault NilModelPolicy = iota
+	// NilReturnsZero returns the zero result and no error.
+	NilReturnsZero
+	// NilReturnsError returns an error wrapping ErrNilModel.
+	NilReturnsError
+)
+
+// WithNilModelPolicy sets the policy for nil models of specs whose NilModel
+// is NilModelDefault.  A nil model carries no state, and so no engine: the
+// policy applied is that of the spec's FallbackEngine, else of the default
+// engine if one is set (see SetDefault), as for WithFallbackFetch.  It sets
+// SpecDefaults.NilModel.
+func WithNilModelPolicy(p NilModelPolicy) ConfigOption {
+	return func(c *Config) { c.specDefaults.NilModel = p }
+}
+
+func (p NilModelPolicy) String() string {
+	switch p {
+	case NilModelDefault:
+		return "NilModelDefault"
+	case NilReturnsZero:
+		return "NilReturnsZero"
+	case NilReturnsError:
+		return "NilReturnsError"
+	}
+	return fmt.Sprintf("NilModelPolicy(%d)", int(p))
+}
+
+// nilModel returns the error, if any, for a nil model under the policy,
+// deferring to that of e, the spec's FallbackEngine, else of the default
+// engine.
+func (p NilModelPolicy) nilModel(cacheKey string, e *Engine) error {
+	if p == NilModelDefault {
+		if e == nil {
+			e = defaultIfSet()
+		}
+		if e != nil {
+			p = e.config.specDefaults.NilModel
+		}
+	}
+	if p == NilReturnsError {
+		return fmt.Errorf("%s: key %q: %w", packagePrefix, cacheKey, ErrNilModel)
+	}
+	return nil
+}
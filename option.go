@@ -0,0 +1,57 @@
+package lode
+
+import "context"
+
+// Option is a value that may be absent, as returned by OneOpt.  Unlike the
+// zero Relation One returns for a missing relation, it tells a missing
+// relation from a present zero value, which matters for value-typed
+// relations.  The zero Option is absent.
+type Option[T any] struct {
+	value T
+	ok    bool
+}
+
+// Some returns a present Option holding v.
+func Some[T any](v T) Option[T] { return Option[T]{value: v, ok: true} }
+
+// None returns an absent Option.
+func None[T any]() Option[T] { return Option[T]{} }
+
+// Get returns the value and whether it is present.
+func (o Option[T]) Get() (T, bool) { return o.value, o.ok }
+
+// OrZero returns the value, or the zero T if absent.
+func (o Option[T]) OrZero() T { return o.value }
+
+// OrElse returns the value, or def if absent.
+func (o Option[T]) OrElse(def T) T {
+	if o.ok {
+		return o.value
+	}
+	return def
+}
+
+// Map returns f applied to the value, or an absent Option if o is absent.
+// For a result of another type, use MapOption.
+func (o Option[T]) Map(f func(T) T) Option[T] {
+	return MapOption(o, f)
+}
+
+// MapOption is Option.Map for an f returning a different type; Go methods
+// cannot introduce type parameters.
+func MapOption[T, U any](o Option[T], f func(T) U) Option[U] {
+	if !o.ok {
+		return Option[U]{}
+	}
+	return Some(f(o.value))
+}
+
+// OneOpt is One returning an Option: absent when the model has no relation
+// (or no key), present otherwise, even if the relation is a zero value.
+func OneOpt[JoinKey comparable, Model hasState, Relation any](ctx context.Context, args RelationSpec[JoinKey, Model, Relation]) (Option[Relation], error) {
+	relations, _, err := ManyInfo(ctx, args)
+	if err != nil || len(relations) == 0 {
+		return Option[Relation]{}, err
+	}
+	return Some(args.first(relations)), nil
+}
@@ -0,0 +1,103 @@
+package lode
+
+import (
+	"context"
+	"strconv"
+	"testing"
+)
+
+// nicknames are value-typed relations, where One cannot tell an empty
+// nickname from none.
+type nickname struct {
+	AuthorID int
+	Name     string
+}
+
+func TestOneOpt_ValueRelation(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	NewEngine().InitHandles([]*Author{a1, a2})
+
+	spec := NewRelation(
+		func(a *Author) (int, bool) { return a.ID, true },
+		func(n nickname) int { return n.AuthorID },
+		func(context.Context, []int) ([]nickname, error) {
+			return []nickname{{AuthorID: 1}}, nil // present, but empty
+		},
+		"nickname",
+	)
+	got, err := OneOpt(ctx, spec.For(a1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := got.Get(); !ok || n != (nickname{AuthorID: 1}) {
+		t.Fatalf("Get() = %v, %v; want the empty nickname", n, ok)
+	}
+	missing, err := OneOpt(ctx, spec.For(a2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := missing.Get(); ok {
+		t.Fatal("author 2 has a nickname")
+	}
+	if n := missing.OrElse(nickname{Name: "anon"}); n.Name != "anon" {
+		t.Fatalf("OrElse = %v", n)
+	}
+	if n := missing.OrZero(); n != (nickname{}) {
+		t.Fatalf("OrZero = %v", n)
+	}
+}
+
+func TestOneOpt_PointerRelation(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	NewEngine().InitHandles([]*Author{a1, a2})
+
+	spec := NewRelation(
+		func(a *Author) (int, bool) { return a.ID, true },
+		func(b *Book) int { return b.AuthorID },
+		func(context.Context, []int) ([]*Book, error) {
+			return []*Book{{ID: 7, AuthorID: 1, Title: "Dune"}, nil}, nil
+		},
+		"book",
+	)
+	got, err := OneOpt(ctx, spec.For(a1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := got.OrZero(); b == nil || b.ID != 7 {
+		t.Fatalf("OrZero = %v; want book 7", b)
+	}
+	title := MapOption(got, func(b *Book) string { return b.Title })
+	if s := title.OrElse("untitled"); s != "Dune" {
+		t.Fatalf("mapped title = %q", s)
+	}
+
+	missing, _ := OneOpt(ctx, spec.For(a2))
+	if b := missing.OrZero(); b != nil {
+		t.Fatalf("OrZero = %v; want nil", b)
+	}
+	if s := MapOption(missing, func(b *Book) string { return b.Title }).OrElse("untitled"); s != "untitled" {
+		t.Fatalf("mapped missing title = %q", s)
+	}
+}
+
+func TestOption_Map(t *testing.T) {
+	t.Parallel()
+	double := func(n int) int { return 2 * n }
+	if v, ok := Some(0).Map(double).Get(); !ok || v != 0 {
+		t.Fatalf("Some(0).Map = %v, %v", v, ok)
+	}
+	if _, ok := None[int]().Map(double).Get(); ok {
+		t.Fatal("None().Map is present")
+	}
+	if s := MapOption(Some(21), func(n int) string { return strconv.Itoa(2 * n) }).OrZero(); s != "42" {
+		t.Fatalf("MapOption = %q", s)
+	}
+	var zero Option[string]
+	if _, ok := zero.Get(); ok {
+		t.Fatal("the zero Option is present")
+	}
+}
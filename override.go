@@ -0,0 +1,53 @@
+package lode
+
+import (
+	"reflect"
+)
+
+// overrideKey identifies an override: one model under one namespaced key.
+type overrideKey struct {
+	cacheKey string
+	model    any
+}
+
+// Override makes Resolve return value for model under cacheKey instead of
+// what the key's resolver would, without building or touching the resolver
+// shared by model's siblings.  For Many and One, value is the model's
+// []Relation under the spec's CacheKey (CacheKey+SinglePerKeySuffix for
+// SinglePerKey specs); Many only consults it for models with a key.
+//
+// Overrides are not cached results: they survive Reset, Invalidate, and
+// ResetPrefix, and last until ClearOverride or until the state is released.
+// A Resolve whose Result type differs from value's reports an error.
+func Override[Model hasState, Result any](model Model, cacheKey string, value Result) error {
+	if isNil(model) || model.lodeState() == nil {
+		return notInitialized(model)
+	}
+	s := model.lodeState()
+	s.overrides.Store(overrideKey{cacheKey: s.engine.key(cacheKey), model: model}, value)
+	return nil
+}
+
+// ClearOverride removes model's override for cacheKey, if any.
+func ClearOverride(model hasState, cacheKey string) error {
+	if isNil(model) || model.lodeState() == nil {
+		return notInitialized(model)
+	}
+	s := model.lodeState()
+	s.overrides.Delete(overrideKey{cacheKey: s.engine.key(cacheKey), model: model})
+	return nil
+}
+
+// override returns model's override for the namespaced cacheKey.
+func override[Result any](s *loaderState, cacheKey string, model hasState) (Result, bool, error) {
+	var zero Result
+	v, ok := s.overrides.Load(overrideKey{cacheKey: cacheKey, model: model})
+	if !ok {
+		return zero, false, nil
+	}
+	r, ok := v.(Result)
+	if !ok {
+		return zero, true, typeMismatch[Result](cacheKey, reflect.TypeOf(v))
+	}
+	return r, true, nil
+}
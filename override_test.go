@@ -0,0 +1,88 @@
+package lode
+
+import (
+	"context"
+	"testing"
+)
+
+func TestOverride(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine(WithKeyNamespace("ns"))
+	a1, a2 := &Author{ID: 1, Name: "a1"}, &Author{ID: 2, Name: "a2"}
+	eng.InitHandles([]*Author{a1, a2})
+
+	var builds int
+	spec := countingGreeting(&builds)
+	resolve := func(a *Author) string {
+		t.Helper()
+		spec.Model = a
+		got, err := Resolve(ctx, spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	if err := Override(a1, "greet", "flagged"); err != nil {
+		t.Fatal(err)
+	}
+	if got := resolve(a1); got != "flagged" || builds != 0 {
+		t.Fatalf("Resolve(a1) = %q after %d builds; want the override and no build", got, builds)
+	}
+	if got := resolve(a2); got != "hi a2" {
+		t.Fatalf("Resolve(a2) = %q; the override leaked to a sibling", got)
+	}
+
+	// Overrides outlive invalidation of the key they sit over.
+	if err := a1.Invalidate("greet"); err != nil {
+		t.Fatal(err)
+	}
+	if got := resolve(a1); got != "flagged" {
+		t.Fatalf("Resolve(a1) after Invalidate = %q; want the override", got)
+	}
+
+	if err := ClearOverride(a1, "greet"); err != nil {
+		t.Fatal(err)
+	}
+	if got := resolve(a1); got != "hi a1" {
+		t.Fatalf("Resolve(a1) after ClearOverride = %q", got)
+	}
+
+	if err := Override(a1, "greet", 42); err != nil {
+		t.Fatal(err)
+	}
+	spec.Model = a1
+	if _, err := Resolve(ctx, spec); err == nil {
+		t.Fatal("want an error for an override of the wrong type")
+	}
+}
+
+func TestOverride_Many(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles([]*Author{a1, a2})
+
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(context.Context, []int) ([]*Book, error) {
+			return []*Book{{ID: 1, AuthorID: 1, Title: "real"}, {ID: 2, AuthorID: 2, Title: "other"}}, nil
+		},
+	}
+	if err := Override(a1, "books", []*Book{{ID: 9, Title: "fake"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Many(ctx, spec.For(a1)); err != nil || !equalStrings(titles(got), []string{"fake"}) {
+		t.Fatalf("Many(a1) = %v, %v; want the override", titles(got), err)
+	}
+	if got, err := One(ctx, spec.For(a1)); err != nil || got.Title != "fake" {
+		t.Fatalf("One(a1) = %v, %v; want the override", got, err)
+	}
+	if got, err := Many(ctx, spec.For(a2)); err != nil || !equalStrings(titles(got), []string{"other"}) {
+		t.Fatalf("Many(a2) = %v, %v", titles(got), err)
+	}
+}
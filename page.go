@@ -0,0 +1,40 @@
+package lode
+
+import "context"
+
+// Page selects a window of a model's relations for ManyPage.
+type Page struct {
+	Offset int // negative is treated as 0
+	Limit  int // zero or negative means no limit
+}
+
+// PageInfo describes the window ManyPage returned.
+type PageInfo struct {
+	// Total is the number of relations in the model's whole group.
+	Total int
+	// HasMore reports whether relations follow the returned window.
+	HasMore bool
+}
+
+// ManyPage is Many for one page of the model's relations: the whole group is
+// resolved, and cached, as by Many, so later pages are served without
+// fetching again.  An offset past the end returns no relations and HasMore
+// false.  The returned slice shares the cached group and has no spare
+// capacity, so appending to it copies.
+func ManyPage[JoinKey comparable, Model hasState, Relation any](ctx context.Context, args RelationSpec[JoinKey, Model, Relation], page Page) ([]Relation, PageInfo, error) {
+	all, err := Many(ctx, args)
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
+	info := PageInfo{Total: len(all)}
+	lo := max(page.Offset, 0)
+	if lo >= len(all) {
+		return nil, info, nil
+	}
+	hi := len(all)
+	if page.Limit > 0 && page.Limit < hi-lo {
+		hi = lo + page.Limit
+	}
+	info.HasMore = hi < len(all)
+	return all[lo:hi:hi], info, nil
+}
@@ -0,0 +1,69 @@
+package lode
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/willhf/lode/lodetest"
+)
+
+func TestManyPage(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles([]*Author{a1, a2})
+
+	var all []*Book
+	for i := range 7 {
+		all = append(all, &Book{ID: i, AuthorID: 1, Title: fmt.Sprint(i)})
+	}
+	rec := lodetest.FetchFunc(lodetest.FetchFromSlice(all, func(b *Book) int { return b.AuthorID }))
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       a1,
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch:       rec.Fetch,
+	}
+
+	cases := []struct {
+		page    Page
+		want    []string
+		hasMore bool
+	}{
+		{Page{Offset: 0, Limit: 3}, []string{"0", "1", "2"}, true},
+		{Page{Offset: 3, Limit: 3}, []string{"3", "4", "5"}, true},
+		{Page{Offset: 6, Limit: 3}, []string{"6"}, false},
+		{Page{Offset: 4, Limit: 3}, []string{"4", "5", "6"}, false}, // ends exactly at the end
+		{Page{Offset: 7, Limit: 3}, []string{}, false},
+		{Page{Offset: 100, Limit: 3}, []string{}, false},
+		{Page{Offset: -2, Limit: 2}, []string{"0", "1"}, true},
+		{Page{Offset: 5}, []string{"5", "6"}, false},
+	}
+	for _, tc := range cases {
+		got, info, err := ManyPage(ctx, spec, tc.page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalStrings(titles(got), tc.want) || info != (PageInfo{Total: 7, HasMore: tc.hasMore}) {
+			t.Fatalf("%+v: got %v %+v; want %v hasMore=%v", tc.page, titles(got), info, tc.want, tc.hasMore)
+		}
+	}
+	if rec.Calls() != 1 {
+		t.Fatalf("fetch calls = %d; want 1", rec.Calls())
+	}
+
+	// Appending to a page must not clobber the cached group.
+	first, _, _ := ManyPage(ctx, spec, Page{Limit: 2})
+	_ = append(first, &Book{Title: "intruder"})
+	if again, _ := Many(ctx, spec); again[2].Title != "2" {
+		t.Fatalf("cached group modified: %v", titles(again))
+	}
+
+	spec.Model = a2
+	if got, info, err := ManyPage(ctx, spec, Page{Limit: 5}); err != nil || len(got) != 0 || info != (PageInfo{}) {
+		t.Fatalf("empty group: %v %+v %v", titles(got), info, err)
+	}
+}
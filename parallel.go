@@ -0,0 +1,70 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+var errPanicked = errors.New("panicked")
+
+// Parallel calls fns concurrently and waits for them, for views that read
+// several relations of one model, each a batched query but otherwise one
+// round trip after another:
+//
+//	var books []*Book
+//	var publisher *Publisher
+//	err := lode.Parallel(ctx,
+//		func(ctx context.Context) (err error) { books, err = author.Books(ctx); return },
+//		func(ctx context.Context) (err error) { publisher, err = author.Publisher(ctx); return },
+//	)
+//
+// Distinct cache keys build at once, within the engine's
+// WithMaxConcurrentBuilds limit like any other builds; calls sharing a key
+// share its build.  The first error cancels the ctx passed to the other
+// functions and is returned once they all have.  Builds that ctx's
+// cancellation interrupts fail, unless the engine uses
+// WithDetachedBuildContext, and are retried by the next call like any failed
+// build.  A panic in a function is recovered and returned as an error
+// carrying its stack.
+func Parallel(ctx context.Context, fns ...func(ctx context.Context) error) error {
+	return parallel(ctx, "Parallel: function %d", fns)
+}
+
+// parallel is Parallel naming fns[i] in panics as label formatted with i.
+func parallel(ctx context.Context, label string, fns []func(ctx context.Context) error) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	var (
+		wg    sync.WaitGroup
+		once  sync.Once
+		first error
+	)
+	for i, fn := range fns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := callRecovered(ctx, fmt.Sprintf(label, i), fn); err != nil {
+				once.Do(func() {
+					first = err
+					cancel(err)
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	return first
+}
+
+// callRecovered calls fn, turning a panic into an error naming it as what,
+// e.g. "Parallel: function 2".
+func callRecovered(ctx context.Context, what string, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s: %s %w: %v\n%s", packagePrefix, what, errPanicked, r, debug.Stack())
+		}
+	}()
+	return fn(ctx)
+}
@@ -0,0 +1,96 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParallel_RunsConcurrently(t *testing.T) {
+	t.Parallel()
+	var barrier sync.WaitGroup
+	barrier.Add(3)
+	fn := func(context.Context) error {
+		barrier.Done()
+		barrier.Wait() // returns only once all three have started
+		return nil
+	}
+	if err := Parallel(context.Background(), fn, fn, fn); err != nil {
+		t.Fatal(err)
+	}
+	if err := Parallel(context.Background()); err != nil {
+		t.Fatalf("Parallel() = %v", err)
+	}
+}
+
+func TestParallel_FirstErrorCancels(t *testing.T) {
+	t.Parallel()
+	boom := errors.New("boom")
+	var canceled atomic.Bool
+	err := Parallel(context.Background(),
+		func(context.Context) error { return boom },
+		func(ctx context.Context) error {
+			<-ctx.Done()
+			canceled.Store(errors.Is(context.Cause(ctx), boom))
+			return ctx.Err()
+		},
+	)
+	if err != boom || !canceled.Load() {
+		t.Fatalf("err = %v, sibling canceled by it = %v; want boom, true", err, canceled.Load())
+	}
+}
+
+func TestParallel_RecoversPanics(t *testing.T) {
+	t.Parallel()
+	err := Parallel(context.Background(),
+		func(context.Context) error { return nil },
+		func(context.Context) error { panic("kaboom") },
+	)
+	if !errors.Is(err, errPanicked) || !strings.Contains(err.Error(), "function 1 panicked: kaboom") || !strings.Contains(err.Error(), "parallel_test.go") {
+		t.Fatalf("err = %v; want the panic with its stack", err)
+	}
+}
+
+func TestParallel_RespectsMaxConcurrentBuilds(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	a := &Author{ID: 1}
+	NewEngine(WithMaxConcurrentBuilds(2)).InitHandles(a)
+
+	var running, peak atomic.Int32
+	spec := func(key string) ResolveSpec[*Author, string] {
+		return ResolveSpec[*Author, string]{
+			CacheKey: key,
+			Build: func(context.Context, []*Author) (ResolverFunc[*Author, string], error) {
+				n := running.Add(1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				defer running.Add(-1)
+				return func(*Author) string { return key }, nil
+			},
+		}
+	}
+	var fns []func(context.Context) error
+	for _, key := range []string{"a", "b", "c", "d", "e", "a"} {
+		fns = append(fns, func(ctx context.Context) error {
+			got, err := Resolve(ctx, spec(key).For(a))
+			if err == nil && got != key {
+				err = errors.New("got " + got)
+			}
+			return err
+		})
+	}
+	if err := Parallel(ctx, fns...); err != nil {
+		t.Fatal(err)
+	}
+	if p := peak.Load(); p > 2 {
+		t.Fatalf("%d builds ran at once; want at most 2", p)
+	}
+}
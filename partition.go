@@ -0,0 +1,60 @@
+package lode
+
+import "context"
+
+type partitionCtxKey struct{}
+
+// PartitionOf returns the partition a fetch for a RelationSpec with
+// PartitionBy is for, so the fetch can filter by it as well as by key (e.g.
+// add a tenant condition).  ok is false outside such fetches.
+func PartitionOf(ctx context.Context) (partition any, ok bool) {
+	p, ok := ctx.Value(partitionCtxKey{}).(partitionValue)
+	return p.v, ok
+}
+
+// partitionValue wraps partitions in the ctx so a nil partition is still
+// found.
+type partitionValue struct{ v any }
+
+// loadPartitioned is load for PartitionBy specs: it loads each partition of
+// models on its own and looks models up in their partition's groups, so
+// equal keys in different partitions never share relations.  The resolver
+// stays one per cache key.
+func (args RelationSpec[JoinKey, Model, Relation]) loadPartitioned(ctx context.Context, loader *loaderState, models []Model) (ResolverFunc[Model, []Relation], error) {
+	order, parts := args.partitions(models)
+	lookups := make(map[any]func(JoinKey) []Relation, len(parts))
+	for _, p := range order {
+		lookup, err := args.load(partitionCtx(ctx, p), loader, parts[p])
+		if err != nil {
+			return nil, err
+		}
+		lookups[p] = lookup
+	}
+	return func(m Model) []Relation {
+		id, ok := args.ModelKey(m)
+		lookup := lookups[args.PartitionBy(m)]
+		if !ok || lookup == nil {
+			return nil
+		}
+		return lookup(id)
+	}, nil
+}
+
+// partitions splits models by PartitionBy, returning the partitions in the
+// order their first model appears.
+func (args RelationSpec[JoinKey, Model, Relation]) partitions(models []Model) (order []any, parts map[any][]Model) {
+	parts = make(map[any][]Model)
+	for _, m := range models {
+		p := args.PartitionBy(m)
+		if _, ok := parts[p]; !ok {
+			order = append(order, p)
+		}
+		parts[p] = append(parts[p], m)
+	}
+	return order, parts
+}
+
+// partitionCtx returns ctx for the fetches of partition p; see PartitionOf.
+func partitionCtx(ctx context.Context, p any) context.Context {
+	return context.WithValue(ctx, partitionCtxKey{}, partitionValue{p})
+}
@@ -0,0 +1,59 @@
+package lode
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestMany_PartitionBy(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	// Publisher 1 exists in both orgs: equal keys must not share books.
+	pubs := []*Publisher{{ID: 1, OrgID: 10}, {ID: 2, OrgID: 20}, {ID: 1, OrgID: 20}, {ID: 3, OrgID: 10}}
+	NewEngine().InitHandles(pubs)
+
+	var mu sync.Mutex
+	fetches := map[int][]int{}
+	spec := RelationSpec[int, *Publisher, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(p *Publisher) (int, bool) { return p.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		PartitionBy: func(p *Publisher) any { return p.OrgID },
+		Fetch: func(ctx context.Context, keys []int) ([]*Book, error) {
+			p, ok := PartitionOf(ctx)
+			if !ok {
+				t.Error("fetch without a partition")
+			}
+			org := p.(int)
+			mu.Lock()
+			fetches[org] = slices.Sorted(slices.Values(keys))
+			mu.Unlock()
+			var books []*Book
+			for _, k := range keys {
+				books = append(books, &Book{AuthorID: k, Title: fmt.Sprintf("org %d pub %d", org, k)})
+			}
+			return books, nil
+		},
+	}
+
+	for _, p := range pubs {
+		books, err := Many(ctx, spec.For(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := fmt.Sprintf("org %d pub %d", p.OrgID, p.ID)
+		if len(books) != 1 || books[0].Title != want {
+			t.Fatalf("publisher %d/%d got %v; want [%s]", p.OrgID, p.ID, titles(books), want)
+		}
+	}
+	if len(fetches) != 2 || !slices.Equal(fetches[10], []int{1, 3}) || !slices.Equal(fetches[20], []int{1, 2}) {
+		t.Fatalf("fetches = %v; want org 10 with [1 3] and org 20 with [1 2]", fetches)
+	}
+
+	if _, ok := PartitionOf(ctx); ok {
+		t.Fatal("PartitionOf outside a fetch reported a partition")
+	}
+}
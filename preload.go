@@ -0,0 +1,122 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Preloader warms one cache key for a model's batch.  Create one with
+// PreloadMany or PreloadResolve and run several with PreloadAll.
+type Preloader interface {
+	cacheKey() string
+	preload(ctx context.Context) error
+}
+
+type preloadFunc struct {
+	key string
+	fn  func(context.Context) error
+}
+
+func (p preloadFunc) cacheKey() string                  { return p.key }
+func (p preloadFunc) preload(ctx context.Context) error { return p.fn(ctx) }
+
+// PreloadMany returns a Preloader that builds the spec's relation for the
+// batch of spec.Model, exactly as Many would.
+func PreloadMany[JoinKey comparable, Model hasState, Relation any](spec RelationSpec[JoinKey, Model, Relation]) Preloader {
+	return preloadFunc{key: spec.CacheKey, fn: func(ctx context.Context) error {
+		_, err := Many(ctx, spec)
+		return err
+	}}
+}
+
+// PreloadResolve returns a Preloader that builds the spec's resolver for the
+// batch of spec.Model, exactly as Resolve would.
+func PreloadResolve[Model hasState, Result any](spec ResolveSpec[Model, Result]) Preloader {
+	return preloadFunc{key: spec.CacheKey, fn: func(ctx context.Context) error {
+		_, err := Resolve(ctx, spec)
+		return err
+	}}
+}
+
+// PreloadAll runs the preloaders concurrently and waits for all of them.
+// Keys that build successfully stay cached even when others fail, so callers
+// can render partially.  If any preloader fails the error is a
+// *PreloadError naming the failed keys; a panic in a preloader is recovered
+// and reported as its key's error, carrying its stack.
+func PreloadAll(ctx context.Context, preloaders ...Preloader) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(preloaders))
+	for i, p := range preloaders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = callRecovered(ctx, fmt.Sprintf("PreloadAll: preloader %d", i), p.preload)
+		}()
+	}
+	wg.Wait()
+	var failed map[string]error
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if failed == nil {
+			failed = make(map[string]error)
+		}
+		k := preloaders[i].cacheKey()
+		if prev, ok := failed[k]; ok {
+			err = errors.Join(prev, err) // preloaders sharing a key
+		}
+		failed[k] = err
+	}
+	if failed == nil {
+		return nil
+	}
+	return &PreloadError{failed: failed}
+}
+
+// PreloadError reports which cache keys failed in PreloadAll.  errors.Is and
+// errors.As reach the individual causes.
+type PreloadError struct {
+	failed map[string]error
+}
+
+// Failed returns the error for each failed cache key, joined in the order
+// of the preloaders when several share a key.
+func (e *PreloadError) Failed() map[string]error {
+	out := make(map[string]error, len(e.failed))
+	for k, err := range e.failed {
+		out[k] = err
+	}
+	return out
+}
+
+func (e *PreloadError) keys() []string {
+	keys := make([]string, 0, len(e.failed))
+	for k := range e.failed {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (e *PreloadError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: preload failed for %d key(s)", packagePrefix, len(e.failed))
+	for _, k := range e.keys() {
+		fmt.Fprintf(&b, "; %q: %v", k, e.failed[k])
+	}
+	return b.String()
+}
+
+// Unwrap returns the causes ordered by cache key.
+func (e *PreloadError) Unwrap() []error {
+	var errs []error
+	for _, k := range e.keys() {
+		errs = append(errs, e.failed[k])
+	}
+	return errs
+}
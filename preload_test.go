@@ -0,0 +1,149 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/willhf/lode/lodetest"
+)
+
+func TestPreloadAll_PartialFailure(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+
+	a1, a2 := &Author{ID: 1, Name: "Alice"}, &Author{ID: 2, Name: "Bob"}
+	eng.InitHandles([]*Author{a1, a2})
+
+	var greetBuilds atomic.Int32
+	booksFetch := lodetest.FetchFunc(lodetest.FetchFromSlice([]*Book{{ID: 1, AuthorID: 2, Title: "B"}}, func(b *Book) int { return b.AuthorID }))
+	books := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       a1,
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch:       booksFetch.Fetch,
+	}
+	errBoom := errors.New("boom")
+	broken := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "broken",
+		Model:       a1,
+		ModelKey:    books.ModelKey,
+		RelationKey: books.RelationKey,
+		Fetch:       lodetest.FetchError[*Book, int](errBoom),
+	}
+	greet := ResolveSpec[*Author, string]{
+		CacheKey: "greet",
+		Model:    a1,
+		Build: func(ctx context.Context, models []*Author) (ResolverFunc[*Author, string], error) {
+			greetBuilds.Add(1)
+			return func(a *Author) string { return "hi " + a.Name }, nil
+		},
+	}
+
+	err := PreloadAll(ctx, PreloadMany(books), PreloadMany(broken), PreloadResolve(greet))
+
+	var perr *PreloadError
+	if !errors.As(err, &perr) {
+		t.Fatalf("PreloadAll err = %v; want *PreloadError", err)
+	}
+	failed := perr.Failed()
+	if len(failed) != 1 || !errors.Is(failed["broken"], errBoom) {
+		t.Fatalf("Failed() = %v; want only \"broken\"", failed)
+	}
+	if !errors.Is(err, errBoom) {
+		t.Fatal("errors.Is should reach the cause")
+	}
+	if msg := err.Error(); !strings.Contains(msg, `"broken"`) || strings.Contains(msg, `"books"`) || strings.Contains(msg, `"greet"`) {
+		t.Fatalf("error message %q should name only the failed key", msg)
+	}
+
+	// The successful keys are warm for every sibling.
+	books.Model = a2
+	got, err := Many(ctx, books)
+	if err != nil || len(got) != 1 {
+		t.Fatalf("Many(a2) = %v, %v", got, err)
+	}
+	greet.Model = a2
+	if s, err := Resolve(ctx, greet); err != nil || s != "hi Bob" {
+		t.Fatalf("Resolve(a2) = %q, %v", s, err)
+	}
+	if booksFetch.Calls() != 1 || greetBuilds.Load() != 1 {
+		t.Fatalf("fetches=%d builds=%d; want 1 and 1", booksFetch.Calls(), greetBuilds.Load())
+	}
+}
+
+func TestPreloadAll_SharedKeyKeepsEveryError(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles(a1) // separate batches, so both preloaders build
+	eng.InitHandles(a2)
+
+	errFirst, errSecond := errors.New("first"), errors.New("second")
+	spec := func(a *Author, err error) RelationSpec[int, *Author, *Book] {
+		return RelationSpec[int, *Author, *Book]{
+			CacheKey:    "books",
+			Model:       a,
+			ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+			RelationKey: func(b *Book) int { return b.AuthorID },
+			Fetch:       lodetest.FetchError[*Book, int](err),
+		}
+	}
+	err := PreloadAll(ctx, PreloadMany(spec(a1, errFirst)), PreloadMany(spec(a2, errSecond)))
+	var perr *PreloadError
+	if !errors.As(err, &perr) {
+		t.Fatalf("PreloadAll err = %v; want *PreloadError", err)
+	}
+	failed := perr.Failed()
+	if len(failed) != 1 || !errors.Is(failed["books"], errFirst) || !errors.Is(failed["books"], errSecond) {
+		t.Fatalf("Failed() = %v; want both errors under \"books\"", failed)
+	}
+}
+
+func TestPreloadAll_Success(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine()
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+
+	err := PreloadAll(context.Background(), PreloadResolve(ResolveSpec[*Author, int]{
+		CacheKey: "one",
+		Model:    a,
+		Build: func(context.Context, []*Author) (ResolverFunc[*Author, int], error) {
+			return func(*Author) int { return 1 }, nil
+		},
+	}))
+	if err != nil {
+		t.Fatalf("PreloadAll err = %v; want nil", err)
+	}
+	if err := PreloadAll(context.Background()); err != nil {
+		t.Fatalf("PreloadAll() err = %v; want nil", err)
+	}
+}
+
+func TestPreloadAll_PanicIsKeyError(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine()
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+
+	err := PreloadAll(context.Background(), PreloadResolve(ResolveSpec[*Author, int]{
+		CacheKey: "panics",
+		Model:    a,
+		Build: func(context.Context, []*Author) (ResolverFunc[*Author, int], error) {
+			panic("kaboom")
+		},
+	}))
+	var perr *PreloadError
+	if !errors.As(err, &perr) {
+		t.Fatalf("PreloadAll err = %v; want *PreloadError", err)
+	}
+	if err := perr.Failed()["panics"]; !errors.Is(err, errPanicked) || !strings.Contains(err.Error(), "kaboom") {
+		t.Fatalf("Failed()[\"panics\"] = %v; want the recovered panic", err)
+	}
+}
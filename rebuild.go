@@ -0,0 +1,56 @@
+package lode
+
+import "fmt"
+
+// RebuildPolicy says what callers get while a cached value that was
+// invalidated is rebuilt.  The first build of a key always makes concurrent
+// callers wait: there is nothing else to give them.
+type RebuildPolicy int
+
+const (
+	// BlockDuringRebuild makes callers wait for the rebuild, as for a first
+	// build.  It is the default.
+	BlockDuringRebuild RebuildPolicy = iota
+	// ServeStaleDuringRebuild lets the first caller after an invalidation
+	// run the rebuild while its siblings get the previous value at once,
+	// flagged Info.Stale, until the new value is stored.  It applies to
+	// rebuilds after Reset, Invalidate, ResetPrefix, Engine.ResetAll,
+	// Engine.InvalidateKey, and version changes (see SetVersionSource).  A
+	// failed build has no value to serve, so its retry serves the last
+	// value that built successfully, if any.
+	ServeStaleDuringRebuild
+)
+
+func (p RebuildPolicy) String() string {
+	switch p {
+	case BlockDuringRebuild:
+		return "BlockDuringRebuild"
+	case ServeStaleDuringRebuild:
+		return "ServeStaleDuringRebuild"
+	}
+	return fmt.Sprintf("RebuildPolicy(%d)", int(p))
+}
+
+// WithRebuildPolicy sets what callers get during rebuilds of per-state
+// values.  Global values always rebuild under BlockDuringRebuild.
+func WithRebuildPolicy(p RebuildPolicy) ConfigOption {
+	return func(c *Config) { c.rebuildPolicy = p }
+}
+
+// successor returns the entry that replaces old on an invalidation: a fresh
+// one, carrying the value to serve while it builds under
+// ServeStaleDuringRebuild.
+func (e *Engine) successor(old *resolverEntry) *resolverEntry {
+	fresh := &resolverEntry{}
+	if e.config.rebuildPolicy != ServeStaleDuringRebuild {
+		return fresh
+	}
+	if h := old.ready.Load(); h != nil && h.err == nil {
+		stale := *h
+		stale.info.Stale = true
+		fresh.stale = &stale
+	} else {
+		fresh.stale = old.stale
+	}
+	return fresh
+}
@@ -0,0 +1,110 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestServeStaleDuringRebuild(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	NewEngine(WithRebuildPolicy(ServeStaleDuringRebuild)).InitHandles([]*Author{a1, a2})
+
+	var builds atomic.Int32
+	var fail atomic.Bool
+	var gate chan struct{} // closed to let a blocked build finish
+	started := make(chan struct{}, 1)
+	spec := ResolveSpec[*Author, int32]{
+		CacheKey: "build_no",
+		Build: func(context.Context, []*Author) (ResolverFunc[*Author, int32], error) {
+			n := builds.Add(1)
+			if gate != nil {
+				started <- struct{}{}
+				<-gate
+			}
+			if fail.Load() {
+				return nil, errors.New("boom")
+			}
+			return func(*Author) int32 { return n }, nil
+		},
+	}
+
+	// rebuildBlocked invalidates the key, starts a rebuild for a1 that waits
+	// on the gate, and checks that a2 meanwhile gets want, flagged stale.
+	rebuildBlocked := func(want int32) <-chan int32 {
+		t.Helper()
+		if err := a1.Invalidate("build_no"); err != nil {
+			t.Fatal(err)
+		}
+		gate = make(chan struct{})
+		done := make(chan int32)
+		go func() {
+			n, _ := Resolve(ctx, spec.For(a1))
+			done <- n
+		}()
+		<-started
+		n, info, err := ResolveInfo(ctx, spec.For(a2))
+		if err != nil || n != want || !info.Stale || !info.CacheHit {
+			t.Fatalf("sibling during rebuild = %d, %+v, %v; want stale %d", n, info, err, want)
+		}
+		return done
+	}
+
+	if n, info, _ := ResolveInfo(ctx, spec.For(a1)); n != 1 || info.Stale {
+		t.Fatalf("first Resolve = %d, %+v", n, info)
+	}
+
+	done := rebuildBlocked(1)
+	close(gate)
+	if n := <-done; n != 2 {
+		t.Fatalf("rebuild = %d; want 2", n)
+	}
+	gate = nil
+	if n, info, _ := ResolveInfo(ctx, spec.For(a2)); n != 2 || info.Stale {
+		t.Fatalf("after rebuild = %d, %+v; want fresh 2", n, info)
+	}
+
+	// A failed rebuild is cached as usual; retrying it serves the last good
+	// value.
+	fail.Store(true)
+	if err := a1.Invalidate("build_no"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Resolve(ctx, spec.For(a1)); err == nil {
+		t.Fatal("failing rebuild succeeded")
+	}
+	fail.Store(false)
+	done = rebuildBlocked(2)
+	close(gate)
+	if n := <-done; n != 4 {
+		t.Fatalf("retry = %d; want 4", n)
+	}
+}
+
+func TestRebuildPolicy_DefaultBlocks(t *testing.T) {
+	t.Parallel()
+	if p := NewEngine().Config().RebuildPolicy(); p != BlockDuringRebuild {
+		t.Fatalf("default policy = %v", p)
+	}
+	ctx := context.Background()
+	a1 := &Author{ID: 1}
+	NewEngine().InitHandles([]*Author{a1})
+	builds := 0
+	spec := ResolveSpec[*Author, int]{
+		CacheKey: "build_no",
+		Build: func(context.Context, []*Author) (ResolverFunc[*Author, int], error) {
+			builds++
+			return func(*Author) int { return builds }, nil
+		},
+	}
+	Resolve(ctx, spec.For(a1))
+	if err := a1.Invalidate("build_no"); err != nil {
+		t.Fatal(err)
+	}
+	if n, info, _ := ResolveInfo(ctx, spec.For(a1)); n != 2 || info.Stale || info.CacheHit {
+		t.Fatalf("after Invalidate = %d, %+v; want a fresh build", n, info)
+	}
+}
@@ -0,0 +1,84 @@
+package lode
+
+import "context"
+
+// callModeKey carries the callMode of a Resolve, Many, or One call.
+type callModeKey struct{}
+
+// callMode holds the per-call options set by WithForceRefresh and
+// WithNoStore.
+type callMode struct {
+	forceRefresh bool
+	noStore      bool
+}
+
+func withCallMode(ctx context.Context, set func(*callMode)) context.Context {
+	mode, _ := ctx.Value(callModeKey{}).(callMode)
+	set(&mode)
+	return context.WithValue(ctx, callModeKey{}, mode)
+}
+
+// WithForceRefresh returns a ctx that makes Resolve, Many, and One rebuild
+// the value they would have found cached, e.g. because the user asked for a
+// refresh, and store the result for every model of the batch.  Only the
+// value a call is made for is refreshed: builds run with the option
+// removed, so what they resolve in turn is served from the cache as usual.
+// Concurrent calls share one rebuild, as do siblings calling without the
+// option while it runs (they get the previous value instead under
+// ServeStaleDuringRebuild).  A value that is not yet built, or still
+// building, is not built twice.  On a frozen state the call fails with
+// ErrFrozen.
+func WithForceRefresh(ctx context.Context) context.Context {
+	return withCallMode(ctx, func(m *callMode) { m.forceRefresh = true })
+}
+
+// WithNoStore returns a ctx that makes Resolve, Many, and One leave the
+// cache as they find it: a cached value is used if there is one, and
+// otherwise the call builds one for itself, shared with no other call and
+// not stored.  Combined with WithForceRefresh the call always builds, still
+// without storing.  As with WithForceRefresh, the builds themselves resolve
+// through the cache.
+func WithNoStore(ctx context.Context) context.Context {
+	return withCallMode(ctx, func(m *callMode) { m.noStore = true })
+}
+
+// refresh replaces c's built entry with a fresh one for WithForceRefresh,
+// or adopts the entry another caller replaced it with.
+func (c *CacheEntry) refresh() error {
+	if c.entry.ready.Load() == nil {
+		return nil
+	}
+	if c.state != nil {
+		if err := c.state.checkFrozen(); err != nil {
+			return err
+		}
+	}
+	fresh := c.e.successor(c.entry)
+	if c.entries.CompareAndSwap(c.cacheKey, c.entry, fresh) {
+		c.state.release(c.entry)
+		c.entry = fresh
+		return nil
+	}
+	v, _ := c.entries.LoadOrStore(c.cacheKey, fresh)
+	c.entry = v.(*resolverEntry)
+	return nil
+}
+
+// buildUncached serves a WithNoStore call: the cached value unless
+// ignoreCache is set, or else a build of its own.
+func (c *CacheEntry) buildUncached(ctx context.Context, build func(ctx context.Context) (any, error), version uint64, versioned, ignoreCache bool) (*resolverHolder, bool) {
+	if h := c.entry.ready.Load(); h != nil && !ignoreCache && (!versioned || h.version == version) {
+		return h, false
+	}
+	if err := c.checkCycle(ctx); err != nil {
+		return &resolverHolder{err: err}, false
+	}
+	h := c.run(ctx, build, version)
+	if c.e.mem != nil && c.state != nil {
+		// The charge is released at once: the value is never cached.
+		unstored := &resolverEntry{}
+		unstored.ready.Store(h)
+		c.state.release(unstored)
+	}
+	return h, true
+}
@@ -0,0 +1,124 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithForceRefresh(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	NewEngine().InitHandles([]*Author{a1, a2})
+
+	var builds, nested atomic.Int32
+	var gate chan struct{} // if set, builds wait for it to close
+	started := make(chan struct{}, 1)
+	inner := ResolveSpec[*Author, int32]{
+		CacheKey: "inner",
+		Build: func(context.Context, []*Author) (ResolverFunc[*Author, int32], error) {
+			n := nested.Add(1)
+			return func(*Author) int32 { return n }, nil
+		},
+	}
+	spec := ResolveSpec[*Author, int32]{
+		CacheKey: "build_no",
+		Build: func(ctx context.Context, _ []*Author) (ResolverFunc[*Author, int32], error) {
+			if _, err := Resolve(ctx, inner.For(a1)); err != nil {
+				return nil, err
+			}
+			n := builds.Add(1)
+			if gate != nil {
+				started <- struct{}{}
+				<-gate
+			}
+			return func(*Author) int32 { return n }, nil
+		},
+	}
+	get := func(ctx context.Context, a *Author, want int32) {
+		t.Helper()
+		if n, err := Resolve(ctx, spec.For(a)); err != nil || n != want {
+			t.Fatalf("Resolve(%d) = %d, %v; want %d", a.ID, n, err, want)
+		}
+	}
+
+	get(ctx, a1, 1)
+	get(ctx, a2, 1)
+	// Refreshes arriving while one runs share it.
+	refresh := WithForceRefresh(ctx)
+	gate = make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			get(refresh, a1, 2)
+		}()
+		if i == 0 {
+			<-started
+		}
+	}
+	time.Sleep(50 * time.Millisecond) // let the others reach the build
+	close(gate)
+	wg.Wait()
+	gate = nil
+	if builds.Load() != 2 {
+		t.Fatalf("builds = %d; want concurrent refreshes to share one", builds.Load())
+	}
+	get(ctx, a2, 2)
+	get(ctx, a1, 2)
+	if nested.Load() != 1 {
+		t.Fatalf("nested builds = %d; want the refresh to reuse the cached inner value", nested.Load())
+	}
+
+	a1.Freeze()
+	if _, err := Resolve(refresh, spec.For(a1)); !errors.Is(err, ErrFrozen) {
+		t.Fatalf("refresh of a frozen state err = %v; want ErrFrozen", err)
+	}
+	get(ctx, a1, 2)
+}
+
+func TestWithNoStore(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	NewEngine().InitHandles([]*Author{a1, a2})
+
+	fetches := 0
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(_ context.Context, ids []int) ([]*Book, error) {
+			fetches++
+			return []*Book{{ID: fetches, AuthorID: 1}}, nil
+		},
+	}
+	noStore := WithNoStore(ctx)
+
+	// Unbuilt: each call builds for itself and stores nothing.
+	for want := 1; want <= 2; want++ {
+		if books, err := Many(noStore, spec.For(a1)); err != nil || books[0].ID != want {
+			t.Fatalf("no-store Many = %v, %v; want book %d", titles(books), err, want)
+		}
+	}
+	if books, _ := Many(ctx, spec.For(a1)); books[0].ID != 3 {
+		t.Fatalf("cached Many = %v; want a fresh build", titles(books))
+	}
+
+	// Built: no-store uses the cache; with force refresh it builds without
+	// replacing it.
+	if books, _ := Many(noStore, spec.For(a1)); books[0].ID != 3 || fetches != 3 {
+		t.Fatalf("no-store Many over a warm cache = %v after %d fetches", titles(books), fetches)
+	}
+	if books, _ := Many(WithForceRefresh(noStore), spec.For(a1)); books[0].ID != 4 {
+		t.Fatalf("no-store refresh = %v; want book 4", titles(books))
+	}
+	if books, _ := Many(ctx, spec.For(a1)); books[0].ID != 3 {
+		t.Fatalf("cached Many after no-store refresh = %v; want book 3 still", titles(books))
+	}
+}
@@ -0,0 +1,49 @@
+package lode
+
+import (
+	"sync"
+	"weak"
+)
+
+// stateRegistry tracks the states an engine has bound without keeping them
+// alive: a state is dropped once its models become unreachable.
+type stateRegistry struct {
+	mu     sync.Mutex
+	states []weak.Pointer[loaderState]
+	limit  int // prune when len(states) reaches limit
+}
+
+func (r *stateRegistry) add(s *loaderState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.states) >= r.limit {
+		r.prune()
+		r.limit = max(2*len(r.states), 64)
+	}
+	r.states = append(r.states, weak.Make(s))
+}
+
+// live returns the states that are still reachable.
+func (r *stateRegistry) live() []*loaderState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune()
+	out := make([]*loaderState, 0, len(r.states))
+	for _, wp := range r.states {
+		if s := wp.Value(); s != nil {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func (r *stateRegistry) prune() {
+	kept := r.states[:0]
+	for _, wp := range r.states {
+		if wp.Value() != nil {
+			kept = append(kept, wp)
+		}
+	}
+	clear(r.states[len(kept):])
+	r.states = kept
+}
@@ -0,0 +1,146 @@
+package lode
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/willhf/lode/lodetest"
+)
+
+func countingGreeting(builds *int) ResolveSpec[*Author, string] {
+	return ResolveSpec[*Author, string]{
+		CacheKey: "greet",
+		Build: func(ctx context.Context, models []*Author) (ResolverFunc[*Author, string], error) {
+			*builds++
+			return func(a *Author) string { return "hi " + a.Name }, nil
+		},
+	}
+}
+
+func TestScope_IsolatedStatesSharedConfig(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	var fetchKeys []string
+	parent := NewEngine(WithKeyNamespace("req"), WithHooks(Hooks{OnFetch: func(ev FetchEvent) {
+		fetchKeys = append(fetchKeys, ev.CacheKey)
+	}}))
+	child := parent.Scope()
+
+	pa, ca := &Author{ID: 1, Name: "parent"}, &Author{ID: 2, Name: "child"}
+	parent.InitHandles(pa)
+	child.InitHandles(ca)
+
+	var builds int
+	spec := countingGreeting(&builds)
+	resolve := func(a *Author) {
+		t.Helper()
+		spec.Model = a
+		if _, err := Resolve(ctx, spec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resolve(pa)
+	resolve(ca)
+	if builds != 2 {
+		t.Fatalf("builds = %d; want 2", builds)
+	}
+
+	child.ResetAll()
+	resolve(pa) // still cached on the parent
+	if builds != 2 {
+		t.Fatalf("child ResetAll touched the parent: builds = %d", builds)
+	}
+	resolve(ca)
+	if builds != 3 {
+		t.Fatalf("child state not reset: builds = %d", builds)
+	}
+
+	parent.ResetAll()
+	resolve(ca) // parent ResetAll must not touch the child either
+	if builds != 3 {
+		t.Fatalf("parent ResetAll touched the child: builds = %d", builds)
+	}
+	resolve(pa)
+	if builds != 4 {
+		t.Fatalf("parent state not reset: builds = %d", builds)
+	}
+
+	// Hooks and the key namespace come from the shared configuration.
+	_, err := Many(ctx, RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       ca,
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch:       func(context.Context, []int) ([]*Book, error) { return nil, nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalStrings(fetchKeys, []string{"req:books"}) {
+		t.Fatalf("parent hook saw %v; want [req:books] from the child", fetchKeys)
+	}
+}
+
+func TestStateRegistry_DropsUnreachableStates(t *testing.T) {
+	eng := NewEngine()
+	keep := &Author{ID: 1}
+	eng.InitHandles(keep)
+	for i := 0; i < 100; i++ {
+		eng.InitHandles(&Author{ID: i})
+	}
+	runtime.GC()
+
+	live := eng.states.live()
+	if len(live) != 1 || live[0] != keep.core {
+		t.Fatalf("live states = %d; want only the reachable one", len(live))
+	}
+	runtime.KeepAlive(keep)
+}
+
+func TestLiveStates(t *testing.T) {
+	clock := lodetest.NewFakeClock(time.Unix(1000, 0))
+	eng := NewEngine(WithLeakTracking(), WithClock(clock))
+
+	old := []*Author{{ID: 1}, {ID: 2}}
+	eng.InitHandles(old)
+	if _, err := Resolve(context.Background(), ResolveSpec[*Author, int]{
+		CacheKey: "n",
+		Model:    old[0],
+		Build: func(context.Context, []*Author) (ResolverFunc[*Author, int], error) {
+			return func(*Author) int { return 1 }, nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	released := []*Author{{ID: 3}}
+	eng.InitHandles(released)
+	young := &Author{ID: 4}
+	eng.InitHandles(young)
+
+	got := eng.LiveStates(time.Minute)
+	want := []LiveState{{ModelType: "*lode.Author", Models: 2, CacheKeys: 1, BindID: old[0].core.bindID, Age: time.Minute}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("LiveStates(1m) = %+v; want %+v", got, want)
+	}
+	if n := len(eng.LiveStates(0)); n != 3 {
+		t.Fatalf("LiveStates(0) = %d states; want 3", n)
+	}
+
+	released = nil
+	runtime.GC()
+	if n := len(eng.LiveStates(0)); n != 2 {
+		t.Fatalf("LiveStates(0) after release = %d states; want 2", n)
+	}
+	runtime.KeepAlive(old)
+	runtime.KeepAlive(young)
+
+	if got := NewEngine().LiveStates(0); got != nil {
+		t.Fatalf("LiveStates without tracking = %+v; want nil", got)
+	}
+}
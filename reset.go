@@ -0,0 +1,106 @@
+package lode
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// checkFrozen returns the error a reset of a frozen state reports.  A Freeze
+// racing with a reset may or may not stop it.
+func (s *loaderState) checkFrozen() error {
+	if s.frozen.Load() {
+		return fmt.Errorf("%s: %w: %d %s models", packagePrefix, ErrFrozen, reflect.ValueOf(s.models).Len(), s.modelType())
+	}
+	return nil
+}
+
+// reset clears every entry and reports it through OnReset.
+func (s *loaderState) reset() error {
+	if err := s.checkFrozen(); err != nil {
+		return err
+	}
+	cleared := s.deleteEntries(func(string) bool { return true })
+	gen := s.generation.Add(1)
+	s.engine.onReset(ResetEvent{ModelType: s.modelType(), KeysCleared: cleared, Generation: gen})
+	return nil
+}
+
+// invalidate clears the entries for cacheKeys, which are given as the caller
+// wrote them, reports each through OnInvalidate, and returns how many entries
+// it cleared.
+func (s *loaderState) invalidate(cacheKeys []string) (int, error) {
+	if err := s.checkFrozen(); err != nil {
+		return 0, err
+	}
+	if len(cacheKeys) == 0 {
+		return 0, nil
+	}
+	gen := s.generation.Add(1)
+	cleared := 0
+	for _, k := range cacheKeys {
+		key, single := s.engine.key(k), s.engine.key(k+SinglePerKeySuffix)
+		cleared += s.deleteEntries(func(entry string) bool { return entry == key || entry == single })
+		s.engine.onInvalidate(InvalidateEvent{CacheKey: key, ModelType: s.modelType(), Generation: gen})
+	}
+	return cleared, nil
+}
+
+// resetPrefix clears the entries whose cache key starts with prefix and
+// reports each cleared key through OnInvalidate.
+func (s *loaderState) resetPrefix(prefix string) error {
+	if err := s.checkFrozen(); err != nil {
+		return err
+	}
+	var keys []string
+	s.deleteEntries(func(entry string) bool {
+		if s.engine.hasKeyPrefix(entry, prefix) {
+			keys = append(keys, entry)
+			return true
+		}
+		return false
+	})
+	if len(keys) == 0 {
+		return nil
+	}
+	gen := s.generation.Add(1)
+	for _, k := range keys {
+		s.engine.onInvalidate(InvalidateEvent{CacheKey: k, ModelType: s.modelType(), Generation: gen})
+	}
+	return nil
+}
+
+// deleteEntries removes the resolver entries whose key matches and returns
+// how many it removed.  Removing, rather than clearing, an entry is what makes
+// resets safe against in-flight builds: the build finishes into an entry that
+// is no longer reachable from the map, and the next Resolve stores a new one.
+// Entries are never reused, so no generation check is needed on the way out.
+// Under ServeStaleDuringRebuild an entry with a value to serve is replaced
+// by a fresh one carrying that value instead.
+//
+// The Range is not a snapshot, so an entry stored concurrently with the reset
+// may or may not be removed; either way it was built after the reset began.
+func (s *loaderState) deleteEntries(match func(cacheKey string) bool) int {
+	s.sharedFetches.Clear() // see WithFetchDedup
+	n := 0
+	s.resolverEntries.Range(func(k, v any) bool {
+		if !match(k.(string)) {
+			return true
+		}
+		if fresh := s.engine.successor(v.(*resolverEntry)); fresh.stale != nil {
+			if s.resolverEntries.CompareAndSwap(k, v, fresh) {
+				s.release(v.(*resolverEntry))
+				n++
+			}
+		} else if old, ok := s.resolverEntries.LoadAndDelete(k); ok {
+			s.release(old.(*resolverEntry))
+			n++
+		}
+		return true
+	})
+	return n
+}
+
+// modelType names the bound element type, e.g. "*app.Author".
+func (s *loaderState) modelType() string {
+	return reflect.TypeOf(s.models).Elem().String()
+}
@@ -0,0 +1,372 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// warm resolves each key on a so its state holds one entry per key.
+func warm(t *testing.T, a *Author, keys ...string) {
+	t.Helper()
+	for _, k := range keys {
+		_, err := Resolve(context.Background(), ResolveSpec[*Author, int]{
+			CacheKey: k,
+			Model:    a,
+			Build: func(context.Context, []*Author) (ResolverFunc[*Author, int], error) {
+				return func(*Author) int { return 1 }, nil
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func cached(a *Author, key string) bool {
+	_, ok := a.core.resolverEntries.Load(key)
+	return ok
+}
+
+type resetRecorder struct {
+	resets      []ResetEvent
+	invalidates []InvalidateEvent
+}
+
+func (r *resetRecorder) hooks() ConfigOption {
+	return WithHooks(Hooks{
+		OnReset:      func(ev ResetEvent) { r.resets = append(r.resets, ev) },
+		OnInvalidate: func(ev InvalidateEvent) { r.invalidates = append(r.invalidates, ev) },
+	})
+}
+
+func TestResetHooks_Reset(t *testing.T) {
+	t.Parallel()
+	var rec resetRecorder
+	eng := NewEngine(rec.hooks())
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	res, _ := eng.Bind([]*Author{a1, a2})
+
+	warm(t, a1, "books", "awards")
+	a2.Reset()
+	warm(t, a1, "books")
+	res.Batches[0].State.Reset()
+
+	want := []ResetEvent{
+		{ModelType: "*lode.Author", KeysCleared: 2, Generation: 1},
+		{ModelType: "*lode.Author", KeysCleared: 1, Generation: 2},
+	}
+	if !reflect.DeepEqual(rec.resets, want) {
+		t.Fatalf("resets = %+v; want %+v", rec.resets, want)
+	}
+	if g := res.Batches[0].State.Generation(); g != 2 {
+		t.Fatalf("Generation() = %d; want 2", g)
+	}
+}
+
+func TestResetHooks_EngineResetAll(t *testing.T) {
+	t.Parallel()
+	var rec resetRecorder
+	eng := NewEngine(rec.hooks())
+	a, b := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles(a)
+	eng.InitHandles(b)
+	warm(t, a, "books")
+	warm(t, b, "books", "awards")
+
+	eng.ResetAll()
+	if len(rec.resets) != 2 || rec.resets[0].KeysCleared+rec.resets[1].KeysCleared != 3 {
+		t.Fatalf("resets = %+v; want one per state, 3 keys in all", rec.resets)
+	}
+	if cached(a, "books") || cached(b, "awards") {
+		t.Fatal("entries survived ResetAll")
+	}
+}
+
+func TestResetHooks_Invalidate(t *testing.T) {
+	t.Parallel()
+	var rec resetRecorder
+	eng := NewEngine(WithKeyNamespace("ns"), rec.hooks())
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles([]*Author{a1, a2})
+	warm(t, a1, "books", "books"+SinglePerKeySuffix, "awards")
+
+	a2.Invalidate("books")
+	if cached(a1, "ns:books") || cached(a1, "ns:books"+SinglePerKeySuffix) || !cached(a1, "ns:awards") {
+		t.Fatal("Invalidate cleared the wrong entries")
+	}
+	want := []InvalidateEvent{{CacheKey: "ns:books", ModelType: "*lode.Author", Generation: 1}}
+	if !reflect.DeepEqual(rec.invalidates, want) {
+		t.Fatalf("invalidates = %+v; want %+v", rec.invalidates, want)
+	}
+	if len(rec.resets) != 0 {
+		t.Fatalf("Invalidate fired OnReset: %+v", rec.resets)
+	}
+
+	(&Author{}).Invalidate("books") // unbound: no-op
+}
+
+func TestInvalidate_RebuildsOnceForBatch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	NewEngine().InitHandles([]*Author{a1, a2})
+	fetches := map[int]int{}
+	books := threeBooks(fetches)
+	aggregates := 0
+	chapters := ResolveSpec[*Author, int]{
+		CacheKey: "num_chapters",
+		Build: func(context.Context, []*Author) (ResolverFunc[*Author, int], error) {
+			aggregates++
+			return func(*Author) int { return 42 }, nil
+		},
+	}
+	walk := func() {
+		t.Helper()
+		for _, a := range []*Author{a1, a2} {
+			if _, err := Many(ctx, books.For(a)); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Resolve(ctx, chapters.For(a)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	walk()
+	if err := a1.Invalidate("books"); err != nil {
+		t.Fatal(err)
+	}
+	walk()
+	if fetches[1] != 2 || fetches[2] != 2 {
+		t.Fatalf("fetches = %v; want each author fetched once before and once after Invalidate", fetches)
+	}
+	if aggregates != 1 {
+		t.Fatalf("num_chapters built %d times; want 1", aggregates)
+	}
+}
+
+func TestEngine_InvalidateKey(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine()
+	a, b, frozen := &Author{ID: 1}, &Author{ID: 2}, &Author{ID: 3}
+	eng.InitHandles(a)
+	eng.InitHandles(b)
+	eng.InitHandles(frozen)
+	scoped := &Author{ID: 4}
+	eng.Scope().InitHandles(scoped)
+	for _, m := range []*Author{a, b, frozen, scoped} {
+		warm(t, m, "books", "awards")
+	}
+	frozen.Freeze()
+
+	n, err := eng.InvalidateKey("books")
+	if n != 2 || !errors.Is(err, ErrFrozen) {
+		t.Fatalf("InvalidateKey = %d, %v; want 2 and ErrFrozen", n, err)
+	}
+	if cached(a, "books") || cached(b, "books") || !cached(a, "awards") || !cached(b, "awards") {
+		t.Fatal("InvalidateKey cleared the wrong entries")
+	}
+	if !cached(frozen, "books") || !cached(scoped, "books") {
+		t.Fatal("InvalidateKey cleared a frozen or scoped state")
+	}
+
+	frozen.Unfreeze()
+	if n, err := eng.InvalidateKey("books"); n != 1 || err != nil {
+		t.Fatalf("InvalidateKey = %d, %v; want 1, nil", n, err)
+	}
+}
+
+func TestResetHooks_ResetPrefix(t *testing.T) {
+	t.Parallel()
+	var rec resetRecorder
+	eng := NewEngine(rec.hooks())
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+	warm(t, a, "stats:books", "stats:awards", "books")
+
+	a.ResetPrefix("stats:")
+	if cached(a, "stats:books") || cached(a, "stats:awards") || !cached(a, "books") {
+		t.Fatal("ResetPrefix cleared the wrong entries")
+	}
+	if len(rec.invalidates) != 2 {
+		t.Fatalf("invalidates = %+v; want one per cleared key", rec.invalidates)
+	}
+	for _, ev := range rec.invalidates {
+		if ev.Generation != 1 || ev.ModelType != "*lode.Author" {
+			t.Fatalf("event = %+v", ev)
+		}
+	}
+
+	a.ResetPrefix("nothing")
+	if len(rec.invalidates) != 2 {
+		t.Fatalf("ResetPrefix with no matches fired hooks: %+v", rec.invalidates)
+	}
+}
+
+// TestReset_RacesWithBuilds hammers every reset path against concurrent
+// resolves.  Writers bump the source version and then reset; a resolve that
+// starts after the reset returned must see at least that version, even when
+// a build that read an older version was still in flight during the reset.
+func TestReset_RacesWithBuilds(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+
+	authors := make([]*Author, 8)
+	for i := range authors {
+		authors[i] = &Author{ID: i}
+	}
+	eng.InitHandles(authors)
+
+	var version atomic.Int64
+	spec := func(a *Author) ResolveSpec[*Author, int64] {
+		return ResolveSpec[*Author, int64]{
+			CacheKey: "version",
+			Model:    a,
+			Build: func(context.Context, []*Author) (ResolverFunc[*Author, int64], error) {
+				v := version.Load()
+				runtime.Gosched() // widen the window for a reset mid-build
+				return func(*Author) int64 { return v }, nil
+			},
+		}
+	}
+	resets := []func(a *Author){
+		func(a *Author) { a.Reset() },
+		func(a *Author) { a.Invalidate("version") },
+		func(a *Author) { a.ResetPrefix("ver") },
+		func(*Author) { eng.ResetAll() },
+	}
+
+	var readers, writers sync.WaitGroup
+	stop := make(chan struct{})
+	for i := range 8 {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			a := authors[i]
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if _, err := Resolve(ctx, spec(a)); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	for i := range 4 {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			for n := range 200 {
+				a := authors[(i+n)%len(authors)]
+				want := version.Add(1)
+				resets[n%len(resets)](a)
+				got, err := Resolve(ctx, spec(a))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if got < want {
+					t.Errorf("resolve after reset saw version %d; want >= %d", got, want)
+					return
+				}
+			}
+		}()
+	}
+	writers.Wait()
+	close(stop)
+	readers.Wait()
+}
+
+func TestFreeze(t *testing.T) {
+	t.Parallel()
+	var rec resetRecorder
+	eng := NewEngine(rec.hooks())
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	res, _ := eng.Bind([]*Author{a1, a2})
+	warm(t, a1, "books", "awards")
+
+	a1.Freeze()
+	if !res.Batches[0].State.Frozen() {
+		t.Fatal("Frozen() = false after Freeze")
+	}
+	for name, reset := range map[string]func() error{
+		"Reset":            a2.Reset,
+		"Invalidate":       func() error { return a2.Invalidate("books") },
+		"ResetPrefix":      func() error { return a2.ResetPrefix("b") },
+		"State.Reset":      res.Batches[0].State.Reset,
+		"BindResult.Reset": res.ResetAll,
+		"Engine.ResetAll":  eng.ResetAll,
+	} {
+		if err := reset(); !errors.Is(err, ErrFrozen) {
+			t.Fatalf("%s on frozen state: err = %v; want ErrFrozen", name, err)
+		}
+	}
+	if !cached(a1, "books") || !cached(a1, "awards") || len(rec.resets)+len(rec.invalidates) != 0 {
+		t.Fatal("frozen state was mutated")
+	}
+
+	// Resolves, first builds included, still work.
+	warm(t, a2, "fresh")
+	if !cached(a1, "fresh") {
+		t.Fatal("build on frozen state not cached")
+	}
+
+	a2.Unfreeze()
+	if err := a1.Invalidate("books"); err != nil || cached(a1, "books") {
+		t.Fatalf("Invalidate after Unfreeze: %v", err)
+	}
+	if err := eng.ResetAll(); err != nil || cached(a1, "awards") {
+		t.Fatalf("ResetAll after Unfreeze: %v", err)
+	}
+}
+
+func TestFreeze_ConcurrentResolves(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+	authors := make([]*Author, 16)
+	for i := range authors {
+		authors[i] = &Author{ID: i}
+	}
+	eng.InitHandles(authors)
+	authors[0].Freeze()
+
+	var builds atomic.Int32
+	var wg sync.WaitGroup
+	for _, a := range authors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, key := range []string{"a", "b", "c"} {
+				_, err := Resolve(ctx, ResolveSpec[*Author, int]{
+					CacheKey: key,
+					Model:    a,
+					Build: func(context.Context, []*Author) (ResolverFunc[*Author, int], error) {
+						builds.Add(1)
+						return func(a *Author) int { return a.ID }, nil
+					},
+				})
+				if err != nil {
+					t.Error(err)
+				}
+				if err := a.Reset(); !errors.Is(err, ErrFrozen) {
+					t.Errorf("Reset: err = %v; want ErrFrozen", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n := builds.Load(); n != 3 {
+		t.Fatalf("builds = %d; want one per key", n)
+	}
+}
@@ -0,0 +1,46 @@
+package lode
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"slices"
+)
+
+// Sample returns up to n of spec.Model's relations, as Many would load them
+// (from the cache if warm), picked pseudo-randomly but stably: the choice is
+// seeded by the cache key and the model's key, so repeated calls, across
+// processes too, show the same relations as long as the relation itself is
+// unchanged.  The sample keeps the relations' order and is the caller's to
+// modify.  It is a debugging aid for groups too large to print.
+func Sample[JoinKey comparable, Model hasState, Relation any](ctx context.Context, spec RelationSpec[JoinKey, Model, Relation], n int) ([]Relation, error) {
+	relations, err := Many(ctx, spec)
+	if err != nil || n <= 0 || len(relations) == 0 {
+		return nil, err
+	}
+	if n >= len(relations) {
+		return slices.Clone(relations), nil
+	}
+	key, _ := spec.ModelKey(spec.Model)
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s\x00%v", spec.CacheKey, key)
+	r := rand.New(rand.NewPCG(h.Sum64(), uint64(len(relations))))
+
+	// A partial Fisher-Yates shuffle of the indexes picks n of them.
+	idx := make([]int, len(relations))
+	for i := range idx {
+		idx[i] = i
+	}
+	for i := range n {
+		j := i + r.IntN(len(idx)-i)
+		idx[i], idx[j] = idx[j], idx[i]
+	}
+	picked := idx[:n]
+	slices.Sort(picked)
+	out := make([]Relation, n)
+	for i, k := range picked {
+		out[i] = relations[k]
+	}
+	return out, nil
+}
@@ -0,0 +1,68 @@
+package lode
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestSample(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	NewEngine().InitHandles([]*Author{a1, a2})
+
+	fetches := 0
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(context.Context, []int) ([]*Book, error) {
+			fetches++
+			var books []*Book
+			for i := range 100 {
+				books = append(books, &Book{ID: i, AuthorID: 1}, &Book{ID: 100 + i, AuthorID: 2})
+			}
+			return books, nil
+		},
+	}
+	ids := func(bs []*Book) []int {
+		out := make([]int, len(bs))
+		for i, b := range bs {
+			out[i] = b.ID
+		}
+		return out
+	}
+
+	first, err := Sample(ctx, spec.For(a1), 5)
+	if err != nil || len(first) != 5 {
+		t.Fatalf("Sample = %v, %v; want 5 books", first, err)
+	}
+	got := ids(first)
+	if !slices.IsSorted(got) || got[0] < 0 || got[4] >= 100 {
+		t.Fatalf("sample %v is not an ordered subset of author 1's books", got)
+	}
+	again, _ := Sample(ctx, spec.For(a1), 5)
+	if !slices.Equal(ids(again), got) {
+		t.Fatalf("second sample %v differs from %v", ids(again), got)
+	}
+	other, _ := Sample(ctx, spec.For(a2), 5)
+	if len(other) != 5 || ids(other)[0] < 100 {
+		t.Fatalf("author 2's sample %v", ids(other))
+	}
+	if fetches != 1 {
+		t.Fatalf("fetched %d times; want 1", fetches)
+	}
+
+	for _, tc := range []struct{ n, want int }{{0, 0}, {-1, 0}, {100, 100}, {500, 100}} {
+		s, err := Sample(ctx, spec.For(a1), tc.n)
+		if err != nil || len(s) != tc.want {
+			t.Fatalf("Sample(%d) has %d books, err %v; want %d", tc.n, len(s), err, tc.want)
+		}
+	}
+	all, _ := Sample(ctx, spec.For(a1), 100)
+	all[0] = nil // the sample is the caller's
+	if books, _ := Many(ctx, spec.For(a1)); books[0] == nil {
+		t.Fatal("Sample returned the cached slice")
+	}
+}
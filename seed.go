@@ -0,0 +1,67 @@
+package lode
+
+import "fmt"
+
+var errAlreadyBuilt = &kindError{"key already built", ErrMisconfigured}
+
+// Seed installs data as the result of spec on the state spec.Model is bound
+// to, so later Many and One calls with spec's CacheKey are cache hits that
+// never call Fetch.  It is for relations already in memory, e.g. a parent
+// list loaded with its children, and for stubbing relations in tests.
+//
+// Models are looked up in data by spec.ModelKey; Fetch and RelationKey are
+// not used.  The relations are used as given: nil elements are kept and
+// nothing is bound.  For a SinglePerKey spec the data serves that spec's
+// cache key, which One reads.
+//
+// Seed fails if the key has been built (or is being built) on the state,
+// unless overwrite is set, in which case the entry is replaced as by an
+// Invalidate and a frozen state reports ErrFrozen.
+func Seed[JoinKey comparable, Model hasState, Relation any](spec RelationSpec[JoinKey, Model, Relation], data map[JoinKey][]Relation, overwrite bool) error {
+	if isNil(spec.Model) || spec.Model.lodeState() == nil {
+		return notInitialized(spec.Model)
+	}
+	s := spec.Model.lodeState()
+	cacheKey := spec.CacheKey
+	if spec.SinglePerKey {
+		cacheKey += SinglePerKeySuffix
+	}
+	cacheKey = s.engine.key(cacheKey)
+
+	holder := &resolverHolder{resolver: ResolverFunc[Model, []Relation](func(m Model) []Relation {
+		if id, ok := spec.ModelKey(m); ok {
+			return data[id]
+		}
+		return nil
+	})}
+	holder.version, _ = s.engine.version(cacheKey)
+	if overwrite {
+		if err := s.checkFrozen(); err != nil {
+			return err
+		}
+		if old, ok := s.resolverEntries.Swap(cacheKey, builtEntry(holder)); ok {
+			s.release(old.(*resolverEntry))
+		}
+		return nil
+	}
+	// The entry's once tells a fresh entry from one that is built or being
+	// built, which Do waits for and then skips.
+	entry := s.engine.entry(&s.resolverEntries, cacheKey).entry
+	seeded := false
+	entry.once.Do(func() {
+		seeded = true
+		entry.ready.Store(holder)
+	})
+	if !seeded {
+		return fmt.Errorf("%s: key %q: %w", packagePrefix, cacheKey, errAlreadyBuilt)
+	}
+	return nil
+}
+
+// builtEntry returns an entry that already holds h.
+func builtEntry(h *resolverHolder) *resolverEntry {
+	entry := &resolverEntry{}
+	entry.once.Do(func() {})
+	entry.ready.Store(h)
+	return entry
+}
@@ -0,0 +1,100 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSeed(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine(WithKeyNamespace("ns"))
+	a1, a2 := &Author{ID: 1}, &Author{ID: 2}
+	eng.InitHandles([]*Author{a1, a2})
+
+	fetches := 0
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(context.Context, []int) ([]*Book, error) {
+			fetches++
+			return nil, nil
+		},
+	}
+	data := map[int][]*Book{
+		1: {{ID: 10, AuthorID: 1, Title: "A"}, {ID: 11, AuthorID: 1, Title: "B"}},
+	}
+	if err := Seed(spec.For(a1), data, false); err != nil {
+		t.Fatal(err)
+	}
+
+	got1, info, err := ManyInfo(ctx, spec.For(a1))
+	if err != nil || !info.CacheHit {
+		t.Fatalf("Many(a1): info = %+v, err = %v; want a cache hit", info, err)
+	}
+	if want := []string{"A", "B"}; !equalStrings(titles(got1), want) {
+		t.Fatalf("Many(a1) = %v; want %v", titles(got1), want)
+	}
+	if got2, err := Many(ctx, spec.For(a2)); err != nil || got2 != nil {
+		t.Fatalf("Many(a2) = %v, %v; want nil", got2, err)
+	}
+	if fetches != 0 {
+		t.Fatalf("Fetch called %d times; want 0", fetches)
+	}
+
+	// Seeding a built key fails unless told to overwrite.
+	if err := Seed(spec.For(a2), data, false); !errors.Is(err, errAlreadyBuilt) {
+		t.Fatalf("second Seed: err = %v; want errAlreadyBuilt", err)
+	}
+	if err := Seed(spec.For(a2), map[int][]*Book{2: {{ID: 12, AuthorID: 2, Title: "C"}}}, true); err != nil {
+		t.Fatal(err)
+	}
+	if got2, _ := Many(ctx, spec.For(a2)); !equalStrings(titles(got2), []string{"C"}) {
+		t.Fatalf("Many(a2) after overwrite = %v; want [C]", titles(got2))
+	}
+
+	a1.Freeze()
+	if err := Seed(spec.For(a1), data, true); !errors.Is(err, ErrFrozen) {
+		t.Fatalf("overwrite of frozen state: err = %v; want ErrFrozen", err)
+	}
+	if fetches != 0 {
+		t.Fatalf("Fetch called %d times; want 0", fetches)
+	}
+}
+
+func TestSeed_SinglePerKeyServesOne(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "firstBook",
+		Model:       a,
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(context.Context, []int) ([]*Book, error) {
+			t.Fatal("Fetch called for a seeded key")
+			return nil, nil
+		},
+		SinglePerKey: true,
+	}
+	if err := Seed(spec, map[int][]*Book{1: {{ID: 10, AuthorID: 1, Title: "A"}}}, false); err != nil {
+		t.Fatal(err)
+	}
+	got, err := One(ctx, spec)
+	if err != nil || got == nil || got.Title != "A" {
+		t.Fatalf("One = %v, %v; want A", got, err)
+	}
+}
+
+func TestSeed_Unbound(t *testing.T) {
+	t.Parallel()
+	spec := RelationSpec[int, *Author, *Book]{CacheKey: "books", Model: &Author{ID: 1}}
+	if err := Seed(spec, nil, false); !errors.Is(err, ErrNotInitialized) {
+		t.Fatalf("err = %v; want ErrNotInitialized", err)
+	}
+}
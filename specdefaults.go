@@ -0,0 +1,121 @@
+package lode
+
+// SpecDefaults holds engine-wide defaults for RelationSpec fields that tune
+// behavior rather than describe a relation.  A default applies to specs that
+// leave the field at its zero value; a value set on the spec always wins.
+// A default left at its zero value is the package's own.  Since a false
+// CompactGroups is its zero value, a spec cannot turn off an engine default
+// of true; the *bool fields can be turned either way.
+type SpecDefaults struct {
+	// MaxPages is the default RelationSpec.MaxPages.  Zero leaves it at
+	// DefaultMaxPages.
+	MaxPages int
+	// MaxKeysPerFetch is the default RelationSpec.MaxKeysPerFetch, as set
+	// by WithFetchChunkSize.  Zero fetches all keys at once.
+	MaxKeysPerFetch int
+	// NilModel is the default RelationSpec.NilModel, as set by
+	// WithNilModelPolicy.  NilModelDefault means NilReturnsZero.
+	NilModel NilModelPolicy
+	// CompactGroups is the default RelationSpec.CompactGroups.
+	CompactGroups bool
+	// BindRelations is the default RelationSpec.BindRelations.  Nil binds.
+	BindRelations *bool
+	// StableSort is the default RelationSpec.StableSort.  Nil sorts
+	// unstably.
+	StableSort *bool
+	// CopyResults is the default RelationSpec.CopyResults.  Nil shares.
+	CopyResults *bool
+	// StrictFetch is the default RelationSpec.StrictFetch.  Nil drops
+	// unexpected relations.
+	StrictFetch *bool
+	// RequireModelKey is the default RelationSpec.RequireModelKey.  Nil
+	// treats a model without a key as one without relations.
+	RequireModelKey *bool
+}
+
+// WithSpecDefaults sets the engine's spec defaults.  Fields of d left at
+// their zero value keep the default set by earlier options, so it combines
+// with WithFetchChunkSize and WithNilModelPolicy in either order.
+func WithSpecDefaults(d SpecDefaults) ConfigOption {
+	return func(c *Config) {
+		d.inherit(c.specDefaults)
+		c.specDefaults = d
+	}
+}
+
+// SpecDefaults returns the engine's spec defaults; see WithSpecDefaults.
+func (c Config) SpecDefaults() SpecDefaults { return c.specDefaults }
+
+// inherit sets the fields of d left at their zero value to those of from.
+func (d *SpecDefaults) inherit(from SpecDefaults) {
+	if d.MaxPages == 0 {
+		d.MaxPages = from.MaxPages
+	}
+	if d.MaxKeysPerFetch == 0 {
+		d.MaxKeysPerFetch = from.MaxKeysPerFetch
+	}
+	if d.NilModel == NilModelDefault {
+		d.NilModel = from.NilModel
+	}
+	if !d.CompactGroups {
+		d.CompactGroups = from.CompactGroups
+	}
+	inheritBool(&d.BindRelations, from.BindRelations)
+	inheritBool(&d.StableSort, from.StableSort)
+	inheritBool(&d.CopyResults, from.CopyResults)
+	inheritBool(&d.StrictFetch, from.StrictFetch)
+	inheritBool(&d.RequireModelKey, from.RequireModelKey)
+}
+
+func inheritBool(b **bool, from *bool) {
+	if *b == nil {
+		*b = from
+	}
+}
+
+// isSet reports whether b is set to true.
+func isSet(b *bool) bool { return b != nil && *b }
+
+// withDefaults returns args with the fields SpecDefaults covers, where left
+// at their zero value, set to e's defaults.  e may be nil, for a model with
+// no engine to take them from.
+func (args RelationSpec[JoinKey, Model, Relation]) withDefaults(e *Engine) RelationSpec[JoinKey, Model, Relation] {
+	if e == nil {
+		return args
+	}
+	d := SpecDefaults{
+		MaxPages:        args.MaxPages,
+		MaxKeysPerFetch: args.MaxKeysPerFetch,
+		NilModel:        args.NilModel,
+		CompactGroups:   args.CompactGroups,
+		BindRelations:   args.BindRelations,
+		StableSort:      args.StableSort,
+		CopyResults:     args.CopyResults,
+		StrictFetch:     args.StrictFetch,
+		RequireModelKey: args.RequireModelKey,
+	}
+	d.inherit(e.config.specDefaults)
+	args.MaxPages = d.MaxPages
+	args.MaxKeysPerFetch = d.MaxKeysPerFetch
+	args.NilModel = d.NilModel
+	args.CompactGroups = d.CompactGroups
+	args.BindRelations = d.BindRelations
+	args.StableSort = d.StableSort
+	args.CopyResults = d.CopyResults
+	args.StrictFetch = d.StrictFetch
+	args.RequireModelKey = d.RequireModelKey
+	return args
+}
+
+// specEngine returns the engine whose defaults apply to a spec for m: that
+// m is bound to, else fallbackTo (the spec's FallbackEngine), else the
+// default engine if set, else nil.
+func specEngine(m hasState, fallbackTo *Engine) *Engine {
+	if s := m.lodeState(); s != nil {
+		return s.engine
+	}
+	if fallbackTo != nil {
+		return fallbackTo
+	}
+	return defaultIfSet()
+}
@@ -0,0 +1,249 @@
+package lode
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestSpecDefaults_Precedence(t *testing.T) {
+	t.Parallel()
+	yes, no := true, false
+	type spec = RelationSpec[int, *Author, *Book]
+	type field struct {
+		name       string
+		setDefault func(*SpecDefaults, int) // 1 and 2 name two distinct non-zero values
+		setSpec    func(*spec, int)
+		get        func(spec) int // 0 for the package default
+	}
+	fields := []field{
+		{"MaxPages",
+			func(d *SpecDefaults, v int) { d.MaxPages = v },
+			func(s *spec, v int) { s.MaxPages = v },
+			func(s spec) int { return s.MaxPages }},
+		{"MaxKeysPerFetch",
+			func(d *SpecDefaults, v int) { d.MaxKeysPerFetch = v },
+			func(s *spec, v int) { s.MaxKeysPerFetch = v },
+			func(s spec) int { return s.MaxKeysPerFetch }},
+		{"NilModel",
+			func(d *SpecDefaults, v int) { d.NilModel = NilModelPolicy(v) },
+			func(s *spec, v int) { s.NilModel = NilModelPolicy(v) },
+			func(s spec) int { return int(s.NilModel) }},
+		{"CompactGroups", // a spec can only turn it on
+			func(d *SpecDefaults, v int) { d.CompactGroups = v == 1 },
+			func(s *spec, v int) { s.CompactGroups = v == 1 },
+			func(s spec) int {
+				if s.CompactGroups {
+					return 1
+				}
+				return 0
+			}},
+	}
+	boolField := func(name string, d func(*SpecDefaults) **bool, s func(*spec) **bool) {
+		toPtr := func(v int) *bool {
+			if v == 1 {
+				return &yes
+			}
+			return &no
+		}
+		fields = append(fields, field{name,
+			func(ds *SpecDefaults, v int) { *d(ds) = toPtr(v) },
+			func(sp *spec, v int) { *s(sp) = toPtr(v) },
+			func(sp spec) int {
+				switch p := *s(&sp); {
+				case p == nil:
+					return 0
+				case *p:
+					return 1
+				}
+				return 2
+			}})
+	}
+	boolField("BindRelations", func(d *SpecDefaults) **bool { return &d.BindRelations }, func(s *spec) **bool { return &s.BindRelations })
+	boolField("StableSort", func(d *SpecDefaults) **bool { return &d.StableSort }, func(s *spec) **bool { return &s.StableSort })
+	boolField("CopyResults", func(d *SpecDefaults) **bool { return &d.CopyResults }, func(s *spec) **bool { return &s.CopyResults })
+	boolField("StrictFetch", func(d *SpecDefaults) **bool { return &d.StrictFetch }, func(s *spec) **bool { return &s.StrictFetch })
+	boolField("RequireModelKey", func(d *SpecDefaults) **bool { return &d.RequireModelKey }, func(s *spec) **bool { return &s.RequireModelKey })
+
+	for _, f := range fields {
+		cases := []struct {
+			name         string
+			engine, spec int
+			want         int
+		}{
+			{name: "package default", want: 0},
+			{name: "engine default", engine: 1, want: 1},
+			{name: "spec only", spec: 1, want: 1},
+			{name: "spec over engine", engine: 2, spec: 1, want: 1},
+		}
+		for _, tc := range cases {
+			if f.name == "CompactGroups" && tc.name == "spec over engine" {
+				continue // false is its zero value, so the engine's true wins
+			}
+			var d SpecDefaults
+			if tc.engine != 0 {
+				f.setDefault(&d, tc.engine)
+			}
+			var s spec
+			if tc.spec != 0 {
+				f.setSpec(&s, tc.spec)
+			}
+			got := f.get(s.withDefaults(NewEngine(WithSpecDefaults(d))))
+			if got != tc.want {
+				t.Errorf("%s, %s: got %d; want %d", f.name, tc.name, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestSpecDefaults_CombineWithOptions(t *testing.T) {
+	t.Parallel()
+	for _, opts := range [][]ConfigOption{
+		{WithFetchChunkSize(2), WithNilModelPolicy(NilReturnsError), WithSpecDefaults(SpecDefaults{MaxPages: 3})},
+		{WithSpecDefaults(SpecDefaults{MaxPages: 3}), WithFetchChunkSize(2), WithNilModelPolicy(NilReturnsError)},
+	} {
+		c := NewConfig(opts...)
+		want := SpecDefaults{MaxPages: 3, MaxKeysPerFetch: 2, NilModel: NilReturnsError}
+		if got := c.SpecDefaults(); got != want {
+			t.Fatalf("SpecDefaults() = %+v; want %+v", got, want)
+		}
+		if c.FetchChunkSize() != 2 || c.NilModelPolicy() != NilReturnsError {
+			t.Fatalf("FetchChunkSize() = %d, NilModelPolicy() = %v; want 2, NilReturnsError", c.FetchChunkSize(), c.NilModelPolicy())
+		}
+	}
+}
+
+func TestMany_SortRelationsStable(t *testing.T) {
+	t.Parallel()
+	yes := true
+	eng := NewEngine(WithSpecDefaults(SpecDefaults{StableSort: &yes}))
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+
+	var fetched []*Book
+	for i := range 40 {
+		fetched = append(fetched, &Book{ID: i, AuthorID: 1, Title: "ab"[i%2 : i%2+1]})
+	}
+	got, err := Many(context.Background(), RelationSpec[int, *Author, *Book]{
+		CacheKey:      "books",
+		Model:         a,
+		ModelKey:      func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey:   func(b *Book) int { return b.AuthorID },
+		Fetch:         func(context.Context, []int) ([]*Book, error) { return fetched, nil },
+		SortRelations: func(a, b *Book) int { return cmp.Compare(a.Title, b.Title) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	for _, b := range got {
+		ids = append(ids, b.ID)
+	}
+	want := []int{0, 2, 4, 6, 8, 10, 12, 14, 16, 18, 20, 22, 24, 26, 28, 30, 32, 34, 36, 38,
+		1, 3, 5, 7, 9, 11, 13, 15, 17, 19, 21, 23, 25, 27, 29, 31, 33, 35, 37, 39}
+	if !slices.Equal(ids, want) {
+		t.Fatalf("IDs = %v; want ties in fetch order %v", ids, want)
+	}
+	if fetched[1].ID != 1 {
+		t.Fatal("sorting reordered the fetch's own slice")
+	}
+}
+
+func TestMany_CopyResults(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	for _, copied := range []bool{false, true} {
+		eng := NewEngine(WithSpecDefaults(SpecDefaults{CopyResults: &copied}))
+		a := &Author{ID: 1}
+		eng.InitHandles(a)
+		spec := threeBooks(map[int]int{}).For(a)
+
+		first, err := Many(ctx, spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		first[0] = nil
+		second, err := Many(ctx, spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := second[0] != nil; got != copied {
+			t.Fatalf("CopyResults %v: cached result kept after the caller's write = %v", copied, got)
+		}
+	}
+}
+
+func TestMany_StrictFetch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	yes, no := true, false
+	stray := []*Book{{ID: 1, AuthorID: 1}, nil, {ID: 2, AuthorID: 99}}
+	for _, tc := range []struct {
+		name          string
+		engine, spec  *bool
+		compactGroups bool
+		grouped       bool
+		wantErr       bool
+	}{
+		{name: "package default"},
+		{name: "engine default", engine: &yes, wantErr: true},
+		{name: "spec over engine", engine: &yes, spec: &no},
+		{name: "compact groups", spec: &yes, compactGroups: true, wantErr: true},
+		{name: "FetchGrouped", spec: &yes, grouped: true, wantErr: true},
+	} {
+		eng := NewEngine(WithSpecDefaults(SpecDefaults{StrictFetch: tc.engine}))
+		a := &Author{ID: 1}
+		eng.InitHandles(a)
+		spec := RelationSpec[int, *Author, *Book]{
+			CacheKey:      "books",
+			Model:         a,
+			ModelKey:      func(a *Author) (int, bool) { return a.ID, true },
+			RelationKey:   func(b *Book) int { return b.AuthorID },
+			StrictFetch:   tc.spec,
+			CompactGroups: tc.compactGroups,
+		}
+		if tc.grouped {
+			spec.FetchGrouped = func(context.Context, []int) (map[int][]*Book, error) {
+				return map[int][]*Book{1: stray[:1], 99: stray[2:]}, nil
+			}
+		} else {
+			spec.Fetch = func(context.Context, []int) ([]*Book, error) { return stray, nil }
+		}
+		got, err := Many(ctx, spec)
+		if gotErr := errors.Is(err, ErrUnexpectedRelation); gotErr != tc.wantErr {
+			t.Fatalf("%s: err = %v; want ErrUnexpectedRelation %v", tc.name, err, tc.wantErr)
+		}
+		if !tc.wantErr && len(got) != 1 {
+			t.Fatalf("%s: got %d relations; want 1", tc.name, len(got))
+		}
+	}
+}
+
+func TestMany_RequireModelKey(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	yes, no := true, false
+	for _, tc := range []struct {
+		name         string
+		engine, spec *bool
+		wantErr      bool
+	}{
+		{name: "package default"},
+		{name: "engine default", engine: &yes, wantErr: true},
+		{name: "spec over engine", engine: &yes, spec: &no},
+		{name: "spec only", spec: &yes, wantErr: true},
+	} {
+		eng := NewEngine(WithSpecDefaults(SpecDefaults{RequireModelKey: tc.engine}))
+		a := &Author{}
+		eng.InitHandles(a)
+		spec := threeBooks(map[int]int{}).For(a)
+		spec.ModelKey = func(a *Author) (int, bool) { return a.ID, a.ID != 0 }
+		spec.RequireModelKey = tc.spec
+		_, err := One(ctx, spec)
+		if gotErr := errors.Is(err, ErrNotFound); gotErr != tc.wantErr {
+			t.Fatalf("%s: err = %v; want ErrNotFound %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
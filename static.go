@@ -0,0 +1,27 @@
+package lode
+
+// NewStaticState returns a state holding models, exactly as given, for
+// tests and for wrappers that manage their own batches: bind models to it
+// with BindStatic, and Resolve, Many, and One treat them as one batch, the
+// same as models bound by an Engine.  The state belongs to a private engine
+// with the default configuration, and is not tracked by any Engine, so
+// Engine.ResetAll and friends do not see it.
+//
+// Unlike Engine.InitHandles, this path does not walk models reflectively or
+// split them into batches of WithBatchSize.  models must not be changed while
+// the state is in use.
+func NewStaticState[T hasState](models []T) *State {
+	e := NewEngine()
+	id := e.binds.Add(1)
+	return &State{s: &loaderState{models: models, engine: e, bindID: id}}
+}
+
+// BindStatic binds models, normally the slice s was created with, to s.
+// Nil models are skipped; models already bound elsewhere are rebound.
+func BindStatic[T hasState](models []T, s *State) {
+	for _, m := range models {
+		if !isNil(m) {
+			m.handle().setLodeState(s.s)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package lode
+
+import (
+	"context"
+	"testing"
+)
+
+// binders bind a batch of authors the two ways Resolve and Many must not be
+// able to tell apart.
+var binders = map[string]func(authors []*Author){
+	"engine": func(authors []*Author) { NewEngine().InitHandles(authors) },
+	"static": func(authors []*Author) { BindStatic(authors, NewStaticState(authors)) },
+}
+
+func TestStaticState_BehavesLikeBound(t *testing.T) {
+	t.Parallel()
+	for name, bind := range binders {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			authors := []*Author{{ID: 1}, {ID: 2}, {ID: 3}}
+			bind(authors)
+
+			builds := 0
+			resolve := ResolveSpec[*Author, int]{
+				CacheKey: "count",
+				Build: func(_ context.Context, models []*Author) (ResolverFunc[*Author, int], error) {
+					builds++
+					if len(models) != 3 {
+						t.Errorf("Build got %d models; want the batch of 3", len(models))
+					}
+					return func(a *Author) int { return 10 * a.ID }, nil
+				},
+			}
+			var fetched []int
+			many := RelationSpec[int, *Author, *Book]{
+				CacheKey:    "books",
+				ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+				RelationKey: func(b *Book) int { return b.AuthorID },
+				Fetch: func(_ context.Context, keys []int) ([]*Book, error) {
+					fetched = append(fetched, keys...)
+					return []*Book{{ID: 9, AuthorID: 2}}, nil
+				},
+			}
+			for _, a := range authors {
+				if n, err := Resolve(ctx, resolve.For(a)); err != nil || n != 10*a.ID {
+					t.Fatalf("Resolve(%d) = %d, %v", a.ID, n, err)
+				}
+				books, err := Many(ctx, many.For(a))
+				want := 0
+				if a.ID == 2 {
+					want = 1
+				}
+				if err != nil || len(books) != want {
+					t.Fatalf("Many(%d) = %v, %v", a.ID, titles(books), err)
+				}
+			}
+			if builds != 1 || len(fetched) != 3 {
+				t.Fatalf("builds = %d, fetched keys %v; want one build and one fetch of 3 keys", builds, fetched)
+			}
+			if books, _ := Many(ctx, many.For(authors[1])); books[0].core == nil {
+				t.Fatal("fetched relations were not bound")
+			}
+
+			st, ok := StateOf(authors[0])
+			if !ok || st.Len() != 3 {
+				t.Fatalf("StateOf = %v, %v", st.Len(), ok)
+			}
+			if err := authors[2].Reset(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Resolve(ctx, resolve.For(authors[0])); err != nil || builds != 2 {
+				t.Fatalf("after Reset: builds = %d, err %v; want a rebuild", builds, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package lode
+
+// KeyLabel identifies a cache key in engine-wide bookkeeping, which spans
+// states and so model types.  Specs for different model types may use the
+// same cache key without sharing a circuit breaker or fetch stats.
+type KeyLabel struct {
+	ModelType string // the bound type, e.g. "*app.Author"; empty for Global
+	CacheKey  string // namespace included
+}
+
+// String returns the label as "cacheKey (modelType)", or the bare cache key
+// when there is no model type.
+func (l KeyLabel) String() string {
+	if l.ModelType == "" {
+		return l.CacheKey
+	}
+	return l.CacheKey + " (" + l.ModelType + ")"
+}
+
+// Stats is a point-in-time snapshot of engine-wide bookkeeping.
+type Stats struct {
+	// Circuits holds the circuit breaker state per cache key and model
+	// type; nil unless the engine was created WithCircuitBreaker.
+	Circuits map[KeyLabel]CircuitStats
+	// SkippedRelations counts fetched relations dropped by Many because
+	// they were nil or could not be placed under a key.
+	SkippedRelations uint64
+	// EmptyBuilds counts Many builds that found no model with a key and so
+	// cached an empty result without calling Fetch.
+	EmptyBuilds uint64
+	// Fetches holds the key counts of the fetches per cache key and model
+	// type; nil unless the engine was created WithFetchStats.
+	Fetches map[KeyLabel]FetchStats
+	// MemoryUsed is the size of the relations cached by the engine's
+	// states, and Evictions and BudgetRejections count the entries evicted
+	// and the builds failed to stay within budget; all zero unless the
+	// engine was created WithMemoryBudget.
+	MemoryUsed       int64
+	Evictions        uint64
+	BudgetRejections uint64
+
+	singleKeyFetches int // thresholds for Anomalies
+	fetchKeyCeiling  int
+}
+
+// Stats returns a snapshot of the engine's bookkeeping.
+func (e *Engine) Stats() Stats {
+	s := Stats{SkippedRelations: e.skipped.Load(), EmptyBuilds: e.empty.Load()}
+	if e.breaker != nil {
+		s.Circuits = e.breaker.stats(e.now())
+	}
+	if e.mem != nil {
+		s.MemoryUsed = e.mem.used.Load()
+		s.Evictions = e.mem.evictions.Load()
+		s.BudgetRejections = e.mem.rejections.Load()
+	}
+	if e.fetches != nil {
+		s.Fetches = e.fetches.snapshot()
+		s.singleKeyFetches = e.config.singleKeyFetches
+		s.fetchKeyCeiling = e.config.fetchKeyCeiling
+	}
+	return s
+}
@@ -0,0 +1,72 @@
+package lode
+
+import (
+	"context"
+	"fmt"
+)
+
+var errNoFetchStream = &kindError{"Stream requires FetchStream", ErrMisconfigured}
+
+// Stream fetches the spec's relations for every model bound alongside
+// spec.Model, like Many, but hands each relation to fn as it arrives instead
+// of grouping and caching them.  It exists for jobs (exports, backfills) that
+// cannot hold a whole relation in memory.
+//
+// Stream bypasses the resolver cache entirely: every call fetches, nothing is
+// stored, and a cached Many result for the same CacheKey is neither used nor
+// affected.  spec.FetchStream must be set.  fn receives the relation's join
+// key (per RelationKey) and the relation; returning an error stops the stream
+// and is returned from Stream.  Fetched relations are not bound.  As in
+// Many, nil relations and those RelationKeyOK rejects are skipped, and only
+// the models Applies accepts are fetched for.  PartitionBy, KeyOrder,
+// MaxKeysPerFetch, WithFetchChunkSize, and Limiter apply as for Many.
+func Stream[JoinKey comparable, Model hasState, Relation any](ctx context.Context, spec RelationSpec[JoinKey, Model, Relation], fn func(parentKey JoinKey, rel Relation) error) error {
+	if spec.FetchStream == nil {
+		return fmt.Errorf("%s: key %q: %w", packagePrefix, spec.CacheKey, errNoFetchStream)
+	}
+	if isNil(spec.Model) {
+		return spec.NilModel.nilModel(spec.CacheKey, spec.FallbackEngine)
+	}
+	loader := spec.Model.lodeState()
+	if loader == nil {
+		return notInitialized(spec.Model)
+	}
+	models, err := typedModels[Model](loader)
+	if err != nil {
+		return err
+	}
+	models = applicable(models, spec.Applies)
+	if spec.PartitionBy == nil {
+		return spec.stream(ctx, loader.engine, models, fn)
+	}
+	order, parts := spec.partitions(models)
+	for _, p := range order {
+		if err := spec.stream(partitionCtx(ctx, p), loader.engine, parts[p], fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stream is Stream's fetch of the relations of models.
+func (spec RelationSpec[JoinKey, Model, Relation]) stream(ctx context.Context, e *Engine, models []Model, fn func(parentKey JoinKey, rel Relation) error) error {
+	keys := spec.orderKeys(spec.modelKeys(models))
+	for from, to := range ChunkRanges(len(keys), spec.maxKeysPerFetch(e)) {
+		err := spec.limited(ctx, e, func() error {
+			return spec.FetchStream(ctx, keys[from:to], func(rel Relation) error {
+				if isNilValue(rel) {
+					return nil // as Many drops them
+				}
+				k, ok := spec.relationKey(rel)
+				if !ok {
+					return nil
+				}
+				return fn(k, rel)
+			})
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func streamBooks(all []*Book, gotKeys *[]int) func(context.Context, []int, func(*Book) error) error {
+	return func(_ context.Context, keys []int, yield func(*Book) error) error {
+		*gotKeys = append([]int(nil), keys...)
+		slices.Sort(*gotKeys)
+		for _, b := range all {
+			if !slices.Contains(keys, b.AuthorID) {
+				continue
+			}
+			if err := yield(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestStream_YieldsWithoutCaching(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine()
+	a1, a2, a3 := &Author{ID: 1}, &Author{ID: 2}, &Author{ID: 1}
+	eng.InitHandles([]*Author{a1, a2, a3})
+
+	all := []*Book{
+		{ID: 1, AuthorID: 1, Title: "A1-1"},
+		{ID: 2, AuthorID: 3, Title: "other"},
+		{ID: 3, AuthorID: 2, Title: "A2-1"},
+	}
+	var keys []int
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       a1,
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		FetchStream: streamBooks(all, &keys),
+	}
+
+	type pair struct {
+		key   int
+		title string
+	}
+	var got []pair
+	for i := 0; i < 2; i++ {
+		got = got[:0]
+		err := Stream(ctx, spec, func(k int, b *Book) error {
+			got = append(got, pair{k, b.Title})
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []pair{{1, "A1-1"}, {2, "A2-1"}}; !slices.Equal(got, want) {
+			t.Fatalf("streamed %v; want %v", got, want)
+		}
+		if !slices.Equal(keys, []int{1, 2}) {
+			t.Fatalf("keys = %v; want deduplicated [1 2]", keys)
+		}
+		keys = nil
+	}
+
+	var n int
+	a1.core.resolverEntries.Range(func(any, any) bool { n++; return true })
+	if n != 0 {
+		t.Fatalf("Stream cached %d entries; want none", n)
+	}
+}
+
+func TestStream_CallbackErrorStops(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine()
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+
+	var keys []int
+	errStop := errors.New("stop")
+	calls := 0
+	err := Stream(context.Background(), RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       a,
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		FetchStream: streamBooks([]*Book{{AuthorID: 1}, {AuthorID: 1}}, &keys),
+	}, func(int, *Book) error {
+		calls++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || calls != 1 {
+		t.Fatalf("err = %v, calls = %d; want errStop after 1 call", err, calls)
+	}
+}
+
+func TestMany_UsesFetchStreamWhenFetchUnset(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine()
+	a := &Author{ID: 1}
+	eng.InitHandles(a)
+
+	var keys []int
+	got, err := Many(context.Background(), RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       a,
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		FetchStream: streamBooks([]*Book{{AuthorID: 1, Title: "x"}, {AuthorID: 1, Title: "y"}}, &keys),
+	})
+	if err != nil || !equalStrings(titles(got), []string{"x", "y"}) {
+		t.Fatalf("Many = %v, %v", titles(got), err)
+	}
+}
+
+func TestStream_KeyOrderAndMaxKeysPerFetch(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine()
+	authors := []*Author{{ID: 3}, {ID: 1}, {ID: 2}}
+	eng.InitHandles(authors)
+
+	var calls [][]int
+	err := Stream(context.Background(), RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       authors[0],
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		FetchStream: func(_ context.Context, keys []int, _ func(*Book) error) error {
+			calls = append(calls, slices.Clone(keys))
+			return nil
+		},
+		KeyOrder:        func(a, b int) int { return b - a },
+		MaxKeysPerFetch: 2,
+	}, func(int, *Book) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 || !slices.Equal(calls[0], []int{3, 2}) || !slices.Equal(calls[1], []int{1}) {
+		t.Fatalf("FetchStream calls = %v; want [[3 2] [1]]", calls)
+	}
+}
+
+func TestStream_RelationKeyOKNilsAndApplies(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine()
+	authors := []*Author{{ID: 1}, {ID: 2}, {ID: 3}}
+	eng.InitHandles(authors)
+
+	var fetched []int
+	var got []string
+	err := Stream(context.Background(), RelationSpec[int, *Author, *Book]{
+		CacheKey:      "books",
+		Model:         authors[0],
+		ModelKey:      func(a *Author) (int, bool) { return a.ID, true },
+		RelationKeyOK: func(b *Book) (int, bool) { return b.AuthorID, b.AuthorID != 0 },
+		Applies:       func(a *Author) bool { return a.ID != 2 },
+		FetchStream: func(_ context.Context, keys []int, yield func(*Book) error) error {
+			fetched = append(fetched, keys...)
+			for _, b := range []*Book{{AuthorID: 1, Title: "kept"}, nil, {AuthorID: 0, Title: "keyless"}} {
+				if err := yield(b); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}, func(_ int, b *Book) error {
+		got = append(got, b.Title)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(fetched, []int{1, 3}) || !slices.Equal(got, []string{"kept"}) {
+		t.Fatalf("fetched %v and streamed %v; want [1 3] and [kept]", fetched, got)
+	}
+}
+
+func TestStream_PartitionBy(t *testing.T) {
+	t.Parallel()
+	eng := NewEngine()
+	authors := []*Author{{ID: 1, Name: "t1"}, {ID: 2, Name: "t2"}, {ID: 3, Name: "t1"}}
+	eng.InitHandles(authors)
+
+	var calls []string
+	err := Stream(context.Background(), RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		Model:       authors[0],
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		PartitionBy: func(a *Author) any { return a.Name },
+		FetchStream: func(ctx context.Context, keys []int, _ func(*Book) error) error {
+			p, _ := PartitionOf(ctx)
+			calls = append(calls, fmt.Sprint(p, keys))
+			return nil
+		},
+	}, func(int, *Book) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(calls, []string{"t1[1 3]", "t2[2]"}) {
+		t.Fatalf("FetchStream calls = %q; want one per partition with its own keys", calls)
+	}
+}
@@ -0,0 +1,38 @@
+package lode
+
+import "fmt"
+
+var errSubsetKey = &kindError{"key used both with and without Subset", ErrMisconfigured}
+
+// subsetBuilt records that the resolver for cacheKey on s is built over a
+// Subset; see checkSubset.
+func (s *loaderState) subsetBuilt(cacheKey string) {
+	s.subsetKeys.Store(cacheKey, struct{}{})
+	s.hasSubsets.Store(true)
+}
+
+// checkSubset reports an error if the resolver for cacheKey on s, now built,
+// was built with a Subset and subset is false, or the other way around.
+// Subset builds record their keys before they run, so a built key they did
+// not record was built over the full batch.
+func (s *loaderState) checkSubset(cacheKey string, subset bool) error {
+	if !subset && !s.hasSubsets.Load() {
+		return nil
+	}
+	if _, ok := s.subsetKeys.Load(cacheKey); ok != subset {
+		return fmt.Errorf("%s: key %q: %w", packagePrefix, cacheKey, errSubsetKey)
+	}
+	return nil
+}
+
+// both returns a predicate accepting what a and b both accept, either of
+// which may be nil.
+func both[Model any](a, b func(Model) bool) func(Model) bool {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	return func(m Model) bool { return a(m) && b(m) }
+}
@@ -0,0 +1,63 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestResolveSpec_Subset(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	authors := []*Author{{ID: 1, Name: "active"}, {ID: 2}, {ID: 3, Name: "active"}, {ID: 4}}
+	NewEngine().InitHandles(authors)
+	active := func(a *Author) bool { return a.Name == "active" }
+
+	inputs := map[string][]int{}
+	batchSize := func(key string) ResolveSpec[*Author, int] {
+		return ResolveSpec[*Author, int]{
+			CacheKey: key,
+			Build: func(_ context.Context, models []*Author) (ResolverFunc[*Author, int], error) {
+				for _, m := range models {
+					inputs[key] = append(inputs[key], m.ID)
+				}
+				return func(*Author) int { return len(models) }, nil
+			},
+		}
+	}
+	full := batchSize("batch_size")
+	subset := batchSize("active_batch_size")
+	subset.Subset = active
+
+	for _, a := range authors {
+		n, err := Resolve(ctx, subset.For(a))
+		want := 0
+		if active(a) {
+			want = 2
+		}
+		if err != nil || n != want {
+			t.Fatalf("author %d: subset = %d, %v; want %d", a.ID, n, err, want)
+		}
+		if n, err := Resolve(ctx, full.For(a)); err != nil || n != 4 {
+			t.Fatalf("author %d: full = %d, %v; want 4", a.ID, n, err)
+		}
+	}
+	if got := inputs["active_batch_size"]; len(got) != 2 || got[0] != 1 || got[1] != 3 {
+		t.Fatalf("subset build saw %v; want [1 3]", got)
+	}
+	if got := inputs["batch_size"]; len(got) != 4 {
+		t.Fatalf("full build saw %v; want all 4", got)
+	}
+
+	// Sharing a key between the two is reported from either side.
+	misused := full
+	misused.CacheKey = "active_batch_size"
+	if _, err := Resolve(ctx, misused.For(authors[0])); !errors.Is(err, errSubsetKey) {
+		t.Fatalf("full spec on a subset key: err = %v; want errSubsetKey", err)
+	}
+	misused = subset
+	misused.CacheKey = "batch_size"
+	if _, err := Resolve(ctx, misused.For(authors[0])); !errors.Is(err, errSubsetKey) {
+		t.Fatalf("subset spec on a full key: err = %v; want errSubsetKey", err)
+	}
+}
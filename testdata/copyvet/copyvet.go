@@ -0,0 +1,20 @@
+// Package copyvet copies bound models by value.  TestHandle_VetFlagsCopies
+// expects go vet to report every copy.
+package copyvet
+
+import "github.com/willhf/lode"
+
+type Author struct {
+	ID int
+	lode.Handle
+}
+
+func Names(authors []Author) []int {
+	var ids []int
+	for _, a := range authors { // copies each Author
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
+
+func ByValue(a Author) int { return a.ID }
@@ -0,0 +1,18 @@
+// Package mapkey keys a map by bound models.  TestHandle_StrictNotComparable
+// expects it to build only without the lodestrict tag.
+package mapkey
+
+import "github.com/willhf/lode"
+
+type Author struct {
+	ID int
+	lode.Handle
+}
+
+func Seen(authors []*Author) map[Author]bool {
+	seen := make(map[Author]bool)
+	for _, a := range authors {
+		seen[*a] = true
+	}
+	return seen
+}
@@ -0,0 +1,154 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func (b *Book) authorID() int { return b.AuthorID }
+
+// authorIDOf is RelationKey for Book values.  Its type parameter keeps go
+// vet's copylocks check from flagging the Book passed by value.
+func authorIDOf[B any, P interface {
+	*B
+	authorID() int
+}](b B) int {
+	return P(&b).authorID()
+}
+
+// valueBooks fetches Book values: two per author, except none for authors
+// with negative ids and one all-zero book (but for its key) for author 0.
+func valueBooks(fetches *int) RelationSpec[int, *Author, Book] {
+	return RelationSpec[int, *Author, Book]{
+		CacheKey:    "value_books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: authorIDOf[Book],
+		Fetch: func(_ context.Context, ids []int) ([]Book, error) {
+			*fetches++
+			var out []Book
+			for _, id := range ids {
+				switch {
+				case id == 0:
+					out = append(out, Book{})
+				case id > 0:
+					out = append(out, Book{ID: 10 * id, AuthorID: id}, Book{ID: 10*id + 1, AuthorID: id})
+				}
+			}
+			return out, nil
+		},
+	}
+}
+
+func TestValueRelations_Bound(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	for name, spec := range map[string]func(*int) RelationSpec[int, *Author, Book]{
+		"grouped": valueBooks,
+		"compact": func(n *int) RelationSpec[int, *Author, Book] {
+			s := valueBooks(n)
+			s.CompactGroups = true
+			return s
+		},
+		"fetch grouped": func(n *int) RelationSpec[int, *Author, Book] {
+			s := valueBooks(n)
+			s.Fetch = nil
+			s.FetchGrouped = func(_ context.Context, ids []int) (map[int][]Book, error) {
+				*n++
+				grouped := make(map[int][]Book)
+				for _, id := range ids {
+					grouped[id] = []Book{{ID: 10 * id, AuthorID: id}, {ID: 10*id + 1, AuthorID: id}}
+				}
+				return grouped, nil
+			}
+			return s
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			eng := NewEngine(WithDebug(), WithMembershipCheck())
+			authors := []*Author{{ID: 1}, {ID: 2}, {ID: 3}}
+			eng.InitHandles(authors)
+			fetches := 0
+			spec := spec(&fetches)
+
+			var states []State
+			for _, a := range authors {
+				books, err := Many(ctx, spec.For(a))
+				if err != nil || len(books) != 2 || books[0].ID != 10*a.ID {
+					t.Fatalf("author %d: %d books, %v", a.ID, len(books), err)
+				}
+				for i := range books {
+					s, ok := StateOf(&books[i])
+					if !ok {
+						t.Fatalf("author %d: book %d is unbound", a.ID, i)
+					}
+					states = append(states, s)
+					// The values returned are the bound ones, so nested
+					// calls pass the debug and membership checks.
+					n, err := Resolve(ctx, ResolveSpec[*Book, int]{
+						CacheKey: "batch",
+						Model:    &books[i],
+						Build: func(_ context.Context, ms []*Book) (ResolverFunc[*Book, int], error) {
+							return func(*Book) int { return len(ms) }, nil
+						},
+					})
+					if err != nil || n != 6 {
+						t.Fatalf("author %d: nested Resolve = %d, %v; want the 6 books' batch", a.ID, n, err)
+					}
+				}
+			}
+			for _, s := range states[1:] {
+				if s != states[0] {
+					t.Fatal("the books were bound in more than one batch")
+				}
+			}
+			if fetches != 1 {
+				t.Fatalf("fetched %d times; want 1", fetches)
+			}
+		})
+	}
+}
+
+func TestOneOK_ValueRelations(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	zero, none, two := &Author{ID: 0}, &Author{ID: -1}, &Author{ID: 2}
+	NewEngine().InitHandles([]*Author{zero, none, two})
+	fetches := 0
+	spec := valueBooks(&fetches)
+
+	// One cannot tell the all-zero book from no book; OneOK can.
+	b0, _ := One(ctx, spec.For(zero))
+	b1, _ := One(ctx, spec.For(none))
+	if b0.ID != b1.ID || b0.AuthorID != b1.AuthorID {
+		t.Fatalf("One = book %d and book %d", b0.ID, b1.ID)
+	}
+	if b, ok, err := OneOK(ctx, spec.For(zero)); !ok || err != nil || b.AuthorID != 0 {
+		t.Fatalf("OneOK(zero) = book %d, %v, %v; want the all-zero book", b.ID, ok, err)
+	}
+	if _, ok, err := OneOK(ctx, spec.For(none)); ok || err != nil {
+		t.Fatalf("OneOK(none) = %v, %v; want not found", ok, err)
+	}
+	if b, ok, err := OneOK(ctx, spec.For(two)); !ok || err != nil || b.ID != 20 {
+		t.Fatalf("OneOK(two) = book %d, %v, %v", b.ID, ok, err)
+	}
+
+	// OneStrict agrees: the zero book is found, and none is ErrNotFound.
+	if _, err := OneStrict(ctx, spec.For(zero)); err != nil {
+		t.Fatalf("OneStrict(zero) = %v", err)
+	}
+	if _, err := OneStrict(ctx, spec.For(none)); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("OneStrict(none) = %v; want ErrNotFound", err)
+	}
+	var multiple *MultipleError
+	if _, err := OneStrict(ctx, spec.For(two)); !errors.As(err, &multiple) || multiple.Count != 2 {
+		t.Fatalf("OneStrict(two) = %v; want a MultipleError of 2", err)
+	}
+
+	// SinglePerKey keeps one bound value per key.
+	spec.SinglePerKey = true
+	if b, ok, err := OneOK(ctx, spec.For(two)); !ok || err != nil || b.ID != 20 || b.lodeState() == nil {
+		t.Fatalf("SinglePerKey OneOK = book %d, %v, %v; want book 20, bound", b.ID, ok, err)
+	}
+}
@@ -0,0 +1,51 @@
+package lode
+
+import "sync"
+
+// SetVersionSource makes e (and its scopes) check every cached value
+// against source, for invalidation driven by events such as a pub/sub
+// "books changed" message: each build records source's version of its cache
+// key, and a Resolve that finds the version since moved on rebuilds, one
+// build shared by concurrent callers as usual.  Values built while the
+// version moves are rebuilt on the next call.
+//
+// source is called on every Resolve, Many, and One, so it must be cheap,
+// e.g. an atomic load of a counter the app bumps.  It gets the cache key as
+// call sites write it: without the engine's key namespace, key version, or
+// SinglePerKeySuffix.  Versions apply per key across all states, so bump
+// them only for keys that changed.  A nil source turns the check off.
+func (e *Engine) SetVersionSource(source func(cacheKey string) uint64) {
+	if source == nil {
+		e.versions.Store(nil)
+		return
+	}
+	e.versions.Store(&source)
+}
+
+// version returns the current version of cacheKey, a stored key, and
+// whether a version source is set.
+func (e *Engine) version(cacheKey string) (uint64, bool) {
+	source := e.versions.Load()
+	if source == nil {
+		return 0, false
+	}
+	return (*source)(e.callerKey(cacheKey)), true
+}
+
+// renew replaces c's stale entry in entries with a fresh one, or adopts the
+// entry another caller replaced it with, so one rebuild serves them all.
+func (c *CacheEntry) renew(entries *sync.Map, version uint64) {
+	for {
+		fresh := c.e.successor(c.entry)
+		if entries.CompareAndSwap(c.cacheKey, c.entry, fresh) {
+			c.state.release(c.entry)
+			c.entry = fresh
+			return
+		}
+		v, _ := entries.LoadOrStore(c.cacheKey, fresh)
+		c.entry = v.(*resolverEntry)
+		if h := c.entry.ready.Load(); h == nil || h.version == version {
+			return
+		}
+	}
+}
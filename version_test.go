@@ -0,0 +1,88 @@
+package lode
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSetVersionSource(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	eng := NewEngine(WithKeyNamespace("ns"))
+	authors := []*Author{{ID: 1}, {ID: 2}}
+	eng.InitHandles(authors)
+
+	var booksVersion atomic.Uint64
+	var mu sync.Mutex
+	asked := map[string]bool{}
+	eng.SetVersionSource(func(cacheKey string) uint64 {
+		mu.Lock()
+		asked[cacheKey] = true
+		mu.Unlock()
+		if cacheKey == "books" {
+			return booksVersion.Load()
+		}
+		return 0
+	})
+
+	var fetches atomic.Int32
+	spec := RelationSpec[int, *Author, *Book]{
+		CacheKey:    "books",
+		ModelKey:    func(a *Author) (int, bool) { return a.ID, true },
+		RelationKey: func(b *Book) int { return b.AuthorID },
+		Fetch: func(context.Context, []int) ([]*Book, error) {
+			fetches.Add(1)
+			return []*Book{{AuthorID: 1}}, nil
+		},
+	}
+	resolveAll := func() {
+		t.Helper()
+		var wg sync.WaitGroup
+		for range 10 {
+			for _, a := range authors {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := Many(ctx, spec.For(a)); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+		}
+		wg.Wait()
+	}
+
+	resolveAll()
+	resolveAll()
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("fetched %d times at version 0; want 1", n)
+	}
+	booksVersion.Add(1) // "books changed"
+	resolveAll()
+	if n := fetches.Load(); n != 2 {
+		t.Fatalf("fetched %d times after the bump; want exactly one rebuild", n)
+	}
+	resolveAll()
+	if n := fetches.Load(); n != 2 {
+		t.Fatalf("fetched %d times at an unchanged version; want no rebuild", n)
+	}
+
+	spec.SinglePerKey = true
+	if _, err := Many(ctx, spec.For(authors[0])); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(asked) != 1 || !asked["books"] {
+		t.Fatalf("source asked for %v; want only the bare key \"books\"", asked)
+	}
+
+	eng.SetVersionSource(nil)
+	booksVersion.Add(1)
+	resolveAll()
+	if n := fetches.Load(); n != 3 { // 2 plus the SinglePerKey build
+		t.Fatalf("fetched %d times without a source; want 3", n)
+	}
+}
@@ -0,0 +1,186 @@
+package lode
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// BuildEvent describes one finished build of a cache key.
+type BuildEvent struct {
+	// CacheKey is the key as call sites write it: without the key
+	// namespace, key version, or SinglePerKeySuffix.
+	CacheKey  string
+	ModelType string // empty for Global
+	Duration  time.Duration
+	Err       error
+}
+
+func (e *Engine) onBuild(ev BuildEvent) {
+	for _, h := range e.config.hooks {
+		if h.OnBuild != nil {
+			h.OnBuild(ev)
+		}
+	}
+}
+
+// WarmupRecorder records the cache keys built while its hooks are
+// registered, to be replayed with Engine.WarmFrom: record a request to a
+// latency-critical endpoint once, persist the manifest, and warm the next
+// requests' engines from it.
+//
+//	rec := lode.NewWarmupRecorder()
+//	engine := lode.NewEngine(lode.WithHooks(rec.Hooks()))
+//	... serve the request ...
+//	manifest := rec.Manifest()
+type WarmupRecorder struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+// NewWarmupRecorder returns an empty recorder.
+func NewWarmupRecorder() *WarmupRecorder { return &WarmupRecorder{} }
+
+// Hooks returns the hooks that feed the recorder.
+func (r *WarmupRecorder) Hooks() Hooks {
+	return Hooks{OnBuild: func(ev BuildEvent) {
+		if ev.ModelType == "" {
+			return // Global values are not tied to a batch
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if !slices.Contains(r.keys, ev.CacheKey) {
+			r.keys = append(r.keys, ev.CacheKey)
+		}
+	}}
+}
+
+// Manifest returns the recorded cache keys in the order they were first
+// built.
+func (r *WarmupRecorder) Manifest() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.keys)
+}
+
+// SpecRegistry holds the specs Engine.WarmFrom can warm, by cache key.  Add
+// specs with AddMany and AddResolve, typically once at startup; their Model
+// is ignored.
+type SpecRegistry struct {
+	mu    sync.RWMutex
+	specs map[string][]warmSpec
+}
+
+type warmSpec struct {
+	cacheKey  string
+	modelType reflect.Type
+	preload   func(ctx context.Context, model any) error
+}
+
+// NewSpecRegistry returns an empty registry.
+func NewSpecRegistry() *SpecRegistry {
+	return &SpecRegistry{specs: make(map[string][]warmSpec)}
+}
+
+// AddMany registers spec for warming its relation, as Many would.
+func AddMany[JoinKey comparable, Model hasState, Relation any](r *SpecRegistry, spec RelationSpec[JoinKey, Model, Relation]) {
+	r.add(warmSpec{spec.CacheKey, reflect.TypeFor[Model](), func(ctx context.Context, m any) error {
+		_, err := Many(ctx, spec.For(m.(Model)))
+		return err
+	}})
+}
+
+// AddResolve registers spec for warming its resolver, as Resolve would.
+func AddResolve[Model hasState, Result any](r *SpecRegistry, spec ResolveSpec[Model, Result]) {
+	r.add(warmSpec{spec.CacheKey, reflect.TypeFor[Model](), func(ctx context.Context, m any) error {
+		_, err := Resolve(ctx, spec.For(m.(Model)))
+		return err
+	}})
+}
+
+func (r *SpecRegistry) add(s warmSpec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.specs[s.cacheKey] = append(r.specs[s.cacheKey], s)
+}
+
+// warmPlan is what Engine.WarmFrom asked for.
+type warmPlan struct {
+	ctx   context.Context
+	specs []warmSpec
+}
+
+// WarmFrom makes the engine (and its scopes) warm the manifest's cache keys
+// for every state it binds from now on, as soon as it binds: the specs
+// registered for those keys whose Model the state's models fit are built in
+// the background with ctx, so accessors called later find them built or
+// building.  Keys of the manifest without a registered spec are ignored.
+// Warming states bound by a warm build cascades, so a manifest of nested
+// relations warms them all.  Warm builds take build slots under
+// WithMaxConcurrentBuilds like any other.  A failed warm build, panics
+// included, is reported to Hooks.OnWarmError and retried by the next
+// accessor, as usual.  Calling WarmFrom again replaces the plan; a nil
+// manifest stops warming.
+func (e *Engine) WarmFrom(ctx context.Context, manifest []string, specs *SpecRegistry) {
+	if len(manifest) == 0 {
+		e.warm.Store(nil)
+		return
+	}
+	plan := &warmPlan{ctx: ctx}
+	specs.mu.RLock()
+	for _, k := range manifest {
+		plan.specs = append(plan.specs, specs.specs[k]...)
+	}
+	specs.mu.RUnlock()
+	e.warm.Store(plan)
+}
+
+// warmState starts the planned warm builds that apply to s.
+func (e *Engine) warmState(s *loaderState) {
+	plan := e.warm.Load()
+	if plan == nil {
+		return
+	}
+	models := reflect.ValueOf(s.models)
+	var model any
+	for i := range models.Len() {
+		if el := models.Index(i); !el.IsNil() {
+			model = el.Interface()
+			break
+		}
+	}
+	if model == nil {
+		return
+	}
+	for _, spec := range plan.specs {
+		if models.Type().Elem().AssignableTo(spec.modelType) {
+			go e.warmOne(plan.ctx, spec, model)
+		}
+	}
+}
+
+// warmOne runs one warm build for model's batch, reporting its failure.
+func (e *Engine) warmOne(ctx context.Context, spec warmSpec, model any) {
+	err := callRecovered(ctx, "warm build of "+strconv.Quote(spec.cacheKey), func(ctx context.Context) error {
+		return spec.preload(ctx, model)
+	})
+	if err == nil {
+		return
+	}
+	ev := WarmErrorEvent{CacheKey: spec.cacheKey, ModelType: reflect.TypeOf(model).String(), Err: err}
+	for _, h := range e.config.hooks {
+		if h.OnWarmError != nil {
+			h.OnWarmError(ev)
+		}
+	}
+}
+
+// WarmErrorEvent describes one failed warm build; see Engine.WarmFrom.
+type WarmErrorEvent struct {
+	CacheKey  string
+	ModelType string
+	Err       error
+}
@@ -0,0 +1,179 @@
+package lode
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWarmup_RecordAndReplay(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	var fetches atomic.Int32
+	started := make(chan string, 16)
+	books := threeBooks(map[int]int{})
+	fetch := books.Fetch
+	books.Fetch = func(ctx context.Context, ids []int) ([]*Book, error) {
+		fetches.Add(1)
+		started <- "books"
+		return fetch(ctx, ids)
+	}
+	titleLen := ResolveSpec[*Book, int]{
+		CacheKey: "title_len",
+		Build: func(context.Context, []*Book) (ResolverFunc[*Book, int], error) {
+			started <- "title_len"
+			return func(b *Book) int { return len(b.Title) }, nil
+		},
+	}
+	// endpoint is the request path being warmed: every author's books and
+	// their title lengths.
+	endpoint := func(authors []*Author) int {
+		total := 0
+		for _, a := range authors {
+			bs, err := Many(ctx, books.For(a))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, b := range bs {
+				n, err := Resolve(ctx, titleLen.For(b))
+				if err != nil {
+					t.Fatal(err)
+				}
+				total += n
+			}
+		}
+		return total
+	}
+
+	rec := NewWarmupRecorder()
+	eng := NewEngine(WithKeyNamespace("app"), WithHooks(rec.Hooks()))
+	recorded := []*Author{{ID: 1}, {ID: 2}}
+	eng.InitHandles(recorded)
+	Global(ctx, eng, "config", func(context.Context) (int, error) { return 0, nil })
+	want := endpoint(recorded)
+	manifest := rec.Manifest()
+	if !slices.Equal(manifest, []string{"books", "title_len"}) {
+		t.Fatalf("Manifest = %q", manifest)
+	}
+	for len(started) > 0 {
+		<-started
+	}
+
+	specs := NewSpecRegistry()
+	AddMany(specs, books)
+	AddResolve(specs, titleLen)
+	eng = NewEngine(WithKeyNamespace("app"))
+	eng.WarmFrom(ctx, append(manifest, "unregistered"), specs)
+	authors := []*Author{{ID: 1}, {ID: 2}}
+	fetches.Store(0)
+	eng.InitHandles(authors)
+
+	// Both builds start before any accessor is called.
+	var warmed []string
+	for range 2 {
+		select {
+		case key := <-started:
+			warmed = append(warmed, key)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("warmed %q; want books and title_len to start on their own", warmed)
+		}
+	}
+	if got := endpoint(authors); got != want {
+		t.Fatalf("endpoint = %d; want %d", got, want)
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("%d books fetches; want the warm one only", n)
+	}
+
+	eng.WarmFrom(ctx, nil, specs)
+	eng.InitHandles(&Author{ID: 3})
+	select {
+	case key := <-started:
+		t.Fatalf("%s warmed after warming was turned off", key)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+// Each bound batch warms on its own goroutine; run with -race.
+func TestWarmup_SeveralBatches(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	built := make(chan int, 4)
+	titleLen := ResolveSpec[*Book, int]{
+		CacheKey: "title_len",
+		Build: func(_ context.Context, books []*Book) (ResolverFunc[*Book, int], error) {
+			built <- books[0].ID
+			return func(b *Book) int { return len(b.Title) }, nil
+		},
+	}
+	specs := NewSpecRegistry()
+	AddResolve(specs, titleLen)
+	eng := NewEngine(WithBatchSize(1))
+	eng.WarmFrom(ctx, []string{"title_len"}, specs)
+	books := []*Book{{ID: 1, Title: "a"}, {ID: 2, Title: "ab"}, {ID: 3, Title: "abc"}, {ID: 4, Title: "abcd"}}
+	eng.InitHandles(books)
+
+	var warmed []int
+	for range books {
+		select {
+		case id := <-built:
+			warmed = append(warmed, id)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("warmed %v; want every batch", warmed)
+		}
+	}
+	slices.Sort(warmed)
+	if !slices.Equal(warmed, []int{1, 2, 3, 4}) {
+		t.Fatalf("warmed %v; want each book's batch once", warmed)
+	}
+	for _, b := range books {
+		if n, err := Resolve(ctx, titleLen.For(b)); err != nil || n != len(b.Title) {
+			t.Fatalf("Resolve(%d) = %d, %v", b.ID, n, err)
+		}
+	}
+}
+
+func TestWarmup_ErrorHook(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	specs := NewSpecRegistry()
+	AddResolve(specs, ResolveSpec[*Book, int]{
+		CacheKey: "fails",
+		Build: func(context.Context, []*Book) (ResolverFunc[*Book, int], error) {
+			return nil, errors.New("boom")
+		},
+	})
+	AddResolve(specs, ResolveSpec[*Book, int]{
+		CacheKey: "panics",
+		Build: func(context.Context, []*Book) (ResolverFunc[*Book, int], error) {
+			panic("kaboom")
+		},
+	})
+	failed := make(chan WarmErrorEvent, 2)
+	eng := NewEngine(WithHooks(Hooks{OnWarmError: func(ev WarmErrorEvent) { failed <- ev }}))
+	eng.WarmFrom(ctx, []string{"fails", "panics"}, specs)
+	eng.InitHandles(&Book{ID: 1})
+
+	got := map[string]error{}
+	for range 2 {
+		select {
+		case ev := <-failed:
+			if ev.ModelType != "*lode.Book" {
+				t.Errorf("%s: ModelType = %q", ev.CacheKey, ev.ModelType)
+			}
+			got[ev.CacheKey] = ev.Err
+		case <-time.After(10 * time.Second):
+			t.Fatalf("OnWarmError got %v; want both keys", got)
+		}
+	}
+	if err := got["fails"]; err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("fails: err = %v; want boom", err)
+	}
+	if err := got["panics"]; !errors.Is(err, errPanicked) || !strings.Contains(err.Error(), "kaboom") {
+		t.Errorf("panics: err = %v; want the recovered panic", err)
+	}
+}